@@ -0,0 +1,144 @@
+package main
+
+import (
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// Stick deflection under this is drift, and ignored. Past it, the speed
+	// goes from 0 up to stickSpeed pixels per tick at full tilt. It's lower
+	// than keymap.Deadzone, which is for sticks pushed as d-pad directions,
+	// so that slow analog moves aren't lost
+	stickDeadzone = 0.2
+	stickSpeed    = 6
+)
+
+// padLayout is how the face buttons are laid out: which one goes to the
+// next sprite, which to the previous one, and which flip and tint.
+type padLayout struct {
+	name           string
+	next, previous ebiten.GamepadButton
+	flip, tint     ebiten.GamepadButton
+}
+
+// padLayouts are the gamepad bindings Back (or P) goes through, the face
+// buttons are where the labels say on Xbox pads, and swapped around on
+// Nintendo ones.
+//
+//nolint:gochecknoglobal
+var padLayouts = []padLayout{
+	{name: "Xbox", next: keymap.PadA, previous: keymap.PadB, flip: keymap.PadX, tint: keymap.PadY},
+	{name: "Nintendo", next: keymap.PadB, previous: keymap.PadA, flip: keymap.PadY, tint: keymap.PadX},
+}
+
+// gamepad moves the selection with the left stick or the d-pad, and cycles,
+// flips and tints the sprites with the face buttons, as the layout has
+// them.
+type gamepad struct {
+	layout int
+	keys   keymap.Map
+	// Fraction of a pixel the stick moved that's left to move
+	restX float64
+	restY float64
+}
+
+// bind sets up the bindings of the current layout, the d-pad going by the
+// arrow trigger like the keys.
+func (p *gamepad) bind(g *Game) {
+	l := padLayouts[p.layout]
+	trigger := moveTriggers[g.moveTrigger].trigger
+	move := func(x, y int) keymap.Action {
+		return keymap.Do(func() { g.moveSelected(x, y) })
+	}
+
+	p.keys = keymap.Map{
+		{Pads: keymap.Pads(keymap.PadUp), Trigger: trigger, Action: move(0, -translateFactor)},
+		{Pads: keymap.Pads(keymap.PadDown), Trigger: trigger, Action: move(0, translateFactor)},
+		{Pads: keymap.Pads(keymap.PadLeft), Trigger: trigger, Action: move(-translateFactor, 0)},
+		{Pads: keymap.Pads(keymap.PadRight), Trigger: trigger, Action: move(translateFactor, 0)},
+		{Pads: keymap.Pads(l.next), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.cycleSprite(1) })},
+		{Pads: keymap.Pads(l.previous), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.cycleSprite(-1) })},
+		{Pads: keymap.Pads(l.flip), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.flipSelected(true, false) })},
+		{Pads: keymap.Pads(l.tint), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleTint)},
+		{Pads: keymap.Pads(keymap.PadBack), Trigger: keymap.Pressed, Action: keymap.Do(g.nextPadLayout)},
+	}
+}
+
+// nextPadLayout swaps the gamepad bindings for the next layout.
+func (g *Game) nextPadLayout() {
+	g.pad.layout = (g.pad.layout + 1) % len(padLayouts)
+	g.pad.bind(g)
+	g.notify.Push("Gamepad layout: %s", padLayouts[g.pad.layout].name)
+}
+
+// Update runs the bindings, and moves the selection with the left stick of
+// every gamepad.
+func (p *gamepad) Update(g *Game) error {
+	if err := p.keys.Update(); err != nil {
+		return err
+	}
+
+	src, vx, vy := keymap.Input(), 0.0, 0.0
+
+	for _, id := range src.GamepadIDs() {
+		if src.GamepadAxisNum(id) < 2 {
+			continue
+		}
+
+		x, y := deadzone(src.GamepadAxis(id, 0), src.GamepadAxis(id, 1))
+		vx += x * stickSpeed
+		vy += y * stickSpeed
+	}
+
+	if vx == 0 && vy == 0 {
+		p.restX, p.restY = 0, 0
+
+		return nil
+	}
+
+	p.restX += vx
+	p.restY += vy
+	dx, dy := int(p.restX), int(p.restY)
+	p.restX -= float64(dx)
+	p.restY -= float64(dy)
+
+	if dx != 0 || dy != 0 {
+		g.moveSelected(dx, dy)
+	}
+
+	return nil
+}
+
+// deadzone drops stick deflections within the deadzone, and scales the
+// rest so they start from 0 at its edge, keeping the direction. A radial
+// deadzone, unlike one per axis, doesn't snap diagonals to the axes.
+func deadzone(x, y float64) (float64, float64) {
+	m := math.Hypot(x, y)
+	if m <= stickDeadzone {
+		return 0, 0
+	}
+
+	k := math.Min((m-stickDeadzone)/(1-stickDeadzone), 1) / m
+
+	return x * k, y * k
+}
+
+// cycleSprite selects the next sprite, or the previous one for -1.
+func (g *Game) cycleSprite(d int) {
+	g.activeSprite = (g.activeSprite + d + len(g.s)) % len(g.s)
+	g.selected = []int{g.activeSprite}
+}
+
+// HUD describes the gamepad layout, if there's any gamepad.
+func (p *gamepad) HUD() string {
+	if len(keymap.Input().GamepadIDs()) == 0 {
+		return ""
+	}
+
+	l := padLayouts[p.layout]
+
+	return "\nGamepad (" + l.name + ", Back or P swaps): stick or d-pad moves, face buttons cycle, flip and tint"
+}
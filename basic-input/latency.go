@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Frames the screen stays flashed after a click
+const flashFrames = 3
+
+// latencyStats holds the measurements taken with a given vsync setting.
+type latencyStats struct {
+	delays []time.Duration
+	ticks  []int
+}
+
+func (s *latencyStats) add(d time.Duration, ticks int) {
+	s.delays = append(s.delays, d)
+	s.ticks = append(s.ticks, ticks)
+}
+
+func (s *latencyStats) String() string {
+	n := len(s.delays)
+	if n == 0 {
+		return "no samples"
+	}
+
+	sorted := append([]time.Duration(nil), s.delays...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+
+	ticks := 0
+	for _, t := range s.ticks {
+		ticks += t
+	}
+
+	return fmt.Sprintf("n=%d min=%.1fms mean=%.1fms p95=%.1fms max=%.1fms ticks=%.2f",
+		n, ms(sorted[0]), ms(sum/time.Duration(n)), ms(sorted[(n*95)/100]),
+		ms(sorted[n-1]), float64(ticks)/float64(n))
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// latencyProbe measures the time between a click being registered in Update
+// and the Draw that shows the response (a screen flash). It's only an
+// approximation of the real input to photon latency: it misses the OS and
+// driver queues before ebiten sees the click, and the swap and the display
+// after Draw. It does show the effect of vsync and of the TPS/FPS mismatch.
+type latencyProbe struct {
+	enabled bool
+	tick    int
+
+	pending     bool
+	pendingTick int
+	pendingAt   time.Time
+	flash       int
+
+	// Keyed by whether vsync was enabled
+	stats map[bool]*latencyStats
+	keys  keymap.Map
+}
+
+func newLatencyProbe() *latencyProbe {
+	l := &latencyProbe{
+		stats: map[bool]*latencyStats{
+			true:  {},
+			false: {},
+		},
+	}
+	l.keys = l.bindings()
+
+	return l
+}
+
+func (l *latencyProbe) Update() {
+	l.tick++
+	_ = l.keys.Update()
+}
+
+func (l *latencyProbe) bindings() keymap.Map {
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyL), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			l.enabled = !l.enabled
+			l.pending = false
+		})},
+		{Keys: keymap.Keys(ebiten.KeyV), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			if l.enabled {
+				ebiten.SetVsyncEnabled(!ebiten.IsVsyncEnabled())
+			}
+		})},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			if l.enabled && !l.pending {
+				l.pending = true
+				l.pendingTick = l.tick
+				l.pendingAt = time.Now()
+			}
+		})},
+	}
+}
+
+// Draw flashes the screen if there's a click to respond to, measuring how
+// long it took since it was registered.
+func (l *latencyProbe) Draw(screen *ebiten.Image) {
+	if !l.enabled {
+		return
+	}
+
+	if l.pending {
+		l.pending = false
+		l.flash = flashFrames
+
+		d := time.Since(l.pendingAt)
+		ticks := l.tick - l.pendingTick
+		vsync := ebiten.IsVsyncEnabled()
+		l.stats[vsync].add(d, ticks)
+
+		log.Printf("latency: %.1fms, %d ticks (vsync %v)", ms(d), ticks, vsync)
+	}
+
+	if l.flash > 0 {
+		l.flash--
+		_ = screen.Fill(color.White)
+	}
+}
+
+// Summary returns the session statistics, for the HUD.
+func (l *latencyProbe) Summary() string {
+	if !l.enabled {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("\nLatency mode (L to exit, V to toggle vsync, now ")
+	sb.WriteString(fmt.Sprint(ebiten.IsVsyncEnabled()))
+	sb.WriteString("), click to measure\n")
+	sb.WriteString("vsync on:  " + l.stats[true].String() + "\n")
+	sb.WriteString("vsync off: " + l.stats[false].String())
+
+	return sb.String()
+}
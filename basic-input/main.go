@@ -1,150 +1,451 @@
 package main
 
 import (
-	"errors"
-	"fmt"
-	"image/color"
+	"flag"
 	_ "image/png"
 	"log"
+	"math"
+	"strings"
 
+	"github.com/antoniomo/ebiten-exercises/internal/group"
+	"github.com/antoniomo/ebiten-exercises/internal/hint"
+	"github.com/antoniomo/ebiten-exercises/internal/i18n"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/layer"
+	"github.com/antoniomo/ebiten-exercises/internal/notify"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/antoniomo/ebiten-exercises/internal/sprite"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
-	"github.com/hajimehoshi/ebiten/inpututil"
 )
 
+//nolint:gochecknoglobal
+var footerHints = []hint.Hint{
+	{Binding: "Select", What: "Select"},
+	{Binding: "Flip horizontally", What: "Flip"},
+	{Binding: "Cycle tint", What: "Tint"},
+	{Binding: "Cycle anchor", What: "Anchor"},
+	{Binding: "Cycle arrow trigger", What: "Arrows"},
+	{Binding: "Quit", What: "Quit"},
+}
+
 const (
 	translateFactor = 10
 	screenWidth     = 640
 	screenHeight    = 480
-)
-
-var (
-	ErrCleanExit = errors.New("clean exit, no error")
+	// Ticks a sprite keeps walking after its last move, so tapping the
+	// arrows doesn't just twitch it
+	walkTicks = 12
 )
 
 // Sprite is from the ebiten drag and drop (drag) example.
 type Sprite struct {
-	id  string
-	img *ebiten.Image
-	x   int
-	y   int
+	id string
+	// Frame showing, of anim
+	img  *ebiten.Image
+	anim *sprite.Player
+	x    int
+	y    int
+	// Where it was last tick, and how it's walking, see animate
+	lastX, lastY int
+	facingLeft   bool
+	walking      int
+	// See transform.go, anchor and tint are indices in anchors and tints
+	flipX  bool
+	flipY  bool
+	anchor int
+	tint   int
+}
+
+func newSprite(id string, sheet *sprite.Sheet, x, y int) *Sprite {
+	anim := sprite.NewPlayer(sheet, "idle")
+
+	return &Sprite{id: id, img: anim.Image(), anim: anim, x: x, y: y, lastX: x, lastY: y}
 }
 
 func (s *Sprite) In(x, y int) bool {
-	// Check the actual color (alpha) value at the specified position
-	// so that the result of In becomes natural to users.
-	//
-	// Note that this is not a good manner to use At for logic
-	// since color from At might include some errors on some machines.
-	// As this is not so important logic, it's ok to use it so far.
-	return s.img.At(x-s.x, y-s.y).(color.RGBA).A > 0
+	// Check the actual alpha value at the specified position so that the
+	// result of In becomes natural to users. The sheet reads it from its
+	// decoded image, not back from the GPU.
+	m := s.geoM()
+	m.Invert()
+	ix, iy := m.Apply(float64(x), float64(y))
+
+	return s.anim.Opaque(int(math.Floor(ix)), int(math.Floor(iy)))
+}
+
+// animate plays the walk animation facing the way the sprite last moved
+// sideways while it moves, and the idle one once it stops.
+func (s *Sprite) animate() {
+	if s.x != s.lastX || s.y != s.lastY {
+		if s.x != s.lastX {
+			s.facingLeft = s.x < s.lastX
+		}
+
+		s.walking = walkTicks
+	}
+
+	s.lastX, s.lastY = s.x, s.y
+
+	switch {
+	case s.walking == 0:
+		s.anim.Play("idle")
+	case s.facingLeft:
+		s.anim.Play("walk-left")
+	default:
+		s.anim.Play("walk-right")
+	}
+
+	if s.walking > 0 {
+		s.walking--
+	}
+
+	s.anim.Update()
+	s.img = s.anim.Image()
 }
 
-// MoveBy moves the sprite by (x, y).
+// MoveBy moves the sprite by (x, y), keeping it on the screen as drawn.
 func (s *Sprite) MoveBy(x, y int) {
 	w, h := s.img.Size()
+	ox, oy := s.bounds()
 
 	s.x += x
 	s.y += y
 
-	if s.x < 0 {
-		s.x = 0
+	if s.x+ox < 0 {
+		s.x = -ox
 	}
 
-	if s.x > screenWidth-w {
-		s.x = screenWidth - w
+	if s.x+ox > screenWidth-w {
+		s.x = screenWidth - w - ox
 	}
 
-	if s.y < 0 {
-		s.y = 0
+	if s.y+oy < 0 {
+		s.y = -oy
 	}
 
-	if s.y > screenHeight-h {
-		s.y = screenHeight - h
+	if s.y+oy > screenHeight-h {
+		s.y = screenHeight - h - oy
 	}
 }
 
 func (s *Sprite) Draw(screen *ebiten.Image, dx, dy int) {
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(s.x+dx), float64(s.y+dy))
+	op := &ebiten.DrawImageOptions{GeoM: s.geoM(), ColorM: s.colorM()}
+	op.GeoM.Translate(float64(dx), float64(dy))
 	screen.DrawImage(s.img, op)
 }
 
 type Game struct {
 	s            []*Sprite
 	activeSprite int
+	// Sprites moving together, the active one is always among them
+	selected []int
+	groups   group.Groups
+	latency  *latencyProbe
+	window   *windowDemo
+	// Index in moveTriggers
+	moveTrigger int
+	keys        keymap.Map
+	remap       *remapScreen
+	pad         gamepad
+	touch       touchInput
+	// Control hints along the bottom, in the player's language
+	footer   hint.Footer
+	notify   *notify.Notifier
+	renderer layer.Renderer
 }
 
-func (g *Game) Update(screen *ebiten.Image) error {
-	if ebiten.IsKeyPressed(ebiten.KeyUp) {
-		g.s[g.activeSprite].MoveBy(0, -translateFactor)
+// moveSelected moves the selected sprites by (x, y), clamping the move so
+// the bounding box of the whole selection stays on screen, and the sprites
+// keep their formation.
+func (g *Game) moveSelected(x, y int) {
+	minX, minY := screenWidth, screenHeight
+	maxX, maxY := 0, 0
+
+	for _, i := range g.selected {
+		s := g.s[i]
+		w, h := s.img.Size()
+		ox, oy := s.bounds()
+		minX, minY = min(minX, s.x+ox), min(minY, s.y+oy)
+		maxX, maxY = max(maxX, s.x+ox+w), max(maxY, s.y+oy+h)
+	}
+
+	x = max(-minX, min(x, screenWidth-maxX))
+	y = max(-minY, min(y, screenHeight-maxY))
+
+	for _, i := range g.selected {
+		g.s[i].MoveBy(x, y)
 	}
+}
+
+// toggleSelected adds or removes the sprite from the selection, keeping at
+// least one sprite selected.
+func (g *Game) toggleSelected(i int) {
+	for j, s := range g.selected {
+		if s == i {
+			if len(g.selected) > 1 {
+				g.selected = append(g.selected[:j], g.selected[j+1:]...)
+				g.activeSprite = g.selected[0]
+			}
 
-	if ebiten.IsKeyPressed(ebiten.KeyDown) {
-		g.s[g.activeSprite].MoveBy(0, translateFactor)
+			return
+		}
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
-		g.s[g.activeSprite].MoveBy(-translateFactor, 0)
+	g.selected = append(g.selected, i)
+	g.activeSprite = i
+}
+
+// moveTriggers are the ways the arrows can move the selection, T cycles
+// through them to compare.
+//
+//nolint:gochecknoglobal
+var moveTriggers = []struct {
+	name    string
+	trigger keymap.Trigger
+}{
+	{"held", keymap.Held},
+	{"repeat", keymap.Repeat},
+	{"pressed", keymap.Pressed},
+	{"released", keymap.Released},
+}
+
+// bindings is the input of the game, the arrows triggering as set with T.
+// The named ones can be remapped, see remapScreen.
+func (g *Game) bindings() keymap.Map {
+	trigger := moveTriggers[g.moveTrigger].trigger
+	move := func(x, y int) keymap.Action {
+		return keymap.Do(func() { g.moveSelected(x, y) })
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyRight) {
-		g.s[g.activeSprite].MoveBy(translateFactor, 0)
+	return keymap.Map{
+		{Name: "Move up", Keys: keymap.Keys(ebiten.KeyUp), Trigger: trigger, Action: move(0, -translateFactor)},
+		{Name: "Move down", Keys: keymap.Keys(ebiten.KeyDown), Trigger: trigger, Action: move(0, translateFactor)},
+		{Name: "Move left", Keys: keymap.Keys(ebiten.KeyLeft), Trigger: trigger, Action: move(-translateFactor, 0)},
+		{Name: "Move right", Keys: keymap.Keys(ebiten.KeyRight), Trigger: trigger, Action: move(translateFactor, 0)},
+		{Name: "Cycle arrow trigger", Keys: keymap.Keys(ebiten.KeyT), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleMoveTrigger)},
+		{Name: "Flip horizontally", Keys: keymap.Keys(ebiten.KeyH), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.flipSelected(true, false)
+		})},
+		{Name: "Flip vertically", Keys: keymap.Keys(ebiten.KeyJ), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.flipSelected(false, true)
+		})},
+		{Name: "Cycle tint", Keys: keymap.Keys(ebiten.KeyC), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleTint)},
+		{Name: "Cycle anchor", Keys: keymap.Keys(ebiten.KeyA), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleAnchor)},
+		{Name: "Select", Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(g.click)},
+		{Name: "Quit", Keys: keymap.Keys(ebiten.KeyEscape), Action: func() error { return run.ErrCleanExit }},
+		{Keys: keymap.Keys(ebiten.KeyR), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.remap.open = true })},
+		{Keys: keymap.Keys(ebiten.KeyP), Trigger: keymap.Pressed, Action: keymap.Do(g.nextPadLayout)},
 	}
+}
 
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		cx, cy := ebiten.CursorPosition()
-		// Because we draw in slice order, the latest is the one on top,
-		// so check from latest to first
-		for i := len(g.s) - 1; i >= 0; i-- {
-			s := g.s[i]
-			if s.In(cx, cy) {
-				g.activeSprite = i
+func (g *Game) cycleMoveTrigger() {
+	g.moveTrigger = (g.moveTrigger + 1) % len(moveTriggers)
+	g.keys = g.bindings()
+	g.remap.apply(g)
+	g.pad.bind(g)
+	g.notify.Push("Arrows now trigger when %s", moveTriggers[g.moveTrigger].name)
+}
 
-				break
-			}
+// click selects the sprite under the cursor. Shift+click builds up a
+// selection, as in RTS games.
+func (g *Game) click() {
+	cx, cy := keymap.Input().CursorPosition()
+	g.selectAt(cx, cy, keymap.IsKeyPressed(ebiten.KeyShift))
+}
+
+// selectAt selects the sprite at (x, y), or adds it to the selection, or
+// takes it out, if add. It reports whether there was a sprite there.
+func (g *Game) selectAt(x, y int, add bool) bool {
+	// Because we draw in slice order, the latest is the one on top,
+	// so check from latest to first
+	for i := len(g.s) - 1; i >= 0; i-- {
+		if !g.s[i].In(x, y) {
+			continue
+		}
+
+		if add {
+			g.toggleSelected(i)
+		} else {
+			g.activeSprite = i
+			g.selected = []int{i}
 		}
+
+		return true
+	}
+
+	return false
+}
+
+func (g *Game) Update(screen *ebiten.Image) error {
+	if g.window.Update() {
+		return nil
+	}
+
+	if g.remap.Update(g) {
+		g.notify.Update()
+
+		return nil
+	}
+
+	g.latency.Update()
+
+	if err := g.keys.Update(); err != nil {
+		return err
+	}
+
+	if err := g.pad.Update(g); err != nil {
+		return err
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		return ErrCleanExit
+	g.touch.Update(g)
+
+	if members, ok := g.groups.Update(g.selected); ok {
+		g.selected = members
+		g.activeSprite = members[0]
+	}
+
+	for _, s := range g.s {
+		s.animate()
 	}
 
+	g.notify.Update()
+
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	ebitenutil.DebugPrint(screen, "Active sprite: "+g.s[g.activeSprite].id)
+	// The latency flash is a background fill, the sprites stay visible
+	g.renderer.AddFunc(layer.Background, g.latency.Draw)
 
 	for _, s := range g.s {
-		s.Draw(screen, 0, 0)
+		s := s
+		g.renderer.AddFunc(layer.World, func(screen *ebiten.Image) {
+			s.Draw(screen, 0, 0)
+		})
+	}
+
+	// The anchors of the selection over the sprites
+	g.renderer.AddFunc(layer.World+1, func(screen *ebiten.Image) {
+		for _, i := range g.selected {
+			g.s[i].drawAnchor(screen)
+		}
+	})
+
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		ebitenutil.DebugPrint(screen, "Active sprite: "+g.s[g.activeSprite].id+
+			"  Selected: "+g.selectedIDs()+" (Shift+click, Ctrl+1..9 to group, 1..9 to recall)"+
+			"\nArrows: "+moveTriggers[g.moveTrigger].name+" (T)  R remaps the keys"+
+			"\n"+g.s[g.activeSprite].transformHUD()+g.pad.HUD()+g.touch.HUD()+
+			g.latency.Summary())
+	})
+
+	g.renderer.AddFunc(layer.UI, g.window.Draw)
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		g.footer.Draw(screen, g.keys)
+	})
+	g.renderer.AddFunc(layer.UI+1, func(screen *ebiten.Image) {
+		g.remap.Draw(screen, g)
+	})
+	g.renderer.Add(layer.UI+2, g.notify)
+	g.renderer.Draw(screen)
+}
+
+func (g *Game) selectedIDs() string {
+	ids := make([]string, len(g.selected))
+	for i, s := range g.selected {
+		ids[i] = g.s[s].id
+	}
+
+	return strings.Join(ids, ",")
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
 	}
+
+	return b
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenW, screenH int) {
 	return screenWidth, screenHeight
 }
 
+// newGame sets up the three sprites and the demos, with the keys as remap
+// has them.
+func newGame(sheet *sprite.Sheet, monitors int, remap *remapScreen, locale i18n.Locale) *Game {
+	g := &Game{
+		s: []*Sprite{
+			newSprite("0", sheet, 0, 0),
+			newSprite("1", sheet, 100, 100),
+			newSprite("2", sheet, 300, 200),
+		},
+		selected: []int{0},
+		latency:  newLatencyProbe(),
+		window:   newWindowDemo(monitors),
+		notify:   notify.New(),
+		remap:    remap,
+		footer:   hint.Footer{Locale: locale, Hints: footerHints},
+	}
+	g.keys = g.bindings()
+	g.remap.apply(g)
+	g.remap.bind(g)
+	g.pad.bind(g)
+
+	return g
+}
+
 func main() {
-	img, _, err := ebitenutil.NewImageFromFile("../images/gopher.png", ebiten.FilterDefault)
+	monitors := flag.Int("monitors", 1, "monitors side by side, for F7 to move the window across")
+	keysPath := flag.String("keys", "keys.json", "file to keep the remapped keys (R) in")
+	lang := flag.String("lang", "", "language and keyboard of the control hints, like fr or en-azerty, from LANG if empty")
+	script := flag.String("script", "", "input script to play and check instead of the devices, see input-test.txt")
+	sheetPath := flag.String("sheet", "../images/gopher-sheet.json", "sprite sheet of the sprites, with idle, walk-left and walk-right animations")
+	flag.Parse()
+
+	if *monitors < 1 {
+		log.Fatal("there must be at least one monitor")
+	}
+
+	locale := i18n.Detect()
+	if *lang != "" {
+		l, err := i18n.Parse(*lang)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		locale = l
+	}
+
+	remap, err := newRemapScreen(*keysPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	g := &Game{
-		s: []*Sprite{{"0", img, 0, 0}, {"1", img, 100, 100}},
+	sheet, err := sprite.Load(*sheetPath)
+	if err != nil {
+		log.Fatal(err)
 	}
 
+	g := newGame(sheet, *monitors, remap, locale)
+
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Basic Input")
 
-	if err := ebiten.RunGame(g); err != nil {
-		if errors.Is(err, ErrCleanExit) {
-			fmt.Println("Good bye!")
-
-			return
-		}
+	if *script != "" {
+		run.Exit(run.Game(newScriptTest(g, *script)))
 
-		log.Fatal(err)
+		return
 	}
+
+	run.Exit(run.Game(g))
 }
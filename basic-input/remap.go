@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Where the remapping list starts, and the height of its rows
+	remapTop = 48
+	remapRow = 16
+	remapX   = 32
+)
+
+//nolint:gochecknoglobal
+var (
+	remapBackground = color.RGBA{0, 0, 0, 0xe0}
+	remapCursor     = color.RGBA{0x30, 0x50, 0x90, 0xff}
+	// Taken by keys outside the remappable map, group recall, R itself and
+	// the gamepad layout
+	reservedInputs = []string{"R", "P", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+)
+
+// remapScreen lists the named bindings of the game (R), to rebind them:
+// click one, or pick it with the arrows and Enter, and press the new key or
+// mouse button. Taking an input another binding has swaps theirs.
+type remapScreen struct {
+	open bool
+	row  int
+	// Waiting for the new input of the binding in row
+	capturing bool
+	// The config file, the remappings are saved there as they're made
+	path   string
+	config keymap.Config
+	keys   keymap.Map
+}
+
+func newRemapScreen(path string) (*remapScreen, error) {
+	c, err := keymap.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remapScreen{path: path, config: c}, nil
+}
+
+// bind sets up the input of the screen. It's not remappable, so there's
+// always a way back.
+func (r *remapScreen) bind(g *Game) {
+	rows := func() int { return len(r.rows(g)) }
+
+	r.keys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyUp), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			r.row = (r.row + rows() - 1) % rows()
+		})},
+		{Keys: keymap.Keys(ebiten.KeyDown), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			r.row = (r.row + 1) % rows()
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEnter), Trigger: keymap.Pressed, Action: keymap.Do(func() { r.capturing = true })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			_, cy := keymap.Input().CursorPosition()
+			if i := (cy - remapTop) / remapRow; cy >= remapTop && i < rows() {
+				r.row = i
+				r.capturing = true
+			}
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEscape, ebiten.KeyR), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			r.open = false
+		})},
+	}
+}
+
+// rows returns the indices of the remappable bindings in the game keys.
+func (r *remapScreen) rows(g *Game) []int {
+	var rows []int
+
+	for i := range g.keys {
+		if g.keys[i].Name != "" {
+			rows = append(rows, i)
+		}
+	}
+
+	return rows
+}
+
+// apply remaps the game keys as configured, for when they're rebuilt.
+func (r *remapScreen) apply(g *Game) {
+	if err := g.keys.Apply(r.config); err != nil {
+		log.Printf("remapping: %v", err)
+	}
+}
+
+// Update handles the screen while open, and reports whether it is.
+func (r *remapScreen) Update(g *Game) bool {
+	if !r.open {
+		return false
+	}
+
+	if !r.capturing {
+		_ = r.keys.Update()
+
+		return true
+	}
+
+	input, ok := keymap.JustPressed()
+	if !ok {
+		return true
+	}
+
+	r.capturing = false
+
+	if input == keymap.KeyName(ebiten.KeyEscape) {
+		return true
+	}
+
+	r.rebind(g, r.rows(g)[r.row], input)
+
+	return true
+}
+
+// rebind makes input trigger binding i, instead of whatever it did before.
+func (r *remapScreen) rebind(g *Game, i int, input string) {
+	b := &g.keys[i]
+
+	for _, in := range reservedInputs {
+		if in == input {
+			g.notify.Push("%s is taken by the fixed keys", input)
+
+			return
+		}
+	}
+
+	if g.window.keys.Using(input, b.Ctrl) >= 0 {
+		g.notify.Push("%s is taken by the window keys", input)
+
+		return
+	}
+
+	old := b.Inputs()
+
+	if j := g.keys.Using(input, b.Ctrl); j == i {
+		return
+	} else if j >= 0 {
+		other := &g.keys[j]
+		if other.Name == "" {
+			g.notify.Push("%s is taken by a fixed key", input)
+
+			return
+		}
+
+		// Swap, so the other binding isn't left without an input
+		_ = other.SetInputs(old)
+		g.notify.Push("%s was on %s, it's on %s now", other.Name, input, strings.Join(old, ", "))
+	}
+
+	_ = b.SetInputs([]string{input})
+	g.notify.Push("%s is on %s", b.Name, input)
+
+	r.config = g.keys.Config()
+	if err := r.config.Save(r.path); err != nil {
+		log.Printf("saving %s: %v", r.path, err)
+		g.notify.Push("Saving the keys failed, see the log")
+	}
+}
+
+func (r *remapScreen) Draw(screen *ebiten.Image, g *Game) {
+	if !r.open {
+		return
+	}
+
+	sw, sh := screen.Size()
+	ebitenutil.DrawRect(screen, 0, 0, float64(sw), float64(sh), remapBackground)
+	ebitenutil.DebugPrintAt(screen, "Remap keys: click an action, or pick it with the arrows and Enter,\n"+
+		"then press the new key or button. Esc or R goes back.", remapX, 8)
+
+	for row, i := range r.rows(g) {
+		y := remapTop + row*remapRow
+		if row == r.row {
+			ebitenutil.DrawRect(screen, remapX-4, float64(y), float64(sw-2*remapX), remapRow, remapCursor)
+		}
+
+		inputs := g.keys[i].Label(g.footer.Locale)
+		if row == r.row && r.capturing {
+			inputs = "press a key or button (Esc cancels)"
+		}
+
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%-24s %s", g.keys[i].Name, inputs), remapX, y)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// scriptTest plays an input script (-script) through the game instead of
+// the devices, a tick at a time, and checks where the sprites end up. A
+// script is a line per step, # comments:
+//
+//	press Right         hold a key or mouse button down, by its config name
+//	release Right       let go of it
+//	tap H               press, a tick, release, a tick
+//	cursor 120 200      move the mouse
+//	click 120 200       move the mouse, then tap the left button
+//	wait 3              run the game that many ticks
+//	expect sprite 2 at 330 200
+//	expect active 2
+//	expect selected 2,0
+//
+// The whole script runs within the first tick of the window, so it's
+// frame perfect however fast the machine is, and the report of the expects
+// goes to the output. The game quits after, with an error if any failed.
+// go test plays input-test.txt the same way, without a window.
+type scriptTest struct {
+	g      *Game
+	path   string
+	source *keymap.Script
+	out    io.Writer
+	passed int
+	failed int
+}
+
+func newScriptTest(g *Game, path string) *scriptTest {
+	// Scripts go by the default keys, not the remapped ones, and run
+	// whether the window has the focus or not
+	g.remap.config = keymap.Config{}
+	g.keys = g.bindings()
+	g.window.autoPause = false
+
+	return &scriptTest{g: g, path: path, source: keymap.NewScript(), out: os.Stdout}
+}
+
+func (t *scriptTest) Update(screen *ebiten.Image) error {
+	if err := t.run(screen); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(t.out, "%d passed, %d failed\n", t.passed, t.failed)
+
+	if t.failed > 0 {
+		return fmt.Errorf("%s: %d of %d expectations failed", t.path, t.failed, t.passed+t.failed)
+	}
+
+	return run.ErrCleanExit
+}
+
+// run plays the script, with the bindings reading from it meanwhile.
+func (t *scriptTest) run(screen *ebiten.Image) error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	keymap.SetSource(t.source)
+	defer keymap.SetSource(nil)
+
+	sc := bufio.NewScanner(f)
+
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if err := t.step(screen, line, fields[0], fields[1:]); err != nil {
+			return fmt.Errorf("%s:%d: %v", t.path, line, err)
+		}
+	}
+
+	return sc.Err()
+}
+
+// step runs a line of the script.
+func (t *scriptTest) step(screen *ebiten.Image, line int, cmd string, args []string) error {
+	// Input names can have spaces, like Mouse Left
+	name := strings.Join(args, " ")
+
+	switch cmd {
+	case "press":
+		return t.source.Press(name)
+	case "release":
+		return t.source.Release(name)
+	case "tap":
+		return t.tap(screen, name)
+	case "cursor", "click":
+		x, y, err := point(args)
+		if err != nil {
+			return err
+		}
+
+		t.source.MoveCursor(x, y)
+
+		if cmd == "click" {
+			return t.tap(screen, keymap.ButtonName(ebiten.MouseButtonLeft))
+		}
+
+		return nil
+	case "wait":
+		n, err := strconv.Atoi(name)
+		if err != nil {
+			return err
+		}
+
+		return t.ticks(screen, n)
+	case "expect":
+		return t.expect(line, args)
+	}
+
+	return fmt.Errorf("unknown step %q", cmd)
+}
+
+// tap presses and releases the named input, a tick each.
+func (t *scriptTest) tap(screen *ebiten.Image, name string) error {
+	if err := t.source.Press(name); err != nil {
+		return err
+	}
+
+	if err := t.ticks(screen, 1); err != nil {
+		return err
+	}
+
+	if err := t.source.Release(name); err != nil {
+		return err
+	}
+
+	return t.ticks(screen, 1)
+}
+
+// ticks runs the game n ticks.
+func (t *scriptTest) ticks(screen *ebiten.Image, n int) error {
+	for i := 0; i < n; i++ {
+		t.source.Tick()
+
+		if err := t.g.Update(screen); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expect checks the game against an expect line, and reports it.
+func (t *scriptTest) expect(line int, args []string) error {
+	var got, want string
+
+	switch {
+	case len(args) == 5 && args[0] == "sprite" && args[2] == "at":
+		s := t.g.sprite(args[1])
+		if s == nil {
+			return fmt.Errorf("no sprite %s", args[1])
+		}
+
+		x, y, err := point(args[3:])
+		if err != nil {
+			return err
+		}
+
+		got, want = fmt.Sprintf("sprite %s at %d,%d", s.id, s.x, s.y), fmt.Sprintf("sprite %s at %d,%d", s.id, x, y)
+	case len(args) == 2 && args[0] == "active":
+		got, want = "active "+t.g.s[t.g.activeSprite].id, "active "+args[1]
+	case len(args) == 2 && args[0] == "selected":
+		got, want = "selected "+t.g.selectedIDs(), "selected "+args[1]
+	default:
+		return fmt.Errorf("unknown expectation %q", strings.Join(args, " "))
+	}
+
+	if got == want {
+		t.passed++
+		fmt.Fprintf(t.out, "%s:%d: ok, %s\n", t.path, line, got)
+	} else {
+		t.failed++
+		fmt.Fprintf(t.out, "%s:%d: FAIL, %s, want %s\n", t.path, line, got, want)
+	}
+
+	return nil
+}
+
+// sprite returns the sprite with the id, or nil.
+func (g *Game) sprite(id string) *Sprite {
+	for _, s := range g.s {
+		if s.id == id {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func point(args []string) (int, int, error) {
+	if len(args) != 2 {
+		return 0, 0, fmt.Errorf("want x y, got %q", strings.Join(args, " "))
+	}
+
+	x, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	y, err := strconv.Atoi(args[1])
+
+	return x, y, err
+}
+
+func (t *scriptTest) Draw(screen *ebiten.Image) {
+	t.g.Draw(screen)
+}
+
+func (t *scriptTest) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return t.g.Layout(outsideWidth, outsideHeight)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/antoniomo/ebiten-exercises/internal/i18n"
+	"github.com/antoniomo/ebiten-exercises/internal/sprite"
+)
+
+// TestInputScript plays input-test.txt headless, as -script does in the
+// window, through keymap's script source instead of the devices.
+func TestInputScript(t *testing.T) {
+	sheet, err := sprite.Load("../images/gopher-sheet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+
+	st := newScriptTest(newGame(sheet, 1, &remapScreen{}, i18n.Locale{}), "input-test.txt")
+	st.out = &out
+
+	if err := st.run(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if st.failed > 0 || st.passed == 0 {
+		t.Errorf("%d passed, %d failed:\n%s", st.passed, st.failed, out.String())
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/antoniomo/ebiten-exercises/internal/gesture"
+)
+
+// touchInput is the touchscreen, on mobile and in browsers: tapping a
+// sprite selects it, dragging one selects it and drags the selection along,
+// and tapping with two fingers cycles the sprites. It goes through the same
+// selection and moves as the mouse and the arrows.
+type touchInput struct {
+	gestures *gesture.Recognizer
+	// Whether the drag going on started on a sprite
+	dragging bool
+	// Whether the screen was ever touched, for the HUD
+	seen bool
+}
+
+// Update follows the fingers on the screen.
+func (t *touchInput) Update(g *Game) {
+	if t.gestures == nil {
+		t.gestures = gesture.New()
+	}
+
+	for _, e := range t.gestures.Update() {
+		switch e.Kind {
+		case gesture.Tap:
+			g.selectAt(e.X, e.Y, false)
+		case gesture.TwoFingerTap:
+			g.cycleSprite(1)
+		case gesture.DragStart:
+			t.dragging = g.selectAt(e.X, e.Y, false)
+		case gesture.Drag:
+			if t.dragging && (e.DX != 0 || e.DY != 0) {
+				g.moveSelected(e.DX, e.DY)
+			}
+		case gesture.DragEnd:
+			t.dragging = false
+		case gesture.LongPress, gesture.Pinch:
+		}
+	}
+
+	t.seen = t.seen || t.gestures.Active()
+}
+
+// HUD describes the touch controls, once the screen has been touched.
+func (t *touchInput) HUD() string {
+	if !t.seen {
+		return ""
+	}
+
+	return "\nTouch: tap selects, drag moves the selection, two-finger tap cycles"
+}
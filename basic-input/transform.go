@@ -0,0 +1,146 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// Half the size of the anchor cross
+const anchorSize = 4
+
+//nolint:gochecknoglobal
+var (
+	// Points of the sprite it flips around, as fractions of its size, A
+	// cycles through them
+	anchors = []struct {
+		name string
+		x, y float64
+	}{
+		{"top-left", 0, 0},
+		{"center", 0.5, 0.5},
+		{"feet", 0.5, 1},
+	}
+	// Colors C tints the sprite with, multiplying its own, so the first
+	// one leaves it as is
+	tints = []struct {
+		name string
+		clr  color.Color
+	}{
+		{"none", color.White},
+		{"red", color.RGBA{0xff, 0x60, 0x60, 0xff}},
+		{"green", color.RGBA{0x60, 0xff, 0x60, 0xff}},
+		{"blue", color.RGBA{0x60, 0x80, 0xff, 0xff}},
+		{"ghost", color.RGBA{0xff, 0xff, 0xff, 0x80}},
+	}
+	anchorColor = color.RGBA{0xff, 0xff, 0, 0xff}
+)
+
+// geoM places the sprite on the screen, flipped around its anchor, which
+// stays put: the top-left corner of the image is at (x, y) unflipped.
+func (s *Sprite) geoM() ebiten.GeoM {
+	ax, ay := s.anchorAt()
+	sx, sy := 1.0, 1.0
+
+	if s.flipX {
+		sx = -1
+	}
+
+	if s.flipY {
+		sy = -1
+	}
+
+	var m ebiten.GeoM
+	m.Translate(-ax, -ay)
+	m.Scale(sx, sy)
+	m.Translate(float64(s.x)+ax, float64(s.y)+ay)
+
+	return m
+}
+
+// anchorAt returns the anchor in image coordinates.
+func (s *Sprite) anchorAt() (float64, float64) {
+	w, h := s.img.Size()
+	a := anchors[s.anchor]
+
+	return a.x * float64(w), a.y * float64(h)
+}
+
+// bounds returns the top-left corner of the sprite as drawn, flips and all,
+// relative to (x, y).
+func (s *Sprite) bounds() (int, int) {
+	m := s.geoM()
+	w, h := s.img.Size()
+	x0, y0 := m.Apply(0, 0)
+	x1, y1 := m.Apply(float64(w), float64(h))
+
+	return int(math.Min(x0, x1)) - s.x, int(math.Min(y0, y1)) - s.y
+}
+
+// drawAnchor marks the anchor of the sprite with a cross.
+func (s *Sprite) drawAnchor(screen *ebiten.Image) {
+	m := s.geoM()
+	x, y := m.Apply(s.anchorAt())
+	ebitenutil.DrawLine(screen, x-anchorSize, y, x+anchorSize+1, y, anchorColor)
+	ebitenutil.DrawLine(screen, x, y-anchorSize, x, y+anchorSize+1, anchorColor)
+}
+
+// flipSelected flips the selection horizontally (x) and vertically (y). Like
+// the tints and anchors, all of it ends up the way the active sprite does.
+func (g *Game) flipSelected(x, y bool) {
+	active := g.s[g.activeSprite]
+	fx, fy := active.flipX != x, active.flipY != y
+
+	for _, i := range g.selected {
+		g.s[i].flipX, g.s[i].flipY = fx, fy
+		// Flipping around the top-left corner can take it off screen
+		g.s[i].MoveBy(0, 0)
+	}
+}
+
+func (g *Game) cycleTint() {
+	t := (g.s[g.activeSprite].tint + 1) % len(tints)
+	for _, i := range g.selected {
+		g.s[i].tint = t
+	}
+
+	g.notify.Push("Tint: %s", tints[t].name)
+}
+
+func (g *Game) cycleAnchor() {
+	a := (g.s[g.activeSprite].anchor + 1) % len(anchors)
+	for _, i := range g.selected {
+		g.s[i].anchor = a
+		g.s[i].MoveBy(0, 0)
+	}
+
+	g.notify.Push("Anchor: %s", anchors[a].name)
+}
+
+// transformHUD describes the flips, tint and anchor of the active sprite.
+func (s *Sprite) transformHUD() string {
+	flip := "none"
+
+	switch {
+	case s.flipX && s.flipY:
+		flip = "both"
+	case s.flipX:
+		flip = "horizontal"
+	case s.flipY:
+		flip = "vertical"
+	}
+
+	return "Flip: " + flip + " (H and J)  Tint: " + tints[s.tint].name + " (C)  Anchor: " +
+		anchors[s.anchor].name + " (A)"
+}
+
+// colorM tints the sprite.
+func (s *Sprite) colorM() ebiten.ColorM {
+	var m ebiten.ColorM
+	m.Scale(shapes.ColorScale(tints[s.tint].clr))
+
+	return m
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+var (
+	pausedColor = color.RGBA{0, 0, 0, 0xa0}
+	// Window sizes F4 cycles through
+	windowSizes = [][2]int{{640, 480}, {960, 720}, {1280, 960}}
+)
+
+// windowDemo shows off the window and display APIs, behind the function
+// keys.
+type windowDemo struct {
+	legend    bool
+	size      int
+	autoPause bool
+	paused    bool
+	// Which of the monitors the window was sent to with F7, see
+	// nextMonitor
+	monitors int
+	monitor  int
+	keys     keymap.Map
+}
+
+func newWindowDemo(monitors int) *windowDemo {
+	// Keep updating without the focus, otherwise ebiten just stops and
+	// there's nothing to auto-pause
+	ebiten.SetRunnableOnUnfocused(true)
+
+	w := &windowDemo{legend: true, autoPause: true, monitors: monitors}
+	w.keys = w.bindings()
+
+	return w
+}
+
+func (w *windowDemo) bindings() keymap.Map {
+	key := func(k ebiten.Key, fn func()) keymap.Binding {
+		return keymap.Binding{Keys: keymap.Keys(k), Trigger: keymap.Pressed, Action: keymap.Do(fn)}
+	}
+
+	return keymap.Map{
+		key(ebiten.KeyF1, func() { w.legend = !w.legend }),
+		key(ebiten.KeyF2, func() { ebiten.SetWindowDecorated(!ebiten.IsWindowDecorated()) }),
+		key(ebiten.KeyF3, func() { ebiten.SetWindowResizable(!ebiten.IsWindowResizable()) }),
+		key(ebiten.KeyF4, func() {
+			w.size = (w.size + 1) % len(windowSizes)
+			ebiten.SetWindowSize(windowSizes[w.size][0], windowSizes[w.size][1])
+		}),
+		key(ebiten.KeyF5, centerWindow),
+		key(ebiten.KeyF6, func() { ebiten.SetFullscreen(!ebiten.IsFullscreen()) }),
+		key(ebiten.KeyF7, w.nextMonitor),
+		key(ebiten.KeyF8, func() {
+			if ebiten.IsWindowMaximized() {
+				ebiten.RestoreWindow()
+			} else {
+				ebiten.MaximizeWindow()
+			}
+		}),
+		key(ebiten.KeyF9, func() { ebiten.SetWindowFloating(!ebiten.IsWindowFloating()) }),
+		key(ebiten.KeyF10, func() { w.autoPause = !w.autoPause }),
+	}
+}
+
+// Update handles the window keys, and reports whether the game is paused.
+func (w *windowDemo) Update() bool {
+	w.paused = w.autoPause && !ebiten.IsFocused()
+	if w.paused {
+		return true
+	}
+
+	_ = w.keys.Update()
+
+	return false
+}
+
+// centerWindow moves the window to the middle of its monitor.
+func centerWindow() {
+	mw, mh := ebiten.ScreenSizeInFullscreen()
+	ww, wh := ebiten.WindowSize()
+	ebiten.SetWindowPosition((mw-ww)/2, (mh-wh)/2)
+}
+
+// nextMonitor sends the window over to the next monitor, keeping it
+// fullscreen if it was. Ebiten doesn't list the monitors, it only knows the
+// size of the one the window is on, and goes fullscreen there. So this
+// relies on being told how many there are (-monitors), and assumes they're
+// side by side and the same size, moving the window a monitor width at a
+// time.
+func (w *windowDemo) nextMonitor() {
+	fullscreen := ebiten.IsFullscreen()
+	if fullscreen {
+		ebiten.SetFullscreen(false)
+	}
+
+	mw, _ := ebiten.ScreenSizeInFullscreen()
+	x, y := ebiten.WindowPosition()
+	next := (w.monitor + 1) % w.monitors
+	ebiten.SetWindowPosition(x+(next-w.monitor)*mw, y)
+	w.monitor = next
+
+	if fullscreen {
+		ebiten.SetFullscreen(true)
+	}
+}
+
+func (w *windowDemo) Draw(screen *ebiten.Image) {
+	if w.paused {
+		sw, sh := screen.Size()
+		ebitenutil.DrawRect(screen, 0, 0, float64(sw), float64(sh), pausedColor)
+		ebitenutil.DebugPrintAt(screen, "Paused, the window lost the focus", sw/2-100, sh/2)
+
+		return
+	}
+
+	if !w.legend {
+		return
+	}
+
+	x, y := ebiten.WindowPosition()
+	ww, wh := ebiten.WindowSize()
+	mw, mh := ebiten.ScreenSizeInFullscreen()
+
+	lines := []string{
+		fmt.Sprintf("Window %dx%d at %d,%d  Monitor %d/%d (%dx%d, scale %.1f)",
+			ww, wh, x, y, w.monitor+1, w.monitors, mw, mh, ebiten.DeviceScaleFactor()),
+		"F1 legend  F2 decorated: " + onOff(ebiten.IsWindowDecorated()) +
+			"  F3 resizable: " + onOff(ebiten.IsWindowResizable()),
+		"F4 size  F5 center  F6 fullscreen: " + onOff(ebiten.IsFullscreen()) + "  F7 next monitor",
+		"F8 maximize  F9 on top: " + onOff(ebiten.IsWindowFloating()) +
+			"  F10 pause on focus loss: " + onOff(w.autoPause),
+	}
+
+	_, sh := screen.Size()
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), 0, sh-16*len(lines))
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+
+	return "off"
+}
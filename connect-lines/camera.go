@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/camera"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// Zoom per notch of the mouse wheel, and the closest it gets
+	wheelZoom = 1.1
+	maxZoom   = 8
+)
+
+// view is the camera on the graph: the wheel zooms towards the cursor,
+// dragging with the middle button pans, and Home goes back to the whole
+// graph. The graph is drawn as it always was on an image the size of the
+// screen, and that image through the camera, so the blocks never leave
+// the view zoomed all the way out.
+type view struct {
+	cam camera.Camera
+	img *ebiten.Image
+	// Cursor when the pan last moved, while panning
+	panning    bool
+	panX, panY int
+}
+
+func newView() view {
+	img, _ := profile.NewImage(screenWidth, screenHeight, ebiten.FilterNearest)
+
+	return view{cam: camera.New(screenWidth, screenHeight), img: img}
+}
+
+// mouse returns the graph coordinates under the cursor.
+func (g *Game) mouse() (int, int) {
+	cx, cy := keymap.Input().CursorPosition()
+	x, y := g.view.cam.ScreenToWorld(float64(cx), float64(cy))
+
+	return int(math.Floor(x)), int(math.Floor(y))
+}
+
+// viewBindings pan the view while the middle button is held, and show the
+// whole graph with Home.
+func (g *Game) viewBindings() keymap.Map {
+	v := &g.view
+
+	return keymap.Map{
+		{Buttons: keymap.Buttons(ebiten.MouseButtonMiddle), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { v.panning = true })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonMiddle), Trigger: keymap.Released,
+			Action: keymap.Do(func() { v.panning = false })},
+		{Keys: keymap.Keys(ebiten.KeyHome), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			v.cam = camera.New(screenWidth, screenHeight)
+			v.clamp()
+		})},
+	}
+}
+
+// updateView zooms with the wheel and pans, see viewBindings.
+func (g *Game) updateView() {
+	v := &g.view
+	cx, cy := keymap.Input().CursorPosition()
+
+	if _, dy := ebiten.Wheel(); dy != 0 {
+		k := math.Pow(wheelZoom, dy)
+		k = math.Max(1, math.Min(v.cam.Zoom*k, maxZoom)) / v.cam.Zoom
+		v.cam.ZoomAt(k, float64(cx), float64(cy))
+	}
+
+	if v.panning {
+		v.cam.Pan(float64(cx-v.panX), float64(cy-v.panY))
+	}
+
+	v.panX, v.panY = cx, cy
+	v.clamp()
+}
+
+// clamp keeps the view within the graph.
+func (v *view) clamp() {
+	hw, hh := v.cam.Width/2/v.cam.Zoom, v.cam.Height/2/v.cam.Zoom
+	v.cam.X = math.Max(hw, math.Min(v.cam.X, screenWidth-hw))
+	v.cam.Y = math.Max(hh, math.Min(v.cam.Y, screenHeight-hh))
+}
+
+// HUD describes the zoom, for the status line.
+func (v *view) HUD() string {
+	return fmt.Sprintf("Zoom: %.1fx (wheel, middle drag pans, Home shows it all)", v.cam.Zoom)
+}
+
+// draw draws the graph drawn on the view image on the screen, through the
+// camera.
+func (v *view) draw(screen *ebiten.Image) {
+	op := &ebiten.DrawImageOptions{}
+	v.cam.Apply(op)
+	_ = screen.DrawImage(v.img, op)
+}
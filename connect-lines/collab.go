@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"net"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Sites: whoever hosts is 1, whoever joins is 2
+	hostSite = 1
+	joinSite = 2
+	// Ops waiting to be sent, more than that and the peer is too slow
+	outgoingOps = 4096
+	// Ticks between cursor updates
+	cursorEvery = 3
+)
+
+//nolint:gochecknoglobal
+var remoteColor = color.RGBA{0xff, 0x40, 0xff, 0xff}
+
+// Op kinds.
+const (
+	opSnapshot   = "snapshot"
+	opAdd        = "add"
+	opMove       = "move"
+	opConnect    = "connect"
+	opDisconnect = "disconnect"
+	opCursor     = "cursor"
+	// Made up by the reader when the connection drops, never sent
+	opBye = "bye"
+)
+
+// op is an edit, or the remote cursor, sent as a line of JSON. Blocks go by
+// id, as indices differ between sites once blocks are added. Clock and Site
+// are the Lamport timestamp of the edit.
+type op struct {
+	Kind    string   `json:"kind"`
+	Block   string   `json:"block,omitempty"`
+	To      string   `json:"to,omitempty"`
+	X       int      `json:"x,omitempty"`
+	Y       int      `json:"y,omitempty"`
+	Color   string   `json:"color,omitempty"`
+	Clock   uint64   `json:"clock"`
+	Site    int      `json:"site"`
+	Session *session `json:"session,omitempty"`
+}
+
+// stamp orders the edits of a block, the highest one wins.
+type stamp struct {
+	clock uint64
+	site  int
+}
+
+func (s stamp) after(t stamp) bool {
+	return s.clock > t.clock || (s.clock == t.clock && s.site > t.site)
+}
+
+// collab lets two instances edit the same graph over TCP. The host sends
+// the whole graph when the other one joins, and after that they only send
+// operations: adding a block, moving one, connecting or disconnecting two.
+// Moves are last writer wins per block, by Lamport timestamp, and adds can't
+// conflict, so both sides end up with the same graph whatever the order
+// the ops arrive in. Connecting and disconnecting the same blocks at once
+// on both sides is the exception, the connection can end up on one only.
+type collab struct {
+	site     int
+	addr     string
+	peers    chan net.Conn
+	incoming chan op
+	outgoing chan op
+	// Connected to a peer
+	online bool
+
+	clock  uint64
+	added  int
+	stamps map[string]stamp
+	// Positions as last sent or received, moves are found by comparing
+	synced map[string]image.Point
+
+	// Where the peer is pointing at, and the block it has selected
+	cursorX, cursorY int
+	remoteBlock      string
+	lastX, lastY     int
+	ticks            int
+}
+
+// host starts listening for a peer to edit with, one at a time.
+func host(addr string) (*collab, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newCollab(hostSite, l.Addr().String())
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				log.Printf("collab: %v", err)
+
+				return
+			}
+
+			c.peers <- conn
+		}
+	}()
+
+	return c, nil
+}
+
+// join connects to a host, which sends the graph over.
+func join(addr string) (*collab, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newCollab(joinSite, addr)
+	c.peers <- conn
+
+	return c, nil
+}
+
+func newCollab(site int, addr string) *collab {
+	return &collab{
+		site:     site,
+		addr:     addr,
+		peers:    make(chan net.Conn, 1),
+		incoming: make(chan op, outgoingOps),
+		stamps:   make(map[string]stamp),
+		synced:   make(map[string]image.Point),
+	}
+}
+
+// start talks to the peer on conn, each way on its own goroutine.
+func (c *collab) start(conn net.Conn) {
+	out := make(chan op, outgoingOps)
+	c.outgoing = out
+	c.online = true
+
+	go func() {
+		enc := json.NewEncoder(conn)
+
+		for o := range out {
+			if err := enc.Encode(o); err != nil {
+				log.Printf("collab: sending: %v", err)
+
+				break
+			}
+		}
+
+		conn.Close()
+	}()
+
+	go func() {
+		s := bufio.NewScanner(conn)
+		// Snapshots of big graphs are long lines
+		s.Buffer(nil, 64<<20)
+
+		for s.Scan() {
+			var o op
+			if err := json.Unmarshal(s.Bytes(), &o); err != nil {
+				log.Printf("collab: bad op: %v", err)
+
+				continue
+			}
+
+			c.incoming <- o
+		}
+
+		c.incoming <- op{Kind: opBye}
+	}()
+}
+
+// send stamps and sends an op, dropping it if the peer can't keep up. It
+// reports whether the op was queued.
+func (c *collab) send(o op) bool {
+	if !c.online {
+		return false
+	}
+
+	c.clock++
+	o.Clock, o.Site = c.clock, c.site
+
+	select {
+	case c.outgoing <- o:
+		return true
+	default:
+		log.Printf("collab: peer too slow, dropped a %s", o.Kind)
+
+		return false
+	}
+}
+
+// updateCollab sends the local edits and applies the remote ones.
+func (g *Game) updateCollab() {
+	c := g.collab
+	if c == nil {
+		return
+	}
+
+	select {
+	case conn := <-c.peers:
+		if c.online {
+			// Already editing with someone
+			conn.Close()
+
+			break
+		}
+
+		c.start(conn)
+		g.notify.Push("Peer connected")
+
+		if c.site == hostSite {
+			g.sendSnapshot()
+		}
+	default:
+	}
+
+	g.sendMoves()
+	g.sendCursor()
+
+	for {
+		select {
+		case o := <-c.incoming:
+			if err := g.apply(o); err != nil {
+				log.Printf("collab: %v", err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// sendSnapshot sends the whole graph, replacing the peer's.
+func (g *Game) sendSnapshot() {
+	s := g.session()
+	g.collab.send(op{Kind: opSnapshot, Session: &s})
+}
+
+// sendMoves sends the blocks that moved since the last tick, whatever moved
+// them. Moves the peer was too slow for are sent again on the next tick.
+func (g *Game) sendMoves() {
+	c := g.collab
+
+	for _, b := range g.blocks {
+		p := image.Pt(b.x, b.y)
+		if last, ok := c.synced[b.id]; ok && last == p {
+			continue
+		}
+
+		if !c.send(op{Kind: opMove, Block: b.id, X: b.x, Y: b.y}) {
+			continue
+		}
+
+		c.synced[b.id] = p
+		c.stamps[b.id] = stamp{c.clock, c.site}
+	}
+}
+
+func (g *Game) sendCursor() {
+	c := g.collab
+
+	c.ticks++
+	if c.ticks%cursorEvery != 0 {
+		return
+	}
+
+	if x, y := g.mouse(); x != c.lastX || y != c.lastY || c.ticks%60 == 0 {
+		c.lastX, c.lastY = x, y
+		c.send(op{Kind: opCursor, Block: g.blocks[g.selected].id, X: x, Y: y})
+	}
+}
+
+// addBlock adds a block at the cursor, with an id no other site uses.
+func (g *Game) addBlock() {
+	x, y := g.mouse()
+	id := fmt.Sprintf("%d", len(g.blocks))
+
+	if c := g.collab; c != nil {
+		c.added++
+		id = fmt.Sprintf("%d.%d", c.site, c.added)
+	}
+
+	b := NewBlock(0, x, y, blockSize, color.White)
+	b.id = id
+	b.Move(0, 0)
+	g.blocks = append(g.blocks, b)
+	g.metrics.reset(len(g.blocks), g.connections)
+
+	if c := g.collab; c != nil {
+		c.synced[b.id] = image.Pt(b.x, b.y)
+		c.send(op{Kind: opAdd, Block: b.id, X: b.x, Y: b.y, Color: hexColor(b.clr)})
+		c.stamps[b.id] = stamp{c.clock, c.site}
+	}
+
+	g.notify.Push("Block %s added", id)
+}
+
+// link connects two blocks by hand, telling the peer.
+func (g *Game) link(blk1, blk2 int) {
+	g.connect(blk1, blk2)
+
+	if c := g.collab; c != nil {
+		c.send(op{Kind: opConnect, Block: g.blocks[blk1].id, To: g.blocks[blk2].id})
+	}
+}
+
+// unlink disconnects two blocks by hand, telling the peer. If proximity made
+// the connection, it's free to make it again.
+func (g *Game) unlink(blk1, blk2 int) {
+	e := edge(blk1, blk2)
+	g.disconnect(e)
+	delete(g.proximity.edges, e)
+	delete(g.constraints.modes, e)
+	delete(g.weights.set, e)
+	g.metrics.reset(len(g.blocks), g.connections)
+
+	if c := g.collab; c != nil {
+		c.send(op{Kind: opDisconnect, Block: g.blocks[blk1].id, To: g.blocks[blk2].id})
+	}
+}
+
+// blockIndex returns the index of the block with the id, or -1.
+func (g *Game) blockIndex(id string) int {
+	for i, b := range g.blocks {
+		if b.id == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// apply applies a remote op, or returns why it can't.
+func (g *Game) apply(o op) error {
+	c := g.collab
+	if o.Clock > c.clock {
+		c.clock = o.Clock
+	}
+
+	switch o.Kind {
+	case opSnapshot:
+		if o.Session == nil {
+			return errors.New("snapshot without a session")
+		}
+
+		if err := g.restore(*o.Session); err != nil {
+			return fmt.Errorf("bad snapshot: %w", err)
+		}
+
+		c.stamps = make(map[string]stamp)
+		c.synced = make(map[string]image.Point)
+
+		for _, b := range g.blocks {
+			c.synced[b.id] = image.Pt(b.x, b.y)
+		}
+
+		g.notify.Push("Graph received from the peer")
+	case opAdd:
+		if g.blockIndex(o.Block) >= 0 {
+			return nil
+		}
+
+		clr, err := parseHexColor(o.Color)
+		if err != nil {
+			return err
+		}
+
+		b := NewBlock(0, o.X, o.Y, blockSize, clr)
+		b.id = o.Block
+		g.blocks = append(g.blocks, b)
+		g.metrics.reset(len(g.blocks), g.connections)
+		c.synced[b.id] = image.Pt(b.x, b.y)
+		c.stamps[b.id] = stamp{o.Clock, o.Site}
+	case opMove:
+		i := g.blockIndex(o.Block)
+		if i < 0 || !(stamp{o.Clock, o.Site}).after(c.stamps[o.Block]) {
+			return nil
+		}
+
+		b := g.blocks[i]
+		b.Move(o.X-b.x, o.Y-b.y)
+		c.synced[b.id] = image.Pt(b.x, b.y)
+		c.stamps[b.id] = stamp{o.Clock, o.Site}
+	case opConnect:
+		i, j := g.blockIndex(o.Block), g.blockIndex(o.To)
+		if i < 0 || j < 0 || g.metrics.adj[i][j] {
+			return nil
+		}
+
+		g.connect(i, j)
+	case opDisconnect:
+		i, j := g.blockIndex(o.Block), g.blockIndex(o.To)
+		if i < 0 || j < 0 || !g.metrics.adj[i][j] {
+			return nil
+		}
+
+		g.disconnect(edge(i, j))
+		g.metrics.reset(len(g.blocks), g.connections)
+	case opCursor:
+		c.cursorX, c.cursorY = o.X, o.Y
+		c.remoteBlock = o.Block
+	case opBye:
+		close(c.outgoing)
+		c.online = false
+		c.remoteBlock = ""
+		g.notify.Push("Peer disconnected")
+	}
+
+	return nil
+}
+
+// drawRemote draws the peer's cursor and selected block.
+func (g *Game) drawRemote(screen *ebiten.Image) {
+	c := g.collab
+	if c == nil || !c.online {
+		return
+	}
+
+	if i := g.blockIndex(c.remoteBlock); i >= 0 {
+		b := g.blocks[i]
+		s := float64(b.size + 4)
+		x, y := float64(b.x-2), float64(b.y-2)
+		ebitenutil.DrawLine(screen, x, y, x+s, y, remoteColor)
+		ebitenutil.DrawLine(screen, x+s, y, x+s, y+s, remoteColor)
+		ebitenutil.DrawLine(screen, x+s, y+s, x, y+s, remoteColor)
+		ebitenutil.DrawLine(screen, x, y+s, x, y, remoteColor)
+	}
+
+	x, y := float64(c.cursorX), float64(c.cursorY)
+	ebitenutil.DrawLine(screen, x-5, y, x+5, y, remoteColor)
+	ebitenutil.DrawLine(screen, x, y-5, x, y+5, remoteColor)
+}
+
+// HUD describes the collaboration, for the status line.
+func (c *collab) HUD() string {
+	switch {
+	case c == nil:
+		return "Collab: off (-host or -join)"
+	case c.online:
+		return fmt.Sprintf("Collab: editing with a peer (site %d), B adds blocks", c.site)
+	case c.site == hostSite:
+		return "Collab: waiting for a peer on " + c.addr
+	default:
+		return "Collab: disconnected from " + c.addr
+	}
+}
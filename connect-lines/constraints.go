@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// Size of the mark in the middle of constrained connections
+const constraintMark = 4
+
+// constraintMode is what happens when moving a block would stretch a
+// connection past the maximum length.
+type constraintMode int
+
+const (
+	// The connection stretches as much as it likes
+	constraintFree constraintMode = iota
+	// The block at the other end is dragged along, and so on down the chain
+	constraintChain
+	// The move is refused, the block snaps back to where it was
+	constraintSnap
+)
+
+func (m constraintMode) String() string {
+	return [...]string{"free", "chain", "snap back"}[m]
+}
+
+//nolint:gochecknoglobal
+var constraintColors = map[constraintMode]color.Color{
+	constraintChain: color.RGBA{0xff, 0xd0, 0x20, 0xff},
+	constraintSnap:  color.RGBA{0xff, 0x30, 0x30, 0xff},
+}
+
+// constraints limits how long connections get when blocks are moved with
+// the keys, each connection on its own mode, picked with K over it.
+// Connections go by edge, so both directions share the mode.
+type constraints struct {
+	maxLength float64
+	modes     map[connected]constraintMode
+}
+
+func newConstraints(maxLength float64) constraints {
+	return constraints{maxLength: maxLength, modes: make(map[connected]constraintMode)}
+}
+
+// cycleConstraint moves the connection under the cursor to the next mode.
+func (g *Game) cycleConstraint() {
+	x, y := g.mouse()
+
+	c, ok := g.connectionAt(float64(x), float64(y))
+	if !ok {
+		g.notify.Push("No connection under the cursor")
+
+		return
+	}
+
+	e := edge(c.blk1, c.blk2)
+	m := (g.constraints.modes[e] + 1) % (constraintSnap + 1)
+
+	if m == constraintFree {
+		delete(g.constraints.modes, e)
+	} else {
+		g.constraints.modes[e] = m
+	}
+
+	g.notify.Push("Connection %s - %s: %s", g.blocks[e.blk1].id, g.blocks[e.blk2].id, m)
+}
+
+// moveBlock moves block i by (x, y), and then the blocks chained to it
+// along, as far as the constraints need. If a snap back connection would
+// stretch too far, nothing moves at all.
+func (g *Game) moveBlock(i, x, y int) {
+	before := g.positions()
+	g.blocks[i].Move(x, y)
+
+	moved, ok := g.propagate(i, before)
+	if !ok {
+		g.placeBlocks(before)
+
+		return
+	}
+
+	if moved {
+		g.history.chained = true
+	}
+}
+
+// propagate walks the graph out from block i, pulling the blocks at the
+// other end of the chain connections that got too long back within the
+// maximum length. It reports whether it moved any, and false if a snap
+// back connection got too long, or longer if it already was, compared to
+// the positions before. Each block moves once, so cycles may be left
+// stretched.
+func (g *Game) propagate(i int, before []image.Point) (moved, ok bool) {
+	c := &g.constraints
+	visited := map[int]bool{i: true}
+	queue := []int{i}
+
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+
+		for _, conn := range g.connections {
+			v := conn.blk2
+			if conn.blk2 == u {
+				v = conn.blk1
+			} else if conn.blk1 != u {
+				continue
+			}
+
+			mode := c.modes[edge(u, v)]
+			if mode == constraintFree || visited[v] {
+				continue
+			}
+
+			from, to := blockPoint(g.blocks[u]), blockPoint(g.blocks[v])
+
+			d := from.Dist(to)
+			if d <= c.maxLength {
+				continue
+			}
+
+			if mode == constraintSnap {
+				was := pointOf(before[u], g.blocks[u].size).Dist(pointOf(before[v], g.blocks[v].size))
+				if d > was {
+					return moved, false
+				}
+
+				continue
+			}
+
+			// Along the connection, to right at the maximum length
+			p := from.Lerp(to, c.maxLength/d)
+			b := g.blocks[v]
+			b.Move(int(p.X)-b.size/2-b.x, int(p.Y)-b.size/2-b.y)
+
+			visited[v] = true
+			moved = true
+			queue = append(queue, v)
+		}
+	}
+
+	return moved, true
+}
+
+func blockPoint(b *Block) geom.Point {
+	x, y := b.center()
+
+	return geom.Point{X: x, Y: y}
+}
+
+// pointOf is blockPoint for a block of the size at p.
+func pointOf(p image.Point, size int) geom.Point {
+	return geom.Point{X: float64(p.X + size/2), Y: float64(p.Y + size/2)}
+}
+
+// drawConstraints marks the middle of the constrained connections with the
+// color of their mode.
+func (g *Game) drawConstraints(screen *ebiten.Image) {
+	for e, m := range g.constraints.modes {
+		if e.blk1 >= len(g.blocks) || e.blk2 >= len(g.blocks) || !g.metrics.adj[e.blk1][e.blk2] {
+			continue
+		}
+
+		p := blockPoint(g.blocks[e.blk1]).Lerp(blockPoint(g.blocks[e.blk2]), 0.5)
+		ebitenutil.DrawRect(screen, p.X-constraintMark/2, p.Y-constraintMark/2, constraintMark, constraintMark, constraintColors[m])
+	}
+}
+
+// HUD describes the constraints, for the status line.
+func (c *constraints) HUD() string {
+	return fmt.Sprintf("Max length: %.0f px, %d connections constrained (K cycles free, chain, snap back)",
+		c.maxLength, len(c.modes))
+}
+
+// constraintData is a constrained connection in a saved session.
+type constraintData struct {
+	Blocks [2]int `json:"blocks"`
+	Mode   string `json:"mode"`
+}
+
+func parseConstraintMode(s string) (constraintMode, error) {
+	for m := constraintChain; m <= constraintSnap; m++ {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+
+	return constraintFree, fmt.Errorf("unknown constraint mode %q", s)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+)
+
+const (
+	// Push between every two blocks, falling off with the square of the
+	// distance, in pixels per tick per tick at a pixel apart
+	forceCharge = 400
+	// Pull of a connection per pixel it's stretched past its rest length
+	forceSpring     = 0.01
+	forceRestLength = 50
+	// Pull towards the middle of the screen per pixel away, so unconnected
+	// blocks don't all end up against the edges
+	forceGravity = 0.001
+	// Velocity kept from one tick to the next, and the most it gets to
+	forceDamping  = 0.85
+	forceMaxSpeed = 4
+)
+
+// forceLayout lays the graph out as if connections were springs and blocks
+// charges pushing each other away, a step every tick while it's on (P),
+// so connected blocks gather and the rest spread out. Blocks moved by hand
+// meanwhile go on from where they were left. A whole run is one undoable
+// step.
+type forceLayout struct {
+	on bool
+	// Positions as the simulation has them, to the fraction of a pixel,
+	// and the velocities
+	x, y   []float64
+	vx, vy []float64
+	// Where the blocks were when it was turned on
+	from []image.Point
+	// Kinetic energy of the last step, for the HUD
+	energy float64
+}
+
+// toggleForces starts or stops the layout.
+func (g *Game) toggleForces() {
+	f := &g.forces
+	f.on = !f.on
+
+	if f.on {
+		g.endMove()
+		f.from = g.positions()
+		f.x, f.y, f.vx, f.vy = nil, nil, nil, nil
+		g.notify.Push("Force layout on")
+
+		return
+	}
+
+	if to := g.positions(); len(to) == len(f.from) {
+		g.history.push(layoutCmd{f.from, to})
+	}
+
+	g.notify.Push("Force layout off")
+}
+
+// updateForces runs a step of the layout.
+func (g *Game) updateForces() {
+	f := &g.forces
+	if !f.on {
+		return
+	}
+
+	n := len(g.blocks)
+	if len(f.x) != n {
+		f.x, f.y = make([]float64, n), make([]float64, n)
+		f.vx, f.vy = make([]float64, n), make([]float64, n)
+
+		for i, b := range g.blocks {
+			f.x[i], f.y[i] = float64(b.x), float64(b.y)
+		}
+	}
+
+	ax, ay := make([]float64, n), make([]float64, n)
+
+	for i, b := range g.blocks {
+		// Moved by hand, or by the peer, since the last step
+		if b.x != int(math.Round(f.x[i])) || b.y != int(math.Round(f.y[i])) {
+			f.x[i], f.y[i] = float64(b.x), float64(b.y)
+			f.vx[i], f.vy[i] = 0, 0
+		}
+
+		ax[i] = (screenWidth/2 - f.x[i]) * forceGravity
+		ay[i] = (screenHeight/2 - f.y[i]) * forceGravity
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			dx, dy := f.x[j]-f.x[i], f.y[j]-f.y[i]
+
+			d2 := dx*dx + dy*dy
+			if d2 == 0 {
+				// Right on top of each other, push them apart any which way
+				dx, dy, d2 = rand.Float64()-0.5, rand.Float64()-0.5, 1
+			}
+
+			d := math.Sqrt(d2)
+			k := forceCharge / d2 / d
+			ax[i], ay[i] = ax[i]-dx*k, ay[i]-dy*k
+			ax[j], ay[j] = ax[j]+dx*k, ay[j]+dy*k
+		}
+	}
+
+	for _, c := range g.connections {
+		i, j := c.blk1, c.blk2
+		dx, dy := f.x[j]-f.x[i], f.y[j]-f.y[i]
+
+		d := math.Hypot(dx, dy)
+		if d == 0 {
+			continue
+		}
+
+		k := forceSpring * (d - forceRestLength) / d
+		ax[i], ay[i] = ax[i]+dx*k, ay[i]+dy*k
+		ax[j], ay[j] = ax[j]-dx*k, ay[j]-dy*k
+	}
+
+	f.energy = 0
+
+	for i, b := range g.blocks {
+		f.vx[i] = (f.vx[i] + ax[i]) * forceDamping
+		f.vy[i] = (f.vy[i] + ay[i]) * forceDamping
+
+		if v := math.Hypot(f.vx[i], f.vy[i]); v > forceMaxSpeed {
+			f.vx[i], f.vy[i] = f.vx[i]/v*forceMaxSpeed, f.vy[i]/v*forceMaxSpeed
+		}
+
+		f.energy += (f.vx[i]*f.vx[i] + f.vy[i]*f.vy[i]) / 2
+
+		f.x[i] += f.vx[i]
+		f.y[i] += f.vy[i]
+		b.Move(int(math.Round(f.x[i]))-b.x, int(math.Round(f.y[i]))-b.y)
+
+		// Stopped at the edge of the screen
+		if float64(b.x) != math.Round(f.x[i]) {
+			f.x[i], f.vx[i] = float64(b.x), 0
+		}
+
+		if float64(b.y) != math.Round(f.y[i]) {
+			f.y[i], f.vy[i] = float64(b.y), 0
+		}
+	}
+}
+
+// HUD describes the layout, for the status line.
+func (f *forceLayout) HUD() string {
+	if !f.on {
+		return "Force layout: off (P)"
+	}
+
+	return fmt.Sprintf("Force layout: on, energy %.1f (P stops it)", f.energy)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/input"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+)
+
+// Ticks between cursor steps while the d-pad or stick is held
+const padRepeat = 12
+
+// newPadInput returns the gamepad actions. The keys move blocks and
+// connect in their own ways, so only the gamepad does these.
+func newPadInput() *input.Map {
+	m := input.New()
+	m.Delay, m.Interval = padRepeat, padRepeat
+
+	m.Set(input.MoveUp, input.Inputs{Pads: keymap.Pads(keymap.PadUp), Axes: []keymap.Axis{{Index: 1, Sign: -1}}})
+	m.Set(input.MoveDown, input.Inputs{Pads: keymap.Pads(keymap.PadDown), Axes: []keymap.Axis{{Index: 1, Sign: 1}}})
+	m.Set(input.MoveLeft, input.Inputs{Pads: keymap.Pads(keymap.PadLeft), Axes: []keymap.Axis{{Index: 0, Sign: -1}}})
+	m.Set(input.MoveRight, input.Inputs{Pads: keymap.Pads(keymap.PadRight), Axes: []keymap.Axis{{Index: 0, Sign: 1}}})
+	m.Set(input.Select, input.Inputs{Pads: keymap.Pads(keymap.PadA)})
+	m.Set(input.Cancel, input.Inputs{Pads: keymap.Pads(keymap.PadB)})
+	m.Set(input.Toggle, input.Inputs{Pads: keymap.Pads(keymap.PadX)})
+
+	return m
+}
+
+// updateGamepads moves the selection cursor with the d-pad or left stick,
+// selects with A, connects the selected block to the cursor with X, or
+// disconnects them if they are already, and cancels the cursor with B.
+func (g *Game) updateGamepads() {
+	p := g.pad
+	p.Update()
+
+	step := false
+	for _, a := range []input.Action{input.MoveUp, input.MoveDown, input.MoveLeft, input.MoveRight} {
+		step = step || p.Repeated(a)
+	}
+
+	// The stick goes where it's pointed, not only along the axes
+	if dx, dy := p.Vector(input.MoveUp, input.MoveDown, input.MoveLeft, input.MoveRight); step && (dx != 0 || dy != 0) {
+		if i := g.nearestInDirection(g.cursor, dx, dy); i >= 0 {
+			g.cursor = i
+		}
+	}
+
+	if p.Pressed(input.Select) {
+		g.selected = g.cursor
+	}
+
+	if p.Pressed(input.Toggle) && g.cursor != g.selected {
+		g.toggleLink(g.selected, g.cursor)
+	}
+
+	if p.Pressed(input.Cancel) {
+		g.cursor = g.selected
+	}
+}
+
+// nearestInDirection returns the block closest to block from in the (dx, dy)
+// direction, or -1 if there's none. Only blocks within 45 degrees of the
+// direction count, and being off-axis is penalized, so that moving "right"
+// prefers the block straight to the right over a closer diagonal one.
+func (g *Game) nearestInDirection(from int, dx, dy float64) int {
+	l := math.Hypot(dx, dy)
+	dx, dy = dx/l, dy/l
+
+	fx, fy := g.blocks[from].center()
+	best, bestScore := -1, math.Inf(1)
+
+	for i, b := range g.blocks {
+		if i == from {
+			continue
+		}
+
+		bx, by := b.center()
+		vx, vy := bx-fx, by-fy
+
+		along := vx*dx + vy*dy
+		across := math.Abs(vx*dy - vy*dx)
+
+		if along <= 0 || across > along {
+			continue
+		}
+
+		if score := along + 2*across; score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	return best
+}
@@ -3,6 +3,7 @@ module github.com/antoniomo/ebiten-exercises/connect-lines
 go 1.14
 
 require (
+	github.com/antoniomo/ebiten-exercises v0.0.0-00010101000000-000000000000
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20200707082815-5321531c36a2 // indirect
 	github.com/hajimehoshi/ebiten v1.11.7
 	golang.org/x/exp v0.0.0-20200901203048-c4f52b2c50aa // indirect
@@ -10,3 +11,5 @@ require (
 	golang.org/x/mobile v0.0.0-20200801112145-973feb4309de // indirect
 	golang.org/x/sys v0.0.0-20200905004654-be1d3432aa8f // indirect
 )
+
+replace github.com/antoniomo/ebiten-exercises => ../
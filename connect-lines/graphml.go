@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const graphmlNS = "http://graphml.graphdrawing.org/xmlns"
+
+// GraphML documents, as much of them as the graph needs. The attributes
+// are named as Gephi has them, x, y, size and r, g, b for nodes and weight
+// for edges, so it shows graphs as they're drawn here.
+type graphmlDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	NS      string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphmlGraph struct {
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+//nolint:gochecknoglobal
+var graphmlKeys = []graphmlKey{
+	{"x", "node", "x", "double"},
+	{"y", "node", "y", "double"},
+	{"size", "node", "size", "double"},
+	{"r", "node", "r", "int"},
+	{"g", "node", "g", "int"},
+	{"b", "node", "b", "int"},
+	{"a", "node", "a", "int"},
+	{"weight", "edge", "weight", "double"},
+}
+
+func isGraphML(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".graphml")
+}
+
+// graphML converts the session graph. Repeated connections between two
+// blocks become a single edge, with their number as its weight.
+func (s session) graphML() graphmlDoc {
+	doc := graphmlDoc{NS: graphmlNS, Keys: graphmlKeys}
+	doc.Graph.EdgeDefault = "undirected"
+
+	ids := make([]string, len(s.Graph.Blocks))
+
+	for i, b := range s.Graph.Blocks {
+		ids[i] = b.ID
+		if ids[i] == "" {
+			ids[i] = strconv.Itoa(i)
+		}
+
+		clr, _ := parseHexColor(b.Color)
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphmlNode{ID: ids[i], Data: []graphmlData{
+			{"x", strconv.Itoa(b.X)},
+			{"y", strconv.Itoa(b.Y)},
+			{"size", strconv.Itoa(b.Size)},
+			{"r", strconv.Itoa(int(clr.R))},
+			{"g", strconv.Itoa(int(clr.G))},
+			{"b", strconv.Itoa(int(clr.B))},
+			{"a", strconv.Itoa(int(clr.A))},
+		}})
+	}
+
+	weights := make(map[[2]int]int)
+	order := make([][2]int, 0, len(s.Graph.Connections))
+
+	for _, c := range s.Graph.Connections {
+		if c[0] > c[1] {
+			c[0], c[1] = c[1], c[0]
+		}
+
+		if weights[c] == 0 {
+			order = append(order, c)
+		}
+
+		weights[c]++
+	}
+
+	for _, c := range order {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphmlEdge{
+			Source: ids[c[0]],
+			Target: ids[c[1]],
+			Data:   []graphmlData{{"weight", strconv.Itoa(weights[c])}},
+		})
+	}
+
+	return doc
+}
+
+// session converts a GraphML graph back, from here or from other tools.
+// Nodes without a position go around a circle, and the ones without a size
+// or color get the defaults. Edge weights are rounded to a number of
+// connections, at least one.
+func (doc graphmlDoc) session() (session, error) {
+	s := session{Version: sessionVersion}
+
+	// Data goes by key id, which other tools pick as they like
+	names := make(map[string]string)
+	for _, k := range doc.Keys {
+		names[k.ID] = k.Name
+	}
+
+	index := make(map[string]int)
+
+	for i, n := range doc.Graph.Nodes {
+		if _, ok := index[n.ID]; ok {
+			return s, fmt.Errorf("node %q: duplicated", n.ID)
+		}
+
+		index[n.ID] = i
+
+		attrs, err := graphmlAttrs(names, n.Data)
+		if err != nil {
+			return s, fmt.Errorf("node %q: %w", n.ID, err)
+		}
+
+		// Around the middle of the screen, for the ones without a position
+		angle := 2 * math.Pi * float64(i) / float64(len(doc.Graph.Nodes))
+		x, okX := attrs["x"]
+		y, okY := attrs["y"]
+
+		if !okX || !okY {
+			x = screenWidth/2 + screenHeight/3*math.Cos(angle)
+			y = screenHeight/2 + screenHeight/3*math.Sin(angle)
+		}
+
+		size, ok := attrs["size"]
+		if !ok || size < 1 {
+			size = blockSize
+		}
+
+		clr := palette[0]
+
+		if r, ok := attrs["r"]; ok {
+			clr.R, clr.G, clr.B = uint8(r), uint8(attrs["g"]), uint8(attrs["b"])
+			if a, ok := attrs["a"]; ok {
+				clr.A = uint8(a)
+			}
+		}
+
+		s.Graph.Blocks = append(s.Graph.Blocks, blockData{
+			ID:    n.ID,
+			X:     int(math.Round(x)),
+			Y:     int(math.Round(y)),
+			Size:  int(math.Round(size)),
+			Color: hexColor(clr),
+		})
+	}
+
+	for _, e := range doc.Graph.Edges {
+		from, ok1 := index[e.Source]
+		to, ok2 := index[e.Target]
+
+		if !ok1 || !ok2 {
+			return s, fmt.Errorf("edge %s-%s: no such node", e.Source, e.Target)
+		}
+
+		attrs, err := graphmlAttrs(names, e.Data)
+		if err != nil {
+			return s, fmt.Errorf("edge %s-%s: %w", e.Source, e.Target, err)
+		}
+
+		n := 1
+		if w, ok := attrs["weight"]; ok && w > 1 {
+			n = int(math.Round(w))
+		}
+
+		for ; n > 0; n-- {
+			s.Graph.Connections = append(s.Graph.Connections, [2]int{from, to})
+		}
+	}
+
+	return s, nil
+}
+
+// graphmlAttrs returns the numeric data by attribute name, other data is
+// left out.
+func graphmlAttrs(names map[string]string, data []graphmlData) (map[string]float64, error) {
+	attrs := make(map[string]float64)
+
+	for _, d := range data {
+		name := names[d.Key]
+
+		switch name {
+		case "x", "y", "size", "r", "g", "b", "a", "weight":
+		default:
+			continue
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSpace(d.Value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		attrs[name] = v
+	}
+
+	return attrs, nil
+}
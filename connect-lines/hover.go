@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+)
+
+const (
+	// How close the cursor has to be to a block or a connection, in pixels,
+	// as blocks are tiny and lines thin
+	hoverSlack = 3
+)
+
+// updateTooltip finds the block, or otherwise the connection, under the
+// cursor and tells the tooltip about it.
+func (g *Game) updateTooltip() {
+	cx, cy := keymap.Input().CursorPosition()
+	wx, wy := g.mouse()
+	key, text := "", ""
+
+	if i := g.blockAt(wx, wy, hoverSlack); i >= 0 {
+		key = fmt.Sprintf("block %d", i)
+		text = fmt.Sprintf("Block %s\nDegree: %d\nComponent: %d",
+			g.blocks[i].id, g.metrics.Degree(i), g.metrics.Component(i))
+	} else if c, ok := g.connectionAt(float64(wx), float64(wy)); ok {
+		key = fmt.Sprintf("connection %d-%d", c.blk1, c.blk2)
+		text = fmt.Sprintf("Connection %s - %s\nMultiplicity: %d\nWeight: %.0f\nLength: %.1f px",
+			g.blocks[c.blk1].id, g.blocks[c.blk2].id, g.multiplicity(c), g.weight(c), g.length(c))
+	}
+
+	g.tooltip.Update(key, text, cx, cy)
+}
+
+// blockAt returns the topmost block within slack pixels of (x, y), by its
+// shape, or -1.
+func (g *Game) blockAt(x, y, slack int) int {
+	for i := len(g.blocks) - 1; i >= 0; i-- {
+		if g.blocks[i].hit(x, y, slack) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// connectionAt returns the connection closest to (x, y), if it's within the
+// hover slack.
+func (g *Game) connectionAt(x, y float64) (connected, bool) {
+	best, found := hoverSlack+1.0, connected{}
+
+	for _, c := range g.connections {
+		route := g.path(c)
+
+		for i := 1; i < len(route); i++ {
+			a, b := route[i-1], route[i]
+			if d := segmentDistance(x, y, a.X, a.Y, b.X, b.Y); d < best {
+				best, found = d, c
+			}
+		}
+	}
+
+	return found, best <= hoverSlack
+}
+
+// multiplicity is how many times the two blocks of c are connected, in
+// either direction.
+func (g *Game) multiplicity(c connected) int {
+	n := 0
+
+	for _, o := range g.connections {
+		if (o.blk1 == c.blk1 && o.blk2 == c.blk2) || (o.blk1 == c.blk2 && o.blk2 == c.blk1) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// segmentDistance is the distance from (px, py) to the segment from (x1, y1)
+// to (x2, y2).
+func segmentDistance(px, py, x1, y1, x2, y2 float64) float64 {
+	dx, dy := x2-x1, y2-y1
+
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((px-x1)*dx+(py-y1)*dy)/l))
+	}
+
+	return math.Hypot(px-(x1+t*dx), py-(y1+t*dy))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/tween"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// Layout slots, saved with Ctrl+1..4 and shown with 1..4
+	layoutSlots = 4
+	// Ticks the blocks take to get to their place in another layout
+	layoutTweenTicks = 45
+)
+
+//nolint:gochecknoglobal
+var layoutKeys = [layoutSlots]ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4}
+
+// layout is where the blocks are, by id, apart from how they're
+// connected: the same graph can be arranged in several ways, and blocks
+// added after a layout was saved just stay where they are when it's shown.
+type layout struct {
+	name      string
+	positions map[string]image.Point
+}
+
+// layoutTween moves the blocks to another layout over a few ticks, easing
+// in and out, so it's easy to follow where each one went.
+type layoutTween struct {
+	from, to []image.Point
+	tick     int
+}
+
+// layouts keeps the saved layouts, and which were shown last, for Tab to
+// flip between the two to compare them.
+type layouts struct {
+	slots [layoutSlots]*layout
+	// Slots shown last and before that, -1 for none
+	shown, previous int
+	tween           *layoutTween
+}
+
+func newLayouts() layouts {
+	return layouts{shown: -1, previous: -1}
+}
+
+// layoutCmd moves the blocks from one layout to another, as a single
+// undoable step.
+type layoutCmd struct {
+	from, to []image.Point
+}
+
+func (c layoutCmd) do(g *Game)   { g.placeBlocks(c.to) }
+func (c layoutCmd) undo(g *Game) { g.placeBlocks(c.from) }
+
+// positions returns where the blocks are, by index.
+func (g *Game) positions() []image.Point {
+	ps := make([]image.Point, len(g.blocks))
+	for i, b := range g.blocks {
+		ps[i] = image.Pt(b.x, b.y)
+	}
+
+	return ps
+}
+
+// placeBlocks moves the blocks to ps, by index, stopping any layout tween
+// on the way. Blocks added since ps was taken stay where they are.
+func (g *Game) placeBlocks(ps []image.Point) {
+	g.layouts.tween = nil
+
+	for i, p := range ps {
+		if i < len(g.blocks) {
+			b := g.blocks[i]
+			b.Move(p.X-b.x, p.Y-b.y)
+		}
+	}
+}
+
+// saveLayout keeps where the blocks are now in the slot.
+func (g *Game) saveLayout(slot int) {
+	l := &layout{name: fmt.Sprintf("Layout %d", slot+1), positions: make(map[string]image.Point, len(g.blocks))}
+	if old := g.layouts.slots[slot]; old != nil {
+		l.name = old.name
+	}
+
+	for _, b := range g.blocks {
+		l.positions[b.id] = image.Pt(b.x, b.y)
+	}
+
+	g.layouts.slots[slot] = l
+	g.layouts.show(slot)
+	g.notify.Push("%s saved", l.name)
+}
+
+// showLayout moves the blocks to the layout in the slot, animated.
+func (g *Game) showLayout(slot int) {
+	l := g.layouts.slots[slot]
+	if l == nil {
+		g.notify.Push("Nothing saved on %d yet (Ctrl+%d)", slot+1, slot+1)
+
+		return
+	}
+
+	from := g.positions()
+	to := g.positions()
+
+	for i, b := range g.blocks {
+		if p, ok := l.positions[b.id]; ok {
+			to[i] = p
+		}
+	}
+
+	g.endMove()
+	g.history.push(layoutCmd{from, to})
+	g.layouts.tween = &layoutTween{from: from, to: to}
+	g.layouts.show(slot)
+	g.notify.Push("%s", l.name)
+}
+
+// flipLayouts goes back to the layout shown before the current one.
+func (g *Game) flipLayouts() {
+	if g.layouts.previous < 0 {
+		g.notify.Push("Save two layouts to compare them (Ctrl+1..4)")
+
+		return
+	}
+
+	g.showLayout(g.layouts.previous)
+}
+
+func (l *layouts) show(slot int) {
+	if slot != l.shown {
+		l.previous, l.shown = l.shown, slot
+	}
+}
+
+// layoutBindings saves the layouts with Ctrl+1..4, shows them with 1..4,
+// and flips back to the previous one with Tab.
+func (g *Game) layoutBindings() keymap.Map {
+	m := keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyTab), Trigger: keymap.Pressed, Action: keymap.Do(g.flipLayouts)},
+	}
+
+	for slot, k := range layoutKeys {
+		slot := slot
+		m = append(m,
+			keymap.Binding{Keys: keymap.Keys(k), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(func() { g.saveLayout(slot) })},
+			keymap.Binding{Keys: keymap.Keys(k), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.showLayout(slot) })},
+		)
+	}
+
+	return m
+}
+
+// updateLayouts moves the blocks along the layout tween, if one is going.
+func (g *Game) updateLayouts() {
+	t := g.layouts.tween
+	if t == nil {
+		return
+	}
+
+	t.tick++
+	k := tween.InOutCubic(tween.Clamp01(float64(t.tick) / layoutTweenTicks))
+
+	for i, from := range t.from {
+		if i >= len(g.blocks) {
+			break
+		}
+
+		to := t.to[i]
+		b := g.blocks[i]
+		x := int(tween.Lerp(float64(from.X), float64(to.X), k) + 0.5)
+		y := int(tween.Lerp(float64(from.Y), float64(to.Y), k) + 0.5)
+		b.Move(x-b.x, y-b.y)
+	}
+
+	if t.tick >= layoutTweenTicks {
+		g.layouts.tween = nil
+	}
+}
+
+// HUD describes the saved layouts, the one shown in brackets.
+func (l *layouts) HUD() string {
+	var names []string
+
+	for i, s := range l.slots {
+		switch {
+		case s == nil:
+			continue
+		case i == l.shown:
+			names = append(names, fmt.Sprintf("[%d %s]", i+1, s.name))
+		default:
+			names = append(names, fmt.Sprintf("%d %s", i+1, s.name))
+		}
+	}
+
+	if len(names) == 0 {
+		return "Layouts: none (Ctrl+1..4 saves one)"
+	}
+
+	return "Layouts: " + strings.Join(names, "  ") + " (1..4 shows, Tab flips back)"
+}
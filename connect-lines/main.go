@@ -1,8 +1,7 @@
 package main
 
 import (
-	"errors"
-	"fmt"
+	"flag"
 	"image/color"
 	_ "image/png"
 	"log"
@@ -10,46 +9,43 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/antoniomo/ebiten-exercises/internal/input"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/layer"
+	"github.com/antoniomo/ebiten-exercises/internal/notify"
+	"github.com/antoniomo/ebiten-exercises/internal/place"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
+	"github.com/antoniomo/ebiten-exercises/internal/tooltip"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
-	"github.com/hajimehoshi/ebiten/inpututil"
 )
 
 const (
 	screenWidth  = 640
 	screenHeight = 480
 	translate    = 1
-	blocks       = 50
+	blockSize    = 3
 )
 
 var (
-	ErrCleanExit = errors.New("clean exit, no error")
 	//nolint:gochecknoglobal
-	emptyImage    *ebiten.Image
 	selectedColor = color.RGBA{0, 0xff, 0, 0xff}
+	cursorColor   = color.RGBA{0xff, 0xff, 0, 0xff}
+	panelColor    = color.RGBA{0, 0, 0, 0xc0}
+	// Colors the blocks can be assigned with C
+	palette = []color.RGBA{
+		{0xff, 0xff, 0xff, 0xff},
+		{0xff, 0x40, 0x40, 0xff},
+		{0x40, 0x80, 0xff, 0xff},
+		{0xff, 0x80, 0, 0xff},
+		{0xc0, 0x40, 0xff, 0xff},
+	}
 )
 
 //nolint:gochecknoinit
 func init() {
 	rand.Seed(time.Now().UnixNano())
-
-	emptyImage, _ = ebiten.NewImage(1, 1, ebiten.FilterDefault)
-	_ = emptyImage.Fill(color.White)
-}
-
-// colorScale taken from ebitenutil/shapes.go.
-func colorScale(clr color.Color) (rf, gf, bf, af float64) {
-	r, g, b, a := clr.RGBA()
-	if a == 0 {
-		return 0, 0, 0, 0
-	}
-
-	rf = float64(r) / float64(a)
-	gf = float64(g) / float64(a)
-	bf = float64(b) / float64(a)
-	af = float64(a) / 0xffff
-
-	return
 }
 
 type Block struct {
@@ -59,6 +55,9 @@ type Block struct {
 	size int
 	clr  color.Color
 	img  *ebiten.Image
+	// How it's drawn and hit, and the picture if it's an image
+	shape nodeShape
+	pic   *picture
 }
 
 func NewBlock(id, x, y, size int, clr color.Color) *Block {
@@ -69,25 +68,19 @@ func NewBlock(id, x, y, size int, clr color.Color) *Block {
 		size: size,
 		clr:  clr,
 	}
-
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Scale(float64(size), float64(size))
-	op.ColorM.Scale(colorScale(clr))
-
-	b.img, _ = ebiten.NewImage(size, size, ebiten.FilterDefault)
-	_ = b.img.DrawImage(emptyImage, op)
+	b.render()
 
 	return b
 }
 
-// In is from the ebiten drag and drop (drag) example.
+// In is from the ebiten drag and drop (drag) example, by the shape of the
+// block.
 func (b *Block) In(x, y int) bool {
-	// Rectangle approach, good enough here
-	if x >= b.x && x <= b.x+b.size &&
-		y >= b.y && y <= b.y+b.size {
-		return true
-	}
-	return false
+	return b.hit(x, y, 0)
+}
+
+func (b *Block) center() (x, y float64) {
+	return float64(b.x + b.size/2), float64(b.y + b.size/2)
 }
 
 // Move moves the block by (x, y).
@@ -112,16 +105,6 @@ func (b *Block) Move(x, y int) {
 	}
 }
 
-func (b *Block) Draw(screen *ebiten.Image, clr color.Color) {
-	if clr == nil {
-		clr = b.clr
-	}
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(b.x), float64(b.y))
-	op.ColorM.Scale(colorScale(clr))
-	_ = screen.DrawImage(b.img, op)
-}
-
 type connected struct {
 	blk1 int
 	blk2 int
@@ -129,126 +112,321 @@ type connected struct {
 
 type Game struct {
 	fullscreen  bool
+	sessionPath string
 	blocks      []*Block
 	connections []connected
 	selected    int
+	// Gamepad selection cursor, it's always on the selected block unless
+	// it's being moved around with a gamepad
+	cursor      int
+	pad         *input.Map
+	metrics     graphMetrics
+	showMetrics bool
+	proximity   proximity
+	routing     routing
+	tooltip     *tooltip.Tooltip
+	notify      *notify.Notifier
+	collab      *collab
+	styles      styles
+	history     history
+	layouts     layouts
+	view        view
+	forces      forceLayout
+	constraints constraints
+	weights     weights
+	touch       touchInput
+	// Pictures blocks can be drawn as, see cycleShape
+	pictures  []string
+	selection selection
+	// Connection being dragged out with the right button, if any
+	band      *rubberBand
+	keys      keymap.Map
+	moves     keymap.Map
+	snapMoves keymap.Map
+	renderer  layer.Renderer
 }
 
-func (g *Game) Update(screen *ebiten.Image) error {
-	if ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
-		g.blocks[g.selected].Move(0, -translate)
-	}
+// bind sets up the input tables.
+func (g *Game) bind() {
+	g.moves = g.moveBindings(keymap.Held, translate)
+	// Snapped to the grid, blocks move a whole cell per key press
+	g.snapMoves = g.moveBindings(keymap.Pressed, gridSize)
+	g.keys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyF), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.fullscreen = !g.fullscreen
+			ebiten.SetFullscreen(g.fullscreen)
+		})},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(g.pressLeft)},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Released, Action: keymap.Do(g.releaseLeft)},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonRight), Trigger: keymap.Pressed, Action: keymap.Do(g.startBand)},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonRight), Trigger: keymap.Released, Action: keymap.Do(g.dropBand)},
+		{Keys: keymap.Keys(ebiten.KeyC), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.cycleColor(g.selected) })},
+		{Keys: keymap.Keys(ebiten.KeyM), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.showMetrics = !g.showMetrics })},
+		{Keys: keymap.Keys(ebiten.KeyS), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(func() {
+			if err := g.Save(g.sessionPath); err != nil {
+				log.Printf("saving %s: %v", g.sessionPath, err)
+				g.notify.Push("Saving failed, see the log")
+
+				return
+			}
 
-	if ebiten.IsKeyPressed(ebiten.KeyDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
-		g.blocks[g.selected].Move(0, translate)
+			g.notify.Push("Session saved to %s", g.sessionPath)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyL), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(func() {
+			if err := g.Load(g.sessionPath); err != nil {
+				log.Printf("loading %s: %v", g.sessionPath, err)
+				g.notify.Push("Loading failed, see the log")
+
+				return
+			}
+
+			g.notify.Push("Session loaded from %s", g.sessionPath)
+
+			if g.collab != nil {
+				g.sendSnapshot()
+			}
+		})},
+		{Keys: keymap.Keys(ebiten.KeyZ), Trigger: keymap.Repeat, Ctrl: true, Action: keymap.Do(g.undo)},
+		{Keys: keymap.Keys(ebiten.KeyY), Trigger: keymap.Repeat, Ctrl: true, Action: keymap.Do(g.redo)},
+		{Keys: keymap.Keys(ebiten.KeyB), Trigger: keymap.Pressed, Action: keymap.Do(g.addBlock)},
+		{Keys: keymap.Keys(ebiten.KeyV), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleNodeStyle)},
+		{Keys: keymap.Keys(ebiten.KeyT), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleEdgeStyle)},
+		{Keys: keymap.Keys(ebiten.KeyP), Trigger: keymap.Pressed, Action: keymap.Do(g.toggleForces)},
+		{Keys: keymap.Keys(ebiten.KeyK), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleConstraint)},
+		{Keys: keymap.Keys(ebiten.KeyLeftBracket), Trigger: keymap.Repeat, Action: keymap.Do(func() { g.changeWeight(-weightStep) })},
+		{Keys: keymap.Keys(ebiten.KeyRightBracket), Trigger: keymap.Repeat, Action: keymap.Do(func() { g.changeWeight(weightStep) })},
+		{Keys: keymap.Keys(ebiten.KeyBackslash), Trigger: keymap.Pressed, Action: keymap.Do(g.resetWeight)},
+		{Keys: keymap.Keys(ebiten.KeyE), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.weights.labels = !g.weights.labels })},
+		{Keys: keymap.Keys(ebiten.KeyH), Trigger: keymap.Pressed, Action: keymap.Do(g.togglePathMode)},
+		{Keys: keymap.Keys(ebiten.KeyO), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleShape)},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Action: func() error { return run.ErrCleanExit }},
 	}
+	g.keys = append(g.keys, g.layoutBindings()...)
+	g.keys = append(g.keys, g.viewBindings()...)
+	g.keys = append(g.keys, g.proximityBindings()...)
+	g.keys = append(g.keys, g.routingBindings()...)
+}
 
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
-		g.blocks[g.selected].Move(-translate, 0)
+// moveBindings moves the selected block by step, and the blocks selected
+// along with it.
+func (g *Game) moveBindings(trigger keymap.Trigger, step int) keymap.Map {
+	move := func(x, y int) keymap.Action {
+		return keymap.Do(func() {
+			g.startMove(g.selected)
+			g.moveGroup(g.selected, x, y)
+		})
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
-		g.blocks[g.selected].Move(translate, 0)
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyUp, ebiten.KeyW), Trigger: trigger, Action: move(0, -step)},
+		{Keys: keymap.Keys(ebiten.KeyDown, ebiten.KeyS), Trigger: trigger, Action: move(0, step)},
+		{Keys: keymap.Keys(ebiten.KeyLeft, ebiten.KeyA), Trigger: trigger, Action: move(-step, 0)},
+		{Keys: keymap.Keys(ebiten.KeyRight, ebiten.KeyD), Trigger: trigger, Action: move(step, 0)},
 	}
+}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
-		g.fullscreen = !g.fullscreen
-		ebiten.SetFullscreen(g.fullscreen)
+// blockAtCursor returns the index of the block under the mouse, or -1.
+func (g *Game) blockAtCursor() int {
+	cx, cy := g.mouse()
+	// Because we draw in slice order, the latest is the one on top,
+	// so check from latest to first
+	for i := len(g.blocks) - 1; i >= 0; i-- {
+		if g.blocks[i].In(cx, cy) {
+			return i
+		}
 	}
 
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		cx, cy := ebiten.CursorPosition()
-		// Because we draw in slice order, the latest is the one on top,
-		// so check from latest to first
-		for i := len(g.blocks) - 1; i >= 0; i-- {
-			b := g.blocks[i]
-			if b.In(cx, cy) {
-				g.selected = i
+	return -1
+}
 
-				break
-			}
-		}
+// selectBlock selects the block, and picks it as an end of the shortest path
+// in path mode.
+func (g *Game) selectBlock(i int) {
+	g.selected = i
+	g.cursor = i
+
+	if g.weights.picking {
+		g.pickPathEnd(i)
 	}
+}
 
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
-		cx, cy := ebiten.CursorPosition()
-		// Because we draw in slice order, the latest is the one on top,
-		// so check from latest to first
-		for i := len(g.blocks) - 1; i >= 0; i-- {
-			b := g.blocks[i]
-			if b.In(cx, cy) {
-				if i != g.selected {
-					g.connect(g.selected, i)
-				}
+// toggleLink connects two blocks, or disconnects them if they are already,
+// as an undoable step.
+func (g *Game) toggleLink(blk1, blk2 int) {
+	if g.metrics.adj[blk1][blk2] {
+		g.do(unlinkCmd{blk1, blk2})
+		g.notify.Push("Connection removed")
 
-				break
-			}
-		}
+		return
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		return ErrCleanExit
+	g.do(linkCmd{blk1, blk2})
+	g.notify.Push("Connection added")
+}
+
+func (g *Game) Update(screen *ebiten.Image) error {
+	moves := g.moves
+	if g.routing.snap {
+		moves = g.snapMoves
 	}
 
-	return nil
+	_ = moves.Update()
+
+	g.updateView()
+	g.updateTouch()
+	g.updateSelection()
+
+	g.updateHistory()
+	g.updateLayouts()
+	g.updateForces()
+
+	g.updateGamepads()
+	g.updateProximity()
+	g.updateRouting()
+	g.updateCollab()
+	g.updateShortestPath()
+	g.updateTooltip()
+	g.notify.Update()
+
+	return g.keys.Update()
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	ebitenutil.DebugPrint(screen, "Active block: "+g.blocks[g.selected].id)
-
-	// Draw connections first
-	for _, c := range g.connections {
-		b1 := g.blocks[c.blk1]
-		b2 := g.blocks[c.blk2]
-		b1x := float64(b1.x + b1.size/2)
-		b1y := float64(b1.y + b1.size/2)
-		b2x := float64(b2.x + b2.size/2)
-		b2y := float64(b2.y + b2.size/2)
-		ebitenutil.DrawLine(screen, b1x, b1y, b2x, b2y, color.White)
-	}
-
-	for i, b := range g.blocks {
-		if i == g.selected {
-			b.Draw(screen, selectedColor)
-		} else {
-			b.Draw(screen, nil)
+	// Connections and the auto-connect radius go under the blocks
+	g.renderer.AddFunc(layer.Background, g.drawGrid)
+	g.renderer.AddFunc(layer.World-1, g.drawRadius)
+	g.renderer.AddFunc(layer.World-1, g.drawConnections)
+	g.renderer.AddFunc(layer.World-1, g.drawConstraints)
+	g.renderer.AddFunc(layer.World-1, g.drawWeights)
+
+	max := g.maxDegree()
+
+	g.renderer.AddFunc(layer.World, func(screen *ebiten.Image) {
+		for i := range g.blocks {
+			if !g.inSelection(i) && i != g.cursor {
+				g.drawBlock(screen, i, max, nil)
+			}
+		}
+	})
+
+	// The highlighted blocks go on top of the others
+	g.renderer.AddFunc(layer.World+1, func(screen *ebiten.Image) {
+		g.drawBlock(screen, g.cursor, max, cursorColor)
+		for _, i := range g.selectionOf(g.selected) {
+			g.drawBlock(screen, i, max, selectedColor)
 		}
+	})
+
+	g.renderer.AddFunc(layer.World+2, g.drawRemote)
+	g.renderer.AddFunc(layer.World+2, g.drawBand)
+	g.renderer.AddFunc(layer.World+2, g.drawMarquee)
+
+	// The graph goes through the camera, the UI over it as is
+	_ = g.view.img.Clear()
+	g.renderer.Draw(g.view.img)
+	g.view.draw(screen)
+
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		ebitenutil.DebugPrint(screen, "Active block: "+g.blocks[g.selected].id+"\n"+g.proximity.HUD()+"\n"+g.routing.HUD()+"\n"+g.collab.HUD()+"\n"+g.styles.HUD()+"\n"+g.history.HUD()+"\n"+g.layouts.HUD()+"\n"+g.view.HUD()+"\n"+g.forces.HUD()+"\n"+g.constraints.HUD()+"\n"+g.weights.HUD(g)+"\n"+g.selectionHUD()+"  "+g.shapeHUD()+g.touch.HUD())
+	})
+
+	if g.showMetrics {
+		g.renderer.Add(layer.UI, &g.metrics)
 	}
+
+	g.renderer.Add(layer.UI+1, g.tooltip)
+	g.renderer.Add(layer.UI+2, g.notify)
+	g.renderer.Draw(screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenW, screenH int) {
 	return screenWidth, screenHeight
 }
 
-func (g *Game) init() {
-	// x and y coordinates, randomized
-	xs := rand.Perm(screenWidth)[:blocks]
-	ys := rand.Perm(screenHeight)[:blocks]
+func (g *Game) init(blocks int, placement place.Strategy) {
+	// Keep the blocks fully on screen
+	ps := placement(nil, blocks, screenWidth-blockSize, screenHeight-blockSize)
+
+	g.blocks = make([]*Block, len(ps))
+	for i, p := range ps {
+		g.blocks[i] = NewBlock(i, int(p.X), int(p.Y), blockSize, color.White)
+	}
+
+	g.metrics.reset(len(g.blocks), g.connections)
+}
+
+// cycleColor assigns the next palette color to the block.
+func (g *Game) cycleColor(i int) {
+	b := g.blocks[i]
+	next := palette[0]
+
+	for j, c := range palette {
+		if hexColor(c) == hexColor(b.clr) {
+			next = palette[(j+1)%len(palette)]
 
-	g.blocks = make([]*Block, blocks)
-	for i, x := range xs {
-		g.blocks[i] = NewBlock(i, x, ys[i], 3, color.White)
+			break
+		}
 	}
+
+	g.blocks[i] = NewBlock(i, b.x, b.y, b.size, next)
+	g.blocks[i].id = b.id
+	g.blocks[i].setShape(b.shape, b.pic)
 }
 
 func (g *Game) connect(blk1, blk2 int) {
 	g.connections = append(g.connections, connected{blk1, blk2})
+	g.metrics.addEdge(blk1, blk2)
+	stats.Add(stats.ConnectionsMade, 1)
 }
 
 func main() {
-	g := &Game{}
-	g.init()
+	blocks := flag.Int("blocks", 50, "number of blocks")
+	placement := flag.String("placement", "uniform", "block placement: uniform, poisson or grid")
+	sessionPath := flag.String("session", "graph.json", "file to save (Ctrl+S) and load (Ctrl+L) the graph, GraphML if it ends in .graphml")
+	radius := flag.Float64("radius", 60, "auto-connect radius")
+	maxLength := flag.Float64("max-length", 80, "longest a constrained connection gets, K over one picks what happens past it")
+	hostAddr := flag.String("host", "", "address to wait on for someone to edit the graph with, like :7777")
+	joinAddr := flag.String("join", "", "address of a -host to edit its graph with")
+	picturesDir := flag.String("pictures", "../images", "directory of PNG files blocks can be drawn as, O cycles through them")
+	flag.Parse()
+
+	strategy, err := place.ByName(*placement)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *blocks < 1 {
+		log.Fatal("there must be at least one block")
+	}
+
+	g := &Game{sessionPath: *sessionPath, pad: newPadInput(), tooltip: tooltip.New(), notify: notify.New(), proximity: newProximity(*radius),
+		layouts: newLayouts(), view: newView(), constraints: newConstraints(*maxLength),
+		weights: newWeights(), touch: newTouchInput(), pictures: listPictures(*picturesDir)}
+	g.init(*blocks, strategy)
+	g.bind()
+
+	switch {
+	case *hostAddr != "" && *joinAddr != "":
+		log.Fatal("-host and -join don't go together")
+	case *hostAddr != "":
+		g.collab, err = host(*hostAddr)
+	case *joinAddr != "":
+		g.collab, err = join(*joinAddr)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Connect Lines")
 
-	if err := ebiten.RunGame(g); err != nil {
-		if errors.Is(err, ErrCleanExit) {
-			fmt.Println("Good bye!")
+	err = run.Game(g)
 
-			return
-		}
-
-		log.Fatal(err)
+	// Whatever the periodic saves didn't get to
+	if err := stats.Flush(); err != nil {
+		log.Printf("saving stats: %v", err)
 	}
+
+	run.Exit(err)
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+//nolint:gochecknoglobal
+var (
+	marqueeFill   = color.RGBA{0, 0x40, 0, 0x40}
+	marqueeBorder = color.RGBA{0, 0xff, 0, 0xa0}
+)
+
+// selection is the blocks selected together, by dragging a rectangle with
+// the left button from empty space over them. While the selected block is
+// one of them, moving it with the keys, or dragging it with the mouse,
+// moves them all. Clicking a block outside it, or empty space, lets them
+// go.
+type selection struct {
+	group map[int]bool
+	// Corner the rectangle was started at, while dragging one
+	marquee        bool
+	startX, startY int
+	// Dragging the selected blocks with the mouse, and the cursor when
+	// they last moved
+	dragging     bool
+	lastX, lastY int
+}
+
+// selectionOf returns the blocks a move of block i moves: the group if it's
+// in it, or only itself, in index order.
+func (g *Game) selectionOf(i int) []int {
+	if !g.selection.group[i] {
+		return []int{i}
+	}
+
+	sel := make([]int, 0, len(g.selection.group))
+	for j := range g.selection.group {
+		sel = append(sel, j)
+	}
+
+	sort.Ints(sel)
+
+	return sel
+}
+
+// inSelection reports whether block i is drawn as selected.
+func (g *Game) inSelection(i int) bool {
+	return i == g.selected || g.selection.group[g.selected] && g.selection.group[i]
+}
+
+// pressLeft selects the block under the cursor and starts dragging it, and
+// the group along if it's in it, or starts a rectangle over empty space.
+func (g *Game) pressLeft() {
+	s := &g.selection
+	cx, cy := g.mouse()
+
+	i := g.blockAtCursor()
+	if i < 0 {
+		s.marquee, s.startX, s.startY = true, cx, cy
+
+		return
+	}
+
+	if !s.group[i] {
+		s.group = nil
+	}
+
+	g.selectBlock(i)
+	s.dragging, s.lastX, s.lastY = true, cx, cy
+}
+
+// releaseLeft drops the blocks being dragged, or selects the ones with their
+// center in the rectangle, the topmost becoming the selected block.
+func (g *Game) releaseLeft() {
+	s := &g.selection
+	s.dragging = false
+
+	if !s.marquee {
+		return
+	}
+
+	s.marquee = false
+	r := g.marqueeRect()
+	s.group = nil
+	top := -1
+
+	for i, b := range g.blocks {
+		if x, y := b.center(); image.Pt(int(x), int(y)).In(r) {
+			if s.group == nil {
+				s.group = map[int]bool{}
+			}
+
+			s.group[i] = true
+			top = i
+		}
+	}
+
+	if top >= 0 {
+		g.selectBlock(top)
+	}
+
+	if len(s.group) > 1 {
+		g.notify.Push("%d blocks selected", len(s.group))
+	}
+}
+
+// marqueeRect returns the rectangle being dragged, in graph coordinates.
+func (g *Game) marqueeRect() image.Rectangle {
+	cx, cy := g.mouse()
+
+	return image.Rect(g.selection.startX, g.selection.startY, cx, cy).Canon()
+}
+
+// updateSelection moves the blocks being dragged with the cursor.
+func (g *Game) updateSelection() {
+	s := &g.selection
+	if !s.dragging {
+		return
+	}
+
+	// Recording every tick of the drag, even those the cursor stays still,
+	// keeps it a single undo step
+	g.startMove(g.selected)
+
+	cx, cy := g.mouse()
+	if cx != s.lastX || cy != s.lastY {
+		g.moveGroup(g.selected, cx-s.lastX, cy-s.lastY)
+		s.lastX, s.lastY = cx, cy
+	}
+}
+
+// moveGroup is moveBlock for block i and the blocks selected with it, all
+// by the same amount, stopping at the edges of the screen as a whole so
+// they keep their shape. If a snap back connection would stretch too far,
+// none move.
+func (g *Game) moveGroup(i, x, y int) {
+	sel := g.selectionOf(i)
+	if len(sel) == 1 {
+		g.moveBlock(i, x, y)
+
+		return
+	}
+
+	for _, j := range sel {
+		b := g.blocks[j]
+		x = clampInt(x, -b.x, screenWidth-b.size-b.x)
+		y = clampInt(y, -b.y, screenHeight-b.size-b.y)
+	}
+
+	if x == 0 && y == 0 {
+		return
+	}
+
+	before := g.positions()
+	for _, j := range sel {
+		g.blocks[j].Move(x, y)
+	}
+
+	for _, j := range sel {
+		if _, ok := g.propagate(j, before); !ok {
+			g.placeBlocks(before)
+
+			return
+		}
+	}
+
+	// Several blocks moved, so it's recorded as a layout change
+	g.history.chained = true
+}
+
+// drawMarquee draws the rectangle being dragged.
+func (g *Game) drawMarquee(screen *ebiten.Image) {
+	if !g.selection.marquee {
+		return
+	}
+
+	r := g.marqueeRect()
+	x0, y0, x1, y1 := float64(r.Min.X), float64(r.Min.Y), float64(r.Max.X), float64(r.Max.Y)
+
+	ebitenutil.DrawRect(screen, x0, y0, x1-x0, y1-y0, marqueeFill)
+	ebitenutil.DrawLine(screen, x0, y0, x1, y0, marqueeBorder)
+	ebitenutil.DrawLine(screen, x1, y0, x1, y1, marqueeBorder)
+	ebitenutil.DrawLine(screen, x1, y1, x0, y1, marqueeBorder)
+	ebitenutil.DrawLine(screen, x0, y1, x0, y0, marqueeBorder)
+}
+
+// selectionHUD describes the selection, for the status line.
+func (g *Game) selectionHUD() string {
+	if n := len(g.selectionOf(g.selected)); n > 1 {
+		return fmt.Sprintf("Selection: %d blocks, moved together (left drag)", n)
+	}
+
+	return "Selection: left drag over empty space to select several blocks"
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+
+	if v > hi {
+		return hi
+	}
+
+	return v
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Past this many blocks the all pairs distances get too big to keep
+	// around, and the diameter isn't tracked
+	diameterLimit = 1000
+	unreachable   = math.MaxInt32
+)
+
+// graphMetrics tracks some numbers about the graph, treating it as simple
+// and undirected. Adding a connection updates them incrementally, anything
+// else (loading a session, a new set of blocks) recomputes them from
+// scratch with reset.
+type graphMetrics struct {
+	n     int
+	adj   []map[int]bool
+	edges int
+	// Triangles each node is part of, and the sum of the local clustering
+	// coefficients, to average them without going over every node
+	triangles     []int
+	clusteringSum float64
+	// All pairs shortest path lengths, n*n, nil past diameterLimit
+	dist     []int32
+	diameter int
+	resets   int
+	// Connected component label of each node
+	component []int
+}
+
+// reset recomputes everything for n nodes and the given connections.
+func (m *graphMetrics) reset(n int, connections []connected) {
+	defer profile.Region("metrics")()
+
+	m.n = n
+	m.adj = make([]map[int]bool, n)
+	m.edges = 0
+	m.triangles = make([]int, n)
+	m.clusteringSum = 0
+	m.resets++
+
+	for i := range m.adj {
+		m.adj[i] = map[int]bool{}
+	}
+
+	for _, c := range connections {
+		if c.blk1 != c.blk2 && !m.adj[c.blk1][c.blk2] {
+			m.adj[c.blk1][c.blk2] = true
+			m.adj[c.blk2][c.blk1] = true
+			m.edges++
+		}
+	}
+
+	// Every triangle gets counted once from each of its edges' ends, that
+	// is twice per node
+	for u := range m.adj {
+		for v := range m.adj[u] {
+			for w := range m.adj[v] {
+				if w != u && m.adj[u][w] {
+					m.triangles[u]++
+				}
+			}
+		}
+
+		m.triangles[u] /= 2
+	}
+
+	for u := range m.adj {
+		m.clusteringSum += m.clustering(u)
+	}
+
+	m.component = make([]int, n)
+	for i := range m.component {
+		m.component[i] = -1
+	}
+
+	for u := range m.component {
+		if m.component[u] < 0 {
+			m.label(u, u)
+		}
+	}
+
+	m.dist = nil
+	if n <= diameterLimit {
+		m.dist = make([]int32, n*n)
+		for u := 0; u < n; u++ {
+			m.bfs(u)
+		}
+
+		m.diameter = m.maxDistance()
+	}
+}
+
+// bfs fills the distances from u.
+func (m *graphMetrics) bfs(u int) {
+	row := m.dist[u*m.n : (u+1)*m.n]
+	for i := range row {
+		row[i] = unreachable
+	}
+
+	row[u] = 0
+	queue := []int{u}
+
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+
+		for w := range m.adj[v] {
+			if row[w] == unreachable {
+				row[w] = row[v] + 1
+				queue = append(queue, w)
+			}
+		}
+	}
+}
+
+func (m *graphMetrics) maxDistance() int {
+	max := 0
+
+	for _, d := range m.dist {
+		if d != unreachable && int(d) > max {
+			max = int(d)
+		}
+	}
+
+	return max
+}
+
+// addEdge updates the metrics for a new connection between u and v.
+func (m *graphMetrics) addEdge(u, v int) {
+	defer profile.Region("metrics")()
+
+	if u == v || m.adj[u][v] {
+		return
+	}
+
+	// Only the ends of the edge and their common neighbors get new
+	// triangles
+	touched := []int{u, v}
+
+	for w := range m.adj[u] {
+		if m.adj[v][w] {
+			touched = append(touched, w)
+		}
+	}
+
+	for _, w := range touched {
+		m.clusteringSum -= m.clustering(w)
+	}
+
+	m.adj[u][v] = true
+	m.adj[v][u] = true
+	m.edges++
+
+	for _, w := range touched[2:] {
+		m.triangles[u]++
+		m.triangles[v]++
+		m.triangles[w]++
+	}
+
+	for _, w := range touched {
+		m.clusteringSum += m.clustering(w)
+	}
+
+	if m.dist != nil {
+		m.relax(u, v)
+	}
+
+	// Merge the components, keeping the lowest label
+	if lo, hi := m.component[u], m.component[v]; lo != hi {
+		if hi < lo {
+			lo, hi = hi, lo
+		}
+
+		for i, c := range m.component {
+			if c == hi {
+				m.component[i] = lo
+			}
+		}
+	}
+}
+
+// label sets the component of every node reachable from u to c.
+func (m *graphMetrics) label(u, c int) {
+	m.component[u] = c
+	stack := []int{u}
+
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for w := range m.adj[v] {
+			if m.component[w] != c {
+				m.component[w] = c
+				stack = append(stack, w)
+			}
+		}
+	}
+}
+
+// Degree is the number of blocks u is connected to.
+func (m *graphMetrics) Degree(u int) int {
+	return len(m.adj[u])
+}
+
+// Component returns the connected component of u, labeled after its
+// lowest numbered block.
+func (m *graphMetrics) Component(u int) int {
+	return m.component[u]
+}
+
+// relax updates the distances with the new u-v edge, any shorter path now
+// goes i ~> u - v ~> j or i ~> v - u ~> j. Distances only ever shrink, so
+// the diameter is the largest of the updated ones.
+func (m *graphMetrics) relax(u, v int) {
+	n := m.n
+	du := append([]int32(nil), m.dist[u*n:(u+1)*n]...)
+	dv := append([]int32(nil), m.dist[v*n:(v+1)*n]...)
+	m.diameter = 0
+
+	for i := 0; i < n; i++ {
+		row := m.dist[i*n : (i+1)*n]
+
+		for j := 0; j < n; j++ {
+			if du[i] != unreachable && dv[j] != unreachable && du[i]+1+dv[j] < row[j] {
+				row[j] = du[i] + 1 + dv[j]
+			}
+
+			if dv[i] != unreachable && du[j] != unreachable && dv[i]+1+du[j] < row[j] {
+				row[j] = dv[i] + 1 + du[j]
+			}
+
+			if row[j] != unreachable && int(row[j]) > m.diameter {
+				m.diameter = int(row[j])
+			}
+		}
+	}
+}
+
+// clustering is the local clustering coefficient of u, 0 for nodes with
+// less than two neighbors.
+func (m *graphMetrics) clustering(u int) float64 {
+	k := len(m.adj[u])
+	if k < 2 {
+		return 0
+	}
+
+	return 2 * float64(m.triangles[u]) / float64(k*(k-1))
+}
+
+func (m *graphMetrics) AverageDegree() float64 {
+	if m.n == 0 {
+		return 0
+	}
+
+	return 2 * float64(m.edges) / float64(m.n)
+}
+
+// Clustering is the average local clustering coefficient.
+func (m *graphMetrics) Clustering() float64 {
+	if m.n == 0 {
+		return 0
+	}
+
+	return m.clusteringSum / float64(m.n)
+}
+
+func (m *graphMetrics) Draw(screen *ebiten.Image) {
+	diameter := "n/a"
+	if m.dist != nil {
+		// Of the connected components, unconnected pairs are left out
+		diameter = fmt.Sprint(m.diameter)
+	}
+
+	text := fmt.Sprintf("Nodes: %d\nEdges: %d\nAvg degree: %.2f\nDiameter: %s\nClustering: %.3f\nFull recomputes: %d",
+		m.n, m.edges, m.AverageDegree(), diameter, m.Clustering(), m.resets)
+
+	const (
+		x = screenWidth - 200
+		y = 4
+	)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(196, 16*6+8)
+	op.GeoM.Translate(x, y)
+	op.ColorM.Scale(shapes.ColorScale(panelColor))
+	_ = screen.DrawImage(shapes.EmptyImage, op)
+
+	ebitenutil.DebugPrintAt(screen, text, x+4, y+4)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/spatial"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	radiusStep = 5
+	minRadius  = 10
+	maxRadius  = 200
+	// Grid cell size, about the usual radius
+	proximityCell = 50
+	// Segments of the radius circle
+	circleSegments = 48
+)
+
+//nolint:gochecknoglobal
+var radiusColor = color.RGBA{0, 0xff, 0, 0x60}
+
+// proximity connects blocks to all the others within radius as they move,
+// and disconnects them when they go out of range. Only the connections it
+// made itself are taken down, and turning it off leaves them as regular
+// connections.
+type proximity struct {
+	enabled bool
+	radius  float64
+	grid    *spatial.Grid
+	// Connections made by proximity, with the lowest block first
+	edges map[connected]bool
+	// Every block has to be checked, not only the ones that moved
+	dirty bool
+}
+
+func newProximity(radius float64) proximity {
+	return proximity{radius: radius, grid: spatial.New(proximityCell), edges: make(map[connected]bool)}
+}
+
+// reset forgets about the blocks and the connections it made, for when the
+// blocks are replaced.
+func (p *proximity) reset() {
+	p.grid = spatial.New(proximityCell)
+	p.edges = make(map[connected]bool)
+	p.dirty = true
+}
+
+func edge(u, v int) connected {
+	if u > v {
+		u, v = v, u
+	}
+
+	return connected{u, v}
+}
+
+// proximityBindings toggles the proximity connections with N, and changes
+// their radius with , and .
+func (g *Game) proximityBindings() keymap.Map {
+	p := &g.proximity
+
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyN), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			p.enabled = !p.enabled
+			p.edges = make(map[connected]bool)
+			p.dirty = true
+		})},
+		{Keys: keymap.Keys(ebiten.KeyPeriod), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			p.radius = math.Min(p.radius+radiusStep, maxRadius)
+			p.dirty = true
+		})},
+		{Keys: keymap.Keys(ebiten.KeyComma), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			p.radius = math.Max(p.radius-radiusStep, minRadius)
+			p.dirty = true
+		})},
+	}
+}
+
+// updateProximity keeps the proximity connections up to date.
+func (g *Game) updateProximity() {
+	p := &g.proximity
+
+	if !p.enabled {
+		return
+	}
+
+	// The grid follows the blocks, and tells which ones moved
+	var moved []int
+
+	for i, b := range g.blocks {
+		x, y := b.center()
+		if p.grid.Set(i, x, y) || p.dirty {
+			moved = append(moved, i)
+		}
+	}
+
+	p.dirty = false
+	removed := false
+
+	for _, i := range moved {
+		x, y := g.blocks[i].center()
+		near := make(map[int]bool)
+
+		p.grid.Near(x, y, p.radius, func(j int) {
+			if j != i {
+				near[j] = true
+			}
+		})
+
+		for e := range p.edges {
+			if (e.blk1 == i && !near[e.blk2]) || (e.blk2 == i && !near[e.blk1]) {
+				delete(p.edges, e)
+				g.disconnect(e)
+
+				removed = true
+			}
+		}
+
+		for j := range near {
+			e := edge(i, j)
+			if !p.edges[e] && !g.metrics.adj[i][j] {
+				p.edges[e] = true
+				g.connect(e.blk1, e.blk2)
+			}
+		}
+	}
+
+	if removed {
+		g.metrics.reset(len(g.blocks), g.connections)
+	}
+}
+
+// disconnect removes a connection between the blocks of e, in either
+// direction. The metrics have to be reset afterwards.
+func (g *Game) disconnect(e connected) {
+	for i, c := range g.connections {
+		if edge(c.blk1, c.blk2) == e {
+			g.connections = append(g.connections[:i], g.connections[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// HUD describes the proximity mode, for the status line.
+func (p *proximity) HUD() string {
+	if !p.enabled {
+		return "Auto-connect: off (N)"
+	}
+
+	return fmt.Sprintf("Auto-connect: radius %.0f (N, , and .), %d connections", p.radius, len(p.edges))
+}
+
+// drawRadius draws the proximity radius around the selected block.
+func (g *Game) drawRadius(screen *ebiten.Image) {
+	if !g.proximity.enabled {
+		return
+	}
+
+	x, y := g.blocks[g.selected].center()
+	r := g.proximity.radius
+
+	for i := 0; i < circleSegments; i++ {
+		a1 := 2 * math.Pi * float64(i) / circleSegments
+		a2 := 2 * math.Pi * float64(i+1) / circleSegments
+		ebitenutil.DrawLine(screen, x+r*math.Cos(a1), y+r*math.Sin(a1), x+r*math.Cos(a2), y+r*math.Sin(a2), radiusColor)
+	}
+}
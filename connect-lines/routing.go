@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	gridSize = 16
+	// Room left around blocks by the routes
+	routeMargin = 3
+)
+
+//nolint:gochecknoglobal
+var gridColor = color.RGBA{0x30, 0x30, 0x30, 0xff}
+
+// routing snaps the blocks to a grid and draws the connections as
+// horizontal and vertical segments, like a circuit, going around the other
+// blocks when it can. Routes are kept until a block moves, and only the
+// ones it could affect are routed again.
+type routing struct {
+	snap       bool
+	orthogonal bool
+	routes     map[connected][]geom.Point
+	// Where each block was when the routes were made
+	rects []image.Rectangle
+	// Routes made, for the HUD
+	routed int
+}
+
+// routingBindings snaps the blocks to the grid with G, and routes the
+// connections with R.
+func (g *Game) routingBindings() keymap.Map {
+	r := &g.routing
+
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyG), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			r.snap = !r.snap
+			if r.snap {
+				for _, b := range g.blocks {
+					b.Move(snap(b.x)-b.x, snap(b.y)-b.y)
+				}
+			}
+		})},
+		{Keys: keymap.Keys(ebiten.KeyR), Trigger: keymap.Pressed, Action: keymap.Do(func() { r.orthogonal = !r.orthogonal })},
+	}
+}
+
+// updateRouting drops the routes the moved blocks might change.
+func (g *Game) updateRouting() {
+	r := &g.routing
+
+	if len(r.rects) != len(g.blocks) || r.routes == nil {
+		r.reset(g.blocks)
+
+		return
+	}
+
+	for i, b := range g.blocks {
+		rect := blockRect(b)
+		if rect == r.rects[i] {
+			continue
+		}
+
+		for c, route := range r.routes {
+			if c.blk1 == i || c.blk2 == i || crosses(route, r.rects[i]) || crosses(route, rect) {
+				delete(r.routes, c)
+			}
+		}
+
+		r.rects[i] = rect
+	}
+}
+
+// reset drops all the routes, for when the blocks are replaced.
+func (r *routing) reset(blocks []*Block) {
+	r.routes = make(map[connected][]geom.Point)
+	r.rects = make([]image.Rectangle, len(blocks))
+
+	for i, b := range blocks {
+		r.rects[i] = blockRect(b)
+	}
+}
+
+func snap(v int) int {
+	return int(math.Round(float64(v)/gridSize)) * gridSize
+}
+
+// blockRect is the block with the room the routes leave around it.
+func blockRect(b *Block) image.Rectangle {
+	return image.Rect(b.x, b.y, b.x+b.size, b.y+b.size).Inset(-routeMargin)
+}
+
+// path returns the points the connection goes through.
+func (g *Game) path(c connected) []geom.Point {
+	x1, y1 := g.blocks[c.blk1].center()
+	x2, y2 := g.blocks[c.blk2].center()
+
+	if !g.routing.orthogonal {
+		return []geom.Point{{X: x1, Y: y1}, {X: x2, Y: y2}}
+	}
+
+	if route, ok := g.routing.routes[c]; ok {
+		return route
+	}
+
+	route := g.route(c, geom.Point{X: x1, Y: y1}, geom.Point{X: x2, Y: y2})
+	g.routing.routes[c] = route
+	g.routing.routed++
+
+	return route
+}
+
+// route picks, among a few orthogonal routes, the one going through the
+// fewest other blocks, and then the shortest. Those are the two L shapes,
+// and the Z shapes with the middle segment halfway or just past the blocks
+// in the way.
+func (g *Game) route(c connected, from, to geom.Point) []geom.Point {
+	candidates := [][]geom.Point{
+		{from, {X: to.X, Y: from.Y}, to},
+		{from, {X: from.X, Y: to.Y}, to},
+	}
+
+	xs := []float64{(from.X + to.X) / 2}
+	ys := []float64{(from.Y + to.Y) / 2}
+
+	for i, rect := range g.routing.rects {
+		if i == c.blk1 || i == c.blk2 {
+			continue
+		}
+
+		xs = append(xs, float64(rect.Min.X-1), float64(rect.Max.X+1))
+		ys = append(ys, float64(rect.Min.Y-1), float64(rect.Max.Y+1))
+	}
+
+	for _, x := range xs {
+		candidates = append(candidates, []geom.Point{from, {X: x, Y: from.Y}, {X: x, Y: to.Y}, to})
+	}
+
+	for _, y := range ys {
+		candidates = append(candidates, []geom.Point{from, {X: from.X, Y: y}, {X: to.X, Y: y}, to})
+	}
+
+	var best []geom.Point
+
+	bestHits, bestLength := math.MaxInt32, math.Inf(1)
+
+	for _, route := range candidates {
+		hits := 0
+
+		for i, rect := range g.routing.rects {
+			if i != c.blk1 && i != c.blk2 && crosses(route, rect) {
+				hits++
+			}
+		}
+
+		length := 0.0
+		for i := 1; i < len(route); i++ {
+			length += route[i-1].Dist(route[i])
+		}
+
+		if hits < bestHits || (hits == bestHits && length < bestLength) {
+			best, bestHits, bestLength = route, hits, length
+		}
+	}
+
+	return best
+}
+
+// crosses reports whether any segment of the orthogonal route goes through
+// the rectangle.
+func crosses(route []geom.Point, rect image.Rectangle) bool {
+	for i := 1; i < len(route); i++ {
+		a, b := route[i-1], route[i]
+		minX, maxX := math.Min(a.X, b.X), math.Max(a.X, b.X)
+		minY, maxY := math.Min(a.Y, b.Y), math.Max(a.Y, b.Y)
+
+		if maxX >= float64(rect.Min.X) && minX <= float64(rect.Max.X) &&
+			maxY >= float64(rect.Min.Y) && minY <= float64(rect.Max.Y) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// drawConnections draws the connections, routed or straight, styled.
+func (g *Game) drawConnections(screen *ebiten.Image) {
+	for _, c := range g.connections {
+		route := g.path(c)
+		for i := 1; i < len(route); i++ {
+			g.drawEdge(screen, c, route[i-1].X, route[i-1].Y, route[i].X, route[i].Y)
+		}
+	}
+}
+
+// drawGrid draws the snapping grid.
+func (g *Game) drawGrid(screen *ebiten.Image) {
+	if !g.routing.snap {
+		return
+	}
+
+	for x := 0; x < screenWidth; x += gridSize {
+		ebitenutil.DrawLine(screen, float64(x), 0, float64(x), screenHeight, gridColor)
+	}
+
+	for y := 0; y < screenHeight; y += gridSize {
+		ebitenutil.DrawLine(screen, 0, float64(y), screenWidth, float64(y), gridColor)
+	}
+}
+
+// HUD describes the grid and routing, for the status line.
+func (r *routing) HUD() string {
+	return fmt.Sprintf("Grid snap: %s (G)  Routing: %s (R), %d routed",
+		onOff(r.snap), onOff(r.orthogonal), r.routed)
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+
+	return "off"
+}
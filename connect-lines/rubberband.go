@@ -0,0 +1,78 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// Half the size of the mark on the block a band would connect to
+const targetSize = 4
+
+//nolint:gochecknoglobal
+var bandColor = color.RGBA{0xff, 0xff, 0, 0xa0}
+
+// rubberBand is a connection being dragged out with the right mouse
+// button, from the block it was pressed on to the one it's released on.
+// Pressing on empty space, or clicking a block without dragging, connects
+// from the selected block instead, as right clicks always did.
+type rubberBand struct {
+	// Block it was pressed on, or -1
+	from int
+}
+
+// source is the block the connection goes out from, if it ends on target.
+func (r *rubberBand) source(g *Game, target int) int {
+	if r.from < 0 || r.from == target {
+		return g.selected
+	}
+
+	return r.from
+}
+
+// startBand starts dragging a connection from the block under the mouse.
+func (g *Game) startBand() {
+	cx, cy := g.mouse()
+	g.band = &rubberBand{from: g.blockAt(cx, cy, hoverSlack)}
+}
+
+// dropBand connects the blocks at both ends of the band, or disconnects
+// them if they already are, or lets it go if released over empty space.
+func (g *Game) dropBand() {
+	if g.band == nil {
+		return
+	}
+
+	cx, cy := g.mouse()
+	target := g.blockAt(cx, cy, hoverSlack)
+	src := g.band.source(g, target)
+	g.band = nil
+
+	switch {
+	case target < 0:
+		g.notify.Push("Connection cancelled")
+	case target != src:
+		g.toggleLink(src, target)
+	}
+}
+
+// drawBand draws the band from its source to the cursor, snapping to the
+// block it would connect to, which is marked.
+func (g *Game) drawBand(screen *ebiten.Image) {
+	if g.band == nil {
+		return
+	}
+
+	cx, cy := g.mouse()
+	target := g.blockAt(cx, cy, hoverSlack)
+	x1, y1 := g.blocks[g.band.source(g, target)].center()
+	x2, y2 := float64(cx), float64(cy)
+
+	if target >= 0 {
+		x2, y2 = g.blocks[target].center()
+		ebitenutil.DrawRect(screen, x2-targetSize, y2-targetSize, 2*targetSize, 2*targetSize, bandColor)
+	}
+
+	ebitenutil.DrawLine(screen, x1, y1, x2, y2, bandColor)
+}
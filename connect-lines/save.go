@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"log"
+	"sort"
+
+	"github.com/antoniomo/ebiten-exercises/internal/buildinfo"
+)
+
+// sessionVersion is bumped whenever the session format changes in a way
+// that older files can't be read anymore.
+const sessionVersion = 1
+
+// session is what gets saved: the graph itself plus the working state
+// around it, so that loading restores exactly what was on screen.
+type session struct {
+	Version int `json:"version"`
+	// What saved it, for reference
+	Build    buildinfo.Info `json:"build"`
+	Graph    graphData      `json:"graph"`
+	Selected int            `json:"selected"`
+	// Saved layouts of the graph, see layouts
+	Layouts []layoutData `json:"layouts,omitempty"`
+	// Connections with a maximum length, see constraints
+	Constraints []constraintData `json:"constraints,omitempty"`
+	// Connections with their weight set by hand, see weights
+	Weights []weightData `json:"weights,omitempty"`
+}
+
+type layoutData struct {
+	Slot int    `json:"slot"`
+	Name string `json:"name"`
+	// Block positions by block id
+	Positions map[string][2]int `json:"positions"`
+}
+
+type graphData struct {
+	Blocks      []blockData `json:"blocks"`
+	Connections [][2]int    `json:"connections"`
+}
+
+type blockData struct {
+	// Older sessions don't have ids, blocks go by index there
+	ID    string `json:"id,omitempty"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Size  int    `json:"size"`
+	Color string `json:"color"`
+	// Square if missing, image being the path of the picture
+	Shape string `json:"shape,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+func (g *Game) session() session {
+	s := session{
+		Version:  sessionVersion,
+		Build:    buildinfo.Get(),
+		Selected: g.selected,
+	}
+
+	for _, b := range g.blocks {
+		s.Graph.Blocks = append(s.Graph.Blocks, blockData{
+			ID:    b.id,
+			X:     b.x,
+			Y:     b.y,
+			Size:  b.size,
+			Color: hexColor(b.clr),
+			Shape: b.shape.String(),
+		})
+
+		if b.pic != nil {
+			s.Graph.Blocks[len(s.Graph.Blocks)-1].Image = b.pic.path
+		}
+	}
+
+	for _, c := range g.connections {
+		s.Graph.Connections = append(s.Graph.Connections, [2]int{c.blk1, c.blk2})
+	}
+
+	for slot, l := range g.layouts.slots {
+		if l == nil {
+			continue
+		}
+
+		ld := layoutData{Slot: slot, Name: l.name, Positions: make(map[string][2]int, len(l.positions))}
+		for id, p := range l.positions {
+			ld.Positions[id] = [2]int{p.X, p.Y}
+		}
+
+		s.Layouts = append(s.Layouts, ld)
+	}
+
+	for e, m := range g.constraints.modes {
+		if g.metrics.adj[e.blk1][e.blk2] {
+			s.Constraints = append(s.Constraints, constraintData{Blocks: [2]int{e.blk1, e.blk2}, Mode: m.String()})
+		}
+	}
+
+	// Maps have no order, this keeps the same graph saving the same
+	sort.Slice(s.Constraints, func(i, j int) bool {
+		a, b := s.Constraints[i].Blocks, s.Constraints[j].Blocks
+
+		return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+	})
+
+	for e, w := range g.weights.set {
+		if g.metrics.adj[e.blk1][e.blk2] {
+			s.Weights = append(s.Weights, weightData{Blocks: [2]int{e.blk1, e.blk2}, Weight: w})
+		}
+	}
+
+	sort.Slice(s.Weights, func(i, j int) bool {
+		a, b := s.Weights[i].Blocks, s.Weights[j].Blocks
+
+		return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+	})
+
+	return s
+}
+
+func (g *Game) restore(s session) error {
+	if s.Version != sessionVersion {
+		return fmt.Errorf("unsupported session version %d", s.Version)
+	}
+
+	if len(s.Graph.Blocks) == 0 {
+		return fmt.Errorf("the session has no blocks")
+	}
+
+	blocks := make([]*Block, len(s.Graph.Blocks))
+
+	for i, bd := range s.Graph.Blocks {
+		clr, err := parseHexColor(bd.Color)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+
+		shape, err := parseShape(bd.Shape)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+
+		var pic *picture
+
+		if shape == shapeImage {
+			// A missing picture isn't the graph being wrong, the block is
+			// left square
+			if pic, err = loadPicture(bd.Image); err != nil {
+				log.Printf("block %d: picture %s: %v", i, bd.Image, err)
+
+				shape = shapeSquare
+			}
+		}
+
+		blocks[i] = NewBlock(i, bd.X, bd.Y, bd.Size, clr)
+		if bd.ID != "" {
+			blocks[i].id = bd.ID
+		}
+
+		blocks[i].setShape(shape, pic)
+	}
+
+	connections := make([]connected, 0, len(s.Graph.Connections))
+
+	for _, c := range s.Graph.Connections {
+		if c[0] < 0 || c[0] >= len(blocks) || c[1] < 0 || c[1] >= len(blocks) {
+			return fmt.Errorf("connection %v: no such block", c)
+		}
+
+		connections = append(connections, connected{c[0], c[1]})
+	}
+
+	if s.Selected < 0 || s.Selected >= len(blocks) {
+		s.Selected = 0
+	}
+
+	saved := newLayouts()
+
+	for _, ld := range s.Layouts {
+		if ld.Slot < 0 || ld.Slot >= layoutSlots {
+			return fmt.Errorf("layout %q: no slot %d", ld.Name, ld.Slot)
+		}
+
+		l := &layout{name: ld.Name, positions: make(map[string]image.Point, len(ld.Positions))}
+		for id, p := range ld.Positions {
+			l.positions[id] = image.Pt(p[0], p[1])
+		}
+
+		saved.slots[ld.Slot] = l
+	}
+
+	modes := make(map[connected]constraintMode, len(s.Constraints))
+
+	for _, cd := range s.Constraints {
+		b := cd.Blocks
+		if b[0] < 0 || b[0] >= len(blocks) || b[1] < 0 || b[1] >= len(blocks) {
+			return fmt.Errorf("constraint %v: no such block", b)
+		}
+
+		m, err := parseConstraintMode(cd.Mode)
+		if err != nil {
+			return fmt.Errorf("constraint %v: %w", b, err)
+		}
+
+		modes[edge(b[0], b[1])] = m
+	}
+
+	set := make(map[connected]float64, len(s.Weights))
+
+	for _, wd := range s.Weights {
+		b := wd.Blocks
+		if b[0] < 0 || b[0] >= len(blocks) || b[1] < 0 || b[1] >= len(blocks) {
+			return fmt.Errorf("weight %v: no such block", b)
+		}
+
+		if wd.Weight < 0 {
+			return fmt.Errorf("weight %v: %g is negative", b, wd.Weight)
+		}
+
+		set[edge(b[0], b[1])] = wd.Weight
+	}
+
+	g.blocks = blocks
+	g.connections = connections
+	g.selected = s.Selected
+	g.cursor = s.Selected
+	g.metrics.reset(len(blocks), connections)
+	g.proximity.reset()
+	g.routing.reset(blocks)
+	g.history.reset()
+	g.selection = selection{}
+	g.layouts = saved
+	g.forces = forceLayout{}
+	g.constraints.modes = modes
+	g.weights.set = set
+	g.weights.from, g.weights.to, g.weights.route = -1, -1, nil
+
+	return nil
+}
+
+// Save writes the session to a JSON file, or the graph alone to a GraphML
+// one if the path ends in .graphml.
+func (g *Game) Save(path string) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	if isGraphML(path) {
+		data, err = xml.MarshalIndent(g.session().graphML(), "", "  ")
+		data = append([]byte(xml.Header), data...)
+	} else {
+		data, err = json.MarshalIndent(g.session(), "", "  ")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Load replaces the current session with the one in the JSON file, or the
+// graph with the one in the GraphML file.
+func (g *Game) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if isGraphML(path) {
+		var doc graphmlDoc
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+
+		s, err := doc.session()
+		if err != nil {
+			return err
+		}
+
+		return g.restore(s)
+	}
+
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return g.restore(s)
+}
+
+func hexColor(clr color.Color) string {
+	c := color.RGBAModel.Convert(clr).(color.RGBA)
+
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	var c color.RGBA
+
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A); err != nil {
+		return c, fmt.Errorf("bad color %q: %w", s, err)
+	}
+
+	return c, nil
+}
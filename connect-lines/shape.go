@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"log"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// Side of the images round shapes are drawn on, scaled down to the block,
+// so they stay round zoomed in
+const shapeRes = 32
+
+// nodeShape is how a block is drawn and what of it the cursor hits.
+type nodeShape int
+
+const (
+	shapeSquare nodeShape = iota
+	shapeCircle
+	shapeDiamond
+	// One of the pictures, see picture
+	shapeImage
+)
+
+func (s nodeShape) String() string {
+	return [...]string{"square", "circle", "diamond", "image"}[s]
+}
+
+func parseShape(name string) (nodeShape, error) {
+	if name == "" {
+		return shapeSquare, nil
+	}
+
+	for s := shapeSquare; s <= shapeImage; s++ {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+
+	return shapeSquare, fmt.Errorf("unknown shape %q", name)
+}
+
+// picture is an image a block can be drawn as, stretched to its size. The
+// decoded image stays around to hit-test its pixels.
+type picture struct {
+	path string
+	img  *ebiten.Image
+	src  image.Image
+}
+
+// Pictures by path, each file is only loaded once
+//
+//nolint:gochecknoglobal
+var pictures = map[string]*picture{}
+
+func loadPicture(path string) (*picture, error) {
+	if p, ok := pictures[path]; ok {
+		return p, nil
+	}
+
+	img, src, err := ebitenutil.NewImageFromFile(path, ebiten.FilterLinear)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &picture{path: path, img: img, src: src}
+	pictures[path] = p
+
+	return p, nil
+}
+
+// listPictures returns the PNG files in dir, in name order, for O to cycle
+// through. A missing dir has none.
+func listPictures(dir string) []string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+
+	for _, f := range files {
+		if !f.IsDir() && strings.EqualFold(filepath.Ext(f.Name()), ".png") {
+			paths = append(paths, filepath.Join(dir, f.Name()))
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// render draws the block image for its shape and color, the picture as is
+// for images, tinted when drawn.
+func (b *Block) render() {
+	switch b.shape {
+	case shapeSquare:
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(float64(b.size), float64(b.size))
+		op.ColorM.Scale(shapes.ColorScale(b.clr))
+
+		b.img, _ = profile.NewImage(b.size, b.size, ebiten.FilterDefault)
+		_ = b.img.DrawImage(shapes.EmptyImage, op)
+	case shapeCircle, shapeDiamond:
+		vs, is := shapes.Circle(shapeRes / 2)
+		if b.shape == shapeDiamond {
+			vs, is = shapes.RegularPolygon(shapeRes/2, 4)
+		}
+
+		b.img, _ = profile.NewImage(shapeRes, shapeRes, ebiten.FilterLinear)
+		shapes.Draw(b.img, vs, is, b.clr, nil)
+	case shapeImage:
+		b.img = b.pic.img
+	}
+}
+
+// setShape changes the shape of the block, pic being the picture for
+// images.
+func (b *Block) setShape(s nodeShape, pic *picture) {
+	b.shape, b.pic = s, pic
+	if s != shapeImage {
+		b.pic = nil
+	}
+
+	b.render()
+}
+
+// hit reports whether (x, y) is within slack pixels of the block, by its
+// shape: the picture counts where it's not transparent.
+func (b *Block) hit(x, y, slack int) bool {
+	cx, cy := b.center()
+	dx, dy := math.Abs(float64(x)-cx), math.Abs(float64(y)-cy)
+	r := float64(b.size)/2 + float64(slack)
+
+	switch b.shape {
+	case shapeCircle:
+		return math.Hypot(dx, dy) <= r
+	case shapeDiamond:
+		return dx+dy <= r
+	case shapeSquare, shapeImage:
+	}
+
+	if x < b.x-slack || x > b.x+b.size+slack || y < b.y-slack || y > b.y+b.size+slack {
+		return false
+	}
+
+	if b.shape != shapeImage {
+		return true
+	}
+
+	// The pixel of the picture at the closest point of the block
+	bounds := b.pic.src.Bounds()
+	px := bounds.Min.X + clampInt((x-b.x)*bounds.Dx()/b.size, 0, bounds.Dx()-1)
+	py := bounds.Min.Y + clampInt((y-b.y)*bounds.Dy()/b.size, 0, bounds.Dy()-1)
+	_, _, _, a := b.pic.src.At(px, py).RGBA()
+
+	return a > 0
+}
+
+// cycleShape gives the selected blocks the next shape: square, circle,
+// diamond, and then each of the pictures.
+func (g *Game) cycleShape() {
+	b := g.blocks[g.selected]
+	next, pic := b.shape+1, (*picture)(nil)
+
+	// Images go one after the other, and back to squares after the last
+	if b.shape == shapeImage || next == shapeImage {
+		i := 0
+		if b.shape == shapeImage {
+			i = indexOf(g.pictures, b.pic.path) + 1
+		}
+
+		next = shapeSquare
+
+		for ; i < len(g.pictures); i++ {
+			p, err := loadPicture(g.pictures[i])
+			if err != nil {
+				log.Printf("loading %s: %v", g.pictures[i], err)
+
+				continue
+			}
+
+			next, pic = shapeImage, p
+
+			break
+		}
+	}
+
+	for _, i := range g.selectionOf(g.selected) {
+		g.blocks[i].setShape(next, pic)
+	}
+
+	if pic != nil {
+		g.notify.Push("Shape: %s", filepath.Base(pic.path))
+
+		return
+	}
+
+	g.notify.Push("Shape: %s", next)
+}
+
+func indexOf(paths []string, path string) int {
+	for i, p := range paths {
+		if p == path {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// shapeHUD describes the shape of the selected block, for the status line.
+func (g *Game) shapeHUD() string {
+	b := g.blocks[g.selected]
+	if b.shape == shapeImage {
+		return "Shape: " + filepath.Base(b.pic.path) + " (O)"
+	}
+
+	return fmt.Sprintf("Shape: %s (O, %d pictures)", b.shape, len(g.pictures))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Size of the glyphs of the small font, and the space after each
+const (
+	glyphWidth   = 3
+	glyphHeight  = 5
+	glyphSpacing = 1
+)
+
+//nolint:gochecknoglobal
+var (
+	// The small font, for labels on a graph of 3 pixel blocks where the
+	// debug font is way too big. Only what numbers need, a row of pixels
+	// per string.
+	glyphs = map[rune][glyphHeight]string{
+		'0': {"###", "#.#", "#.#", "#.#", "###"},
+		'1': {".#.", "##.", ".#.", ".#.", "###"},
+		'2': {"###", "..#", "###", "#..", "###"},
+		'3': {"###", "..#", ".##", "..#", "###"},
+		'4': {"#.#", "#.#", "###", "..#", "..#"},
+		'5': {"###", "#..", "###", "..#", "###"},
+		'6': {"###", "#..", "###", "#.#", "###"},
+		'7': {"###", "..#", ".#.", ".#.", ".#."},
+		'8': {"###", "#.#", "###", "#.#", "###"},
+		'9': {"###", "#.#", "###", "..#", "###"},
+		'.': {"...", "...", "...", "...", ".#."},
+		'-': {"...", "...", "###", "...", "..."},
+	}
+	// The glyphs, side by side in a white image, made the first time
+	// they're drawn
+	glyphAtlas  *ebiten.Image
+	glyphColumn map[rune]int
+)
+
+// smallTextWidth is how wide s is in the small font, in pixels.
+func smallTextWidth(s string) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+
+	return n*(glyphWidth+glyphSpacing) - glyphSpacing
+}
+
+// drawSmallText draws s in the small font, with its top-left corner at
+// (x, y), in the color. Runes the font doesn't have are left as spaces.
+func drawSmallText(screen *ebiten.Image, s string, x, y float64, clr color.Color) {
+	if glyphAtlas == nil {
+		makeGlyphAtlas()
+	}
+
+	for i, c := range []rune(s) {
+		col, ok := glyphColumn[c]
+		if !ok {
+			continue
+		}
+
+		src := image.Rect(col, 0, col+glyphWidth, glyphHeight)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(x+float64(i*(glyphWidth+glyphSpacing)), y)
+		op.ColorM.Scale(shapes.ColorScale(clr))
+		_ = screen.DrawImage(glyphAtlas.SubImage(src).(*ebiten.Image), op)
+	}
+}
+
+func makeGlyphAtlas() {
+	pix := image.NewRGBA(image.Rect(0, 0, len(glyphs)*glyphWidth, glyphHeight))
+	glyphColumn = make(map[rune]int, len(glyphs))
+	col := 0
+
+	for c, rows := range glyphs {
+		glyphColumn[c] = col
+
+		for y, row := range rows {
+			for x, p := range row {
+				if p == '#' {
+					pix.Set(col+x, y, color.White)
+				}
+			}
+		}
+
+		col += glyphWidth
+	}
+
+	glyphAtlas, _ = ebiten.NewImageFromImage(pix, ebiten.FilterDefault)
+}
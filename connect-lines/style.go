@@ -0,0 +1,205 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// Biggest a block gets, as a multiple of its size, and thickest a
+	// connection gets, in pixels
+	maxBlockScale = 4
+	maxThickness  = 5
+)
+
+//nolint:gochecknoglobal
+var (
+	coldColor = color.RGBA{0x30, 0x60, 0xff, 0xff}
+	hotColor  = color.RGBA{0xff, 0x40, 0x20, 0xff}
+	edgeColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// nodeStyle maps what's known about a block to how it's drawn: a scale for
+// its size, and a color, nil for its own. max is the highest degree in the
+// graph, for rules that go by degree.
+type nodeStyle struct {
+	name  string
+	scale func(g *Game, i, max int) float64
+	color func(g *Game, i, max int) color.Color
+}
+
+// edgeStyle maps what's known about a connection to its thickness and
+// color.
+type edgeStyle struct {
+	name      string
+	thickness func(g *Game, c connected) float64
+	color     func(g *Game, c connected) color.Color
+}
+
+//nolint:gochecknoglobal
+var (
+	// Node styles V cycles through
+	nodeStyles = []nodeStyle{
+		{"plain", nil, nil},
+		{"size by degree", degreeScale, nil},
+		{"heat by degree", degreeScale, func(g *Game, i, max int) color.Color {
+			return mix(coldColor, hotColor, degreeFraction(g, i, max))
+		}},
+		{"color by component", nil, func(g *Game, i, _ int) color.Color {
+			return componentColor(g.metrics.Component(i))
+		}},
+	}
+	// Edge styles T cycles through
+	edgeStyles = []edgeStyle{
+		{"plain", nil, nil},
+		{"thickness by multiplicity", func(g *Game, c connected) float64 {
+			return math.Min(float64(g.multiplicity(c)), maxThickness)
+		}, nil},
+		{"color by component", nil, func(g *Game, c connected) color.Color {
+			return componentColor(g.metrics.Component(c.blk1))
+		}},
+	}
+)
+
+// styles are the style rules picked, as indices in nodeStyles and
+// edgeStyles. They're evaluated on every draw, so they follow the graph as
+// it changes.
+type styles struct {
+	node int
+	edge int
+}
+
+func degreeFraction(g *Game, i, max int) float64 {
+	if max == 0 {
+		return 0
+	}
+
+	return float64(g.metrics.Degree(i)) / float64(max)
+}
+
+func degreeScale(g *Game, i, max int) float64 {
+	return 1 + (maxBlockScale-1)*degreeFraction(g, i, max)
+}
+
+// mix interpolates between two colors.
+func mix(a, b color.RGBA, t float64) color.RGBA {
+	lerp := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t)
+	}
+
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), lerp(a.A, b.A)}
+}
+
+// componentColor gives each component label a hue of its own, going around
+// the wheel by the golden angle so close labels get far apart hues.
+func componentColor(label int) color.RGBA {
+	h := math.Mod(float64(label)*137.508, 360) / 60
+	x := uint8(0xff * (1 - math.Abs(math.Mod(h, 2)-1)))
+
+	switch int(h) {
+	case 0:
+		return color.RGBA{0xff, x, 0, 0xff}
+	case 1:
+		return color.RGBA{x, 0xff, 0, 0xff}
+	case 2:
+		return color.RGBA{0, 0xff, x, 0xff}
+	case 3:
+		return color.RGBA{0, x, 0xff, 0xff}
+	case 4:
+		return color.RGBA{x, 0, 0xff, 0xff}
+	default:
+		return color.RGBA{0xff, 0, x, 0xff}
+	}
+}
+
+func (g *Game) cycleNodeStyle() {
+	g.styles.node = (g.styles.node + 1) % len(nodeStyles)
+	g.notify.Push("Blocks: %s", nodeStyles[g.styles.node].name)
+}
+
+func (g *Game) cycleEdgeStyle() {
+	g.styles.edge = (g.styles.edge + 1) % len(edgeStyles)
+	g.notify.Push("Connections: %s", edgeStyles[g.styles.edge].name)
+}
+
+func (g *Game) maxDegree() int {
+	max := 0
+
+	for i := range g.blocks {
+		if d := g.metrics.Degree(i); d > max {
+			max = d
+		}
+	}
+
+	return max
+}
+
+// drawBlock draws block i styled, with clr overriding the color for the
+// highlighted ones.
+func (g *Game) drawBlock(screen *ebiten.Image, i, max int, clr color.Color) {
+	s := nodeStyles[g.styles.node]
+	b := g.blocks[i]
+	scale := 1.0
+
+	if s.scale != nil {
+		scale = s.scale(g, i, max)
+	}
+
+	if clr == nil && s.color != nil {
+		clr = s.color(g, i, max)
+	}
+
+	if clr == nil {
+		clr = b.clr
+	}
+
+	// Grown around the center, so connections still end in the middle. The
+	// image is stretched to the block, shapes and pictures are bigger
+	x, y := b.center()
+	w, h := b.img.Size()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(b.size)/float64(w), float64(b.size)/float64(h))
+	op.GeoM.Translate(-float64(b.size)/2, -float64(b.size)/2)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(x, y)
+	op.ColorM.Scale(shapes.ColorScale(clr))
+	_ = screen.DrawImage(b.img, op)
+}
+
+// drawEdge draws a segment of a connection, styled.
+func (g *Game) drawEdge(screen *ebiten.Image, c connected, x1, y1, x2, y2 float64) {
+	s := edgeStyles[g.styles.edge]
+	w := 1.0
+	clr := color.Color(edgeColor)
+
+	if s.thickness != nil {
+		w = s.thickness(g, c)
+	}
+
+	if s.color != nil {
+		clr = s.color(g, c)
+	}
+
+	drawSegment(screen, x1, y1, x2, y2, w, clr)
+}
+
+// drawSegment draws a segment w pixels thick in the color.
+func drawSegment(screen *ebiten.Image, x1, y1, x2, y2, w float64, clr color.Color) {
+	// A 1x1 pixel stretched along the segment, and across it for the
+	// thickness
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(0, -0.5)
+	op.GeoM.Scale(math.Hypot(x2-x1, y2-y1), w)
+	op.GeoM.Rotate(math.Atan2(y2-y1, x2-x1))
+	op.GeoM.Translate(x1, y1)
+	op.ColorM.Scale(shapes.ColorScale(clr))
+	_ = screen.DrawImage(shapes.EmptyImage, op)
+}
+
+// HUD describes the styles, for the status line.
+func (s styles) HUD() string {
+	return "Style: blocks " + nodeStyles[s.node].name + " (V), connections " + edgeStyles[s.edge].name + " (T)"
+}
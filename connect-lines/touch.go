@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/gesture"
+)
+
+// Screen pixels around a block a finger still hits, fingers are much
+// bigger than blocks
+const touchSlack = 16
+
+// touchInput is the touchscreen, on mobile and in browsers: tapping a block
+// selects it, dragging one moves it, along with the blocks selected with
+// it, and dragging empty space pans the view. Long pressing another block
+// connects it to the selected one, or disconnects it, and pinching zooms.
+type touchInput struct {
+	gestures *gesture.Recognizer
+	// Block being dragged, or -1, and the fraction of a pixel it moved
+	// that's left to move, as zoomed in a finger moving a pixel moves it
+	// less than one
+	block        int
+	restX, restY float64
+	// Whether the screen was ever touched, for the HUD
+	seen bool
+}
+
+func newTouchInput() touchInput {
+	return touchInput{gestures: gesture.New(), block: -1}
+}
+
+// blockNear returns the block closest to (x, y), screen coordinates, within
+// the touch slack, or -1.
+func (g *Game) blockNear(x, y int) int {
+	wx, wy := g.view.cam.ScreenToWorld(float64(x), float64(y))
+	best, found := touchSlack/g.view.cam.Zoom, -1
+
+	for i, b := range g.blocks {
+		bx, by := b.center()
+		if d := math.Hypot(bx-wx, by-wy) - float64(b.size)/2; d <= best {
+			best, found = d, i
+		}
+	}
+
+	return found
+}
+
+func (g *Game) updateTouch() {
+	t := &g.touch
+	v := &g.view
+
+	for _, e := range t.gestures.Update() {
+		switch e.Kind {
+		case gesture.Tap:
+			if i := g.blockNear(e.X, e.Y); i >= 0 {
+				g.selectBlock(i)
+			}
+		case gesture.LongPress:
+			switch i := g.blockNear(e.X, e.Y); {
+			case i < 0:
+			case i == g.selected:
+				g.notify.Push("Long press another block to connect it")
+			default:
+				g.toggleLink(g.selected, i)
+			}
+		case gesture.DragStart:
+			t.block, t.restX, t.restY = g.blockNear(e.X, e.Y), 0, 0
+			if t.block >= 0 {
+				g.selectBlock(t.block)
+			}
+		case gesture.Drag:
+			if t.block < 0 {
+				v.cam.Pan(float64(e.DX), float64(e.DY))
+
+				continue
+			}
+
+			// Moving every tick of the drag keeps it a single undo step
+			t.restX += float64(e.DX) / v.cam.Zoom
+			t.restY += float64(e.DY) / v.cam.Zoom
+			dx, dy := int(t.restX), int(t.restY)
+			t.restX -= float64(dx)
+			t.restY -= float64(dy)
+
+			g.startMove(t.block)
+			g.moveGroup(t.block, dx, dy)
+		case gesture.DragEnd:
+			t.block = -1
+		case gesture.Pinch:
+			k := math.Max(1, math.Min(v.cam.Zoom*e.Scale, maxZoom)) / v.cam.Zoom
+			v.cam.ZoomAt(k, float64(e.X), float64(e.Y))
+			v.cam.Pan(float64(e.DX), float64(e.DY))
+		case gesture.TwoFingerTap:
+		}
+	}
+
+	v.clamp()
+
+	t.seen = t.seen || t.gestures.Active()
+}
+
+// HUD describes the touch controls, once the screen has been touched.
+func (t *touchInput) HUD() string {
+	if !t.seen {
+		return ""
+	}
+
+	return "\nTouch: tap selects, drag moves or pans, long press connects to the selected block, pinch zooms"
+}
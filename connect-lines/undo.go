@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"image"
+)
+
+// Steps kept for undoing, the oldest are dropped past that
+const maxHistory = 100
+
+// command is an undoable edit. Blocks go by index, which is stable as long
+// as the blocks aren't replaced, and the history is cleared when they are.
+type command interface {
+	do(g *Game)
+	undo(g *Game)
+}
+
+// moveCmd moves a block, all the ticks of a move key held count as one.
+type moveCmd struct {
+	block    int
+	from, to image.Point
+}
+
+func (c moveCmd) do(g *Game) {
+	b := g.blocks[c.block]
+	b.Move(c.to.X-b.x, c.to.Y-b.y)
+}
+
+func (c moveCmd) undo(g *Game) {
+	b := g.blocks[c.block]
+	b.Move(c.from.X-b.x, c.from.Y-b.y)
+}
+
+// linkCmd connects two blocks by hand, unlinkCmd disconnects them.
+type (
+	linkCmd   connected
+	unlinkCmd connected
+)
+
+func (c linkCmd) do(g *Game)     { g.link(c.blk1, c.blk2) }
+func (c linkCmd) undo(g *Game)   { g.unlink(c.blk1, c.blk2) }
+func (c unlinkCmd) do(g *Game)   { g.unlink(c.blk1, c.blk2) }
+func (c unlinkCmd) undo(g *Game) { g.link(c.blk1, c.blk2) }
+
+// history is the undo and redo stacks. A new edit drops whatever could be
+// redone.
+type history struct {
+	undo []command
+	redo []command
+	// The move in progress, and whether the block moved this tick
+	move   *moveCmd
+	moving bool
+	// Whether the move dragged other blocks along, see constraints, and
+	// where they all were before it
+	chained bool
+	before  []image.Point
+}
+
+func (h *history) push(c command) {
+	h.undo = append(h.undo, c)
+	if len(h.undo) > maxHistory {
+		h.undo = h.undo[len(h.undo)-maxHistory:]
+	}
+
+	h.redo = nil
+}
+
+// reset forgets everything, for when the blocks are replaced.
+func (h *history) reset() {
+	*h = history{}
+}
+
+// do runs an edit and records it.
+func (g *Game) do(c command) {
+	g.endMove()
+	c.do(g)
+	g.history.push(c)
+}
+
+// startMove is called before moving the block with the keys, to record
+// where it was.
+func (g *Game) startMove(i int) {
+	h := &g.history
+	if h.move != nil && h.move.block != i {
+		g.endMove()
+	}
+
+	if h.move == nil {
+		b := g.blocks[i]
+		h.move = &moveCmd{block: i, from: image.Pt(b.x, b.y)}
+		h.before = g.positions()
+	}
+
+	h.moving = true
+}
+
+// endMove records the move in progress, if the block ended up elsewhere.
+func (g *Game) endMove() {
+	h := &g.history
+	if h.move == nil {
+		return
+	}
+
+	b := g.blocks[h.move.block]
+	h.move.to = image.Pt(b.x, b.y)
+
+	switch {
+	case h.chained:
+		h.push(layoutCmd{h.before, g.positions()})
+	case h.move.to != h.move.from:
+		h.push(*h.move)
+	}
+
+	h.move, h.chained, h.before = nil, false, nil
+}
+
+// updateHistory ends the move once no key moved the block this tick.
+func (g *Game) updateHistory() {
+	if !g.history.moving {
+		g.endMove()
+	}
+
+	g.history.moving = false
+}
+
+func (g *Game) undo() {
+	g.endMove()
+
+	h := &g.history
+	if len(h.undo) == 0 {
+		g.notify.Push("Nothing to undo")
+
+		return
+	}
+
+	c := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	c.undo(g)
+	h.redo = append(h.redo, c)
+}
+
+func (g *Game) redo() {
+	g.endMove()
+
+	h := &g.history
+	if len(h.redo) == 0 {
+		g.notify.Push("Nothing to redo")
+
+		return
+	}
+
+	c := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	c.do(g)
+	h.undo = append(h.undo, c)
+}
+
+// HUD describes the steps available, for the status line.
+func (h *history) HUD() string {
+	return fmt.Sprintf("Undo: %d steps (Ctrl+Z)  Redo: %d (Ctrl+Y)", len(h.undo), len(h.redo))
+}
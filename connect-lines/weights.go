@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// How much [ and ] change a weight
+	weightStep = 10
+	// Width of the highlighted shortest path
+	pathWidth = 3
+)
+
+//nolint:gochecknoglobal
+var (
+	pathColor = color.RGBA{0xff, 0x40, 0xff, 0xff}
+	// Weights as long as the connection, and the ones set by hand
+	labelColor    = color.RGBA{0xc0, 0xc0, 0xc0, 0xff}
+	labelSetColor = color.RGBA{0xff, 0xd0, 0x20, 0xff}
+)
+
+// weights are what going along the connections costs, for the shortest
+// paths: as much as they're long as drawn, unless set by hand with [ and ]
+// over one. Weights set go by edge, so both directions share them. In path
+// mode (H) clicking two blocks highlights the lightest path between them.
+type weights struct {
+	set    map[connected]float64
+	labels bool
+	// Path mode, its ends, -1 until picked, and the blocks along the path
+	// found between them, with its weight
+	picking bool
+	from    int
+	to      int
+	route   []int
+	total   float64
+}
+
+func newWeights() weights {
+	return weights{set: make(map[connected]float64), from: -1, to: -1}
+}
+
+// length is how long the connection is, as drawn.
+func (g *Game) length(c connected) float64 {
+	return geom.NewPath(g.path(c), false).Length()
+}
+
+// weight returns the weight of the connection.
+func (g *Game) weight(c connected) float64 {
+	if w, ok := g.weights.set[edge(c.blk1, c.blk2)]; ok {
+		return w
+	}
+
+	return g.length(c)
+}
+
+// changeWeight sets the weight of the connection under the cursor d more
+// than it is, not under 0, so Dijkstra still works.
+func (g *Game) changeWeight(d float64) {
+	x, y := g.mouse()
+
+	c, ok := g.connectionAt(float64(x), float64(y))
+	if !ok {
+		g.notify.Push("No connection under the cursor")
+
+		return
+	}
+
+	w := math.Max(0, math.Round(g.weight(c))+d)
+	g.weights.set[edge(c.blk1, c.blk2)] = w
+	g.notify.Push("Connection %s - %s: weight %.0f", g.blocks[c.blk1].id, g.blocks[c.blk2].id, w)
+}
+
+// resetWeight makes the connection under the cursor weigh its length again.
+func (g *Game) resetWeight() {
+	x, y := g.mouse()
+
+	c, ok := g.connectionAt(float64(x), float64(y))
+	if !ok {
+		g.notify.Push("No connection under the cursor")
+
+		return
+	}
+
+	delete(g.weights.set, edge(c.blk1, c.blk2))
+	g.notify.Push("Connection %s - %s: weight by length", g.blocks[c.blk1].id, g.blocks[c.blk2].id)
+}
+
+func (g *Game) togglePathMode() {
+	w := &g.weights
+	w.picking = !w.picking
+	w.from, w.to, w.route = -1, -1, nil
+
+	if w.picking {
+		g.notify.Push("Shortest path: click where it starts")
+	}
+}
+
+// pickPathEnd takes the clicked block as the start of the path, or as its
+// end if the start is picked already.
+func (g *Game) pickPathEnd(i int) {
+	w := &g.weights
+
+	if w.from < 0 || w.to >= 0 {
+		w.from, w.to, w.route = i, -1, nil
+		g.notify.Push("Shortest path: click where it ends")
+
+		return
+	}
+
+	w.to = i
+	g.updateShortestPath()
+
+	if w.route == nil {
+		g.notify.Push("No path from %s to %s", g.blocks[w.from].id, g.blocks[w.to].id)
+	}
+}
+
+// updateShortestPath finds the path again, as weights go by length and
+// change as blocks move.
+func (g *Game) updateShortestPath() {
+	w := &g.weights
+	if w.from < 0 || w.to < 0 {
+		return
+	}
+
+	w.route, w.total = g.shortestPath(w.from, w.to)
+}
+
+// shortestPath returns the blocks along the lightest path from one block to
+// another, and its weight, or nil if there's none. It's Dijkstra's, picking
+// the closest block left by going over them all, which is plenty for the
+// blocks there are.
+func (g *Game) shortestPath(from, to int) ([]int, float64) {
+	n := len(g.blocks)
+	adj := make([]map[int]float64, n)
+
+	for i := range adj {
+		adj[i] = map[int]float64{}
+	}
+
+	// Of the same blocks connected more than once, the lightest counts
+	for _, c := range g.connections {
+		if c.blk1 == c.blk2 {
+			continue
+		}
+
+		w := g.weight(c)
+		if old, ok := adj[c.blk1][c.blk2]; !ok || w < old {
+			adj[c.blk1][c.blk2] = w
+			adj[c.blk2][c.blk1] = w
+		}
+	}
+
+	dist := make([]float64, n)
+	prev := make([]int, n)
+	done := make([]bool, n)
+
+	for i := range dist {
+		dist[i], prev[i] = math.Inf(1), -1
+	}
+
+	dist[from] = 0
+
+	for {
+		u := -1
+
+		for i := range dist {
+			if !done[i] && !math.IsInf(dist[i], 1) && (u < 0 || dist[i] < dist[u]) {
+				u = i
+			}
+		}
+
+		if u < 0 || u == to {
+			break
+		}
+
+		done[u] = true
+
+		for v, w := range adj[u] {
+			if d := dist[u] + w; d < dist[v] {
+				dist[v], prev[v] = d, u
+			}
+		}
+	}
+
+	if math.IsInf(dist[to], 1) {
+		return nil, 0
+	}
+
+	route := []int{to}
+	for u := to; u != from; u = prev[u] {
+		route = append([]int{prev[u]}, route...)
+	}
+
+	return route, dist[to]
+}
+
+// drawWeights highlights the shortest path, and labels the connections
+// with their weights in the middle, if shown.
+func (g *Game) drawWeights(screen *ebiten.Image) {
+	w := &g.weights
+
+	for i := 1; i < len(w.route); i++ {
+		for _, c := range g.connections {
+			if edge(c.blk1, c.blk2) != edge(w.route[i-1], w.route[i]) {
+				continue
+			}
+
+			route := g.path(c)
+			for j := 1; j < len(route); j++ {
+				drawSegment(screen, route[j-1].X, route[j-1].Y, route[j].X, route[j].Y, pathWidth, pathColor)
+			}
+
+			break
+		}
+	}
+
+	if !w.labels {
+		return
+	}
+
+	for _, c := range g.connections {
+		path := geom.NewPath(g.path(c), false)
+		p, _ := path.At(path.Length() / 2)
+
+		clr := labelColor
+		if _, ok := w.set[edge(c.blk1, c.blk2)]; ok {
+			clr = labelSetColor
+		}
+
+		text := fmt.Sprintf("%.0f", g.weight(c))
+		tw := float64(smallTextWidth(text))
+		x, y := math.Round(p.X-tw/2), math.Round(p.Y-glyphHeight/2)
+		ebitenutil.DrawRect(screen, x-1, y-1, tw+2, glyphHeight+2, panelColor)
+		drawSmallText(screen, text, x, y, clr)
+	}
+}
+
+// HUD describes the weights and the path mode, for the status line.
+func (w *weights) HUD(g *Game) string {
+	path := "off"
+
+	switch {
+	case !w.picking:
+	case w.from < 0:
+		path = "click where it starts"
+	case w.to < 0:
+		path = "click where it ends"
+	case w.route == nil:
+		path = fmt.Sprintf("%s to %s, none", g.blocks[w.from].id, g.blocks[w.to].id)
+	default:
+		path = fmt.Sprintf("%s to %s, %d hops weighing %.0f", g.blocks[w.from].id, g.blocks[w.to].id, len(w.route)-1, w.total)
+	}
+
+	return fmt.Sprintf("Weights: labels %s (E), [ and ] over a connection change it, \\ resets  Shortest path (H): %s",
+		onOff(w.labels), path)
+}
+
+// weightData is a connection with its weight set, in a saved session.
+type weightData struct {
+	Blocks [2]int  `json:"blocks"`
+	Weight float64 `json:"weight"`
+}
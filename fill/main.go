@@ -1,9 +1,10 @@
 package main
 
 import (
+	"flag"
 	"image/color"
-	"log"
 
+	"github.com/antoniomo/ebiten-exercises/internal/run"
 	"github.com/hajimehoshi/ebiten"
 )
 
@@ -22,10 +23,10 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 }
 
 func main() {
+	flag.Parse()
+
 	ebiten.SetWindowSize(640, 480)
 	ebiten.SetWindowTitle("Fill")
 
-	if err := ebiten.RunGame(&Game{}); err != nil {
-		log.Fatal(err)
-	}
+	run.Exit(run.Game(&Game{}))
 }
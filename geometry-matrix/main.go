@@ -1,9 +1,11 @@
 package main
 
 import (
+	"flag"
 	_ "image/png"
 	"log"
 
+	"github.com/antoniomo/ebiten-exercises/internal/run"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
 )
@@ -38,10 +40,10 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 }
 
 func main() {
+	flag.Parse()
+
 	ebiten.SetWindowSize(640, 480)
 	ebiten.SetWindowTitle("Geometry Matrix")
 
-	if err := ebiten.RunGame(&Game{}); err != nil {
-		log.Fatal(err)
-	}
+	run.Exit(run.Game(&Game{}))
 }
@@ -0,0 +1,8 @@
+module github.com/antoniomo/ebiten-exercises
+
+go 1.14
+
+require (
+	github.com/fogleman/gg v1.3.0
+	github.com/hajimehoshi/ebiten v1.11.7
+)
@@ -1,8 +1,9 @@
 package main
 
 import (
-	"log"
+	"flag"
 
+	"github.com/antoniomo/ebiten-exercises/internal/run"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
 )
@@ -23,11 +24,11 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 }
 
 func main() {
+	flag.Parse()
+
 	ebiten.SetWindowSize(640, 480)
 	// ebiten.SetWindowSize(1024, 768)
 	ebiten.SetWindowTitle("Hello, World!")
 
-	if err := ebiten.RunGame(&Game{}); err != nil {
-		log.Fatal(err)
-	}
+	run.Exit(run.Game(&Game{}))
 }
@@ -0,0 +1,121 @@
+// Package audio is the audio subsystem shared by the exercises: a single
+// ebiten audio context, looping music and tracks that can be analyzed while
+// they play, so that visuals can react to them.
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/audio"
+)
+
+// SampleRate of every track, ebiten only allows a single audio context.
+const SampleRate = 44100
+
+// 16 bits stereo
+const bytesPerSample = 4
+
+//nolint:gochecknoglobal
+var context *audio.Context
+
+// Context returns the shared audio context, creating it on first use.
+func Context() (*audio.Context, error) {
+	if context != nil {
+		return context, nil
+	}
+
+	var err error
+
+	context, err = audio.NewContext(SampleRate)
+
+	return context, err
+}
+
+// Track is raw 16 bits little endian stereo PCM at SampleRate.
+type Track struct {
+	pcm []byte
+}
+
+// NewTrack builds a Track from samples in [-1, 1], used for both channels.
+func NewTrack(samples []float64) *Track {
+	pcm := make([]byte, len(samples)*bytesPerSample)
+
+	for i, s := range samples {
+		v := int16(math.Max(-1, math.Min(1, s)) * math.MaxInt16)
+		pcm[4*i] = byte(v)
+		pcm[4*i+1] = byte(v >> 8)
+		pcm[4*i+2] = byte(v)
+		pcm[4*i+3] = byte(v >> 8)
+	}
+
+	return &Track{pcm: pcm}
+}
+
+// Panned returns a copy of the track moved to the left, for pan -1, or to
+// the right, for 1, or anywhere in between. It's a constant power pan, the
+// track sounds as loud wherever it is.
+func (t *Track) Panned(pan float64) *Track {
+	a := (math.Max(-1, math.Min(1, pan)) + 1) * math.Pi / 4
+	left, right := math.Cos(a)*math.Sqrt2, math.Sin(a)*math.Sqrt2
+	pcm := make([]byte, len(t.pcm))
+
+	for i := 0; i < len(pcm)/bytesPerSample; i++ {
+		l := math.Max(-1, math.Min(1, t.sample(i)*left))
+		r := math.Max(-1, math.Min(1, t.channel(i, 1)*right))
+		lv, rv := int16(l*math.MaxInt16), int16(r*math.MaxInt16)
+		pcm[4*i] = byte(lv)
+		pcm[4*i+1] = byte(lv >> 8)
+		pcm[4*i+2] = byte(rv)
+		pcm[4*i+3] = byte(rv >> 8)
+	}
+
+	return &Track{pcm: pcm}
+}
+
+// Duration returns the track length.
+func (t *Track) Duration() time.Duration {
+	samples := len(t.pcm) / bytesPerSample
+
+	return time.Duration(samples) * time.Second / SampleRate
+}
+
+// sample returns the left channel of the ith sample, both are the same but
+// on Panned tracks.
+func (t *Track) sample(i int) float64 {
+	return t.channel(i, 0)
+}
+
+// channel returns a channel of the ith sample, 0 for the left one and 1 for
+// the right one.
+func (t *Track) channel(i, ch int) float64 {
+	i %= len(t.pcm) / bytesPerSample
+	v := int16(uint16(t.pcm[4*i+2*ch]) | uint16(t.pcm[4*i+2*ch+1])<<8)
+
+	return float64(v) / math.MaxInt16
+}
+
+// RMS returns the root mean square amplitude, in [0, 1], of the window of
+// the track starting at the given position. Positions past the end wrap
+// around, as for looping music.
+func (t *Track) RMS(at, window time.Duration) float64 {
+	if len(t.pcm) == 0 {
+		return 0
+	}
+
+	start := int(at.Seconds() * SampleRate)
+	n := int(window.Seconds() * SampleRate)
+
+	if n < 1 {
+		n = 1
+	}
+
+	sum := 0.0
+
+	for i := start; i < start+n; i++ {
+		s := t.sample(i)
+		sum += s * s
+	}
+
+	return math.Sqrt(sum / float64(n))
+}
@@ -0,0 +1,67 @@
+package audio
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/audio"
+)
+
+// Music plays a Track in a loop, and knows where it is in the track so it
+// can be analyzed in sync with what's heard.
+type Music struct {
+	track  *Track
+	player *audio.Player
+}
+
+// NewMusic prepares the track to be played in a loop. It doesn't start
+// playing.
+func NewMusic(t *Track) (*Music, error) {
+	ctx, err := Context()
+	if err != nil {
+		return nil, err
+	}
+
+	loop := audio.NewInfiniteLoop(audio.BytesReadSeekCloser(t.pcm), int64(len(t.pcm)))
+
+	p, err := audio.NewPlayer(ctx, loop)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Music{track: t, player: p}, nil
+}
+
+func (m *Music) IsPlaying() bool {
+	return m.player.IsPlaying()
+}
+
+func (m *Music) Play() error {
+	return m.player.Play()
+}
+
+// Toggle pauses the music if it's playing, or resumes it otherwise.
+func (m *Music) Toggle() error {
+	if m.player.IsPlaying() {
+		return m.player.Pause()
+	}
+
+	return m.player.Play()
+}
+
+func (m *Music) SetVolume(v float64) {
+	m.player.SetVolume(v)
+}
+
+func (m *Music) Volume() float64 {
+	return m.player.Volume()
+}
+
+// Level returns the RMS amplitude of the window of music starting at the
+// current playback position, or 0 if it isn't playing.
+func (m *Music) Level(window time.Duration) float64 {
+	if !m.player.IsPlaying() {
+		return 0
+	}
+
+	return m.track.RMS(m.player.Current()%m.track.Duration(), window) * m.player.Volume()
+}
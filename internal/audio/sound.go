@@ -0,0 +1,110 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/audio"
+)
+
+// Sound plays a Track once, from the start every time, for effects.
+type Sound struct {
+	player *audio.Player
+}
+
+// NewSound prepares the track to be played as an effect.
+func NewSound(t *Track) (*Sound, error) {
+	ctx, err := Context()
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := audio.NewPlayerFromBytes(ctx, t.pcm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sound{player: p}, nil
+}
+
+// Play plays the sound from the start, cutting it if it was playing
+// already.
+func (s *Sound) Play() {
+	_ = s.player.Rewind()
+	_ = s.player.Play()
+}
+
+func (s *Sound) SetVolume(v float64) {
+	s.player.SetVolume(v)
+}
+
+// Pans of a Positional sound, from left to right
+const panSteps = 9
+
+// Positional plays a Track from somewhere between the left and the right,
+// for effects that come from a place on screen. Pans are rounded to a few
+// steps, each with its own player, so effects from different places can
+// play over each other.
+type Positional struct {
+	sounds []*Sound
+}
+
+// NewPositional prepares the track to be played as an effect, at every pan.
+func NewPositional(t *Track) (*Positional, error) {
+	p := &Positional{}
+
+	for i := 0; i < panSteps; i++ {
+		s, err := NewSound(t.Panned(2*float64(i)/(panSteps-1) - 1))
+		if err != nil {
+			return nil, err
+		}
+
+		p.sounds = append(p.sounds, s)
+	}
+
+	return p, nil
+}
+
+// Play plays the sound from the left, for pan -1, to the right, for 1.
+func (p *Positional) Play(pan float64) {
+	i := int(math.Round((math.Max(-1, math.Min(1, pan)) + 1) / 2 * (panSteps - 1)))
+	p.sounds[i].Play()
+}
+
+func (p *Positional) SetVolume(v float64) {
+	for _, s := range p.sounds {
+		s.SetVolume(v)
+	}
+}
+
+// Blip synthesizes a short tone sliding from one frequency to another, in
+// Hz, fading out over d seconds.
+func Blip(from, to, d float64) *Track {
+	samples := make([]float64, int(d*SampleRate))
+	phase := 0.0
+
+	for i := range samples {
+		t := float64(i) / float64(len(samples))
+		phase += 2 * math.Pi * (from + (to-from)*t) / SampleRate
+		samples[i] = 0.5 * math.Sin(phase) * (1 - t)
+	}
+
+	return NewTrack(samples)
+}
+
+// Thud synthesizes a short noise burst over a low tone, d seconds long,
+// for knocks and hits.
+func Thud(d float64) *Track {
+	samples := make([]float64, int(d*SampleRate))
+	// Fixed seed, it should sound the same every time
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := range samples {
+		t := float64(i) / SampleRate
+		noise := (rnd.Float64()*2 - 1) * math.Exp(-t*40)
+		tone := math.Sin(2*math.Pi*70*t) * math.Exp(-t*12)
+		samples[i] = 0.6 * (0.5*noise + tone)
+	}
+
+	return NewTrack(samples)
+}
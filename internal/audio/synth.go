@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Beat synthesizes a drum and bass loop of the given bars of 4/4 at bpm, so
+// exercises have some music without shipping audio files.
+func Beat(bpm, bars int) *Track {
+	beat := 60.0 / float64(bpm)
+	beats := 4 * bars
+	n := int(beat * float64(beats) * SampleRate)
+	samples := make([]float64, n)
+	// Fixed seed, the loop should sound the same every time
+	rnd := rand.New(rand.NewSource(1))
+	bassNotes := []float64{55, 55, 65.41, 49}
+
+	for i := range samples {
+		t := float64(i) / SampleRate
+		b := int(t / beat)
+		tb := t - float64(b)*beat // time since the beat started
+
+		// Kick on every beat, a sine dropping in pitch
+		kick := math.Sin(2*math.Pi*(50*tb+50*(1-math.Exp(-tb*30))/3)) * math.Exp(-tb*8)
+
+		// Hi-hat on the off-beats
+		hat := 0.0
+		if to := tb - beat/2; to >= 0 {
+			hat = (rnd.Float64()*2 - 1) * math.Exp(-to*60) * 0.3
+		}
+
+		// Square-ish bass, one note per bar
+		f := bassNotes[(b/4)%len(bassNotes)]
+		bass := 0.2 * math.Copysign(1, math.Sin(2*math.Pi*f*t)) * math.Exp(-tb*3)
+
+		samples[i] = 0.6 * (kick + hat + bass)
+	}
+
+	return NewTrack(samples)
+}
+
+// Drone synthesizes a pad of the notes, in Hz, held together and each
+// swelling in and out at its own pace, a loop d seconds long for calm
+// music. The notes are tuned a hair so they loop without a click.
+func Drone(notes []float64, d float64) *Track {
+	samples := make([]float64, int(d*SampleRate))
+
+	for i := range samples {
+		t := float64(i) / SampleRate
+		s := 0.0
+
+		for j, f := range notes {
+			f = math.Round(f*d) / d
+			swell := 0.5 - 0.5*math.Cos(2*math.Pi*float64(j+1)*t/d)
+			s += swell * (math.Sin(2*math.Pi*f*t) + 0.3*math.Sin(4*math.Pi*f*t))
+		}
+
+		samples[i] = 0.5 * s / float64(len(notes))
+	}
+
+	return NewTrack(samples)
+}
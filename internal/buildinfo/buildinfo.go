@@ -0,0 +1,54 @@
+// Package buildinfo is what code an exercise was built from: the git commit
+// and the build date, set with ldflags at build time, as Flags has them:
+//
+//	go build -ldflags "$(../ldflags.sh)" .
+//
+// Without them, as with a plain go run, it's a development build. run shows
+// it in a corner of every exercise, and saves and exports carry it, so a
+// captured state can be traced back to the code that made it.
+package buildinfo
+
+import "fmt"
+
+// Set with -X, see the package doc. Variables, as -X can't set constants.
+//
+//nolint:gochecknoglobal
+var (
+	Commit string
+	Date   string
+)
+
+// Info is a build, as saved along with states.
+type Info struct {
+	Commit string `json:"commit,omitempty"`
+	Date   string `json:"date,omitempty"`
+}
+
+// Get returns the build this is.
+func Get() Info {
+	return Info{Commit: Commit, Date: Date}
+}
+
+// String is the build as the HUDs show it.
+func (i Info) String() string {
+	switch {
+	case i.Commit == "":
+		return "development build"
+	case i.Date == "":
+		return i.Commit
+	default:
+		return fmt.Sprintf("%s, built %s", i.Commit, i.Date)
+	}
+}
+
+// Flags returns the ldflags that build other binaries as this one, for
+// the launcher to run the exercises with, or "" for a development build.
+func Flags() string {
+	if Commit == "" {
+		return ""
+	}
+
+	const pkg = "github.com/antoniomo/ebiten-exercises/internal/buildinfo"
+
+	return fmt.Sprintf("-X %s.Commit=%s -X %s.Date=%s", pkg, Commit, pkg, Date)
+}
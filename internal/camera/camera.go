@@ -0,0 +1,85 @@
+// Package camera is a view on a world bigger, or closer, than the screen:
+// where it looks, how zoomed in and how rotated. Exercises draw the world
+// through it with Apply, and turn the cursor back into world coordinates
+// with ScreenToWorld to pick things under it at any zoom.
+package camera
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Camera looks at (X, Y) in the world, which ends up at the center of the
+// screen.
+type Camera struct {
+	X, Y float64
+	// Scale from world to screen, over 1 to zoom in
+	Zoom float64
+	// Radians, clockwise on the screen
+	Rotation float64
+	// Size of the screen it's drawn on
+	Width, Height float64
+}
+
+// New returns a camera for a screen of w by h, looking at the middle of a
+// world of the same size, as if there were no camera.
+func New(w, h int) Camera {
+	return Camera{
+		X:      float64(w) / 2,
+		Y:      float64(h) / 2,
+		Zoom:   1,
+		Width:  float64(w),
+		Height: float64(h),
+	}
+}
+
+// GeoM returns the transform from world to screen coordinates.
+func (c Camera) GeoM() ebiten.GeoM {
+	var m ebiten.GeoM
+	m.Translate(-c.X, -c.Y)
+	m.Scale(c.Zoom, c.Zoom)
+	m.Rotate(c.Rotation)
+	m.Translate(c.Width/2, c.Height/2)
+
+	return m
+}
+
+// Apply appends the camera to the transform of op, so whatever it draws
+// where it would in the world goes where the camera sees it.
+func (c Camera) Apply(op *ebiten.DrawImageOptions) {
+	op.GeoM.Concat(c.GeoM())
+}
+
+// WorldToScreen returns where the world point (x, y) is on the screen.
+func (c Camera) WorldToScreen(x, y float64) (float64, float64) {
+	m := c.GeoM()
+
+	return m.Apply(x, y)
+}
+
+// ScreenToWorld returns the world point on the screen at (x, y), like the
+// one under the cursor.
+func (c Camera) ScreenToWorld(x, y float64) (float64, float64) {
+	m := c.GeoM()
+	m.Invert()
+
+	return m.Apply(x, y)
+}
+
+// Pan moves the view by (dx, dy) screen pixels, so the world goes the
+// other way, as if dragged.
+func (c *Camera) Pan(dx, dy float64) {
+	sin, cos := math.Sincos(-c.Rotation)
+	c.X -= (dx*cos - dy*sin) / c.Zoom
+	c.Y -= (dx*sin + dy*cos) / c.Zoom
+}
+
+// ZoomAt zooms by k keeping the world point on the screen at (x, y) where
+// it is, like zooming towards the cursor.
+func (c *Camera) ZoomAt(k, x, y float64) {
+	wx, wy := c.ScreenToWorld(x, y)
+	c.Zoom *= k
+	sx, sy := c.WorldToScreen(wx, wy)
+	c.Pan(x-sx, y-sy)
+}
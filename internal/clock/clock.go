@@ -0,0 +1,109 @@
+// Package clock is simulation time, apart from the ticks of the game loop:
+// it can be paused, slowed down and sped up, while the UI keeps running on
+// ticks as usual. Exercises run their simulation Steps times a tick, and
+// get the time controls by adding Bindings to their own.
+package clock
+
+import (
+	"fmt"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Scales the controls go through, in simulation steps per tick.
+//
+//nolint:gochecknoglobal
+var scales = []float64{0.25, 0.5, 1, 2, 4, 8}
+
+// MaxScale is the most steps Steps returns in a tick.
+const MaxScale = 8
+
+// normal is the index of 1x in scales.
+const normal = 2
+
+// Clock counts simulation steps. The zero value runs at 1x.
+type Clock struct {
+	// Index in scales, from normal
+	scale  int
+	paused bool
+	// Fraction of a step owed from earlier ticks, for scales under 1x
+	owed float64
+	// Steps run in all, for anything that needs simulation time
+	steps int64
+}
+
+// Steps returns how many simulation steps to run this tick, and counts
+// them. Call it once a tick.
+func (c *Clock) Steps() int {
+	if c.paused {
+		return 0
+	}
+
+	c.owed += c.Scale()
+	n := int(c.owed)
+	c.owed -= float64(n)
+	c.steps += int64(n)
+
+	return n
+}
+
+// Now is the steps run so far.
+func (c *Clock) Now() int64 {
+	return c.steps
+}
+
+// Scale is the current time scale, 1 for normal speed.
+func (c *Clock) Scale() float64 {
+	return scales[c.scale+normal]
+}
+
+func (c *Clock) Paused() bool {
+	return c.paused
+}
+
+func (c *Clock) TogglePause() {
+	c.paused = !c.paused
+}
+
+// Faster doubles the time scale, up to MaxScale.
+func (c *Clock) Faster() {
+	if c.scale+normal < len(scales)-1 {
+		c.scale++
+	}
+}
+
+// Slower halves the time scale, down to a quarter.
+func (c *Clock) Slower() {
+	if c.scale+normal > 0 {
+		c.scale--
+		// Don't let a step owed at the faster scale through
+		c.owed = 0
+	}
+}
+
+// Reset goes back to 1x, unpaused.
+func (c *Clock) Reset() {
+	c.scale, c.paused, c.owed = 0, false, 0
+}
+
+// String describes the clock for HUDs, like "2x" or "paused (0.25x)".
+func (c *Clock) String() string {
+	s := fmt.Sprintf("%gx", c.Scale())
+	if c.paused {
+		return "paused (" + s + ")"
+	}
+
+	return s
+}
+
+// Bindings are the time controls: P pauses, comma slows down, period
+// speeds up and Backspace goes back to 1x.
+func (c *Clock) Bindings() keymap.Map {
+	return keymap.Map{
+		{Name: "Pause", Keys: keymap.Keys(ebiten.KeyP), Trigger: keymap.Pressed, Action: keymap.Do(c.TogglePause)},
+		{Name: "Slower", Keys: keymap.Keys(ebiten.KeyComma), Trigger: keymap.Pressed, Action: keymap.Do(c.Slower)},
+		{Name: "Faster", Keys: keymap.Keys(ebiten.KeyPeriod), Trigger: keymap.Pressed, Action: keymap.Do(c.Faster)},
+		{Name: "Normal speed", Keys: keymap.Keys(ebiten.KeyBackspace), Trigger: keymap.Pressed, Action: keymap.Do(c.Reset)},
+	}
+}
@@ -0,0 +1,51 @@
+// Package colorutil has color helpers: blending colors and mapping values
+// to them, for overlays and visualizations.
+package colorutil
+
+import (
+	"image/color"
+	"math"
+)
+
+// Lerp returns the color t of the way from a to b, t from 0 to 1.
+func Lerp(a, b color.RGBA, t float64) color.RGBA {
+	t = math.Max(0, math.Min(t, 1))
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+// Ramp is a color map: its colors evenly spread from 0 to 1, blended in
+// between.
+type Ramp []color.RGBA
+
+// At returns the color of the ramp at t, clamped to 0 to 1.
+func (r Ramp) At(t float64) color.RGBA {
+	switch {
+	case len(r) == 0:
+		return color.RGBA{}
+	case len(r) == 1 || t <= 0:
+		return r[0]
+	case t >= 1:
+		return r[len(r)-1]
+	}
+
+	f := t * float64(len(r)-1)
+	i := int(f)
+
+	return Lerp(r[i], r[i+1], f-float64(i))
+}
+
+// Heat goes from a dark blue through cyan, green and yellow to red, cold to
+// hot.
+//
+//nolint:gochecknoglobal
+var Heat = Ramp{
+	{0x10, 0x10, 0x60, 0xff},
+	{0x00, 0xa0, 0xe0, 0xff},
+	{0x20, 0xd0, 0x40, 0xff},
+	{0xff, 0xe0, 0x20, 0xff},
+	{0xff, 0x30, 0x10, 0xff},
+}
@@ -0,0 +1,157 @@
+// Package entity keeps track of the entities of an exercise and the tags
+// (groups) they belong to, so that cross-cutting features (picking,
+// inspecting, saving...) can find them without knowing their concrete types.
+//
+// Entities are just IDs, their data stays in each exercise's own structs.
+package entity
+
+import (
+	"sort"
+)
+
+// ID identifies an entity. IDs are never reused within a World, so they also
+// give the creation order.
+type ID int
+
+// Tag is a group an entity belongs to, like "selectable" or "enemy".
+type Tag string
+
+// Common tags, exercises are free to add their own.
+const (
+	Selectable Tag = "selectable"
+	Movable    Tag = "movable"
+)
+
+// World creates entities and indexes them by tag.
+type World struct {
+	next  ID
+	alive map[ID][]Tag
+	// Each tag keeps its entities sorted by ID, so queries are cheap and
+	// iterate in a deterministic (creation) order
+	tagged map[Tag][]ID
+}
+
+// NewWorld returns an empty World.
+func NewWorld() *World {
+	return &World{
+		alive:  map[ID][]Tag{},
+		tagged: map[Tag][]ID{},
+	}
+}
+
+// Create returns a new entity with the given tags.
+func (w *World) Create(tags ...Tag) ID {
+	id := w.next
+	w.next++
+	w.alive[id] = nil
+
+	for _, t := range tags {
+		w.Tag(id, t)
+	}
+
+	return id
+}
+
+// Destroy removes the entity and all of its tags.
+func (w *World) Destroy(id ID) {
+	for _, t := range w.alive[id] {
+		w.tagged[t] = remove(w.tagged[t], id)
+	}
+
+	delete(w.alive, id)
+}
+
+// Alive reports whether the entity exists.
+func (w *World) Alive(id ID) bool {
+	_, ok := w.alive[id]
+
+	return ok
+}
+
+// Tag adds the tag to the entity. It's a no-op if the entity doesn't exist or
+// already has it.
+func (w *World) Tag(id ID, t Tag) {
+	tags, ok := w.alive[id]
+	if !ok || w.HasTag(id, t) {
+		return
+	}
+
+	w.alive[id] = append(tags, t)
+	w.tagged[t] = insert(w.tagged[t], id)
+}
+
+// Untag removes the tag from the entity.
+func (w *World) Untag(id ID, t Tag) {
+	tags := w.alive[id]
+	for i, tt := range tags {
+		if tt == t {
+			w.alive[id] = append(tags[:i:i], tags[i+1:]...)
+			w.tagged[t] = remove(w.tagged[t], id)
+
+			return
+		}
+	}
+}
+
+// HasTag reports whether the entity has the tag.
+func (w *World) HasTag(id ID, t Tag) bool {
+	ids := w.tagged[t]
+	i := search(ids, id)
+
+	return i < len(ids) && ids[i] == id
+}
+
+// Tags returns the tags of the entity.
+func (w *World) Tags(id ID) []Tag {
+	return append([]Tag(nil), w.alive[id]...)
+}
+
+// Count returns how many entities have the tag.
+func (w *World) Count(t Tag) int {
+	return len(w.tagged[t])
+}
+
+// ForEachWithTag calls fn for every entity with the tag, in creation order,
+// stopping early if fn returns false. fn may tag, untag or destroy entities,
+// the iteration is over the entities tagged when it started.
+func (w *World) ForEachWithTag(t Tag, fn func(id ID) bool) {
+	ids := append([]ID(nil), w.tagged[t]...)
+	for _, id := range ids {
+		if !fn(id) {
+			return
+		}
+	}
+}
+
+// ForEachWithTagReverse is like ForEachWithTag, but from the newest entity to
+// the oldest, which is the top-most first when drawing in creation order.
+func (w *World) ForEachWithTagReverse(t Tag, fn func(id ID) bool) {
+	ids := append([]ID(nil), w.tagged[t]...)
+	for i := len(ids) - 1; i >= 0; i-- {
+		if !fn(ids[i]) {
+			return
+		}
+	}
+}
+
+func search(ids []ID, id ID) int {
+	return sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+}
+
+func insert(ids []ID, id ID) []ID {
+	i := search(ids, id)
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+
+	return ids
+}
+
+func remove(ids []ID, id ID) []ID {
+	i := search(ids, id)
+	if i == len(ids) || ids[i] != id {
+		return ids
+	}
+
+	return append(ids[:i], ids[i+1:]...)
+}
@@ -0,0 +1,131 @@
+// Package fixed is fixed-point math, for simulations that have to come out
+// bit for bit the same on every platform: floating point may not, as Go
+// can fuse multiplies and adds on some architectures, and the math package
+// functions are free to differ in the last bit. Numbers are 64 bit integers
+// with Bits fractional bits, everything on them is integer math, and a
+// Hasher sums a simulation state up to compare it across runs and machines.
+package fixed
+
+import (
+	"fmt"
+	"math"
+)
+
+// Bits is the number of fractional bits.
+const Bits = 16
+
+// Num is a fixed-point number.
+type Num int64
+
+// One is 1.
+const One Num = 1 << Bits
+
+// Int returns n as a fixed-point number.
+func Int(n int) Num {
+	return Num(n) << Bits
+}
+
+// Float returns the nearest fixed-point number to f. Rounding one float is
+// the same everywhere, so it's fine for constants and loading state, but
+// computing the float isn't necessarily.
+func Float(f float64) Num {
+	return Num(math.Round(f * float64(One)))
+}
+
+// Ratio returns n/d, from integers, always the same.
+func Ratio(n, d int) Num {
+	return Int(n).Div(Int(d))
+}
+
+// Float returns n as a float, for drawing and the like.
+func (n Num) Float() float64 {
+	return float64(n) / float64(One)
+}
+
+// Floor returns the integer part of n, rounding down.
+func (n Num) Floor() int {
+	return int(n >> Bits)
+}
+
+// Mul returns n*m, rounded down.
+func (n Num) Mul(m Num) Num {
+	return (n * m) >> Bits
+}
+
+// Div returns n/m, rounded towards zero. It panics if m is 0.
+func (n Num) Div(m Num) Num {
+	return (n << Bits) / m
+}
+
+// Mod returns n modulo m, from 0 up to m, also for negative n, for
+// wrapping around.
+func (n Num) Mod(m Num) Num {
+	n %= m
+	if n < 0 {
+		n += m
+	}
+
+	return n
+}
+
+// Sqrt returns the square root of n, rounded down, or 0 for negative n.
+func (n Num) Sqrt() Num {
+	if n <= 0 {
+		return 0
+	}
+
+	// The integer square root of n scaled up once more is the root scaled
+	// once, by bits from the top
+	x := uint64(n) << Bits
+
+	var root, bit uint64 = 0, 1 << 62
+	for bit > x {
+		bit >>= 2
+	}
+
+	for ; bit != 0; bit >>= 2 {
+		if x >= root+bit {
+			x -= root + bit
+			root = root>>1 + bit
+		} else {
+			root >>= 1
+		}
+	}
+
+	return Num(root)
+}
+
+// Hypot returns the length of (x, y).
+func Hypot(x, y Num) Num {
+	return (x.Mul(x) + y.Mul(y)).Sqrt()
+}
+
+func (n Num) String() string {
+	return fmt.Sprintf("%.4f", n.Float())
+}
+
+// Hasher sums numbers up with 64 bit FNV-1a, to tell whether two runs
+// went the same.
+type Hasher struct {
+	h uint64
+}
+
+// NewHasher returns an empty Hasher.
+func NewHasher() *Hasher {
+	return &Hasher{h: 14695981039346656037}
+}
+
+// Add adds numbers to the sum.
+func (h *Hasher) Add(ns ...Num) {
+	for _, n := range ns {
+		for i := 0; i < 64; i += 8 {
+			h.h ^= uint64(n>>i) & 0xff
+			h.h *= 1099511628211
+		}
+	}
+}
+
+// Sum returns the sum of the numbers added so far.
+func (h *Hasher) Sum() uint64 {
+	return h.h
+}
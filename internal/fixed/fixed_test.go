@@ -0,0 +1,134 @@
+package fixed
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		n, m, want Num
+	}{
+		{Int(3), Int(4), Int(12)},
+		{Float(1.5), Float(-2), Int(-3)},
+		{Float(0.5), Float(0.5), Float(0.25)},
+		{Int(0), Int(7), 0},
+		// Rounded down, below zero too
+		{Ratio(1, 3), Int(3), One - 1},
+		{1, 1, 0},
+		{-1, 1, -1},
+	}
+
+	for _, tt := range tests {
+		if got := tt.n.Mul(tt.m); got != tt.want {
+			t.Errorf("%d * %d = %d, want %d", tt.n, tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestDiv(t *testing.T) {
+	tests := []struct {
+		n, m, want Num
+	}{
+		{Int(12), Int(4), Int(3)},
+		{Int(-3), Float(1.5), Int(-2)},
+		{Int(1), Int(4), Float(0.25)},
+		// Rounded towards zero
+		{Int(1), Int(3), 21845},
+		{Int(-1), Int(3), -21845},
+		{1, Int(2), 0},
+		{-1, Int(2), 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.n.Div(tt.m); got != tt.want {
+			t.Errorf("%d / %d = %d, want %d", tt.n, tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestDivByZero(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("dividing by 0 didn't panic")
+		}
+	}()
+
+	Int(1).Div(0)
+}
+
+func TestMod(t *testing.T) {
+	tests := []struct {
+		n, m, want Num
+	}{
+		{Int(7), Int(3), Int(1)},
+		{Float(5.5), Int(2), Float(1.5)},
+		{Int(6), Int(3), 0},
+		// Wrapping around from below zero
+		{Int(-1), Int(3), Int(2)},
+		{Float(-0.25), Int(1), Float(0.75)},
+		{Int(-6), Int(3), 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.n.Mod(tt.m); got != tt.want {
+			t.Errorf("%v mod %v = %v, want %v", tt.n, tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestSqrt(t *testing.T) {
+	tests := []struct {
+		n, want Num
+	}{
+		{Int(4), Int(2)},
+		{Int(9), Int(3)},
+		{Float(0.25), Float(0.5)},
+		{One, One},
+		{Int(1 << 20), Int(1 << 10)},
+		{0, 0},
+		{Int(-4), 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.n.Sqrt(); got != tt.want {
+			t.Errorf("sqrt %v = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+
+	// Close to the float root, and rounded down
+	for _, n := range []Num{Int(2), Int(3), Float(0.1), Int(12345), 1, 12345} {
+		r := n.Sqrt()
+		if want := math.Sqrt(n.Float()); math.Abs(r.Float()-want) > 1.0/float64(One) {
+			t.Errorf("sqrt %v = %v, want about %.5f", n, r, want)
+		}
+
+		// With n scaled up once more, r*r <= n < (r+1)*(r+1)
+		if x := int64(n) << Bits; int64(r)*int64(r) > x || (int64(r)+1)*(int64(r)+1) <= x {
+			t.Errorf("sqrt %d = %d, not rounded down", n, r)
+		}
+	}
+}
+
+func TestHypot(t *testing.T) {
+	if got := Hypot(Int(3), Int(-4)); got != Int(5) {
+		t.Errorf("hypot(3, -4) = %v, want 5", got)
+	}
+}
+
+func TestHasher(t *testing.T) {
+	sum := func(ns ...Num) uint64 {
+		h := NewHasher()
+		h.Add(ns...)
+
+		return h.Sum()
+	}
+
+	if sum(One, Int(2)) != sum(One, Int(2)) {
+		t.Error("the same numbers summed up differently")
+	}
+
+	if sum(One, Int(2)) == sum(Int(2), One) {
+		t.Error("the order of the numbers doesn't count")
+	}
+}
@@ -0,0 +1,84 @@
+package geom
+
+// Outline returns the outlines of a triangle mesh, given as indices in its
+// vertices three by three, like ebiten's DrawTriangles: the loops of edges
+// only one triangle has, as vertex indices. Holes are loops too. The
+// triangles don't need to all go round the same way.
+func Outline(indices []int) [][]int {
+	type edge struct{ a, b int }
+
+	key := func(a, b int) edge {
+		if a > b {
+			return edge{b, a}
+		}
+
+		return edge{a, b}
+	}
+
+	count := make(map[edge]int)
+
+	for i := 0; i+2 < len(indices); i += 3 {
+		for j := 0; j < 3; j++ {
+			count[key(indices[i+j], indices[i+(j+1)%3])]++
+		}
+	}
+
+	// Vertices on the outline, and the ones next to them on it
+	next := make(map[int][]int)
+
+	for e, n := range count {
+		if n == 1 {
+			next[e.a] = append(next[e.a], e.b)
+			next[e.b] = append(next[e.b], e.a)
+		}
+	}
+
+	used := make(map[edge]bool)
+
+	var loops [][]int
+
+	for {
+		// Lowest first, so the same mesh always gives the same loops
+		start := -1
+
+		for v, ws := range next {
+			for _, w := range ws {
+				if !used[key(v, w)] && (start < 0 || v < start) {
+					start = v
+				}
+			}
+		}
+
+		if start < 0 {
+			return loops
+		}
+
+		loop := []int{start}
+
+		for v := start; ; {
+			w := -1
+
+			for _, u := range next[v] {
+				if !used[key(v, u)] && (w < 0 || u < w) {
+					w = u
+				}
+			}
+
+			// Back at the start, or a broken mesh
+			if w < 0 {
+				break
+			}
+
+			used[key(v, w)] = true
+
+			if w == start {
+				break
+			}
+
+			loop = append(loop, w)
+			v = w
+		}
+
+		loops = append(loops, loop)
+	}
+}
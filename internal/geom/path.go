@@ -0,0 +1,170 @@
+// Package geom has geometry helpers shared between exercises.
+package geom
+
+import (
+	"math"
+	"sort"
+)
+
+// Point is a point in screen coordinates.
+type Point struct {
+	X, Y float64
+}
+
+// Dist returns the distance between p and q.
+func (p Point) Dist(q Point) float64 {
+	return math.Hypot(q.X-p.X, q.Y-p.Y)
+}
+
+// Lerp returns the point at t (0 to 1) from p to q.
+func (p Point) Lerp(q Point, t float64) Point {
+	return Point{p.X + (q.X-p.X)*t, p.Y + (q.Y-p.Y)*t}
+}
+
+// Path is a polyline parameterized by arc length, so that walking along it
+// at a constant step moves at a constant speed, no matter how long each
+// segment is.
+type Path struct {
+	points []Point
+	// Arc length at each point, cum[0] is 0 and the last is the length
+	cum    []float64
+	closed bool
+}
+
+// NewPath returns a path through the points. A closed path goes back to
+// the first point at the end, and wraps around when walked past its length.
+func NewPath(points []Point, closed bool) *Path {
+	p := &Path{
+		points: append([]Point(nil), points...),
+		closed: closed,
+	}
+
+	if closed && len(points) > 1 {
+		p.points = append(p.points, points[0])
+	}
+
+	p.cum = make([]float64, len(p.points))
+	for i := 1; i < len(p.points); i++ {
+		p.cum[i] = p.cum[i-1] + p.points[i-1].Dist(p.points[i])
+	}
+
+	return p
+}
+
+// Points returns the points of the path, the first one repeated at the end
+// for closed paths.
+func (p *Path) Points() []Point {
+	return p.points
+}
+
+// Length is the arc length of the path.
+func (p *Path) Length() float64 {
+	if len(p.cum) == 0 {
+		return 0
+	}
+
+	return p.cum[len(p.cum)-1]
+}
+
+// At returns the point at arc length s along the path, and the angle of the
+// path there in radians, as in math.Atan2. s is clamped to the ends of open
+// paths, and wraps around closed ones.
+func (p *Path) At(s float64) (Point, float64) {
+	switch len(p.points) {
+	case 0:
+		return Point{}, 0
+	case 1:
+		return p.points[0], 0
+	}
+
+	l := p.Length()
+	if p.closed && l > 0 {
+		s = math.Mod(s, l)
+		if s < 0 {
+			s += l
+		}
+	}
+
+	s = math.Max(0, math.Min(s, l))
+
+	// First point past s, the segment is the one ending there
+	i := sort.SearchFloat64s(p.cum, s)
+	if i == 0 {
+		i = 1
+	}
+
+	// Zero length segments (repeated points) have no direction, use the
+	// next one that does
+	j := i
+	for j < len(p.points)-1 && p.cum[j] == p.cum[j-1] {
+		j++
+	}
+
+	a, b := p.points[j-1], p.points[j]
+	angle := math.Atan2(b.Y-a.Y, b.X-a.X)
+
+	seg := p.cum[i] - p.cum[i-1]
+	if seg == 0 {
+		return p.points[i], angle
+	}
+
+	return p.points[i-1].Lerp(p.points[i], (s-p.cum[i-1])/seg), angle
+}
+
+// Slice returns the part of the path from arc length from to arc length
+// to, corners included, both clamped to the ends of the path.
+func (p *Path) Slice(from, to float64) []Point {
+	l := p.Length()
+	from, to = math.Max(0, from), math.Min(to, l)
+
+	if len(p.points) == 0 || to < from {
+		return nil
+	}
+
+	start, _ := p.At(from)
+	end, _ := p.At(to)
+	pts := []Point{start}
+
+	for i, s := range p.cum {
+		if s > from && s < to {
+			pts = append(pts, p.points[i])
+		}
+	}
+
+	return append(pts, end)
+}
+
+// Dashes returns the dashes of a dashed line along the path, dash long and
+// gap apart, the first starting offset along it, as parts of the path.
+// Moving the offset a bit every frame makes the dashes march. On closed
+// paths they're stretched a little to fit a whole number of them, so
+// there's no seam where the path closes.
+func (p *Path) Dashes(dash, gap, offset float64) [][]Point {
+	l := p.Length()
+	period := dash + gap
+
+	if l == 0 || period <= 0 {
+		return nil
+	}
+
+	if p.closed {
+		k := l / math.Max(1, math.Round(l/period)) / period
+		dash, gap, offset = dash*k, gap*k, offset*k
+		period = dash + gap
+	}
+
+	var dashes [][]Point
+
+	for s := math.Mod(offset, period) - period; s < l; s += period {
+		if s+dash > 0 {
+			dashes = append(dashes, p.Slice(s, s+dash))
+		}
+
+		// The dash cut at the start continues here, on closed paths
+		if p.closed && s < 0 && s+dash > 0 {
+			dashes = append(dashes, p.Slice(l+s, l))
+		}
+	}
+
+	return dashes
+}
@@ -0,0 +1,23 @@
+package geom
+
+// InPolygon reports whether q is inside the polygon with the given
+// vertices, by counting how many of its edges a ray from q to the right
+// crosses. It works for concave polygons too, self-intersecting ones are
+// taken by the even-odd rule. Points on the left and top edges are in, on
+// the right and bottom ones out, so a point on an edge shared by two
+// polygons is in only one of them.
+func InPolygon(q Point, vs []Point) bool {
+	in := false
+
+	for i, j := 0, len(vs)-1; i < len(vs); j, i = i, i+1 {
+		a, b := vs[i], vs[j]
+		// Half-open on y, so a vertex right at q's height counts for only
+		// one of its edges
+		if (a.Y > q.Y) != (b.Y > q.Y) &&
+			q.X < a.X+(q.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			in = !in
+		}
+	}
+
+	return in
+}
@@ -0,0 +1,65 @@
+package geom
+
+import "testing"
+
+func TestInPolygon(t *testing.T) {
+	square := []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	// A U opening upwards, its notch from x 3 to 7 down to y 6
+	u := []Point{{0, 0}, {3, 0}, {3, 6}, {7, 6}, {7, 0}, {10, 0}, {10, 10}, {0, 10}}
+	// A star, concave at every other vertex
+	star := []Point{{5, 0}, {6, 4}, {10, 4}, {7, 6}, {8, 10}, {5, 7}, {2, 10}, {3, 6}, {0, 4}, {4, 4}}
+	// A bow tie crossing itself at (5, 5), in by the even-odd rule
+	bowTie := []Point{{0, 0}, {10, 10}, {10, 0}, {0, 10}}
+
+	tests := []struct {
+		name string
+		q    Point
+		vs   []Point
+		want bool
+	}{
+		{"square center", Point{5, 5}, square, true},
+		{"square left", Point{-1, 5}, square, false},
+		{"square right", Point{11, 5}, square, false},
+		{"square above", Point{5, -1}, square, false},
+		{"square corner outside", Point{11, 11}, square, false},
+		{"square left edge", Point{0, 5}, square, true},
+		{"square top edge", Point{5, 0}, square, true},
+		{"square right edge", Point{10, 5}, square, false},
+		{"square bottom edge", Point{5, 10}, square, false},
+		{"u left arm", Point{1, 1}, u, true},
+		{"u right arm", Point{9, 1}, u, true},
+		{"u notch", Point{5, 3}, u, false},
+		{"u under the notch", Point{5, 8}, u, true},
+		{"u level with the notch bottom", Point{5, 6}, u, true},
+		{"star center", Point{5, 5}, star, true},
+		{"star tip", Point{5, 1}, star, true},
+		{"star between tips", Point{1, 8}, star, false},
+		{"star between the bottom tips", Point{5, 9}, star, false},
+		{"bow tie left wing", Point{1, 5}, bowTie, true},
+		{"bow tie top gap", Point{5, 1}, bowTie, false},
+		{"no vertices", Point{0, 0}, nil, false},
+		{"a segment", Point{5, 0}, []Point{{0, 0}, {10, 0}}, false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InPolygon(tt.q, tt.vs); got != tt.want {
+				t.Errorf("InPolygon(%v) = %v, want %v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+// Points on an edge shared by two polygons are in exactly one of them, so
+// hit testing a mesh finds a triangle under every point.
+func TestInPolygonSharedEdge(t *testing.T) {
+	a := []Point{{0, 0}, {10, 0}, {0, 10}}
+	b := []Point{{10, 0}, {10, 10}, {0, 10}}
+
+	for _, q := range []Point{{5, 5}, {2, 8}, {7.5, 2.5}, {9, 1}} {
+		if InPolygon(q, a) == InPolygon(q, b) {
+			t.Errorf("%v on the shared edge is in both triangles or in neither", q)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package geom
+
+// Overlap reports whether two convex polygons overlap, by the separating
+// axis theorem: they don't if and only if the normal of some edge of either
+// one separates them, with all of one's vertices projecting on it before
+// all of the other's. Polygons only touching don't overlap.
+func Overlap(a, b []Point) bool {
+	return !separates(a, b) && !separates(b, a)
+}
+
+// separates reports whether the normal of an edge of a separates a and b.
+func separates(a, b []Point) bool {
+	for i, j := 0, len(a)-1; i < len(a); j, i = i, i+1 {
+		axis := Point{a[j].Y - a[i].Y, a[i].X - a[j].X}
+		minA, maxA := project(a, axis)
+		minB, maxB := project(b, axis)
+
+		if maxA <= minB || maxB <= minA {
+			return true
+		}
+	}
+
+	return false
+}
+
+// project returns the extent of the polygon along the axis.
+func project(vs []Point, axis Point) (min, max float64) {
+	for i, v := range vs {
+		d := v.X*axis.X + v.Y*axis.Y
+		if i == 0 || d < min {
+			min = d
+		}
+
+		if i == 0 || d > max {
+			max = d
+		}
+	}
+
+	return min, max
+}
@@ -0,0 +1,60 @@
+package geom
+
+import "math"
+
+// Simplify drops the points of a polyline that stray less than tol from
+// the line through their neighbours that are kept, by Ramer–Douglas–Peucker.
+// The ends are always kept.
+func Simplify(pts []Point, tol float64) []Point {
+	if len(pts) < 3 {
+		return append([]Point(nil), pts...)
+	}
+
+	keep := make([]bool, len(pts))
+	keep[0], keep[len(pts)-1] = true, true
+
+	var rdp func(i, j int)
+	rdp = func(i, j int) {
+		far, d := -1, tol
+
+		for k := i + 1; k < j; k++ {
+			if dk := SegmentDist(pts[k], pts[i], pts[j]); dk > d {
+				far, d = k, dk
+			}
+		}
+
+		if far < 0 {
+			return
+		}
+
+		keep[far] = true
+		rdp(i, far)
+		rdp(far, j)
+	}
+	rdp(0, len(pts)-1)
+
+	var out []Point
+
+	for i, p := range pts {
+		if keep[i] {
+			out = append(out, p)
+		}
+	}
+
+	return out
+}
+
+// SegmentDist returns the distance from p to the segment from a to b.
+func SegmentDist(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+
+	l2 := dx*dx + dy*dy
+	if l2 == 0 {
+		return p.Dist(a)
+	}
+
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / l2
+	t = math.Max(0, math.Min(t, 1))
+
+	return p.Dist(a.Lerp(b, t))
+}
@@ -0,0 +1,111 @@
+package geom
+
+import "math"
+
+// Relative difference between the area of a polygon and of its triangles
+// put down to rounding
+const areaSlack = 1e-6
+
+// Area returns the signed area of the polygon with the given vertices,
+// positive when they go clockwise on the screen, where y grows down.
+func Area(vs []Point) float64 {
+	a := 0.0
+
+	for i, j := 0, len(vs)-1; i < len(vs); j, i = i, i+1 {
+		a += vs[j].X*vs[i].Y - vs[i].X*vs[j].Y
+	}
+
+	return a / 2
+}
+
+// Triangulate cuts a simple polygon, concave or not, into triangles by
+// clipping its ears, and returns them as indices into vs, three by
+// three. It reports false for polygons it can't cut, the ones that cross
+// themselves.
+func Triangulate(vs []Point) ([]int, bool) {
+	if len(vs) < 3 {
+		return nil, false
+	}
+
+	// Remaining vertices, clockwise
+	idx := make([]int, len(vs))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	if Area(vs) < 0 {
+		for i, j := 0, len(idx)-1; i < j; i, j = i+1, j-1 {
+			idx[i], idx[j] = idx[j], idx[i]
+		}
+	}
+
+	tris := make([]int, 0, 3*(len(vs)-2))
+
+	for len(idx) > 3 {
+		clipped := false
+
+		for i := range idx {
+			a, b, c := idx[(i+len(idx)-1)%len(idx)], idx[i], idx[(i+1)%len(idx)]
+
+			turn := cross(vs[a], vs[b], vs[c])
+			if turn < 0 || turn > 0 && !isEar(vs, idx, a, b, c) {
+				continue
+			}
+
+			// A vertex in a straight line with its neighbours is no
+			// triangle, it's just dropped
+			if turn > 0 {
+				tris = append(tris, a, b, c)
+			}
+
+			idx = append(idx[:i], idx[i+1:]...)
+			clipped = true
+
+			break
+		}
+
+		if !clipped {
+			return nil, false
+		}
+	}
+
+	if cross(vs[idx[0]], vs[idx[1]], vs[idx[2]]) > 0 {
+		tris = append(tris, idx...)
+	}
+
+	// Polygons that cross themselves can still have ears to clip, but
+	// the triangles don't cover them the way the even-odd rule does
+	covered := 0.0
+	for i := 0; i < len(tris); i += 3 {
+		covered += Area([]Point{vs[tris[i]], vs[tris[i+1]], vs[tris[i+2]]})
+	}
+
+	if len(tris) == 0 || math.Abs(covered-math.Abs(Area(vs))) > areaSlack*covered {
+		return nil, false
+	}
+
+	return tris, true
+}
+
+// cross is the z of the cross product of ab and bc, positive when a, b, c
+// turn clockwise on the screen.
+func cross(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-b.Y) - (b.Y-a.Y)*(c.X-b.X)
+}
+
+// isEar reports whether none of the remaining vertices but a, b and c are
+// in the triangle abc, so it can be cut off.
+func isEar(vs []Point, idx []int, a, b, c int) bool {
+	for _, i := range idx {
+		if i == a || i == b || i == c {
+			continue
+		}
+
+		p := vs[i]
+		if cross(vs[a], vs[b], p) >= 0 && cross(vs[b], vs[c], p) >= 0 && cross(vs[c], vs[a], p) >= 0 {
+			return false
+		}
+	}
+
+	return true
+}
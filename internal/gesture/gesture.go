@@ -0,0 +1,213 @@
+// Package gesture recognizes touchscreen gestures, taps, long presses, drags
+// and pinches, from the touches of keymap.Input, so the exercises can be
+// played on mobile and in browsers the same way they are with a mouse.
+package gesture
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+)
+
+// Kind is what a gesture is.
+type Kind int
+
+const (
+	// Tap is a finger going down and up quickly, without moving, at X, Y
+	Tap Kind = iota
+	// TwoFingerTap is a Tap with two fingers, at the first one
+	TwoFingerTap
+	// LongPress is a finger held still for a while, at X, Y. It fires as
+	// soon as it's held long enough, and the finger lifting after isn't a
+	// Tap
+	LongPress
+	// DragStart is a finger starting to move, at where it went down
+	DragStart
+	// Drag is the dragging finger at X, Y, moved DX, DY since the last
+	// tick. It comes every tick of the drag, moved or not
+	Drag
+	// DragEnd is the dragging finger lifting, or a second one going down
+	DragEnd
+	// Pinch is two fingers moving: their middle is at X, Y, moved DX, DY
+	// since the last tick, and Scale is how much further apart they are
+	Pinch
+)
+
+// Event is a gesture, or a step of one, in screen pixels.
+type Event struct {
+	Kind   Kind
+	X, Y   int
+	DX, DY int
+	Scale  float64
+}
+
+// Recognizer follows the fingers on the screen, from the first one down to
+// the last one up, and tells what gestures they make. The fields are the
+// tuning, New sets the defaults.
+type Recognizer struct {
+	// Pixels a finger can move and still be still, for taps and long
+	// presses
+	Slop int
+	// Ticks a tap can take at most, and a long press at least
+	TapTicks       int
+	LongPressTicks int
+
+	// Where each finger went down, and where it was last tick, and the
+	// first one down
+	start map[int]image.Point
+	last  map[int]image.Point
+	first int
+	// Of the gesture going on: the most fingers at once, for how long,
+	// and whether any moved past the slop, a long press fired or a drag
+	// is on
+	fingers  int
+	ticks    int
+	moved    bool
+	pressed  bool
+	dragging bool
+}
+
+func New() *Recognizer {
+	return &Recognizer{Slop: 10, TapTicks: 15, LongPressTicks: 30}
+}
+
+// Active reports whether there are fingers on the screen.
+func (r *Recognizer) Active() bool {
+	return len(r.last) > 0
+}
+
+// Update follows the fingers a tick, and returns the gestures they made.
+func (r *Recognizer) Update() []Event {
+	src := keymap.Input()
+	ids := src.TouchIDs()
+
+	if len(ids) == 0 {
+		return r.lift()
+	}
+
+	if r.last == nil {
+		r.start, r.last = make(map[int]image.Point), make(map[int]image.Point)
+		r.first = ids[0]
+	}
+
+	// Pinches go by the same two fingers whatever order they come in
+	sort.Ints(ids)
+
+	r.ticks++
+	pos := make(map[int]image.Point, len(ids))
+
+	for _, id := range ids {
+		p := image.Pt(src.TouchPosition(id))
+		pos[id] = p
+
+		if _, ok := r.start[id]; !ok {
+			r.start[id] = p
+		}
+
+		if d := p.Sub(r.start[id]); d.X*d.X+d.Y*d.Y > r.Slop*r.Slop {
+			r.moved = true
+		}
+	}
+
+	if len(ids) > r.fingers {
+		r.fingers = len(ids)
+	}
+
+	var events []Event
+
+	switch {
+	case r.fingers == 1:
+		events = r.single(pos[ids[0]], r.start[ids[0]], r.last[ids[0]])
+	case r.dragging:
+		r.dragging = false
+		events = append(events, Event{Kind: DragEnd, X: r.last[ids[0]].X, Y: r.last[ids[0]].Y})
+	}
+
+	if len(ids) == 2 && r.moved {
+		if e, ok := r.pinch(pos[ids[0]], pos[ids[1]], ids); ok {
+			events = append(events, e)
+		}
+	}
+
+	r.last = pos
+
+	return events
+}
+
+// single follows a gesture of one finger, at p, that went down at start
+// and was at last the tick before.
+func (r *Recognizer) single(p, start, last image.Point) []Event {
+	switch {
+	case r.pressed:
+		return nil
+	case !r.moved:
+		if r.ticks == r.LongPressTicks {
+			r.pressed = true
+
+			return []Event{{Kind: LongPress, X: p.X, Y: p.Y}}
+		}
+
+		return nil
+	case !r.dragging:
+		r.dragging = true
+		d := p.Sub(start)
+
+		return []Event{{Kind: DragStart, X: start.X, Y: start.Y}, {Kind: Drag, X: p.X, Y: p.Y, DX: d.X, DY: d.Y}}
+	}
+
+	d := p.Sub(last)
+
+	return []Event{{Kind: Drag, X: p.X, Y: p.Y, DX: d.X, DY: d.Y}}
+}
+
+// pinch follows two fingers, now at a and b, if they were on the screen
+// last tick too.
+func (r *Recognizer) pinch(a, b image.Point, ids []int) (Event, bool) {
+	la, oka := r.last[ids[0]]
+	lb, okb := r.last[ids[1]]
+
+	if !oka || !okb {
+		return Event{}, false
+	}
+
+	was := math.Hypot(float64(lb.X-la.X), float64(lb.Y-la.Y))
+	is := math.Hypot(float64(b.X-a.X), float64(b.Y-a.Y))
+	scale := 1.0
+
+	if was > 0 && is > 0 {
+		scale = is / was
+	}
+
+	mid, lmid := a.Add(b).Div(2), la.Add(lb).Div(2)
+	d := mid.Sub(lmid)
+
+	return Event{Kind: Pinch, X: mid.X, Y: mid.Y, DX: d.X, DY: d.Y, Scale: scale}, true
+}
+
+// lift ends the gesture once the last finger is up. Taps are where the
+// first finger went down.
+func (r *Recognizer) lift() []Event {
+	if r.last == nil {
+		return nil
+	}
+
+	var events []Event
+
+	p, l := r.start[r.first], r.last[r.first]
+
+	switch {
+	case r.dragging:
+		events = append(events, Event{Kind: DragEnd, X: l.X, Y: l.Y})
+	case r.moved || r.pressed || r.ticks > r.TapTicks:
+	case r.fingers == 1:
+		events = append(events, Event{Kind: Tap, X: p.X, Y: p.Y})
+	case r.fingers == 2:
+		events = append(events, Event{Kind: TwoFingerTap, X: p.X, Y: p.Y})
+	}
+
+	*r = Recognizer{Slop: r.Slop, TapTicks: r.TapTicks, LongPressTicks: r.LongPressTicks}
+
+	return events
+}
@@ -0,0 +1,73 @@
+// Package group implements RTS style control groups: Ctrl+1..9 assigns the
+// current selection to a group, 1..9 selects it back.
+package group
+
+import (
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Count is the number of groups, numbered 1 to Count.
+const Count = 9
+
+//nolint:gochecknoglobal
+var keys = [Count]ebiten.Key{
+	ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4, ebiten.Key5,
+	ebiten.Key6, ebiten.Key7, ebiten.Key8, ebiten.Key9,
+}
+
+// Groups holds the members of each group, as whatever indices or IDs the
+// exercise uses for the things being grouped.
+type Groups struct {
+	members [Count][]int
+}
+
+// Assign replaces the members of group n.
+func (g *Groups) Assign(n int, members []int) {
+	g.members[n-1] = append([]int(nil), members...)
+}
+
+// Members returns a copy of the members of group n.
+func (g *Groups) Members(n int) []int {
+	return append([]int(nil), g.members[n-1]...)
+}
+
+// Remove takes a member out of every group, for things that go away.
+func (g *Groups) Remove(member int) {
+	for i, ms := range g.members {
+		kept := ms[:0]
+
+		for _, m := range ms {
+			if m != member {
+				kept = append(kept, m)
+			}
+		}
+
+		g.members[i] = kept
+	}
+}
+
+// Update handles the group keys, assigning selection to the group on
+// Ctrl+n, and returning the members of the group to select on n. ok is
+// false when no group was selected.
+func (g *Groups) Update(selection []int) (members []int, ok bool) {
+	for i, k := range keys {
+		if !keymap.IsKeyJustPressed(k) {
+			continue
+		}
+
+		if keymap.IsKeyPressed(ebiten.KeyControl) {
+			g.Assign(i+1, selection)
+
+			return nil, false
+		}
+
+		if len(g.members[i]) == 0 {
+			return nil, false
+		}
+
+		return g.Members(i + 1), true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,127 @@
+// Package hint is a footer of control hints along the bottom of the screen,
+// like "Esc: Quit", labelled for the language and keyboard of the player,
+// or for their gamepad. The inputs come from the bindings as they are when
+// drawn, so the hints follow any remapping.
+package hint
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/i18n"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Size of the debug font
+	charWidth  = 6
+	lineHeight = 16
+	padding    = 4
+	separator  = "   "
+)
+
+//nolint:gochecknoglobal
+var background = color.RGBA{0, 0, 0, 0xc0}
+
+// Hint is what the binding named Binding does, in English, translated if
+// it's one of the common actions.
+type Hint struct {
+	Binding string
+	What    string
+	// Shown instead of the keys and the gamepad inputs of the binding, if
+	// set, for a hint standing for a few bindings, like "Arrows" for the
+	// four moving a cursor
+	Keys string
+	Pad  string
+}
+
+// Footer is the hints of an exercise.
+type Footer struct {
+	Locale i18n.Locale
+	Hints  []Hint
+	// Label the hints with the gamepad inputs instead of the keys and the
+	// mouse, see keymap.Device
+	Pad bool
+}
+
+// label returns the inputs of the binding of h, for the device of the
+// footer, empty if it has none there.
+func (f *Footer) label(h Hint, b *keymap.Binding) string {
+	label, override := b.Label(f.Locale), h.Keys
+	if f.Pad {
+		label, override = b.PadLabel(f.Locale), h.Pad
+	}
+
+	if label != "" && override != "" {
+		return f.Locale.Word(override)
+	}
+
+	return label
+}
+
+// Lines returns the hints for the bindings of m, wrapped to fit width
+// pixels. Hints for bindings m doesn't have, or that have no inputs on the
+// device of the footer, are left out.
+func (f *Footer) Lines(m keymap.Map, width int) []string {
+	var (
+		lines []string
+		line  string
+	)
+
+	for _, h := range f.Hints {
+		b := find(m, h.Binding)
+		if b == nil {
+			continue
+		}
+
+		label := f.label(h, b)
+		if label == "" {
+			continue
+		}
+
+		s := label + ": " + f.Locale.Word(h.What)
+
+		switch {
+		case line == "":
+			line = s
+		case len(line+separator+s)*charWidth > width-2*padding:
+			lines = append(lines, line)
+			line = s
+		default:
+			line += separator + s
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func find(m keymap.Map, name string) *keymap.Binding {
+	for i := range m {
+		if m[i].Name == name {
+			return &m[i]
+		}
+	}
+
+	return nil
+}
+
+// Draw draws the hints for the bindings of m along the bottom of the
+// screen, over a dark band.
+func (f *Footer) Draw(screen *ebiten.Image, m keymap.Map) {
+	sw, sh := screen.Size()
+
+	lines := f.Lines(m, sw)
+	if len(lines) == 0 {
+		return
+	}
+
+	h := len(lines)*lineHeight + padding
+	ebitenutil.DrawRect(screen, 0, float64(sh-h), float64(sw), float64(h), background)
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), padding, sh-h)
+}
@@ -0,0 +1,193 @@
+// Package i18n is the language and keyboard layout of the player, for the
+// few words the exercises show about their controls: key names and the
+// common actions. Words stay within ASCII, as the debug font has nothing
+// else.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Lang is a language, by its ISO 639-1 code.
+type Lang string
+
+const (
+	English Lang = "en"
+	French  Lang = "fr"
+	Spanish Lang = "es"
+	German  Lang = "de"
+)
+
+// Layout is a keyboard layout, by its first letters.
+type Layout string
+
+const (
+	QWERTY Layout = "qwerty"
+	AZERTY Layout = "azerty"
+	QWERTZ Layout = "qwertz"
+)
+
+// Layouts usual for each language, the rest use QWERTY.
+//
+//nolint:gochecknoglobal
+var defaultLayouts = map[Lang]Layout{
+	French: AZERTY,
+	German: QWERTZ,
+}
+
+// Words by language, from the English ones the exercises use. Missing
+// words stay in English.
+//
+//nolint:gochecknoglobal
+var words = map[Lang]map[string]string{
+	French: {
+		"Space":        "Espace",
+		"Shift":        "Maj",
+		"Enter":        "Entree",
+		"Esc":          "Echap",
+		"Backspace":    "Retour",
+		"Delete":       "Suppr",
+		"Insert":       "Inser",
+		"Home":         "Debut",
+		"End":          "Fin",
+		"PgUp":         "Pg Prec",
+		"PgDn":         "Pg Suiv",
+		"Up":           "Haut",
+		"Down":         "Bas",
+		"Left":         "Gauche",
+		"Right":        "Droite",
+		"Mouse Left":   "Clic gauche",
+		"Mouse Right":  "Clic droit",
+		"Mouse Middle": "Clic milieu",
+		"Quit":         "Quitter",
+		"Select":       "Choisir",
+		"Move":         "Deplacer",
+		"Save":         "Enregistrer",
+		"Load":         "Charger",
+		"Undo":         "Annuler",
+		"Redo":         "Retablir",
+		"Fullscreen":   "Plein ecran",
+	},
+	Spanish: {
+		"Space":        "Espacio",
+		"Shift":        "Mayus",
+		"Enter":        "Intro",
+		"Backspace":    "Retroceso",
+		"Delete":       "Supr",
+		"Home":         "Inicio",
+		"End":          "Fin",
+		"PgUp":         "RePag",
+		"PgDn":         "AvPag",
+		"Up":           "Arriba",
+		"Down":         "Abajo",
+		"Left":         "Izquierda",
+		"Right":        "Derecha",
+		"Mouse Left":   "Clic izquierdo",
+		"Mouse Right":  "Clic derecho",
+		"Mouse Middle": "Clic central",
+		"Quit":         "Salir",
+		"Select":       "Elegir",
+		"Move":         "Mover",
+		"Save":         "Guardar",
+		"Load":         "Cargar",
+		"Undo":         "Deshacer",
+		"Redo":         "Rehacer",
+		"Fullscreen":   "Pantalla completa",
+	},
+	German: {
+		"Space":        "Leertaste",
+		"Shift":        "Umschalt",
+		"Ctrl":         "Strg",
+		"Backspace":    "Ruecktaste",
+		"Delete":       "Entf",
+		"Insert":       "Einfg",
+		"Home":         "Pos1",
+		"End":          "Ende",
+		"PgUp":         "Bild auf",
+		"PgDn":         "Bild ab",
+		"Up":           "Hoch",
+		"Down":         "Runter",
+		"Left":         "Links",
+		"Right":        "Rechts",
+		"Mouse Left":   "Linksklick",
+		"Mouse Right":  "Rechtsklick",
+		"Mouse Middle": "Mittelklick",
+		"Quit":         "Beenden",
+		"Select":       "Auswaehlen",
+		"Move":         "Bewegen",
+		"Save":         "Speichern",
+		"Load":         "Laden",
+		"Undo":         "Rueckgaengig",
+		"Redo":         "Wiederholen",
+		"Fullscreen":   "Vollbild",
+	},
+}
+
+// Locale is the language of the player and the layout of their keyboard.
+// The zero value is English on QWERTY.
+type Locale struct {
+	Lang   Lang
+	Layout Layout
+}
+
+// Parse reads a locale like "fr", "fr-azerty" or "en-qwertz": a language,
+// and optionally the layout if it's not the usual one for it.
+func Parse(s string) (Locale, error) {
+	parts := strings.SplitN(strings.ToLower(s), "-", 2)
+	l := Locale{Lang: Lang(parts[0])}
+
+	if _, ok := words[l.Lang]; !ok && l.Lang != English {
+		return Locale{}, fmt.Errorf("unknown language %q", parts[0])
+	}
+
+	l.Layout = defaultLayouts[l.Lang]
+	if len(parts) == 2 {
+		l.Layout = Layout(parts[1])
+	}
+
+	switch l.Layout {
+	case "":
+		l.Layout = QWERTY
+	case QWERTY, AZERTY, QWERTZ:
+	default:
+		return Locale{}, fmt.Errorf("unknown keyboard layout %q", parts[1])
+	}
+
+	return l, nil
+}
+
+// Detect returns the locale of the environment, as in LANG=fr_FR.UTF-8,
+// or English if there's none or it's not one we have.
+func Detect() Locale {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		// fr_FR.UTF-8 is fr for us
+		fields := strings.FieldsFunc(os.Getenv(v), func(r rune) bool { return r == '_' || r == '.' })
+		if len(fields) == 0 {
+			continue
+		}
+
+		if l, err := Parse(fields[0]); err == nil {
+			return l
+		}
+	}
+
+	l, _ := Parse(string(English))
+
+	return l
+}
+
+// Word returns the English word w in the language of the locale, or as is
+// if there's no translation.
+func (l Locale) Word(w string) string {
+	if t, ok := words[l.Lang][w]; ok {
+		return t
+	}
+
+	return w
+}
+
+func (l Locale) String() string {
+	return string(l.Lang) + "-" + string(l.Layout)
+}
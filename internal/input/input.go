@@ -0,0 +1,394 @@
+// Package input maps what the player means, like moving, rotating,
+// selecting or quitting, to the inputs that do it. The keyboard, the mouse
+// and every gamepad count at once. The inputs of an action can be changed
+// while the game runs.
+//
+// keymap binds inputs to callbacks run from a table. input is for polling
+// instead, for code that asks whether Select was pressed in the middle of its
+// own logic, like modal panels. It's built on keymap: the gamepad buttons,
+// axes, deadzone and the devices are keymap's, and keys and mouse buttons go
+// by the same names as in keymap configs. What input adds is the actions,
+// their hold times, and rebinding them to gamepad buttons and axes by name.
+package input
+
+import (
+	"fmt"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Axes past this aren't looked at
+const maxAxes = 8
+
+// Action is something the player means to do.
+type Action int
+
+const (
+	MoveUp Action = iota
+	MoveDown
+	MoveLeft
+	MoveRight
+	RotateLeft
+	RotateRight
+	Select
+	Cancel
+	// Toggle turns something on or off, like a link between two blocks
+	Toggle
+	// Menu opens or closes a panel
+	Menu
+	Quit
+	numActions
+)
+
+//nolint:gochecknoglobal
+var actionNames = [numActions]string{
+	"Move up", "Move down", "Move left", "Move right", "Rotate left", "Rotate right",
+	"Select", "Cancel", "Toggle", "Menu", "Quit",
+}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+// Inputs are what triggers an action, any of them.
+type Inputs struct {
+	Keys    []ebiten.Key
+	Buttons []ebiten.MouseButton
+	// Buttons of any of the gamepads
+	Pads []ebiten.GamepadButton
+	Axes []keymap.Axis
+}
+
+// defaults are the inputs every map starts with. Esc both cancels and
+// quits, the games that use both say which goes first by checking it first,
+// or rebind one of them.
+//
+//nolint:gochecknoglobal
+var defaults = [numActions]Inputs{
+	MoveUp:      {Keys: keymap.Keys(ebiten.KeyUp, ebiten.KeyW), Pads: keymap.Pads(keymap.PadUp), Axes: keymap.Sticks(keymap.StickUp)},
+	MoveDown:    {Keys: keymap.Keys(ebiten.KeyDown, ebiten.KeyS), Pads: keymap.Pads(keymap.PadDown), Axes: keymap.Sticks(keymap.StickDown)},
+	MoveLeft:    {Keys: keymap.Keys(ebiten.KeyLeft, ebiten.KeyA), Pads: keymap.Pads(keymap.PadLeft), Axes: keymap.Sticks(keymap.StickLeft)},
+	MoveRight:   {Keys: keymap.Keys(ebiten.KeyRight, ebiten.KeyD), Pads: keymap.Pads(keymap.PadRight), Axes: keymap.Sticks(keymap.StickRight)},
+	RotateLeft:  {Keys: keymap.Keys(ebiten.KeyQ), Pads: keymap.Pads(keymap.PadLB)},
+	RotateRight: {Keys: keymap.Keys(ebiten.KeyE), Pads: keymap.Pads(keymap.PadRB)},
+	Select:      {Keys: keymap.Keys(ebiten.KeyEnter, ebiten.KeySpace), Pads: keymap.Pads(keymap.PadA)},
+	Cancel:      {Keys: keymap.Keys(ebiten.KeyEscape, ebiten.KeyBackspace), Pads: keymap.Pads(keymap.PadB)},
+	Toggle:      {Keys: keymap.Keys(ebiten.KeyX), Pads: keymap.Pads(keymap.PadX)},
+	Menu:        {Keys: keymap.Keys(ebiten.KeyTab), Pads: keymap.Pads(keymap.PadStart)},
+	Quit:        {Keys: keymap.Keys(ebiten.KeyEscape), Pads: keymap.Pads(keymap.PadBack)},
+}
+
+// Map is the inputs of every action, and how long each has been held.
+// Update it once a tick, before asking about the actions.
+type Map struct {
+	// Ticks held before Repeated repeats, and between repeats, zero for
+	// keymap's defaults
+	Delay    int
+	Interval int
+
+	inputs   [numActions]Inputs
+	held     [numActions]int
+	released [numActions]bool
+	// Ticks each axis has been pushed each way, on any gamepad
+	axes [maxAxes][2]int
+}
+
+// New returns a map with the default inputs.
+func New() *Map {
+	m := &Map{}
+
+	for a, in := range defaults {
+		m.inputs[a] = Inputs{
+			Keys:    append([]ebiten.Key(nil), in.Keys...),
+			Buttons: append([]ebiten.MouseButton(nil), in.Buttons...),
+			Pads:    append([]ebiten.GamepadButton(nil), in.Pads...),
+			Axes:    append([]keymap.Axis(nil), in.Axes...),
+		}
+	}
+
+	return m
+}
+
+// Update counts how long each action has been held, by any of its inputs.
+func (m *Map) Update() {
+	for i := range m.axes {
+		for j, sign := range [2]int{-1, 1} {
+			if (keymap.Axis{Index: i, Sign: sign}).Value() > keymap.Deadzone {
+				m.axes[i][j]++
+			} else {
+				m.axes[i][j] = 0
+			}
+		}
+	}
+
+	for a := range m.inputs {
+		if m.Strength(Action(a)) > 0 {
+			m.held[a]++
+			m.released[a] = false
+
+			continue
+		}
+
+		m.released[a] = m.held[a] > 0
+		m.held[a] = 0
+	}
+}
+
+// Strength is how much the action is held right now: 1 for keys and
+// buttons, and as far as the most pushed of its axes otherwise, 0 if
+// nothing is past the deadzone.
+func (m *Map) Strength(a Action) float64 {
+	in, src := &m.inputs[a], keymap.Input()
+
+	for _, k := range in.Keys {
+		if src.KeyPressDuration(k) > 0 {
+			return 1
+		}
+	}
+
+	for _, b := range in.Buttons {
+		if src.MouseButtonPressDuration(b) > 0 {
+			return 1
+		}
+	}
+
+	for _, id := range src.GamepadIDs() {
+		for _, p := range in.Pads {
+			if src.GamepadButtonPressDuration(id, p) > 0 {
+				return 1
+			}
+		}
+	}
+
+	v := 0.0
+
+	for _, ax := range in.Axes {
+		if p := ax.Value(); p > keymap.Deadzone && p > v {
+			v = p
+		}
+	}
+
+	return v
+}
+
+// Held reports whether the action is held.
+func (m *Map) Held(a Action) bool {
+	return m.held[a] > 0
+}
+
+// Pressed reports whether the action started this tick. Holding a second
+// input of an action that's already held doesn't press it again.
+func (m *Map) Pressed(a Action) bool {
+	return m.held[a] == 1
+}
+
+// Released reports whether the action stopped this tick.
+func (m *Map) Released(a Action) bool {
+	return m.released[a]
+}
+
+// Repeated reports whether the action started this tick, or has been held
+// long enough to repeat, like keys in a text field.
+func (m *Map) Repeated(a Action) bool {
+	delay, interval := m.Delay, m.Interval
+	if delay == 0 {
+		delay = keymap.DefaultDelay
+	}
+
+	if interval == 0 {
+		interval = keymap.DefaultInterval
+	}
+
+	d := m.held[a]
+
+	return d == 1 || (d > delay && (d-1-delay)%interval == 0)
+}
+
+// Vector is the direction the four actions point to together, with the
+// analog strength of the axes, for moving with either the keys or a stick.
+func (m *Map) Vector(up, down, left, right Action) (x, y float64) {
+	return m.Strength(right) - m.Strength(left), m.Strength(down) - m.Strength(up)
+}
+
+// Inputs returns the names of the inputs of the action.
+func (m *Map) Inputs(a Action) []string {
+	in := &m.inputs[a]
+	b := keymap.Binding{Keys: in.Keys, Buttons: in.Buttons}
+	names := b.Inputs()
+
+	for _, p := range in.Pads {
+		names = append(names, PadName(p))
+	}
+
+	for _, ax := range in.Axes {
+		names = append(names, AxisName(ax))
+	}
+
+	return names
+}
+
+// Set replaces the inputs of the action.
+func (m *Map) Set(a Action, in Inputs) {
+	m.inputs[a] = in
+}
+
+// Rebind replaces the inputs of the action with the named ones.
+func (m *Map) Rebind(a Action, names ...string) error {
+	var in Inputs
+
+	for _, name := range names {
+		if err := in.add(name); err != nil {
+			return fmt.Errorf("%s: %v", a, err)
+		}
+	}
+
+	m.Set(a, in)
+
+	return nil
+}
+
+// Bind adds the named input to the action, taking it from any other action
+// that had it.
+func (m *Map) Bind(a Action, name string) error {
+	var in Inputs
+	if err := in.add(name); err != nil {
+		return fmt.Errorf("%s: %v", a, err)
+	}
+
+	for b := range m.inputs {
+		if err := m.Unbind(Action(b), name); err != nil {
+			return err
+		}
+	}
+
+	return m.Rebind(a, append(m.Inputs(a), name)...)
+}
+
+// Unbind takes the named input away from the action, if it has it.
+func (m *Map) Unbind(a Action, name string) error {
+	var names []string
+
+	for _, n := range m.Inputs(a) {
+		if n != name {
+			names = append(names, n)
+		}
+	}
+
+	return m.Rebind(a, names...)
+}
+
+// add adds the named input.
+func (in *Inputs) add(name string) error {
+	var (
+		n    int
+		sign rune
+	)
+
+	if _, err := fmt.Sscanf(name, "Pad %d", &n); err == nil && PadName(ebiten.GamepadButton(n)) == name {
+		if n < 0 || n > int(ebiten.GamepadButtonMax) {
+			return fmt.Errorf("unknown gamepad button %q", name)
+		}
+
+		in.Pads = append(in.Pads, ebiten.GamepadButton(n))
+
+		return nil
+	}
+
+	if _, err := fmt.Sscanf(name, "Axis %d%c", &n, &sign); err == nil && (sign == '-' || sign == '+') {
+		ax := keymap.Axis{Index: n, Sign: 1}
+		if sign == '-' {
+			ax.Sign = -1
+		}
+
+		if n < 0 || n >= maxAxes || AxisName(ax) != name {
+			return fmt.Errorf("unknown gamepad axis %q", name)
+		}
+
+		in.Axes = append(in.Axes, ax)
+
+		return nil
+	}
+
+	var b keymap.Binding
+	if err := b.SetInputs([]string{name}); err != nil {
+		return err
+	}
+
+	in.Keys = append(in.Keys, b.Keys...)
+	in.Buttons = append(in.Buttons, b.Buttons...)
+
+	return nil
+}
+
+// PadName is the name of a gamepad button, by its number: Pad 0, Pad 11...
+func PadName(p ebiten.GamepadButton) string {
+	return fmt.Sprintf("Pad %d", p)
+}
+
+// AxisName is the name of an axis pushed one way: Axis 0+, Axis 1-...
+func AxisName(ax keymap.Axis) string {
+	if ax.Sign < 0 {
+		return fmt.Sprintf("Axis %d-", ax.Index)
+	}
+
+	return fmt.Sprintf("Axis %d+", ax.Index)
+}
+
+// JustPressed returns the name of an input pressed this tick, if any, on
+// the keyboard, the mouse or a gamepad, for capturing the input to rebind
+// an action to. Axes count when they go past the deadzone, so it only sees
+// them after Update.
+func (m *Map) JustPressed() (string, bool) {
+	if name, ok := keymap.JustPressed(); ok {
+		return name, true
+	}
+
+	src := keymap.Input()
+
+	for _, id := range src.GamepadIDs() {
+		for p := ebiten.GamepadButton(0); p <= ebiten.GamepadButtonMax; p++ {
+			if src.GamepadButtonPressDuration(id, p) == 1 {
+				return PadName(p), true
+			}
+		}
+	}
+
+	for i := range m.axes {
+		for j, sign := range [2]int{-1, 1} {
+			if m.axes[i][j] == 1 {
+				return AxisName(keymap.Axis{Index: i, Sign: sign}), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// Config returns the inputs of every action, by their name.
+func (m *Map) Config() keymap.Config {
+	c := make(keymap.Config)
+
+	for a := range m.inputs {
+		c[Action(a).String()] = m.Inputs(Action(a))
+	}
+
+	return c
+}
+
+// Apply rebinds the actions named in the config, the rest stay as they
+// are.
+func (m *Map) Apply(c keymap.Config) error {
+	for a := range m.inputs {
+		names, ok := c[Action(a).String()]
+		if !ok {
+			continue
+		}
+
+		if err := m.Rebind(Action(a), names...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
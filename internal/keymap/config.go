@@ -0,0 +1,181 @@
+package keymap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+//nolint:gochecknoglobal
+var buttonNames = map[ebiten.MouseButton]string{
+	ebiten.MouseButtonLeft:   "Mouse Left",
+	ebiten.MouseButtonRight:  "Mouse Right",
+	ebiten.MouseButtonMiddle: "Mouse Middle",
+}
+
+// KeyName is the name of a key in configs, as ebiten has it: A, 1, Up, F1...
+func KeyName(k ebiten.Key) string {
+	return k.String()
+}
+
+// ButtonName is the name of a mouse button in configs.
+func ButtonName(b ebiten.MouseButton) string {
+	return buttonNames[b]
+}
+
+// JustPressed returns the name of an input pressed this tick, if any, for
+// capturing the input to remap a binding to. The modifiers don't count,
+// bindings take them apart.
+func JustPressed() (string, bool) {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if k == ebiten.KeyAlt || k == ebiten.KeyControl || k == ebiten.KeyShift {
+			continue
+		}
+
+		if IsKeyJustPressed(k) {
+			return KeyName(k), true
+		}
+	}
+
+	for b, name := range buttonNames {
+		if source.MouseButtonPressDuration(b) == 1 {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// Inputs returns the names of the keys and buttons of the binding.
+func (b *Binding) Inputs() []string {
+	inputs := make([]string, 0, len(b.Keys)+len(b.Buttons))
+
+	for _, k := range b.Keys {
+		inputs = append(inputs, KeyName(k))
+	}
+
+	for _, m := range b.Buttons {
+		inputs = append(inputs, ButtonName(m))
+	}
+
+	return inputs
+}
+
+// SetInputs replaces the keys and buttons of the binding with the named
+// ones.
+func (b *Binding) SetInputs(inputs []string) error {
+	var (
+		keys    []ebiten.Key
+		buttons []ebiten.MouseButton
+	)
+
+next:
+	for _, in := range inputs {
+		for m, name := range buttonNames {
+			if in == name {
+				buttons = append(buttons, m)
+
+				continue next
+			}
+		}
+
+		for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+			if in == KeyName(k) {
+				keys = append(keys, k)
+
+				continue next
+			}
+		}
+
+		return fmt.Errorf("unknown key or button %q", in)
+	}
+
+	b.Keys, b.Buttons = keys, buttons
+
+	return nil
+}
+
+// Using returns the index of the binding triggered by the input, with
+// Control held or not, or -1.
+func (m Map) Using(input string, ctrl bool) int {
+	for i := range m {
+		if m[i].Ctrl != ctrl {
+			continue
+		}
+
+		for _, in := range m[i].Inputs() {
+			if in == input {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// Config is the inputs of the bindings, by their name, to keep remappings
+// in a file.
+type Config map[string][]string
+
+// Config returns the inputs of the named bindings.
+func (m Map) Config() Config {
+	c := make(Config)
+
+	for i := range m {
+		if m[i].Name != "" {
+			c[m[i].Name] = m[i].Inputs()
+		}
+	}
+
+	return c
+}
+
+// Apply remaps the bindings named in the config, the rest stay as they
+// are.
+func (m Map) Apply(c Config) error {
+	for i := range m {
+		inputs, ok := c[m[i].Name]
+		if !ok || m[i].Name == "" {
+			continue
+		}
+
+		if err := m[i].SetInputs(inputs); err != nil {
+			return fmt.Errorf("%s: %v", m[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadConfig reads a config, a missing file is just no remappings.
+func LoadConfig(path string) (Config, error) {
+	c := make(Config)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return c, nil
+}
+
+// Save writes the config as JSON.
+func (c Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,189 @@
+// Package keymap binds keys, mouse buttons and gamepad buttons to actions,
+// declaratively:
+// each binding says what triggers it, so Update functions become tables
+// instead of chains of ifs.
+package keymap
+
+import "github.com/hajimehoshi/ebiten"
+
+// Defaults for Repeat bindings, in ticks, as in ebiten's typewriter example.
+const (
+	DefaultDelay    = 30
+	DefaultInterval = 3
+)
+
+// Trigger is when a binding fires.
+type Trigger int
+
+const (
+	// Held fires every tick while held, for continuous actions like moving.
+	Held Trigger = iota
+	// Pressed fires once, on the tick the key goes down.
+	Pressed
+	// Repeat fires when the key goes down and, after Delay ticks held,
+	// every Interval ticks, like keys repeat in a text field.
+	Repeat
+	// Released fires once, on the tick the key goes up.
+	Released
+)
+
+// Action is what a binding does. An error stops the rest of the map and is
+// returned from Update, so bindings can end the game.
+type Action func() error
+
+// Do adapts a function that can't fail into an Action.
+func Do(fn func()) Action {
+	return func() error {
+		fn()
+
+		return nil
+	}
+}
+
+// Binding is an action with the keys, mouse buttons, gamepad buttons and
+// axes that trigger it, any of them.
+type Binding struct {
+	// Identifies the binding in a Config, only named bindings are remapped
+	Name    string
+	Keys    []ebiten.Key
+	Buttons []ebiten.MouseButton
+	// Buttons of any of the gamepads, they're left alone by remapping
+	Pads []ebiten.GamepadButton
+	// Axes of any of the gamepads, pushed past Deadzone they're held like
+	// buttons, and left alone by remapping too
+	Axes    []Axis
+	Trigger Trigger
+	// Only fire with Control held, and otherwise only without, so Ctrl+S
+	// doesn't also trigger S
+	Ctrl bool
+	// For Repeat, zero for the defaults
+	Delay    int
+	Interval int
+	Action   Action
+
+	// Ticks the axes have been pushed, which the devices don't count as
+	// they do for buttons, and whether they were let go this tick
+	axisTicks    int
+	axisReleased bool
+}
+
+// Triggered reports whether the binding fires this tick. It counts how long
+// the axes are pushed, so call it once a tick, as Map.Update does.
+func (b *Binding) Triggered() bool {
+	b.updateAxes()
+
+	if IsKeyPressed(ebiten.KeyControl) != b.Ctrl {
+		return false
+	}
+
+	for _, k := range b.Keys {
+		if b.fires(source.KeyPressDuration(k), source.IsKeyJustReleased(k)) {
+			return true
+		}
+	}
+
+	for _, m := range b.Buttons {
+		if b.fires(source.MouseButtonPressDuration(m), source.IsMouseButtonJustReleased(m)) {
+			return true
+		}
+	}
+
+	if len(b.Axes) > 0 && b.fires(b.axisTicks, b.axisReleased) {
+		return true
+	}
+
+	if len(b.Pads) == 0 {
+		return false
+	}
+
+	for _, id := range source.GamepadIDs() {
+		for _, p := range b.Pads {
+			if b.fires(source.GamepadButtonPressDuration(id, p), source.IsGamepadButtonJustReleased(id, p)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// updateAxes counts another tick of the axes pushed, or starts over if
+// none of them is.
+func (b *Binding) updateAxes() {
+	if len(b.Axes) == 0 {
+		return
+	}
+
+	held := b.axisTicks
+	b.axisTicks = 0
+
+	for _, ax := range b.Axes {
+		if ax.Value() > Deadzone {
+			b.axisTicks = held + 1
+
+			break
+		}
+	}
+
+	b.axisReleased = held > 0 && b.axisTicks == 0
+}
+
+// fires is Triggered for one key, held for d ticks so far (0 if it isn't
+// held).
+func (b *Binding) fires(d int, released bool) bool {
+	switch b.Trigger {
+	case Held:
+		return d > 0
+	case Pressed:
+		return d == 1
+	case Repeat:
+		delay, interval := b.Delay, b.Interval
+		if delay == 0 {
+			delay = DefaultDelay
+		}
+
+		if interval == 0 {
+			interval = DefaultInterval
+		}
+
+		return d == 1 || (d > delay && (d-1-delay)%interval == 0)
+	case Released:
+		return released
+	}
+
+	return false
+}
+
+// Map is a table of bindings, checked in order.
+type Map []Binding
+
+// Update runs the actions of the bindings that fire this tick, stopping at
+// the first error.
+func (m Map) Update() error {
+	for i := range m {
+		if !m[i].Triggered() {
+			continue
+		}
+
+		if err := m[i].Action(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Keys is shorthand for the keys of a binding.
+func Keys(keys ...ebiten.Key) []ebiten.Key {
+	return keys
+}
+
+// Buttons is shorthand for the mouse buttons of a binding.
+func Buttons(buttons ...ebiten.MouseButton) []ebiten.MouseButton {
+	return buttons
+}
+
+// Pads is shorthand for the gamepad buttons of a binding.
+func Pads(buttons ...ebiten.GamepadButton) []ebiten.GamepadButton {
+	return buttons
+}
@@ -0,0 +1,112 @@
+package keymap
+
+import (
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/i18n"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Labels of the keys that aren't named by what's printed on them, in
+// English on QWERTY.
+//
+//nolint:gochecknoglobal
+var keyLabels = map[ebiten.Key]string{
+	ebiten.KeyApostrophe:   "'",
+	ebiten.KeyBackslash:    "\\",
+	ebiten.KeyComma:        ",",
+	ebiten.KeyControl:      "Ctrl",
+	ebiten.KeyEqual:        "=",
+	ebiten.KeyEscape:       "Esc",
+	ebiten.KeyGraveAccent:  "`",
+	ebiten.KeyLeftBracket:  "[",
+	ebiten.KeyMinus:        "-",
+	ebiten.KeyPageDown:     "PgDn",
+	ebiten.KeyPageUp:       "PgUp",
+	ebiten.KeyPeriod:       ".",
+	ebiten.KeyRightBracket: "]",
+	ebiten.KeySemicolon:    ";",
+	ebiten.KeySlash:        "/",
+}
+
+// Keys are where they'd be on a US keyboard, so on other layouts some of
+// them have something else printed on them. Only the ones printed in ASCII
+// are here, the rest keep their US label.
+//
+//nolint:gochecknoglobal
+var layoutLabels = map[i18n.Layout]map[ebiten.Key]string{
+	i18n.AZERTY: {
+		ebiten.KeyA:         "Q",
+		ebiten.KeyQ:         "A",
+		ebiten.KeyW:         "Z",
+		ebiten.KeyZ:         "W",
+		ebiten.KeyM:         ",",
+		ebiten.KeySemicolon: "M",
+		ebiten.KeyComma:     ";",
+		ebiten.KeyPeriod:    ":",
+		ebiten.KeySlash:     "!",
+	},
+	i18n.QWERTZ: {
+		ebiten.KeyY:     "Z",
+		ebiten.KeyZ:     "Y",
+		ebiten.KeySlash: "-",
+	},
+}
+
+// KeyLabel is the name of a key for on-screen hints: what's printed on it
+// with the keyboard layout of loc, in its language. Configs use KeyName
+// instead, which doesn't change with either.
+func KeyLabel(k ebiten.Key, loc i18n.Locale) string {
+	if l, ok := layoutLabels[loc.Layout][k]; ok {
+		return l
+	}
+
+	if l, ok := keyLabels[k]; ok {
+		return loc.Word(l)
+	}
+
+	return loc.Word(k.String())
+}
+
+// ButtonLabel is the name of a mouse button for on-screen hints, in the
+// language of loc.
+func ButtonLabel(b ebiten.MouseButton, loc i18n.Locale) string {
+	return loc.Word(buttonNames[b])
+}
+
+// Label describes the inputs of the binding for on-screen hints, like
+// "Ctrl+S" or "Up/W".
+func (b *Binding) Label(loc i18n.Locale) string {
+	labels := make([]string, 0, len(b.Keys)+len(b.Buttons))
+
+	for _, k := range b.Keys {
+		labels = append(labels, KeyLabel(k, loc))
+	}
+
+	for _, m := range b.Buttons {
+		labels = append(labels, ButtonLabel(m, loc))
+	}
+
+	s := strings.Join(labels, "/")
+	if b.Ctrl {
+		s = KeyLabel(ebiten.KeyControl, loc) + "+" + s
+	}
+
+	return s
+}
+
+// PadLabel describes the gamepad inputs of the binding for on-screen
+// hints, like "A" or "D-pad Up/Stick Up", empty if it has none.
+func (b *Binding) PadLabel(loc i18n.Locale) string {
+	labels := make([]string, 0, len(b.Pads)+len(b.Axes))
+
+	for _, p := range b.Pads {
+		labels = append(labels, PadLabel(p, loc))
+	}
+
+	for _, ax := range b.Axes {
+		labels = append(labels, AxisLabel(ax, loc))
+	}
+
+	return strings.Join(labels, "/")
+}
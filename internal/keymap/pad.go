@@ -0,0 +1,201 @@
+package keymap
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/i18n"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Ebiten only knows about raw gamepad buttons, these are the usual ones for
+// an XInput (Xbox-like) controller on GLFW, where the d-pad hat is appended
+// after the regular buttons.
+const (
+	PadA     = ebiten.GamepadButton0
+	PadB     = ebiten.GamepadButton1
+	PadX     = ebiten.GamepadButton2
+	PadY     = ebiten.GamepadButton3
+	PadLB    = ebiten.GamepadButton4
+	PadRB    = ebiten.GamepadButton5
+	PadBack  = ebiten.GamepadButton6
+	PadStart = ebiten.GamepadButton7
+	// Clicking the sticks
+	PadLS    = ebiten.GamepadButton9
+	PadRS    = ebiten.GamepadButton10
+	PadUp    = ebiten.GamepadButton11
+	PadRight = ebiten.GamepadButton12
+	PadDown  = ebiten.GamepadButton13
+	PadLeft  = ebiten.GamepadButton14
+)
+
+const (
+	// Deadzone is how far an axis has to be pushed to count, under it is
+	// drift
+	Deadzone = 0.5
+	// The axes of the two sticks, the triggers come after them and rest
+	// pushed all the way one way
+	stickAxes = 4
+)
+
+// Axis is a gamepad axis pushed one way, like the left stick up, which is
+// axis 1 going negative.
+type Axis struct {
+	Index int
+	// -1 or 1
+	Sign int
+}
+
+// Value returns how far the axis is pushed its way, on the gamepad that
+// pushes it the most, on the Source.
+func (ax Axis) Value() float64 {
+	v := 0.0
+
+	for _, id := range source.GamepadIDs() {
+		if ax.Index >= source.GamepadAxisNum(id) {
+			continue
+		}
+
+		if p := source.GamepadAxis(id, ax.Index) * float64(ax.Sign); p > v {
+			v = p
+		}
+	}
+
+	return v
+}
+
+// IsPadJustPressed reports whether the button went down this tick, on any
+// of the gamepads, on the Source.
+func IsPadJustPressed(p ebiten.GamepadButton) bool {
+	for _, id := range source.GamepadIDs() {
+		if source.GamepadButtonPressDuration(id, p) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sticks is shorthand for the axes of a binding.
+func Sticks(axes ...Axis) []Axis {
+	return axes
+}
+
+// The left stick pushed each way, to bind along with the d-pad.
+//
+//nolint:gochecknoglobal
+var (
+	StickUp    = Axis{1, -1}
+	StickDown  = Axis{1, 1}
+	StickLeft  = Axis{0, -1}
+	StickRight = Axis{0, 1}
+)
+
+// The right stick pushed each way, for a second direction like a camera.
+//
+//nolint:gochecknoglobal
+var (
+	RightStickUp    = Axis{3, -1}
+	RightStickDown  = Axis{3, 1}
+	RightStickLeft  = Axis{2, -1}
+	RightStickRight = Axis{2, 1}
+)
+
+// Labels of the gamepad buttons, as printed on an Xbox controller.
+//
+//nolint:gochecknoglobal
+var padLabels = map[ebiten.GamepadButton]string{
+	PadA:     "A",
+	PadB:     "B",
+	PadX:     "X",
+	PadY:     "Y",
+	PadLB:    "LB",
+	PadRB:    "RB",
+	PadBack:  "Back",
+	PadStart: "Start",
+	PadLS:    "LS",
+	PadRS:    "RS",
+	PadUp:    "D-pad Up",
+	PadRight: "D-pad Right",
+	PadDown:  "D-pad Down",
+	PadLeft:  "D-pad Left",
+}
+
+//nolint:gochecknoglobal
+var axisLabels = map[Axis]string{
+	StickUp:    "Stick Up",
+	StickDown:  "Stick Down",
+	StickLeft:  "Stick Left",
+	StickRight: "Stick Right",
+
+	RightStickUp:    "Right Stick Up",
+	RightStickDown:  "Right Stick Down",
+	RightStickLeft:  "Right Stick Left",
+	RightStickRight: "Right Stick Right",
+}
+
+// PadLabel is the name of a gamepad button for on-screen hints, in the
+// language of loc.
+func PadLabel(p ebiten.GamepadButton, loc i18n.Locale) string {
+	if l, ok := padLabels[p]; ok {
+		return loc.Word(l)
+	}
+
+	return fmt.Sprintf("%s %d", loc.Word("Pad"), p)
+}
+
+// AxisLabel is the name of an axis pushed one way for on-screen hints, in
+// the language of loc.
+func AxisLabel(ax Axis, loc i18n.Locale) string {
+	if l, ok := axisLabels[ax]; ok {
+		return loc.Word(l)
+	}
+
+	sign := '+'
+	if ax.Sign < 0 {
+		sign = '-'
+	}
+
+	return fmt.Sprintf("%s %d%c", loc.Word("Axis"), ax.Index, sign)
+}
+
+// Device is what the player used last, the keyboard and mouse or a
+// gamepad, for hints to show the inputs of the one in their hands.
+type Device struct {
+	// Whether it's a gamepad
+	Pad bool
+
+	cursorX, cursorY int
+}
+
+// Update switches to whatever was used this tick, if anything. Call it once
+// a tick.
+func (d *Device) Update() {
+	x, y := source.CursorPosition()
+	moved := x != d.cursorX || y != d.cursorY
+	d.cursorX, d.cursorY = x, y
+
+	if _, ok := JustPressed(); ok || moved {
+		d.Pad = false
+
+		return
+	}
+
+	for _, id := range source.GamepadIDs() {
+		for p := ebiten.GamepadButton(0); p <= ebiten.GamepadButtonMax; p++ {
+			if source.GamepadButtonPressDuration(id, p) == 1 {
+				d.Pad = true
+
+				return
+			}
+		}
+
+		for i := 0; i < stickAxes && i < source.GamepadAxisNum(id); i++ {
+			if math.Abs(source.GamepadAxis(id, i)) > Deadzone {
+				d.Pad = true
+
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package keymap
+
+import "github.com/hajimehoshi/ebiten"
+
+// Script is a Source played by hand instead of the devices, for tests:
+// press and release keys and mouse buttons by their config names, move the
+// cursor, and Tick once before each Update, as ebiten does with the
+// devices. It has no gamepads, nor a touchscreen.
+type Script struct {
+	// Keys and mouse buttons, by their ebiten types, held down now, and
+	// for how many ticks so far
+	held      map[interface{}]bool
+	durations map[interface{}]int
+	released  map[interface{}]bool
+	x, y      int
+}
+
+func NewScript() *Script {
+	return &Script{
+		held:      make(map[interface{}]bool),
+		durations: make(map[interface{}]int),
+		released:  make(map[interface{}]bool),
+	}
+}
+
+// input returns the key or mouse button of a config name.
+func input(name string) (interface{}, error) {
+	var b Binding
+	if err := b.SetInputs([]string{name}); err != nil {
+		return nil, err
+	}
+
+	if len(b.Keys) > 0 {
+		return b.Keys[0], nil
+	}
+
+	return b.Buttons[0], nil
+}
+
+// Press holds the named key or mouse button down from the next Tick.
+func (s *Script) Press(name string) error {
+	in, err := input(name)
+	if err != nil {
+		return err
+	}
+
+	s.held[in] = true
+
+	return nil
+}
+
+// Release lets go of the named key or mouse button at the next Tick.
+func (s *Script) Release(name string) error {
+	in, err := input(name)
+	if err != nil {
+		return err
+	}
+
+	delete(s.held, in)
+
+	return nil
+}
+
+// MoveCursor puts the cursor at (x, y).
+func (s *Script) MoveCursor(x, y int) {
+	s.x, s.y = x, y
+}
+
+// Tick starts a tick: what's held is held a tick longer, and what was let
+// go of is just released.
+func (s *Script) Tick() {
+	for in := range s.released {
+		delete(s.released, in)
+	}
+
+	for in := range s.durations {
+		if !s.held[in] {
+			delete(s.durations, in)
+			s.released[in] = true
+		}
+	}
+
+	for in := range s.held {
+		s.durations[in]++
+	}
+}
+
+func (s *Script) KeyPressDuration(k ebiten.Key) int {
+	return s.durations[k]
+}
+
+func (s *Script) IsKeyJustReleased(k ebiten.Key) bool {
+	return s.released[k]
+}
+
+func (s *Script) MouseButtonPressDuration(b ebiten.MouseButton) int {
+	return s.durations[b]
+}
+
+func (s *Script) IsMouseButtonJustReleased(b ebiten.MouseButton) bool {
+	return s.released[b]
+}
+
+func (s *Script) CursorPosition() (int, int) {
+	return s.x, s.y
+}
+
+func (s *Script) GamepadIDs() []int {
+	return nil
+}
+
+func (s *Script) GamepadButtonPressDuration(id int, b ebiten.GamepadButton) int {
+	return 0
+}
+
+func (s *Script) IsGamepadButtonJustReleased(id int, b ebiten.GamepadButton) bool {
+	return false
+}
+
+func (s *Script) GamepadAxisNum(id int) int {
+	return 0
+}
+
+func (s *Script) GamepadAxis(id, axis int) float64 {
+	return 0
+}
+
+func (s *Script) TouchIDs() []int {
+	return nil
+}
+
+func (s *Script) TouchPosition(id int) (int, int) {
+	return 0, 0
+}
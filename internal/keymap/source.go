@@ -0,0 +1,105 @@
+package keymap
+
+import (
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// Source is where bindings read the input from, the devices unless a test
+// plays a Script instead, see SetSource. Code that checks input outside of
+// bindings, like the cursor on a click, reads it here too, so scripts reach
+// it.
+type Source interface {
+	KeyPressDuration(k ebiten.Key) int
+	IsKeyJustReleased(k ebiten.Key) bool
+	MouseButtonPressDuration(b ebiten.MouseButton) int
+	IsMouseButtonJustReleased(b ebiten.MouseButton) bool
+	CursorPosition() (x, y int)
+	GamepadIDs() []int
+	GamepadButtonPressDuration(id int, b ebiten.GamepadButton) int
+	IsGamepadButtonJustReleased(id int, b ebiten.GamepadButton) bool
+	GamepadAxisNum(id int) int
+	GamepadAxis(id, axis int) float64
+	TouchIDs() []int
+	TouchPosition(id int) (x, y int)
+}
+
+// devices is the Source of the keyboard, mouse and gamepads, through
+// ebiten.
+type devices struct{}
+
+func (devices) KeyPressDuration(k ebiten.Key) int {
+	return inpututil.KeyPressDuration(k)
+}
+
+func (devices) IsKeyJustReleased(k ebiten.Key) bool {
+	return inpututil.IsKeyJustReleased(k)
+}
+
+func (devices) MouseButtonPressDuration(b ebiten.MouseButton) int {
+	return inpututil.MouseButtonPressDuration(b)
+}
+
+func (devices) IsMouseButtonJustReleased(b ebiten.MouseButton) bool {
+	return inpututil.IsMouseButtonJustReleased(b)
+}
+
+func (devices) CursorPosition() (int, int) {
+	return ebiten.CursorPosition()
+}
+
+func (devices) GamepadIDs() []int {
+	return ebiten.GamepadIDs()
+}
+
+func (devices) GamepadButtonPressDuration(id int, b ebiten.GamepadButton) int {
+	return inpututil.GamepadButtonPressDuration(id, b)
+}
+
+func (devices) IsGamepadButtonJustReleased(id int, b ebiten.GamepadButton) bool {
+	return inpututil.IsGamepadButtonJustReleased(id, b)
+}
+
+func (devices) GamepadAxisNum(id int) int {
+	return ebiten.GamepadAxisNum(id)
+}
+
+func (devices) GamepadAxis(id, axis int) float64 {
+	return ebiten.GamepadAxis(id, axis)
+}
+
+func (devices) TouchIDs() []int {
+	return ebiten.TouchIDs()
+}
+
+func (devices) TouchPosition(id int) (int, int) {
+	return ebiten.TouchPosition(id)
+}
+
+//nolint:gochecknoglobal
+var source Source = devices{}
+
+// SetSource makes the bindings read from s, nil goes back to the devices.
+func SetSource(s Source) {
+	if s == nil {
+		s = devices{}
+	}
+
+	source = s
+}
+
+// Input is the Source the bindings read from.
+func Input() Source {
+	return source
+}
+
+// IsKeyPressed reports whether the key is held, on the Source.
+func IsKeyPressed(k ebiten.Key) bool {
+	return source.KeyPressDuration(k) > 0
+}
+
+// IsKeyJustPressed reports whether the key went down this tick, on the
+// Source.
+func IsKeyJustPressed(k ebiten.Key) bool {
+	return source.KeyPressDuration(k) == 1
+}
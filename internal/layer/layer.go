@@ -0,0 +1,83 @@
+// Package layer draws things by layer (background, world, effects, UI)
+// instead of in whatever order the code happens to draw them, so that
+// features from different packages compose: particles above entities, UI
+// above everything.
+package layer
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Layer is a draw depth, lower layers are drawn first. The named layers are
+// spaced so exercises can slot their own in between, like World - 1 for
+// shadows under the entities.
+type Layer int
+
+const (
+	Background Layer = 0
+	World      Layer = 100
+	Effects    Layer = 200
+	UI         Layer = 300
+)
+
+// Drawable is anything that can draw itself.
+type Drawable interface {
+	Draw(screen *ebiten.Image)
+}
+
+// Layered is a Drawable that knows its own layer, like an entity with a
+// depth field.
+type Layered interface {
+	Drawable
+	Layer() Layer
+}
+
+// DrawFunc adapts a function to a Drawable.
+type DrawFunc func(screen *ebiten.Image)
+
+func (f DrawFunc) Draw(screen *ebiten.Image) {
+	f(screen)
+}
+
+type item struct {
+	layer Layer
+	d     Drawable
+}
+
+// Renderer collects the drawables of a frame, and draws them sorted by
+// layer. Within a layer they keep the order they were added in.
+type Renderer struct {
+	items []item
+}
+
+// Add queues d to be drawn on layer l.
+func (r *Renderer) Add(l Layer, d Drawable) {
+	r.items = append(r.items, item{l, d})
+}
+
+// AddFunc queues f to be drawn on layer l.
+func (r *Renderer) AddFunc(l Layer, f func(screen *ebiten.Image)) {
+	r.Add(l, DrawFunc(f))
+}
+
+// AddLayered queues d to be drawn on its own layer.
+func (r *Renderer) AddLayered(d Layered) {
+	r.Add(d.Layer(), d)
+}
+
+// Draw draws everything queued since the last Draw, and empties the queue.
+func (r *Renderer) Draw(screen *ebiten.Image) {
+	sort.SliceStable(r.items, func(i, j int) bool {
+		return r.items[i].layer < r.items[j].layer
+	})
+
+	for i, it := range r.items {
+		it.d.Draw(screen)
+		// Don't keep the drawables alive until the next frame
+		r.items[i] = item{}
+	}
+
+	r.items = r.items[:0]
+}
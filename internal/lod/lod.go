@@ -0,0 +1,116 @@
+// Package lod picks the level of detail of the items of dense scenes, like
+// starfields or particle clouds: items out of view are culled, items too
+// small to make out are merged into a static texture drawn in one go, and
+// only the rest are drawn one by one. It counts each, for the HUD.
+package lod
+
+import (
+	"fmt"
+
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Level is how an item gets drawn.
+type Level int
+
+const (
+	// Culled items are out of view, they aren't drawn.
+	Culled Level = iota
+	// Merged items are in a Static texture.
+	Merged
+	// Drawn items are drawn on their own.
+	Drawn
+)
+
+// Policy picks the level of items by their bounding box on the screen.
+type Policy struct {
+	// The visible area, in screen pixels
+	Width, Height float64
+	// Items smaller than this, in pixels, are merged, 0 merges none
+	MinSize float64
+}
+
+// Tiny reports whether an item of the size is merged, wherever it is.
+func (p Policy) Tiny(size float64) bool {
+	return size < p.MinSize
+}
+
+// Level returns the level of an item size pixels wide centered at (x, y) on
+// the screen. Out of view comes first, so a tiny item out of view counts as
+// culled.
+func (p Policy) Level(x, y, size float64) Level {
+	h := size / 2
+	if x+h < 0 || y+h < 0 || x-h > p.Width || y-h > p.Height {
+		return Culled
+	}
+
+	if p.Tiny(size) {
+		return Merged
+	}
+
+	return Drawn
+}
+
+// Counters is how many items got each level, this frame.
+type Counters struct {
+	Drawn  int
+	Merged int
+	Culled int
+}
+
+// Add counts an item.
+func (c *Counters) Add(l Level) {
+	switch l {
+	case Culled:
+		c.Culled++
+	case Merged:
+		c.Merged++
+	case Drawn:
+		c.Drawn++
+	}
+}
+
+func (c Counters) String() string {
+	return fmt.Sprintf("drawn %d, merged %d, culled %d", c.Drawn, c.Merged, c.Culled)
+}
+
+// Static is the texture of the merged items. It's only redrawn when its key
+// changes, say the zoom, or when invalidated, as the items stay merged the
+// same otherwise.
+type Static struct {
+	img   *ebiten.Image
+	key   float64
+	valid bool
+}
+
+// Image returns the texture, w by h, redrawing it with draw first if the
+// key changed.
+func (s *Static) Image(w, h int, key float64, draw func(dst *ebiten.Image)) *ebiten.Image {
+	if s.valid && s.key == key {
+		return s.img
+	}
+
+	if s.img != nil {
+		if iw, ih := s.img.Size(); iw != w || ih != h {
+			_ = s.img.Dispose()
+			s.img = nil
+		}
+	}
+
+	if s.img == nil {
+		s.img, _ = profile.NewImage(w, h, ebiten.FilterDefault)
+	}
+
+	_ = s.img.Clear()
+	draw(s.img)
+	s.key, s.valid = key, true
+
+	return s.img
+}
+
+// Invalidate makes the next Image redraw the texture, for when the items
+// change.
+func (s *Static) Invalidate() {
+	s.valid = false
+}
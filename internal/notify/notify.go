@@ -0,0 +1,133 @@
+// Package notify shows toasts: short messages stacked in a corner of the
+// screen that fade out after a while, so state changes that would otherwise
+// go unnoticed (a connection added, a file saved) get some feedback.
+package notify
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Size of the ebitenutil debug font glyphs
+	charWidth  = 6
+	lineHeight = 16
+	padding    = 4
+	// Room between the toasts, and from the screen edges
+	spacing = 4
+	margin  = 8
+)
+
+//nolint:gochecknoglobal
+var boxColor = color.RGBA{0x20, 0x20, 0x20, 0xe0}
+
+type toast struct {
+	text  string
+	ticks int
+	// Box and text, drawn once so they can fade together, the debug font
+	// can't fade by itself
+	img *ebiten.Image
+}
+
+// Notifier shows up to Visible toasts at a time, in the bottom right
+// corner, newest at the bottom. The rest wait, up to MaxQueue of them, and
+// older ones are dropped when more come. Duration and Fade are in ticks.
+type Notifier struct {
+	Visible  int
+	MaxQueue int
+	Duration int
+	Fade     int
+
+	shown   []*toast
+	waiting []string
+}
+
+// New returns a notifier showing toasts for two seconds, fading out over
+// the last half, at the default TPS.
+func New() *Notifier {
+	return &Notifier{Visible: 4, MaxQueue: 16, Duration: 120, Fade: 30}
+}
+
+// Push queues a toast, formatted as with fmt.Sprintf.
+func (n *Notifier) Push(format string, args ...interface{}) {
+	n.waiting = append(n.waiting, fmt.Sprintf(format, args...))
+	if over := len(n.waiting) - n.MaxQueue; over > 0 {
+		n.waiting = n.waiting[over:]
+	}
+}
+
+// Update ages the toasts, and brings in waiting ones as others go away.
+func (n *Notifier) Update() {
+	kept := n.shown[:0]
+
+	for _, t := range n.shown {
+		t.ticks++
+		if t.ticks < n.Duration {
+			kept = append(kept, t)
+		} else if t.img != nil {
+			_ = t.img.Dispose()
+		}
+	}
+
+	n.shown = kept
+
+	for len(n.shown) < n.Visible && len(n.waiting) > 0 {
+		n.shown = append(n.shown, &toast{text: n.waiting[0]})
+		n.waiting = n.waiting[1:]
+	}
+}
+
+func (n *Notifier) Draw(screen *ebiten.Image) {
+	sw, sh := screen.Size()
+	y := sh - margin
+
+	for i := len(n.shown) - 1; i >= 0; i-- {
+		t := n.shown[i]
+		if t.img == nil {
+			t.img = render(t.text)
+		}
+
+		w, h := t.img.Size()
+		y -= h
+
+		alpha := 1.0
+		if left := n.Duration - t.ticks; n.Fade > 0 && left < n.Fade {
+			alpha = float64(left) / float64(n.Fade)
+		}
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(sw-margin-w), float64(y))
+		op.ColorM.Scale(1, 1, 1, alpha)
+		_ = screen.DrawImage(t.img, op)
+
+		y -= spacing
+	}
+}
+
+// Len returns how many toasts are showing or waiting.
+func (n *Notifier) Len() int {
+	return len(n.shown) + len(n.waiting)
+}
+
+func render(text string) *ebiten.Image {
+	lines := strings.Split(text, "\n")
+	cols := 0
+
+	for _, l := range lines {
+		if c := utf8.RuneCountInString(l); c > cols {
+			cols = c
+		}
+	}
+
+	img, _ := profile.NewImage(cols*charWidth+2*padding, len(lines)*lineHeight+2*padding, ebiten.FilterDefault)
+	_ = img.Fill(boxColor)
+	ebitenutil.DebugPrintAt(img, text, padding, padding)
+
+	return img
+}
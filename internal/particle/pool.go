@@ -0,0 +1,103 @@
+// Package particle is a fixed size pool of simple particles. Dead particles
+// are recycled for new ones, so spawning doesn't allocate, and a busy effect
+// just can't go over its budget.
+package particle
+
+// Particle is a point moving at a constant velocity until its life runs
+// out. What it looks like is up to whoever draws it.
+type Particle struct {
+	X, Y   float64
+	VX, VY float64
+	// Ticks lived, and ticks to live
+	Age  int
+	Life int
+	// For the owner, like a size or a color index
+	Kind int
+}
+
+// Fade is how far the particle is into its life, 0 to 1.
+func (p *Particle) Fade() float64 {
+	if p.Life <= 0 {
+		return 1
+	}
+
+	return float64(p.Age) / float64(p.Life)
+}
+
+type Pool struct {
+	particles []Particle
+	alive     []bool
+	// Indices of the dead particles, ready to be reused
+	free []int
+}
+
+func NewPool(capacity int) *Pool {
+	p := &Pool{
+		particles: make([]Particle, capacity),
+		alive:     make([]bool, capacity),
+		free:      make([]int, capacity),
+	}
+
+	for i := range p.free {
+		p.free[i] = capacity - 1 - i
+	}
+
+	return p
+}
+
+// Spawn returns a particle to set up, or nil if the pool is full.
+func (p *Pool) Spawn() *Particle {
+	if len(p.free) == 0 {
+		return nil
+	}
+
+	i := p.free[len(p.free)-1]
+	p.free = p.free[:len(p.free)-1]
+	p.alive[i] = true
+	p.particles[i] = Particle{}
+
+	return &p.particles[i]
+}
+
+// Update moves the particles and ages them, recycling the ones over their
+// life. Anything else, like pushing them around, goes through Each.
+func (p *Pool) Update() {
+	for i := range p.particles {
+		if !p.alive[i] {
+			continue
+		}
+
+		pt := &p.particles[i]
+		pt.X += pt.VX
+		pt.Y += pt.VY
+		pt.Age++
+
+		if pt.Age >= pt.Life {
+			p.kill(i)
+		}
+	}
+}
+
+// Each calls fn with every live particle. fn returning false kills it.
+func (p *Pool) Each(fn func(pt *Particle) bool) {
+	for i := range p.particles {
+		if p.alive[i] && !fn(&p.particles[i]) {
+			p.kill(i)
+		}
+	}
+}
+
+func (p *Pool) kill(i int) {
+	p.alive[i] = false
+	p.free = append(p.free, i)
+}
+
+// Live returns how many particles are alive.
+func (p *Pool) Live() int {
+	return len(p.particles) - len(p.free)
+}
+
+// Cap returns the pool size.
+func (p *Pool) Cap() int {
+	return len(p.particles)
+}
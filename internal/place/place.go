@@ -0,0 +1,202 @@
+// Package place has helpers to scatter things (stars, blocks...) over an
+// area.
+//
+// Unlike picking coordinates from rand.Perm, counts aren't capped by the area
+// dimensions and points can share a row or a column.
+package place
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+type Point struct {
+	X, Y float64
+}
+
+// Strategy places n points in the [0, w) x [0, h) area, using rnd as the
+// source of randomness, or the global one if nil.
+type Strategy func(rnd *rand.Rand, n int, w, h float64) []Point
+
+// ByName returns the strategy with the given name, one of "uniform",
+// "poisson" or "grid", with sensible defaults for the spacing and jitter.
+func ByName(name string) (Strategy, error) {
+	switch name {
+	case "uniform":
+		return Uniform, nil
+	case "poisson":
+		return func(rnd *rand.Rand, n int, w, h float64) []Point {
+			return PoissonDisk(rnd, n, w, h, DefaultSpacing(n, w, h))
+		}, nil
+	case "grid":
+		return func(rnd *rand.Rand, n int, w, h float64) []Point {
+			return JitteredGrid(rnd, n, w, h, 0.8)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown placement %q", name)
+	}
+}
+
+// Uniform places n points uniformly at random.
+func Uniform(rnd *rand.Rand, n int, w, h float64) []Point {
+	ps := make([]Point, n)
+	for i := range ps {
+		ps[i] = Point{randFloat(rnd) * w, randFloat(rnd) * h}
+	}
+
+	return ps
+}
+
+// DefaultSpacing returns a minimum distance for PoissonDisk that comfortably
+// fits n points in the area.
+func DefaultSpacing(n int, w, h float64) float64 {
+	if n <= 0 {
+		return 0
+	}
+
+	// A Poisson disk covers about 70% of the densest packing
+	return 0.7 * math.Sqrt(w*h/float64(n))
+}
+
+// poissonTries is how many candidates are tried around each point before
+// giving up on it, from Bridson's paper.
+const poissonTries = 30
+
+// PoissonDisk places up to n points at random, but never closer than
+// minDist from each other, using Bridson's algorithm. It returns fewer
+// points if the area fills up first.
+func PoissonDisk(rnd *rand.Rand, n int, w, h, minDist float64) []Point {
+	if n <= 0 {
+		return nil
+	}
+
+	if minDist <= 0 {
+		return Uniform(rnd, n, w, h)
+	}
+
+	// Grid of cells small enough to hold a single point each
+	cell := minDist / math.Sqrt2
+	cols, rows := int(math.Ceil(w/cell)), int(math.Ceil(h/cell))
+	grid := make([]int, cols*rows)
+
+	for i := range grid {
+		grid[i] = -1
+	}
+
+	ps := make([]Point, 0, n)
+	active := make([]int, 0, n)
+
+	add := func(p Point) {
+		grid[int(p.Y/cell)*cols+int(p.X/cell)] = len(ps)
+		active = append(active, len(ps))
+		ps = append(ps, p)
+	}
+
+	fits := func(p Point) bool {
+		if p.X < 0 || p.X >= w || p.Y < 0 || p.Y >= h {
+			return false
+		}
+
+		cx, cy := int(p.X/cell), int(p.Y/cell)
+		for y := cy - 2; y <= cy+2; y++ {
+			for x := cx - 2; x <= cx+2; x++ {
+				if x < 0 || x >= cols || y < 0 || y >= rows || grid[y*cols+x] < 0 {
+					continue
+				}
+
+				q := ps[grid[y*cols+x]]
+				if math.Hypot(q.X-p.X, q.Y-p.Y) < minDist {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+
+	add(Point{randFloat(rnd) * w, randFloat(rnd) * h})
+
+	for len(active) > 0 && len(ps) < n {
+		i := randIntn(rnd, len(active))
+		p := ps[active[i]]
+		found := false
+
+		for try := 0; try < poissonTries; try++ {
+			// Candidate in the annulus between minDist and 2 * minDist
+			a := randFloat(rnd) * 2 * math.Pi
+			r := minDist * (1 + randFloat(rnd))
+			c := Point{p.X + r*math.Cos(a), p.Y + r*math.Sin(a)}
+
+			if fits(c) {
+				add(c)
+
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			active[i] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+
+	return ps
+}
+
+// JitteredGrid places n points on a grid covering the area, each of them
+// moved at random within its cell by up to jitter (0 to 1) of the cell size.
+// When n doesn't fill the grid, the empty cells are picked at random.
+func JitteredGrid(rnd *rand.Rand, n int, w, h, jitter float64) []Point {
+	if n <= 0 {
+		return nil
+	}
+
+	// Cells as square as possible for the area aspect ratio
+	cols := int(math.Ceil(math.Sqrt(float64(n) * w / h)))
+	if cols < 1 {
+		cols = 1
+	}
+
+	rows := (n + cols - 1) / cols
+	cw, ch := w/float64(cols), h/float64(rows)
+
+	cells := randPerm(rnd, cols*rows)[:n]
+	ps := make([]Point, n)
+
+	for i, c := range cells {
+		x, y := float64(c%cols), float64(c/cols)
+		ps[i] = Point{
+			X: (x + 0.5 + jitter*(randFloat(rnd)-0.5)) * cw,
+			Y: (y + 0.5 + jitter*(randFloat(rnd)-0.5)) * ch,
+		}
+	}
+
+	return ps
+}
+
+func randFloat(rnd *rand.Rand) float64 {
+	if rnd == nil {
+		return rand.Float64()
+	}
+
+	return rnd.Float64()
+}
+
+func randIntn(rnd *rand.Rand, n int) int {
+	if rnd == nil {
+		return rand.Intn(n)
+	}
+
+	return rnd.Intn(n)
+}
+
+func randPerm(rnd *rand.Rand, n int) []int {
+	if rnd == nil {
+		return rand.Perm(n)
+	}
+
+	return rnd.Perm(n)
+}
@@ -0,0 +1,200 @@
+package profile
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"log"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Ticks between reads of the memory stats, reading them stops the
+	// world for a moment
+	sampleTicks = 30
+	// Ticks the warnings are on, and then off, flashing
+	flashTicks = 20
+	charWidth  = 6
+	lineHeight = 16
+	padding    = 4
+	mb         = 1 << 20
+)
+
+//nolint:gochecknoglobal
+var (
+	showMemory  = flag.Bool("memory", false, "show the memory overlay from the start, F12 toggles it")
+	heapBudget  = flag.Float64("heap-budget", 0, "warn when the heap in use goes over this many MB, 0 for no budget")
+	gcBudget    = flag.Float64("gc-budget", 0, "warn when the GC runs more than this many times a second, 0 for no budget")
+	imageBudget = flag.Int64("image-budget", 0, "warn when more than this many images are alive, 0 for no budget")
+
+	memoryBackground = color.RGBA{0, 0, 0, 0xc0}
+	warningColor     = color.RGBA{0xc0, 0, 0, 0xe0}
+
+	// Images made with NewImage, and those of them the GC collected
+	imagesMade      int64
+	imagesCollected int64
+)
+
+// NewImage is ebiten.NewImage, counting the images for the memory overlay.
+func NewImage(w, h int, filter ebiten.Filter) (*ebiten.Image, error) {
+	img, err := ebiten.NewImage(w, h, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&imagesMade, 1)
+	runtime.SetFinalizer(img, func(*ebiten.Image) { atomic.AddInt64(&imagesCollected, 1) })
+
+	return img, nil
+}
+
+// memory is the memory overlay (F12): the heap, the garbage collector and
+// the images of the game, read every half a second. Whatever goes over its
+// budget flashes, even with the overlay hidden.
+type memory struct {
+	shown bool
+	ticks int
+	// Last read, and the one before, for the rates
+	stats, prev runtime.MemStats
+	at, prevAt  time.Time
+	// Budgets over at the last read, to log each time one is first gone
+	// over
+	over map[string]bool
+	keys keymap.Map
+}
+
+func newMemory() *memory {
+	m := &memory{shown: *showMemory, over: map[string]bool{}}
+	m.keys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyF12), Trigger: keymap.Pressed, Action: keymap.Do(func() { m.shown = !m.shown })},
+	}
+
+	return m
+}
+
+func (m *memory) Update() {
+	_ = m.keys.Update()
+
+	if m.ticks%sampleTicks == 0 {
+		m.prev, m.prevAt = m.stats, m.at
+		runtime.ReadMemStats(&m.stats)
+		m.at = time.Now()
+		m.checkBudgets()
+	}
+
+	m.ticks++
+}
+
+// rate returns how fast a stat went up since the read before, per second.
+func (m *memory) rate(now, before uint64) float64 {
+	if m.prevAt.IsZero() {
+		return 0
+	}
+
+	return float64(now-before) / m.at.Sub(m.prevAt).Seconds()
+}
+
+func (m *memory) heapMB() float64 {
+	return float64(m.stats.HeapInuse) / mb
+}
+
+func (m *memory) gcRate() float64 {
+	return m.rate(uint64(m.stats.NumGC), uint64(m.prev.NumGC))
+}
+
+func liveImages() int64 {
+	return atomic.LoadInt64(&imagesMade) - atomic.LoadInt64(&imagesCollected)
+}
+
+// warnings returns what's over its budget.
+func (m *memory) warnings() []string {
+	var w []string
+
+	if *heapBudget > 0 && m.heapMB() > *heapBudget {
+		w = append(w, fmt.Sprintf("Heap over budget: %.1f MB of %.1f", m.heapMB(), *heapBudget))
+	}
+
+	if *gcBudget > 0 && m.gcRate() > *gcBudget {
+		w = append(w, fmt.Sprintf("GC over budget: %.1f/s of %.1f", m.gcRate(), *gcBudget))
+	}
+
+	if n := liveImages(); *imageBudget > 0 && n > *imageBudget {
+		w = append(w, fmt.Sprintf("Images over budget: %d of %d", n, *imageBudget))
+	}
+
+	return w
+}
+
+// checkBudgets logs the budgets gone over since the last read.
+func (m *memory) checkBudgets() {
+	over := map[string]bool{}
+
+	for _, w := range m.warnings() {
+		kind := strings.Fields(w)[0]
+		over[kind] = true
+
+		if !m.over[kind] {
+			log.Print(w)
+		}
+	}
+
+	m.over = over
+}
+
+func (m *memory) text() string {
+	pause := m.stats.PauseNs[(m.stats.NumGC+255)%256]
+
+	return fmt.Sprintf("Memory (F12)\n"+
+		"Heap: %.1f MB in use, %d objects\n"+
+		"Allocating: %.2f MB/s\n"+
+		"GC: %d cycles, %.1f/s, last pause %.2f ms, %.1f ms in all\n"+
+		"Images: %d alive, %d made",
+		m.heapMB(), m.stats.HeapObjects, m.rate(m.stats.TotalAlloc, m.prev.TotalAlloc)/mb,
+		m.stats.NumGC, m.gcRate(), float64(pause)/1e6, float64(m.stats.PauseTotalNs)/1e6,
+		liveImages(), atomic.LoadInt64(&imagesMade))
+}
+
+// Draw draws the overlay in the top right corner, and the warnings under it
+// in the flashing part of the time.
+func (m *memory) Draw(screen *ebiten.Image) {
+	w, _ := screen.Size()
+	y := padding
+
+	if m.shown {
+		y = m.drawBox(screen, w, y, m.text(), memoryBackground)
+	}
+
+	if (m.ticks/flashTicks)%2 == 1 {
+		return
+	}
+
+	for _, warning := range m.warnings() {
+		y = m.drawBox(screen, w, y, warning, warningColor)
+	}
+}
+
+// drawBox draws the text on a box right aligned at y, and returns where the
+// next one goes.
+func (m *memory) drawBox(screen *ebiten.Image, w, y int, text string, bg color.Color) int {
+	cols := 0
+	for _, l := range strings.Split(text, "\n") {
+		if len(l) > cols {
+			cols = len(l)
+		}
+	}
+
+	bw, bh := cols*charWidth+2*padding, (strings.Count(text, "\n")+1)*lineHeight+2*padding
+	x := w - bw - padding
+
+	ebitenutil.DrawRect(screen, float64(x), float64(y), float64(bw), float64(bh), bg)
+	ebitenutil.DebugPrintAt(screen, text, x+padding, y+padding)
+
+	return y + bh + padding
+}
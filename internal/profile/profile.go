@@ -0,0 +1,60 @@
+// Package profile adds opt-in profiling to the exercises: -pprof serves the
+// net/http/pprof endpoints, and -trace writes a runtime/trace with Update,
+// Draw and whatever subsystems mark themselves with Region, to look at with
+// go tool trace. F12 shows the heap, the GC and the images made with
+// NewImage over the game, and -heap-budget, -gc-budget and -image-budget
+// flash warnings when they're gone over.
+//
+// The flags are registered on import, exercises just call flag.Parse and run
+// their game with RunGame instead of ebiten.RunGame, or with run.Game, that
+// uses it.
+package profile
+
+import (
+	"flag"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+//nolint:gochecknoglobal
+var (
+	pprofAddr = flag.String("pprof", "", "serve pprof on this address, like :6060")
+	tracePath = flag.String("trace", "", "write a runtime trace to this file")
+)
+
+// RunGame is ebiten.RunGame with the profiling asked for in the flags.
+func RunGame(g ebiten.Game) error {
+	stop, err := start(*pprofAddr, *tracePath)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	return ebiten.RunGame(&game{Game: g, memory: newMemory()})
+}
+
+// game wraps a game to trace its Update and Draw, and draws the memory
+// overlay over it.
+type game struct {
+	ebiten.Game
+	memory *memory
+}
+
+func (g *game) Update(screen *ebiten.Image) error {
+	defer Region("Update")()
+
+	g.memory.Update()
+
+	return g.Game.Update(screen)
+}
+
+// Draw is optional for ebiten games, so it might not be there.
+func (g *game) Draw(screen *ebiten.Image) {
+	defer Region("Draw")()
+
+	if d, ok := g.Game.(interface{ Draw(*ebiten.Image) }); ok {
+		d.Draw(screen)
+	}
+
+	g.memory.Draw(screen)
+}
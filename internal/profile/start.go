@@ -0,0 +1,49 @@
+//go:build !js
+// +build !js
+
+package profile
+
+import (
+	"context"
+	"log"
+	"net/http"
+	_ "net/http/pprof" //nolint:gosec // Only served when asked for
+	"os"
+	"runtime/trace"
+)
+
+func start(pprofAddr, tracePath string) (stop func(), err error) {
+	if pprofAddr != "" {
+		go func() {
+			log.Printf("pprof: %v", http.ListenAndServe(pprofAddr, nil))
+		}()
+	}
+
+	if tracePath == "" {
+		return func() {}, nil
+	}
+
+	f, err := os.Create(tracePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := trace.Start(f); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	return func() {
+		trace.Stop()
+		f.Close()
+	}, nil
+}
+
+// Region marks a region in the trace, named after what's being timed, until
+// the returned function is called:
+//
+//	defer profile.Region("paths")()
+func Region(name string) func() {
+	return trace.StartRegion(context.Background(), name).End
+}
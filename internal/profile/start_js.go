@@ -0,0 +1,19 @@
+//go:build js
+// +build js
+
+package profile
+
+import "log"
+
+// There's no pprof server or runtime trace in the browser.
+func start(pprofAddr, tracePath string) (stop func(), err error) {
+	if pprofAddr != "" || tracePath != "" {
+		log.Print("profiling isn't supported in the browser")
+	}
+
+	return func() {}, nil
+}
+
+func Region(name string) func() {
+	return func() {}
+}
@@ -0,0 +1,101 @@
+package run
+
+import (
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"log"
+	"runtime/debug"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/buildinfo"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Where the crash report is saved with C
+	reportPath = "crash.txt"
+	// Size of the debug font characters, and the lines above the trace
+	charWidth  = 6
+	lineHeight = 16
+	headerRows = 4
+)
+
+//nolint:gochecknoglobal
+var crashColor = color.RGBA{0x40, 0x08, 0x08, 0xff}
+
+// crash is the screen shown once the game panicked: what the panic was, and
+// its stack trace, scrolling with the arrows. Ebiten can't reach the
+// clipboard, so C saves the report to a file to copy it from.
+type crash struct {
+	err    *PanicError
+	lines  []string
+	scroll int
+	status string
+	keys   keymap.Map
+}
+
+func newCrash(v interface{}) *crash {
+	c := &crash{err: &PanicError{Value: v, Stack: debug.Stack()}}
+	c.lines = strings.Split(strings.TrimSpace(string(c.err.Stack)), "\n")
+	c.status = "C saves this report to " + reportPath + ", Esc quits"
+	log.Printf("%v (%s)\n%s", c.err, buildinfo.Get(), c.err.Stack)
+
+	c.keys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyUp), Trigger: keymap.Repeat, Action: keymap.Do(func() { c.scrollBy(-1) })},
+		{Keys: keymap.Keys(ebiten.KeyDown), Trigger: keymap.Repeat, Action: keymap.Do(func() { c.scrollBy(1) })},
+		{Keys: keymap.Keys(ebiten.KeyC), Trigger: keymap.Pressed, Action: keymap.Do(c.save)},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Trigger: keymap.Pressed, Action: func() error { return c.err }},
+	}
+
+	return c
+}
+
+func (c *crash) scrollBy(n int) {
+	c.scroll += n
+	if c.scroll > len(c.lines)-1 {
+		c.scroll = len(c.lines) - 1
+	}
+
+	if c.scroll < 0 {
+		c.scroll = 0
+	}
+}
+
+func (c *crash) save() {
+	report := fmt.Sprintf("%v\n%s\n\n%s", c.err, buildinfo.Get(), c.err.Stack)
+	if err := ioutil.WriteFile(reportPath, []byte(report), 0644); err != nil {
+		log.Printf("saving %s: %v", reportPath, err)
+		c.status = "Saving the report failed, see the log. Esc quits"
+
+		return
+	}
+
+	c.status = "Report saved to " + reportPath + ", Esc quits"
+}
+
+func (c *crash) Update() error {
+	return c.keys.Update()
+}
+
+func (c *crash) Draw(screen *ebiten.Image) {
+	_ = screen.Fill(crashColor)
+
+	_, h := screen.Size()
+	// A window too short for the header still shows a line of the trace
+	rows := h/lineHeight - headerRows
+	if rows < 1 {
+		rows = 1
+	}
+
+	end := c.scroll + rows
+
+	if end > len(c.lines) {
+		end = len(c.lines)
+	}
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("The game crashed: %v (%s)\n%s\nUp and Down scroll the trace\n\n%s",
+		c.err.Value, buildinfo.Get(), c.status, strings.Join(c.lines[c.scroll:end], "\n")))
+}
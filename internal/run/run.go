@@ -0,0 +1,152 @@
+// Package run runs the exercises and ends them, telling apart the three
+// ways they end: a clean exit, the player aborting them, and errors. A
+// panic in the game doesn't take the window down either, it shows the
+// crash in it, with the stack trace, instead.
+//
+// Exercises run their game with Game, and pass what it returns to Exit:
+//
+//	err := run.Game(g)
+//	// cleanup
+//	run.Exit(err)
+package run
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/antoniomo/ebiten-exercises/internal/buildinfo"
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// Exit status for aborts, as shells have it for Ctrl+C.
+const abortStatus = 130
+
+//nolint:gochecknoglobal
+var (
+	// ErrCleanExit is for games to return from Update to end normally.
+	ErrCleanExit = errors.New("clean exit, no error")
+	// ErrAbort is what Game returns when the player aborted the game from
+	// the terminal, with Ctrl+C or the like.
+	ErrAbort = errors.New("aborted")
+)
+
+// PanicError is a panic in the game, once the crash screen is closed.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Game runs the game as profile.RunGame does, recovering from its panics
+// into the crash screen, and ending with ErrAbort if the process is
+// interrupted.
+func Game(g ebiten.Game) error {
+	return profile.RunGame(&game{Game: g, aborted: notifyAbort()})
+}
+
+// Exit ends the program as err asks for: it returns for no error, says good
+// bye for a clean exit, and otherwise exits with a failure status.
+func Exit(err error) {
+	switch {
+	case err == nil:
+		return
+	case is(err, ErrCleanExit):
+		fmt.Println("Good bye!")
+	case is(err, ErrAbort):
+		fmt.Println("Aborted")
+		os.Exit(abortStatus)
+	default:
+		log.Fatal(err)
+	}
+}
+
+// is is errors.Is, that gopherjs doesn't have yet, being on go 1.12.
+func is(err, target error) bool {
+	for err != nil {
+		if err == target {
+			return true
+		}
+
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+
+		err = u.Unwrap()
+	}
+
+	return false
+}
+
+// game wraps a game to catch its panics, showing the crash instead of it
+// from then on.
+type game struct {
+	ebiten.Game
+	aborted <-chan struct{}
+	crash   *crash
+}
+
+// recover turns a panic into the crash screen. It has to be deferred
+// directly, recover only works there.
+func (g *game) recover() {
+	if r := recover(); r != nil {
+		g.crash = newCrash(r)
+	}
+}
+
+func (g *game) Update(screen *ebiten.Image) error {
+	select {
+	case <-g.aborted:
+		return ErrAbort
+	default:
+	}
+
+	if g.crash != nil {
+		return g.crash.Update()
+	}
+
+	defer g.recover()
+
+	return g.Game.Update(screen)
+}
+
+// Draw is optional for ebiten games, so it might not be there.
+func (g *game) Draw(screen *ebiten.Image) {
+	if g.crash != nil {
+		g.crash.Draw(screen)
+
+		return
+	}
+
+	defer g.recover()
+
+	if d, ok := g.Game.(interface{ Draw(*ebiten.Image) }); ok {
+		d.Draw(screen)
+	}
+
+	drawBuild(screen)
+}
+
+// drawBuild shows what the game was built from, in the bottom right corner
+// over everything else.
+func drawBuild(screen *ebiten.Image) {
+	text := buildinfo.Get().String()
+	w, h := screen.Size()
+	ebitenutil.DebugPrintAt(screen, text, w-len(text)*charWidth-2, h-lineHeight)
+}
+
+// Layout goes by the window once crashed, so the trace is readable.
+func (g *game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if g.crash != nil {
+		return outsideWidth, outsideHeight
+	}
+
+	return g.Game.Layout(outsideWidth, outsideHeight)
+}
@@ -0,0 +1,27 @@
+//go:build !js
+// +build !js
+
+package run
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyAbort returns a channel closed once the process is interrupted or
+// asked to terminate.
+func notifyAbort() <-chan struct{} {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	aborted := make(chan struct{})
+
+	go func() {
+		<-sig
+		signal.Stop(sig)
+		close(aborted)
+	}()
+
+	return aborted
+}
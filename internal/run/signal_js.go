@@ -0,0 +1,9 @@
+//go:build js
+// +build js
+
+package run
+
+// There are no signals in the browser, games there are never aborted.
+func notifyAbort() <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,255 @@
+// Package scene is a tiny scene manager, so that exercises can switch
+// between screens (menus, game phases, pause screens...) with transitions
+// in between.
+package scene
+
+import (
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Scene is a single screen of an exercise, the Update and Draw of an
+// ebiten.Game. Scenes can also be Enterers, Leavers and Layouters, for the
+// Manager to tell them when they start and stop running, and to ask them
+// the size of their screen.
+type Scene interface {
+	Update() error
+	Draw(screen *ebiten.Image)
+}
+
+// Enterer is a Scene told when it starts running: switched to, or back to
+// once the scene pushed over it is popped, when the transition is done.
+type Enterer interface {
+	Enter()
+}
+
+// Leaver is a Scene told when it stops running: switched away from, or
+// covered by a pushed scene, as the transition starts.
+type Leaver interface {
+	Leave()
+}
+
+// Layouter is a Scene with a screen of its own size, as ebiten.Game.Layout
+// has it. Scenes that aren't get the size of the game.
+type Layouter interface {
+	Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int)
+}
+
+// Manager runs the scene on top of a stack of them: Push covers it with a
+// new one, like a pause menu over the game, Pop goes back to the one below,
+// and Replace and Reset switch to another one in its place or in place of
+// the whole stack. While switching, it renders the outgoing and incoming
+// scenes to offscreen images and lets the Transition blend them.
+type Manager struct {
+	// Bottom to top, the top one runs
+	stack []Scene
+	// While transitioning, the incoming scene and the stack once it's done
+	next    Scene
+	pending []Scene
+
+	transition *Transition
+	tick       int
+	ticks      int
+
+	from *ebiten.Image
+	to   *ebiten.Image
+	// Scenes with a screen size other than the game's are drawn on these
+	// first, and scaled
+	fromScene *ebiten.Image
+	toScene   *ebiten.Image
+	// Last size given to Layout, for Layouters
+	outsideW, outsideH int
+}
+
+// NewManager returns a Manager starting at the given scene.
+func NewManager(initial Scene) *Manager {
+	m := &Manager{stack: []Scene{initial}}
+	enter(initial)
+
+	return m
+}
+
+// Current returns the running scene. While transitioning that's still the
+// outgoing one.
+func (m *Manager) Current() Scene {
+	return m.stack[len(m.stack)-1]
+}
+
+// Transitioning reports whether a transition is in progress.
+func (m *Manager) Transitioning() bool {
+	return m.next != nil
+}
+
+// Push covers the running scene with s, which runs until popped. With a
+// nil Transition the switch is immediate, as with the others. Calling any
+// of them while a transition is in progress finishes that one first.
+func (m *Manager) Push(s Scene, t *Transition) {
+	m.finish()
+	m.switchTo(append(m.stack[:len(m.stack):len(m.stack)], s), t)
+}
+
+// Pop goes back to the scene below the running one. The bottom scene isn't
+// popped, there would be nothing to run.
+func (m *Manager) Pop(t *Transition) {
+	m.finish()
+
+	if len(m.stack) > 1 {
+		m.switchTo(m.stack[:len(m.stack)-1], t)
+	}
+}
+
+// Replace switches to s in place of the running scene.
+func (m *Manager) Replace(s Scene, t *Transition) {
+	m.finish()
+
+	stack := append([]Scene(nil), m.stack...)
+	stack[len(stack)-1] = s
+	m.switchTo(stack, t)
+}
+
+// Reset switches to s in place of the whole stack.
+func (m *Manager) Reset(s Scene, t *Transition) {
+	m.finish()
+	m.switchTo([]Scene{s}, t)
+}
+
+func (m *Manager) switchTo(stack []Scene, t *Transition) {
+	if l, ok := m.Current().(Leaver); ok {
+		l.Leave()
+	}
+
+	m.pending = stack
+	m.next = stack[len(stack)-1]
+
+	if t == nil || t.Duration <= 0 {
+		m.finish()
+
+		return
+	}
+
+	m.transition = t
+	m.tick = 0
+	m.ticks = t.ticks()
+}
+
+// finish ends the transition in progress, if any.
+func (m *Manager) finish() {
+	if m.next == nil {
+		return
+	}
+
+	m.stack = m.pending
+	m.next, m.pending = nil, nil
+	m.transition = nil
+	enter(m.Current())
+}
+
+func enter(s Scene) {
+	if e, ok := s.(Enterer); ok {
+		e.Enter()
+	}
+}
+
+// Update updates the current scene. Scenes are frozen while a transition is
+// running, so that no input leaks to either of them.
+func (m *Manager) Update() error {
+	if m.next != nil {
+		m.tick++
+		if m.tick >= m.ticks {
+			m.finish()
+		}
+
+		return nil
+	}
+
+	return m.Current().Update()
+}
+
+// Layout returns the screen size of the running scene, or of the incoming
+// one while transitioning, for the game's Layout to return. It's the
+// outside size for scenes that aren't Layouters.
+func (m *Manager) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	m.outsideW, m.outsideH = outsideWidth, outsideHeight
+
+	s := m.Current()
+	if m.next != nil {
+		s = m.next
+	}
+
+	if l, ok := s.(Layouter); ok {
+		return l.Layout(outsideWidth, outsideHeight)
+	}
+
+	return outsideWidth, outsideHeight
+}
+
+// Draw draws the current scene, or the transition between the outgoing and
+// incoming ones.
+func (m *Manager) Draw(screen *ebiten.Image) {
+	if m.next == nil {
+		m.Current().Draw(screen)
+
+		return
+	}
+
+	m.ensureOffscreens(screen.Size())
+
+	_ = m.from.Clear()
+	_ = m.to.Clear()
+	m.drawScene(m.from, &m.fromScene, m.Current())
+	m.drawScene(m.to, &m.toScene, m.next)
+
+	m.transition.draw(screen, m.from, m.to, float64(m.tick)/float64(m.ticks))
+}
+
+// drawScene draws s on dst, a Layouter with a screen of another size on
+// img first, scaled to fill dst.
+func (m *Manager) drawScene(dst *ebiten.Image, img **ebiten.Image, s Scene) {
+	l, ok := s.(Layouter)
+	if !ok {
+		s.Draw(dst)
+
+		return
+	}
+
+	dw, dh := dst.Size()
+
+	w, h := l.Layout(m.outsideW, m.outsideH)
+	if w <= 0 || h <= 0 || (w == dw && h == dh) {
+		s.Draw(dst)
+
+		return
+	}
+
+	if *img != nil {
+		if iw, ih := (*img).Size(); iw != w || ih != h {
+			_ = (*img).Dispose()
+			*img = nil
+		}
+	}
+
+	if *img == nil {
+		*img, _ = profile.NewImage(w, h, ebiten.FilterDefault)
+	}
+
+	_ = (*img).Clear()
+	s.Draw(*img)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(dw)/float64(w), float64(dh)/float64(h))
+	_ = dst.DrawImage(*img, op)
+}
+
+func (m *Manager) ensureOffscreens(w, h int) {
+	if m.from != nil {
+		if fw, fh := m.from.Size(); fw == w && fh == h {
+			return
+		}
+
+		_ = m.from.Dispose()
+		_ = m.to.Dispose()
+	}
+
+	m.from, _ = profile.NewImage(w, h, ebiten.FilterDefault)
+	m.to, _ = profile.NewImage(w, h, ebiten.FilterDefault)
+}
@@ -0,0 +1,140 @@
+package scene
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/internal/tween"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Effect blends the outgoing scene (from) into the incoming one (to), t
+// going from 0 (only from) to 1 (only to).
+type Effect interface {
+	Draw(screen, from, to *ebiten.Image, t float64)
+}
+
+// Transition is an Effect played over Duration, with the progress shaped by
+// Easing (linear if nil).
+type Transition struct {
+	Effect   Effect
+	Duration time.Duration
+	Easing   tween.Easing
+}
+
+// NewFade returns a cross-fade transition.
+func NewFade(d time.Duration) *Transition {
+	return &Transition{Effect: Fade{}, Duration: d, Easing: tween.InOutQuad}
+}
+
+// NewWipe returns a left to right wipe transition.
+func NewWipe(d time.Duration) *Transition {
+	return &Transition{Effect: Wipe{}, Duration: d, Easing: tween.InOutCubic}
+}
+
+// NewPixelate returns a transition that pixelates the outgoing scene, and
+// then un-pixelates the incoming one.
+func NewPixelate(d time.Duration) *Transition {
+	return &Transition{Effect: &Pixelate{MaxBlock: 16}, Duration: d, Easing: tween.Linear}
+}
+
+func (t *Transition) ticks() int {
+	n := int(t.Duration.Seconds() * float64(ebiten.MaxTPS()))
+	if n < 1 {
+		n = 1
+	}
+
+	return n
+}
+
+func (t *Transition) draw(screen, from, to *ebiten.Image, progress float64) {
+	progress = tween.Clamp01(progress)
+	if t.Easing != nil {
+		progress = t.Easing(progress)
+	}
+
+	t.Effect.Draw(screen, from, to, progress)
+}
+
+// Fade cross-fades both scenes.
+type Fade struct{}
+
+func (Fade) Draw(screen, from, to *ebiten.Image, t float64) {
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(1, 1, 1, 1-t)
+	_ = screen.DrawImage(from, op)
+
+	op = &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(1, 1, 1, t)
+	_ = screen.DrawImage(to, op)
+}
+
+// Wipe uncovers the incoming scene from left to right.
+type Wipe struct{}
+
+func (Wipe) Draw(screen, from, to *ebiten.Image, t float64) {
+	_ = screen.DrawImage(from, nil)
+
+	w, h := to.Size()
+
+	edge := int(float64(w) * t)
+	if edge <= 0 {
+		return
+	}
+
+	_ = screen.DrawImage(to.SubImage(image.Rect(0, 0, edge, h)).(*ebiten.Image), nil)
+}
+
+// Pixelate grows the pixels of the outgoing scene up to MaxBlock, then
+// shrinks them back on the incoming one.
+type Pixelate struct {
+	MaxBlock int
+
+	small *ebiten.Image
+}
+
+func (p *Pixelate) Draw(screen, from, to *ebiten.Image, t float64) {
+	src := from
+	if t >= 0.5 {
+		src = to
+	}
+
+	// 0 -> 1 -> 0 over the whole transition
+	amount := 1 - math.Abs(2*t-1)
+
+	block := 1 + int(amount*float64(p.MaxBlock-1))
+	if block <= 1 {
+		_ = screen.DrawImage(src, nil)
+
+		return
+	}
+
+	w, h := src.Size()
+	sw, sh := (w+block-1)/block, (h+block-1)/block
+
+	if p.small != nil {
+		if cw, ch := p.small.Size(); cw < sw || ch < sh {
+			_ = p.small.Dispose()
+			p.small = nil
+		}
+	}
+
+	if p.small == nil {
+		// Sized for the smallest block, so it can be reused for the
+		// whole transition
+		p.small, _ = profile.NewImage(w, h, ebiten.FilterNearest)
+	}
+
+	_ = p.small.Clear()
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(1/float64(block), 1/float64(block))
+	_ = p.small.DrawImage(src, op)
+
+	op = &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(block), float64(block))
+	op.Filter = ebiten.FilterNearest
+	_ = screen.DrawImage(p.small.SubImage(image.Rect(0, 0, sw, sh)).(*ebiten.Image), op)
+}
@@ -0,0 +1,76 @@
+// Package raster draws the shapes of package shapes with gg, antialiased,
+// into images to upload to ebiten. It's plain Go, so it can run off the
+// main thread, only the upload can't. Sizes are in screen units, drawn at k
+// pixels per unit for HiDPI screens, 1 otherwise.
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// Context returns a context w by h screen units big, at k pixels per unit,
+// drawing in screen units.
+func Context(w, h int, k float64) *gg.Context {
+	dc := gg.NewContext(int(math.Ceil(float64(w)*k)), int(math.Ceil(float64(h)*k)))
+	dc.Scale(k, k)
+
+	return dc
+}
+
+func fill(dc *gg.Context, clr color.Color) image.Image {
+	dc.SetColor(clr)
+	dc.Fill()
+
+	return dc.Image()
+}
+
+// Triangle is an isosceles triangle pointing up, in a w by h box.
+func Triangle(w, h int, clr color.Color, k float64) image.Image {
+	dc := Context(w, h, k)
+	dc.MoveTo(0, float64(h))
+	dc.LineTo(float64(w)/2, 0)
+	dc.LineTo(float64(w), float64(h))
+	dc.ClosePath()
+
+	return fill(dc, clr)
+}
+
+// RegularPolygon is a polygon of n sides inscribed in a circle of radius r.
+func RegularPolygon(r, n int, clr color.Color, k float64) image.Image {
+	dc := Context(r*2, r*2, k)
+	dc.DrawRegularPolygon(n, float64(r), float64(r), float64(r), 0)
+
+	return fill(dc, clr)
+}
+
+// Circle is a circle of radius r.
+func Circle(r int, clr color.Color, k float64) image.Image {
+	dc := Context(r*2, r*2, k)
+	dc.DrawCircle(float64(r), float64(r), float64(r))
+
+	return fill(dc, clr)
+}
+
+// Rectangle is a w by h rectangle.
+func Rectangle(w, h int, clr color.Color, k float64) image.Image {
+	dc := Context(w, h, k)
+	dc.DrawRectangle(0, 0, float64(w), float64(h))
+
+	return fill(dc, clr)
+}
+
+// Line is a segment from (x1, y1) to (x2, y2), thickness wide, in a w by h
+// image.
+func Line(w, h int, x1, y1, x2, y2, thickness float64, clr color.Color, k float64) image.Image {
+	dc := Context(w, h, k)
+	dc.DrawLine(x1, y1, x2, y2)
+	dc.SetLineWidth(thickness)
+	dc.SetColor(clr)
+	dc.Stroke()
+
+	return dc.Image()
+}
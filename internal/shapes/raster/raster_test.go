@@ -0,0 +1,84 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestContextSize(t *testing.T) {
+	tests := []struct {
+		name string
+		w, h int
+		k    float64
+		want image.Point
+	}{
+		{"unscaled", 10, 20, 1, image.Pt(10, 20)},
+		{"retina", 10, 20, 2, image.Pt(20, 40)},
+		{"fractional scale rounds up", 10, 3, 1.25, image.Pt(13, 4)},
+		{"empty", 0, 0, 2, image.Pt(0, 0)},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Context(tt.w, tt.h, tt.k).Image().Bounds().Size(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShapes(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+
+	tests := []struct {
+		name string
+		img  image.Image
+		// A point inside the shape and one outside of it, in pixels
+		in, out image.Point
+	}{
+		{"triangle", Triangle(20, 20, red, 1), image.Pt(10, 15), image.Pt(1, 1)},
+		{"hexagon", RegularPolygon(10, 6, red, 1), image.Pt(10, 10), image.Pt(10, 0)},
+		{"circle", Circle(10, red, 1), image.Pt(10, 10), image.Pt(0, 0)},
+		{"circle at 2x", Circle(10, red, 2), image.Pt(20, 20), image.Pt(1, 1)},
+		{"rectangle", Rectangle(10, 4, red, 1), image.Pt(5, 2), image.Pt(-1, -1)},
+		{"line", Line(20, 20, 0, 10, 20, 10, 4, red, 1), image.Pt(10, 10), image.Pt(10, 2)},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := color.RGBAModel.Convert(tt.img.At(tt.in.X, tt.in.Y)); got != red {
+				t.Errorf("got %v inside, want %v", got, red)
+			}
+
+			if _, _, _, a := tt.img.At(tt.out.X, tt.out.Y).RGBA(); a != 0 {
+				t.Errorf("got alpha %#x outside, want none", a)
+			}
+		})
+	}
+}
+
+func TestColors(t *testing.T) {
+	tests := []struct {
+		name string
+		clr  color.Color
+		want color.RGBA
+	}{
+		{"transparent", color.Transparent, color.RGBA{}},
+		{"black", color.Black, color.RGBA{0, 0, 0, 0xff}},
+		{"white", color.White, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{"half transparent", color.NRGBA{0xff, 0, 0, 0x80}, color.RGBA{0x80, 0, 0, 0x80}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			img := Rectangle(4, 4, tt.clr, 1)
+			if got := color.RGBAModel.Convert(img.At(2, 2)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
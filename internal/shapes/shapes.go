@@ -0,0 +1,156 @@
+// Package shapes has the drawing basics the exercises kept copying around:
+// a white pixel to stretch into anything, the color scale to tint it, and
+// triangle meshes for simple shapes, for ebiten's DrawTriangles. The
+// triangles of the meshes all wind clockwise on screen, and shapes with no
+// area are nil meshes. The raster subpackage draws the same shapes with gg
+// instead.
+package shapes
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Pixels along the circumference per segment of a Circle, and the fewest
+// segments one gets
+const (
+	circleStep     = 4
+	circleSegments = 12
+)
+
+//nolint:gochecknoglobal
+var (
+	// EmptyImage is a white pixel, to draw rectangles by scaling it and
+	// colors by tinting it.
+	EmptyImage *ebiten.Image
+)
+
+//nolint:gochecknoinit
+func init() {
+	EmptyImage, _ = profile.NewImage(1, 1, ebiten.FilterDefault)
+	_ = EmptyImage.Fill(color.White)
+}
+
+// ColorScale returns the color as ColorM.Scale arguments, to tint white
+// images with it. Taken from ebitenutil/shapes.go, with the channels of
+// colors that aren't properly premultiplied clamped to 1, so they can't
+// brighten the image.
+func ColorScale(clr color.Color) (rf, gf, bf, af float64) {
+	r, g, b, a := clr.RGBA()
+	if a == 0 {
+		return 0, 0, 0, 0
+	}
+
+	rf = math.Min(float64(r)/float64(a), 1)
+	gf = math.Min(float64(g)/float64(a), 1)
+	bf = math.Min(float64(b)/float64(a), 1)
+	af = float64(a) / 0xffff
+
+	return
+}
+
+// vertex is a white vertex, the color comes from the draw options.
+func vertex(x, y float64) ebiten.Vertex {
+	return ebiten.Vertex{DstX: float32(x), DstY: float32(y), ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1}
+}
+
+// Triangle is an isosceles triangle pointing up, in a w by h box.
+func Triangle(w, h int) ([]ebiten.Vertex, []uint16) {
+	if w <= 0 || h <= 0 {
+		return nil, nil
+	}
+
+	vs := []ebiten.Vertex{
+		vertex(0, float64(h)),
+		vertex(float64(w)/2, 0),
+		vertex(float64(w), float64(h)),
+	}
+
+	return vs, []uint16{0, 1, 2}
+}
+
+// RegularPolygon is a polygon of n sides inscribed in a circle of radius r,
+// in a 2r by 2r box, as a fan around its center. Based on ebiten's polygons
+// example. It takes at least 3 sides.
+func RegularPolygon(r, n int) ([]ebiten.Vertex, []uint16) {
+	if r <= 0 || n < 3 {
+		return nil, nil
+	}
+
+	vs := make([]ebiten.Vertex, n+1)
+
+	for i := 0; i < n; i++ {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		vs[i] = vertex(float64(r)*math.Cos(a)+float64(r), float64(r)*math.Sin(a)+float64(r))
+	}
+
+	vs[n] = vertex(float64(r), float64(r))
+
+	indices := make([]uint16, 0, 3*n)
+	for i := 0; i < n; i++ {
+		indices = append(indices, uint16(i), uint16((i+1)%n), uint16(n))
+	}
+
+	return vs, indices
+}
+
+// Circle is a regular polygon with enough sides to pass for a circle of
+// radius r.
+func Circle(r int) ([]ebiten.Vertex, []uint16) {
+	n := int(2 * math.Pi * float64(r) / circleStep)
+	if n < circleSegments {
+		n = circleSegments
+	}
+
+	return RegularPolygon(r, n)
+}
+
+// Rectangle is a w by h rectangle.
+func Rectangle(w, h int) ([]ebiten.Vertex, []uint16) {
+	if w <= 0 || h <= 0 {
+		return nil, nil
+	}
+
+	vs := []ebiten.Vertex{
+		vertex(0, 0),
+		vertex(float64(w), 0),
+		vertex(0, float64(h)),
+		vertex(float64(w), float64(h)),
+	}
+
+	return vs, []uint16{0, 1, 2, 2, 1, 3}
+}
+
+// Line is a segment from (x1, y1) to (x2, y2), thickness wide, as a thin
+// rectangle along it.
+func Line(x1, y1, x2, y2, thickness float64) ([]ebiten.Vertex, []uint16) {
+	l := math.Hypot(x2-x1, y2-y1)
+	if l == 0 {
+		return nil, nil
+	}
+
+	// Half the thickness, across the segment
+	nx, ny := (y2-y1)/l*thickness/2, -(x2-x1)/l*thickness/2
+	vs := []ebiten.Vertex{
+		vertex(x1+nx, y1+ny),
+		vertex(x2+nx, y2+ny),
+		vertex(x1-nx, y1-ny),
+		vertex(x2-nx, y2-ny),
+	}
+
+	return vs, []uint16{0, 1, 2, 2, 1, 3}
+}
+
+// Draw draws a mesh from the constructors tinted with the color, with the
+// options, that can be nil.
+func Draw(dst *ebiten.Image, vs []ebiten.Vertex, indices []uint16, clr color.Color, op *ebiten.DrawTrianglesOptions) {
+	if op == nil {
+		op = &ebiten.DrawTrianglesOptions{}
+	}
+
+	op.ColorM.Scale(ColorScale(clr))
+	dst.DrawTriangles(vs, indices, EmptyImage, op)
+}
@@ -0,0 +1,112 @@
+package shapes
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+func TestMeshes(t *testing.T) {
+	tests := []struct {
+		name     string
+		mesh     func() ([]ebiten.Vertex, []uint16)
+		vertices int
+		indices  int
+	}{
+		{"triangle", func() ([]ebiten.Vertex, []uint16) { return Triangle(10, 8) }, 3, 3},
+		{"triangle without width", func() ([]ebiten.Vertex, []uint16) { return Triangle(0, 8) }, 0, 0},
+		{"square", func() ([]ebiten.Vertex, []uint16) { return RegularPolygon(5, 4) }, 5, 12},
+		{"hexagon", func() ([]ebiten.Vertex, []uint16) { return RegularPolygon(5, 6) }, 7, 18},
+		{"two sides", func() ([]ebiten.Vertex, []uint16) { return RegularPolygon(5, 2) }, 0, 0},
+		{"no sides", func() ([]ebiten.Vertex, []uint16) { return RegularPolygon(5, 0) }, 0, 0},
+		{"negative sides", func() ([]ebiten.Vertex, []uint16) { return RegularPolygon(5, -3) }, 0, 0},
+		{"polygon without radius", func() ([]ebiten.Vertex, []uint16) { return RegularPolygon(0, 6) }, 0, 0},
+		{"small circle", func() ([]ebiten.Vertex, []uint16) { return Circle(1) }, circleSegments + 1, 3 * circleSegments},
+		{"circle", func() ([]ebiten.Vertex, []uint16) { return Circle(20) }, 32, 93},
+		{"circle without radius", func() ([]ebiten.Vertex, []uint16) { return Circle(0) }, 0, 0},
+		{"rectangle", func() ([]ebiten.Vertex, []uint16) { return Rectangle(10, 4) }, 4, 6},
+		{"rectangle without height", func() ([]ebiten.Vertex, []uint16) { return Rectangle(10, 0) }, 0, 0},
+		{"line", func() ([]ebiten.Vertex, []uint16) { return Line(0, 0, 10, 5, 2) }, 4, 6},
+		{"line going back", func() ([]ebiten.Vertex, []uint16) { return Line(10, 5, 0, 0, 2) }, 4, 6},
+		{"line without length", func() ([]ebiten.Vertex, []uint16) { return Line(3, 3, 3, 3, 2) }, 0, 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			vs, indices := tt.mesh()
+			if len(vs) != tt.vertices || len(indices) != tt.indices {
+				t.Fatalf("got %d vertices and %d indices, want %d and %d",
+					len(vs), len(indices), tt.vertices, tt.indices)
+			}
+
+			for i := 0; i < len(indices); i += 3 {
+				a, b, c := indices[i], indices[i+1], indices[i+2]
+				if int(a) >= len(vs) || int(b) >= len(vs) || int(c) >= len(vs) {
+					t.Fatalf("triangle %d indexes %d, %d, %d, out of %d vertices", i/3, a, b, c, len(vs))
+				}
+
+				// The y axis points down, so clockwise on screen is a
+				// positive cross product
+				if cross := winding(vs[a], vs[b], vs[c]); cross <= 0 {
+					t.Errorf("triangle %d isn't clockwise, cross product %g", i/3, cross)
+				}
+			}
+		})
+	}
+}
+
+func winding(a, b, c ebiten.Vertex) float32 {
+	return (b.DstX-a.DstX)*(c.DstY-a.DstY) - (b.DstY-a.DstY)*(c.DstX-a.DstX)
+}
+
+func TestLineThickness(t *testing.T) {
+	vs, _ := Line(0, 0, 10, 0, 4)
+
+	for i, v := range vs {
+		if v.DstY != 2 && v.DstY != -2 {
+			t.Errorf("vertex %d is at y %g, want 2 or -2 for a thickness of 4", i, v.DstY)
+		}
+	}
+}
+
+func TestColorScale(t *testing.T) {
+	tests := []struct {
+		name       string
+		clr        color.Color
+		r, g, b, a float64
+	}{
+		{"transparent", color.Transparent, 0, 0, 0, 0},
+		{"transparent with color", color.RGBA{0xff, 0xff, 0xff, 0}, 0, 0, 0, 0},
+		{"black", color.Black, 0, 0, 0, 1},
+		{"white", color.White, 1, 1, 1, 1},
+		{"red", color.RGBA{0xff, 0, 0, 0xff}, 1, 0, 0, 1},
+		{"half transparent white", color.RGBA{0x80, 0x80, 0x80, 0x80}, 1, 1, 1, float64(0x8080) / 0xffff},
+		{"half transparent red", color.NRGBA{0xff, 0, 0, 0x80}, 1, 0, 0, float64(0x8080) / 0xffff},
+		{"not premultiplied", color.RGBA{0xff, 0x40, 0, 0x80}, 1, 0.5, 0, float64(0x8080) / 0xffff},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := ColorScale(tt.clr)
+			if !near(r, tt.r) || !near(g, tt.g) || !near(b, tt.b) || !near(a, tt.a) {
+				t.Errorf("got %.3f, %.3f, %.3f, %.3f, want %.3f, %.3f, %.3f, %.3f",
+					r, g, b, a, tt.r, tt.g, tt.b, tt.a)
+			}
+
+			for _, v := range []float64{r, g, b, a} {
+				if v < 0 || v > 1 {
+					t.Errorf("scale %g out of 0 to 1", v)
+				}
+			}
+		})
+	}
+}
+
+func near(a, b float64) bool {
+	const epsilon = 1e-3
+
+	return a-b < epsilon && b-a < epsilon
+}
@@ -0,0 +1,108 @@
+// Package spatial indexes points by position, for finding what's near
+// something without going through everything.
+package spatial
+
+import (
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+)
+
+type cell [2]int
+
+// Grid is a uniform grid spatial hash. Points are kept in square cells, so
+// a query only looks at the cells the query circle overlaps. The cell size
+// is best around the usual query radius.
+type Grid struct {
+	size  float64
+	cells map[cell][]int
+	pos   map[int]geom.Point
+}
+
+func New(cellSize float64) *Grid {
+	return &Grid{
+		size:  cellSize,
+		cells: make(map[cell][]int),
+		pos:   make(map[int]geom.Point),
+	}
+}
+
+func (g *Grid) cellAt(x, y float64) cell {
+	return cell{int(math.Floor(x / g.size)), int(math.Floor(y / g.size))}
+}
+
+// Set puts id at (x, y), adding it if it wasn't there, and reports whether
+// anything changed.
+func (g *Grid) Set(id int, x, y float64) bool {
+	p := geom.Point{X: x, Y: y}
+
+	old, ok := g.pos[id]
+	if ok && old == p {
+		return false
+	}
+
+	c := g.cellAt(x, y)
+	if ok {
+		if oc := g.cellAt(old.X, old.Y); oc != c {
+			g.remove(oc, id)
+			g.cells[c] = append(g.cells[c], id)
+		}
+	} else {
+		g.cells[c] = append(g.cells[c], id)
+	}
+
+	g.pos[id] = p
+
+	return true
+}
+
+// Remove takes id out of the grid, if it was there.
+func (g *Grid) Remove(id int) {
+	p, ok := g.pos[id]
+	if !ok {
+		return
+	}
+
+	g.remove(g.cellAt(p.X, p.Y), id)
+	delete(g.pos, id)
+}
+
+func (g *Grid) remove(c cell, id int) {
+	ids := g.cells[c]
+
+	for i, other := range ids {
+		if other == id {
+			ids[i] = ids[len(ids)-1]
+			ids = ids[:len(ids)-1]
+
+			break
+		}
+	}
+
+	if len(ids) == 0 {
+		delete(g.cells, c)
+	} else {
+		g.cells[c] = ids
+	}
+}
+
+// Near calls fn with every id within r of (x, y), in no particular order.
+func (g *Grid) Near(x, y, r float64, fn func(id int)) {
+	from, to := g.cellAt(x-r, y-r), g.cellAt(x+r, y+r)
+	center := geom.Point{X: x, Y: y}
+
+	for cy := from[1]; cy <= to[1]; cy++ {
+		for cx := from[0]; cx <= to[0]; cx++ {
+			for _, id := range g.cells[cell{cx, cy}] {
+				if g.pos[id].Dist(center) <= r {
+					fn(id)
+				}
+			}
+		}
+	}
+}
+
+// Len returns how many ids are in the grid.
+func (g *Grid) Len() int {
+	return len(g.pos)
+}
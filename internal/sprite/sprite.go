@@ -0,0 +1,249 @@
+// Package sprite plays frame animations out of sprite sheets: images with
+// frames of the same size in a grid, left to right and top to bottom.
+//
+// Sheets are described by JSON files next to their image, like:
+//
+//	{"image": "gopher-sheet.png", "frameWidth": 240, "frameHeight": 240,
+//	 "animations": [
+//		{"name": "idle", "frames": [0, 1, 2, 3], "ticks": 12, "loop": true}
+//	]}
+//
+// A Sheet and its animations are shared, each thing animated has its own
+// Player.
+package sprite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Animation is a named run of frames of a sheet.
+type Animation struct {
+	Name string `json:"name"`
+	// Frames of the sheet in order, a frame can be in it more than once
+	Frames []int `json:"frames"`
+	// Ticks each frame is shown
+	Ticks int `json:"ticks"`
+	// Start over after the last frame, instead of staying on it
+	Loop bool `json:"loop"`
+}
+
+// Len returns how long the animation runs in ticks, once through.
+func (a *Animation) Len() int {
+	return len(a.Frames) * a.Ticks
+}
+
+// Sheet is a sprite sheet and the animations in it.
+type Sheet struct {
+	Image       string       `json:"image"`
+	FrameWidth  int          `json:"frameWidth"`
+	FrameHeight int          `json:"frameHeight"`
+	Animations  []*Animation `json:"animations"`
+
+	img    *ebiten.Image
+	frames []*ebiten.Image
+	// The image on this side of the GPU, for reading pixels, which ebiten
+	// can't do before the game runs, and does slowly after
+	src image.Image
+}
+
+// Load reads a sheet description and its image, relative to it.
+func Load(path string) (*Sheet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sheet{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	src, err := decode(filepath.Join(filepath.Dir(path), s.Image))
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := ebiten.NewImageFromImage(src, ebiten.FilterDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.init(img, src); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// decode reads the image file at path.
+func decode(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return img, nil
+}
+
+// New returns a sheet of the image, cut in frames of w by h, with the
+// animations.
+func New(img *ebiten.Image, w, h int, anims ...*Animation) (*Sheet, error) {
+	s := &Sheet{FrameWidth: w, FrameHeight: h, Animations: anims}
+	if err := s.init(img, img); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// init cuts the image in frames and checks the animations against them.
+// src is the same image, to read its pixels from.
+func (s *Sheet) init(img *ebiten.Image, src image.Image) error {
+	if s.FrameWidth <= 0 || s.FrameHeight <= 0 {
+		return errors.New("frames have no size")
+	}
+
+	w, h := img.Size()
+	s.img, s.src = img, src
+	s.frames = nil
+
+	for y := 0; y+s.FrameHeight <= h; y += s.FrameHeight {
+		for x := 0; x+s.FrameWidth <= w; x += s.FrameWidth {
+			r := image.Rect(x, y, x+s.FrameWidth, y+s.FrameHeight)
+			s.frames = append(s.frames, img.SubImage(r).(*ebiten.Image))
+		}
+	}
+
+	for _, a := range s.Animations {
+		if len(a.Frames) == 0 || a.Ticks <= 0 {
+			return fmt.Errorf("animation %q has no frames or no timing", a.Name)
+		}
+
+		for _, f := range a.Frames {
+			if f < 0 || f >= len(s.frames) {
+				return fmt.Errorf("animation %q: no frame %d, the sheet has %d", a.Name, f, len(s.frames))
+			}
+		}
+	}
+
+	return nil
+}
+
+// Frame returns frame i of the sheet.
+func (s *Sheet) Frame(i int) *ebiten.Image {
+	return s.frames[i]
+}
+
+// Opaque returns whether frame i has anything drawn at (x, y), in the
+// frame's own coordinates.
+func (s *Sheet) Opaque(i, x, y int) bool {
+	if x < 0 || y < 0 || x >= s.FrameWidth || y >= s.FrameHeight {
+		return false
+	}
+
+	// Frames are parts of the sheet, in its coordinates
+	r := s.frames[i].Bounds()
+	_, _, _, a := s.src.At(r.Min.X+x, r.Min.Y+y).RGBA()
+
+	return a > 0
+}
+
+// Animation returns the animation with the name, nil if there's none.
+func (s *Sheet) Animation(name string) *Animation {
+	for _, a := range s.Animations {
+		if a.Name == name {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// Player plays the animations of a sheet, one at a time.
+type Player struct {
+	sheet *Sheet
+	anim  *Animation
+	// Ticks since the animation started
+	ticks int
+}
+
+// NewPlayer returns a player of the sheet, playing the named animation.
+func NewPlayer(s *Sheet, name string) *Player {
+	p := &Player{sheet: s}
+	p.Play(name)
+
+	return p
+}
+
+// Play switches to the named animation, from its start. Playing the one
+// already playing goes on with it, so it can be called every tick. Unknown
+// names are ignored.
+func (p *Player) Play(name string) {
+	if p.anim != nil && p.anim.Name == name {
+		return
+	}
+
+	if a := p.sheet.Animation(name); a != nil {
+		p.anim, p.ticks = a, 0
+	}
+}
+
+// Playing returns the name of the animation playing, empty if none is.
+func (p *Player) Playing() string {
+	if p.anim == nil {
+		return ""
+	}
+
+	return p.anim.Name
+}
+
+// Update moves the animation a tick on.
+func (p *Player) Update() {
+	if p.anim == nil {
+		return
+	}
+
+	p.ticks++
+	if p.anim.Loop {
+		p.ticks %= p.anim.Len()
+	}
+}
+
+// Index returns the frame of the sheet showing, within the animation.
+func (p *Player) Index() int {
+	if p.anim == nil {
+		return 0
+	}
+
+	i := p.ticks / p.anim.Ticks
+	if i >= len(p.anim.Frames) {
+		i = len(p.anim.Frames) - 1
+	}
+
+	return p.anim.Frames[i]
+}
+
+// Image returns the frame showing.
+func (p *Player) Image() *ebiten.Image {
+	return p.sheet.Frame(p.Index())
+}
+
+// Opaque returns whether the frame showing has anything drawn at (x, y).
+func (p *Player) Opaque(x, y int) bool {
+	return p.sheet.Opaque(p.Index(), x, y)
+}
@@ -0,0 +1,167 @@
+// Package stats keeps running totals of things done across all the
+// exercises, like polygons created or distance flown, in a file they all
+// share.
+//
+// Exercises report events with Add as they happen, and the totals get
+// merged into the file every now and then, in the background, and on Flush
+// before exiting. The -stats flag, registered on import, chooses the file.
+// Other packages can follow the events as they come with Hook.
+package stats
+
+import (
+	"flag"
+	"log"
+	"sync"
+	"time"
+)
+
+// Merge the pending totals into the file at most this often
+const flushEvery = 10 * time.Second
+
+// Event is something counted.
+type Event string
+
+const (
+	PolygonsCreated Event = "polygons_created"
+	ConnectionsMade Event = "connections_made"
+	TurnsPlayed     Event = "turns_played"
+	// In pixels
+	DistanceFlown Event = "distance_flown"
+)
+
+// Events lists all the events, with a description, in the order to show
+// them.
+//
+//nolint:gochecknoglobal
+var Events = []struct {
+	Event Event
+	Label string
+}{
+	{PolygonsCreated, "Polygons created"},
+	{ConnectionsMade, "Connections made"},
+	{TurnsPlayed, "Turns played"},
+	{DistanceFlown, "Distance flown"},
+}
+
+// Totals are the counts for each event.
+type Totals map[Event]float64
+
+//nolint:gochecknoglobal
+var (
+	path = flag.String("stats", "../stats.json", "file to keep the statistics of all exercises in")
+
+	mu sync.Mutex
+	// Not in the file yet, nor being merged into it
+	pending   = Totals{}
+	hooks     []func(e Event, n float64)
+	lastFlush = time.Now()
+	// A background flush is going
+	flushing bool
+
+	// Held while the file is read and written, without mu, so Add never
+	// waits on the disk
+	fileMu sync.Mutex
+)
+
+// Hook calls fn on every event reported from now on.
+func Hook(fn func(e Event, n float64)) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	hooks = append(hooks, fn)
+}
+
+// Add adds n to the event total. Every so often it merges the totals into
+// the file on a goroutine of its own, so the game doesn't wait on the disk,
+// and logs if that fails.
+func Add(e Event, n float64) {
+	mu.Lock()
+	pending[e] += n
+	fns := hooks
+	due := !flushing && time.Since(lastFlush) > flushEvery
+	flushing = flushing || due
+	mu.Unlock()
+
+	for _, fn := range fns {
+		fn(e, n)
+	}
+
+	if due {
+		go flushInBackground()
+	}
+}
+
+func flushInBackground() {
+	if err := Flush(); err != nil {
+		log.Printf("stats: saving %s: %v", *path, err)
+	}
+
+	mu.Lock()
+	flushing = false
+	mu.Unlock()
+}
+
+// Flush merges the pending totals into the file. The file is read again
+// first, so several exercises can share it. Only taking the pending totals
+// holds up Add, the file is read and written after, and the totals go back
+// to pending if that fails.
+func Flush() error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	mu.Lock()
+	lastFlush = time.Now()
+	batch := pending
+	pending = Totals{}
+	mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := merge(batch)
+	if err != nil {
+		mu.Lock()
+		for e, n := range batch {
+			pending[e] += n
+		}
+		mu.Unlock()
+	}
+
+	return err
+}
+
+// merge adds the totals to the ones in the file.
+func merge(batch Totals) error {
+	totals, err := load(*path)
+	if err != nil {
+		return err
+	}
+
+	for e, n := range batch {
+		totals[e] += n
+	}
+
+	return save(*path, totals)
+}
+
+// Load returns the totals so far, including those not in the file yet.
+func Load() (Totals, error) {
+	// No flush is halfway then, with totals in neither the file nor pending
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	totals, err := load(*path)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for e, n := range pending {
+		totals[e] += n
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,103 @@
+//go:build !js
+// +build !js
+
+package stats
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tempDir returns a directory of the test, removed when it ends.
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return dir
+}
+
+// usePath points the totals at a file of the test, with nothing pending
+// and no background flush due.
+func usePath(t *testing.T, p string) {
+	old := *path
+	*path, pending, lastFlush = p, Totals{}, time.Now()
+
+	t.Cleanup(func() { *path, pending = old, Totals{} })
+}
+
+func TestFlushMerges(t *testing.T) {
+	dir := tempDir(t)
+	usePath(t, filepath.Join(dir, "stats.json"))
+
+	if err := save(*path, Totals{TurnsPlayed: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	Add(TurnsPlayed, 3)
+	Add(DistanceFlown, 1.5)
+
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != 0 {
+		t.Errorf("pending after flushing: %v", pending)
+	}
+
+	totals, err := load(*path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if totals[TurnsPlayed] != 5 || totals[DistanceFlown] != 1.5 {
+		t.Errorf("totals in the file: %v, want 5 turns and 1.5 flown", totals)
+	}
+
+	// Only the file is left, no temporary ones
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 {
+		t.Errorf("%d files in the directory, want only the totals", len(files))
+	}
+}
+
+func TestFlushFailureKeepsPending(t *testing.T) {
+	dir := tempDir(t)
+	usePath(t, filepath.Join(dir, "missing", "stats.json"))
+
+	Add(PolygonsCreated, 4)
+
+	if err := Flush(); err == nil {
+		t.Fatal("flushing into a missing directory didn't fail")
+	}
+
+	Add(PolygonsCreated, 1)
+
+	// The directory is there now, the next flush saves both
+	if err := os.Mkdir(filepath.Dir(*path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	totals, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if totals[PolygonsCreated] != 5 {
+		t.Errorf("polygons created: %v, want 5", totals[PolygonsCreated])
+	}
+}
@@ -0,0 +1,64 @@
+//go:build !js
+// +build !js
+
+package stats
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// load reads the totals file, a missing one being no totals yet.
+func load(path string) (Totals, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Totals{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	totals := Totals{}
+	if err := json.Unmarshal(data, &totals); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+func save(path string, totals Totals) error {
+	data, err := json.MarshalIndent(totals, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Written aside and renamed, so an exercise exiting halfway through
+	// doesn't lose the totals. The temporary file is one of its own, so two
+	// exercises saving at once don't write into the same one
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+
+	return err
+}
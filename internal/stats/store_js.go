@@ -0,0 +1,25 @@
+//go:build js
+// +build js
+
+package stats
+
+// There's no file to keep them in the browser, the totals only last while
+// the page is open.
+//
+//nolint:gochecknoglobal
+var browserTotals = Totals{}
+
+func load(path string) (Totals, error) {
+	totals := Totals{}
+	for e, n := range browserTotals {
+		totals[e] = n
+	}
+
+	return totals, nil
+}
+
+func save(path string, totals Totals) error {
+	browserTotals = totals
+
+	return nil
+}
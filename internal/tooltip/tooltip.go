@@ -0,0 +1,127 @@
+// Package tooltip is a small text box shown next to the cursor after it
+// stays for a while over something, fading in, and kept fully on screen.
+package tooltip
+
+import (
+	"image/color"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Size of the ebitenutil debug font glyphs
+	charWidth  = 6
+	lineHeight = 16
+	padding    = 4
+	// Distance from the cursor, so it doesn't cover what it's about
+	offset = 12
+)
+
+var (
+	//nolint:gochecknoglobal
+	boxColor = color.RGBA{0x20, 0x20, 0x20, 0xe0}
+)
+
+// Tooltip shows the text of whatever is being hovered. Delay and Fade are
+// in ticks.
+type Tooltip struct {
+	Delay int
+	Fade  int
+
+	key   string
+	text  string
+	ticks int
+	x     int
+	y     int
+}
+
+// New returns a tooltip with the usual half a second delay and a quick
+// fade in, at the default TPS.
+func New() *Tooltip {
+	return &Tooltip{Delay: 30, Fade: 10}
+}
+
+// Update tells what's under the cursor at (x, y). key identifies it, so
+// the delay starts over when the cursor moves to something else, and an
+// empty key is nothing at all. text can change while hovering the same
+// thing.
+func (t *Tooltip) Update(key, text string, x, y int) {
+	if key != t.key {
+		t.key = key
+		t.ticks = 0
+	}
+
+	t.text = text
+	t.x, t.y = x, y
+
+	if key != "" {
+		t.ticks++
+	}
+}
+
+func (t *Tooltip) Draw(screen *ebiten.Image) {
+	if t.key == "" || t.ticks < t.Delay {
+		return
+	}
+
+	alpha := 1.0
+	if t.Fade > 0 && t.ticks-t.Delay < t.Fade {
+		alpha = float64(t.ticks-t.Delay) / float64(t.Fade)
+	}
+
+	lines := strings.Split(t.text, "\n")
+	cols := 0
+
+	for _, l := range lines {
+		if n := utf8.RuneCountInString(l); n > cols {
+			cols = n
+		}
+	}
+
+	w := cols*charWidth + 2*padding
+	h := len(lines)*lineHeight + 2*padding
+	sw, sh := screen.Size()
+
+	// Below and right of the cursor, flipped to the other side if it
+	// doesn't fit, and clamped for screens too small for either
+	x, y := t.x+offset, t.y+offset
+	if x+w > sw {
+		x = t.x - offset - w
+	}
+
+	if y+h > sh {
+		y = t.y - offset - h
+	}
+
+	x = clamp(x, 0, sw-w)
+	y = clamp(y, 0, sh-h)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(w), float64(h))
+	op.GeoM.Translate(float64(x), float64(y))
+	op.ColorM.Scale(shapes.ColorScale(boxColor))
+	op.ColorM.Scale(1, 1, 1, alpha)
+	_ = screen.DrawImage(shapes.EmptyImage, op)
+
+	// The debug font can't be faded, so the text shows up once the box is
+	// mostly there
+	if alpha > 0.5 {
+		ebitenutil.DebugPrintAt(screen, t.text, x+padding, y+padding)
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+
+	if v < lo {
+		v = lo
+	}
+
+	return v
+}
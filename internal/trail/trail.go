@@ -0,0 +1,109 @@
+// Package trail draws ribbon trails behind moving things, like ships,
+// projectiles or comets: a triangle strip through their latest positions,
+// tapering and fading towards the oldest one.
+package trail
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Trail keeps the latest Length positions pushed to it. Width is the width
+// of the ribbon at the newest position, in the units of the positions.
+type Trail struct {
+	Length int
+	Width  float64
+	Color  color.Color
+
+	points []geom.Point
+	// Reused between draws
+	vs      []ebiten.Vertex
+	indices []uint16
+}
+
+func New(length int, width float64, clr color.Color) *Trail {
+	return &Trail{Length: length, Width: width, Color: clr}
+}
+
+// Push adds the current position, dropping the oldest one if the trail is
+// full. Pushing the same position every tick while still makes the trail
+// shrink away.
+func (t *Trail) Push(x, y float64) {
+	t.points = append(t.points, geom.Point{X: x, Y: y})
+	if len(t.points) > t.Length {
+		t.points = t.points[len(t.points)-t.Length:]
+	}
+}
+
+func (t *Trail) Reset() {
+	t.points = t.points[:0]
+}
+
+// Draw draws the trail with geoM applied to the positions, so they can be
+// kept in world coordinates.
+func (t *Trail) Draw(screen *ebiten.Image, geoM ebiten.GeoM) {
+	n := len(t.points)
+	if n < 2 {
+		return
+	}
+
+	r, g, b, a := shapes.ColorScale(t.Color)
+	t.vs = t.vs[:0]
+	t.indices = t.indices[:0]
+
+	// Sideways direction along the strip, kept from the previous point
+	// when it doesn't move
+	var nx, ny float64
+
+	for i, p := range t.points {
+		prev, next := t.points[max(i-1, 0)], t.points[min(i+1, n-1)]
+		dx, dy := next.X-prev.X, next.Y-prev.Y
+
+		if l := math.Hypot(dx, dy); l > 0 {
+			nx, ny = -dy/l, dx/l
+		}
+
+		// 0 at the tail, 1 at the head
+		age := float64(i) / float64(n-1)
+		w := t.Width * age / 2
+
+		for _, side := range []float64{-1, 1} {
+			x, y := geoM.Apply(p.X+nx*w*side, p.Y+ny*w*side)
+			t.vs = append(t.vs, ebiten.Vertex{
+				DstX:   float32(x),
+				DstY:   float32(y),
+				ColorR: float32(r),
+				ColorG: float32(g),
+				ColorB: float32(b),
+				ColorA: float32(a * age),
+			})
+		}
+
+		if i > 0 {
+			j := uint16(2 * (i - 1))
+			t.indices = append(t.indices, j, j+1, j+2, j+1, j+3, j+2)
+		}
+	}
+
+	screen.DrawTriangles(t.vs, t.indices, shapes.EmptyImage, nil)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,211 @@
+// Package tutorial runs scripted onboarding steps on top of an exercise: each
+// step shows some text, optionally highlights a part of the screen or a
+// tile, and waits for the player to do what it asks before moving on.
+//
+// Scripts are JSON files like:
+//
+//	{"steps": [
+//		{"text": "This is your unit", "tile": [1, 1]},
+//		{"text": "Press Tab to select the next one", "action": "select"}
+//	]}
+//
+// The exercise reports what the player does with Do, using whatever action
+// names make sense for it. Steps without an action are just read, and move
+// on with Enter, or A on a gamepad. Steps can have a "pad" text too, shown
+// instead while the player is on a gamepad.
+package tutorial
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+var (
+	//nolint:gochecknoglobal
+	boxColor       = color.RGBA{0, 0, 0, 0xd0}
+	highlightColor = color.RGBA{0xff, 0x80, 0, 0xff}
+)
+
+// Step is a single tutorial step.
+type Step struct {
+	Text string `json:"text"`
+	// Text for players on a gamepad, if it's different
+	Pad string `json:"pad,omitempty"`
+	// Action the player has to do for the tutorial to go on, empty to just
+	// wait for Enter
+	Action string `json:"action,omitempty"`
+	// Screen rectangle to highlight, as x, y, width and height
+	Highlight *[4]int `json:"highlight,omitempty"`
+	// Tile to highlight, mapped to the screen with Tutorial.TileRect
+	Tile *[2]int `json:"tile,omitempty"`
+}
+
+// Tutorial is a script being played. A nil Tutorial is a finished one, so
+// exercises can call it unconditionally.
+type Tutorial struct {
+	Steps []Step `json:"steps"`
+	// TileRect returns the screen rectangle of a tile, for steps
+	// highlighting tiles
+	TileRect func(x, y int) image.Rectangle
+	// Show the gamepad texts, see keymap.Device. Gamepads have no F1, the
+	// exercise offers Skip some other way, like in a menu
+	Pad bool
+
+	current int
+	ticks   int
+}
+
+// Load reads a script, a missing file is no tutorial at all (nil).
+func Load(path string) (*Tutorial, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tutorial{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return t, nil
+}
+
+// Done reports whether all the steps are done, or the tutorial was skipped.
+func (t *Tutorial) Done() bool {
+	return t == nil || t.current >= len(t.Steps)
+}
+
+// Step returns the current step, nil when done.
+func (t *Tutorial) Step() *Step {
+	if t.Done() {
+		return nil
+	}
+
+	return &t.Steps[t.current]
+}
+
+// Skip ends the tutorial.
+func (t *Tutorial) Skip() {
+	if !t.Done() {
+		t.current = len(t.Steps)
+	}
+}
+
+func (t *Tutorial) next() {
+	t.current++
+	t.ticks = 0
+}
+
+// Do reports an action done by the player, moving on if it's the one the
+// current step waits for.
+func (t *Tutorial) Do(action string) {
+	if s := t.Step(); s != nil && s.Action != "" && s.Action == action {
+		t.next()
+	}
+}
+
+// Update handles the tutorial keys: Enter moves on from steps without an
+// action, F1 skips the whole tutorial. It reports whether the input was
+// consumed, in which case the exercise should ignore it this tick.
+func (t *Tutorial) Update() bool {
+	if t.Done() {
+		return false
+	}
+
+	t.ticks++
+
+	if keymap.IsKeyJustPressed(ebiten.KeyF1) {
+		t.Skip()
+
+		return true
+	}
+
+	if t.Steps[t.current].Action == "" && (keymap.IsKeyJustPressed(ebiten.KeyEnter) || keymap.IsPadJustPressed(keymap.PadA)) {
+		t.next()
+
+		return true
+	}
+
+	return false
+}
+
+func (t *Tutorial) Draw(screen *ebiten.Image) {
+	s := t.Step()
+	if s == nil {
+		return
+	}
+
+	var r image.Rectangle
+
+	switch {
+	case s.Highlight != nil:
+		h := s.Highlight
+		r = image.Rect(h[0], h[1], h[0]+h[2], h[1]+h[3])
+	case s.Tile != nil && t.TileRect != nil:
+		r = t.TileRect(s.Tile[0], s.Tile[1])
+	}
+
+	// Blink the highlight, twice a second at the default TPS
+	if !r.Empty() && t.ticks/15%2 == 0 {
+		drawFrame(screen, r, highlightColor)
+	}
+
+	text, next, skip := s.Text, "Enter", "F1 to skip"
+	if t.Pad {
+		next, skip = "A", "skip it from the menu"
+		if s.Pad != "" {
+			text = s.Pad
+		}
+	}
+
+	if s.Action == "" {
+		text += fmt.Sprintf("\n(%s to continue)", next)
+	}
+
+	text += fmt.Sprintf("\nTutorial %d/%d, %s", t.current+1, len(t.Steps), skip)
+
+	w, h := screen.Size()
+	bh := 16*(strings.Count(text, "\n")+1) + 8
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(w), float64(bh))
+	op.GeoM.Translate(0, float64(h-bh))
+	op.ColorM.Scale(shapes.ColorScale(boxColor))
+	_ = screen.DrawImage(shapes.EmptyImage, op)
+
+	ebitenutil.DebugPrintAt(screen, text, 8, h-bh+4)
+}
+
+func drawFrame(screen *ebiten.Image, r image.Rectangle, clr color.Color) {
+	const width = 2
+
+	x, y := float64(r.Min.X), float64(r.Min.Y)
+	w, h := float64(r.Dx()), float64(r.Dy())
+
+	for _, s := range [...][4]float64{
+		{x - width, y - width, w + 2*width, width},
+		{x - width, y + h, w + 2*width, width},
+		{x - width, y, width, h},
+		{x + w, y, width, h},
+	} {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(s[2], s[3])
+		op.GeoM.Translate(s[0], s[1])
+		op.ColorM.Scale(shapes.ColorScale(clr))
+		_ = screen.DrawImage(shapes.EmptyImage, op)
+	}
+}
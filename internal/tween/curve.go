@@ -0,0 +1,137 @@
+package tween
+
+import "sort"
+
+// Key is a point a Curve goes through: the value V it has at the time T.
+type Key struct {
+	T, V float64
+}
+
+// Curve is a value over time, going smoothly through its keys, for easings
+// and animations drawn by hand instead of written down. Times go from 0 to
+// 1, and values usually too. Between keys it's a Catmull-Rom spline, so it
+// can overshoot a bit; before the first key and after the last one it's
+// flat.
+type Curve struct {
+	// Sorted by time, at least two
+	Keys []Key
+}
+
+// NewCurve returns a curve through the keys, in any order.
+func NewCurve(keys ...Key) *Curve {
+	c := &Curve{Keys: append([]Key(nil), keys...)}
+	sort.Slice(c.Keys, func(i, j int) bool { return c.Keys[i].T < c.Keys[j].T })
+
+	return c
+}
+
+// Copy returns a curve with the same keys, to edit apart.
+func (c *Curve) Copy() *Curve {
+	return &Curve{Keys: append([]Key(nil), c.Keys...)}
+}
+
+// At returns the value of the curve at time t.
+func (c *Curve) At(t float64) float64 {
+	ks := c.Keys
+
+	switch {
+	case len(ks) == 0:
+		return 0
+	case t <= ks[0].T:
+		return ks[0].V
+	case t >= ks[len(ks)-1].T:
+		return ks[len(ks)-1].V
+	}
+
+	i := sort.Search(len(ks), func(i int) bool { return ks[i].T > t }) - 1
+	a, b := ks[i], ks[i+1]
+
+	dt := b.T - a.T
+	if dt <= 0 {
+		return b.V
+	}
+
+	// Cubic Hermite with the tangents of the neighbours
+	s := (t - a.T) / dt
+	s2, s3 := s*s, s*s*s
+	m0, m1 := c.tangent(i)*dt, c.tangent(i+1)*dt
+
+	return (2*s3-3*s2+1)*a.V + (s3-2*s2+s)*m0 + (-2*s3+3*s2)*b.V + (s3-s2)*m1
+}
+
+// tangent is the slope of the curve at the ith key, the one from the key
+// before to the one after, or to the key itself at the ends.
+func (c *Curve) tangent(i int) float64 {
+	ks := c.Keys
+	a, b := ks[max(i-1, 0)], ks[min(i+1, len(ks)-1)]
+
+	if b.T <= a.T {
+		return 0
+	}
+
+	return (b.V - a.V) / (b.T - a.T)
+}
+
+// Easing returns the curve as an easing.
+func (c *Curve) Easing() Easing {
+	return c.At
+}
+
+// Move moves the ith key to (t, v), keeping it between its neighbours in
+// time. The first and the last keys stay at their times, so the curve keeps
+// its length.
+func (c *Curve) Move(i int, t, v float64) {
+	ks := c.Keys
+
+	switch {
+	case i == 0 || i == len(ks)-1:
+		t = ks[i].T
+	default:
+		t = Clamp(t, ks[i-1].T, ks[i+1].T)
+	}
+
+	ks[i] = Key{t, v}
+}
+
+// Insert adds a key at (t, v), within the first and the last keys, and
+// returns its index.
+func (c *Curve) Insert(t, v float64) int {
+	ks := c.Keys
+	t = Clamp(t, ks[0].T, ks[len(ks)-1].T)
+	i := sort.Search(len(ks), func(i int) bool { return ks[i].T > t })
+
+	// The first and the last keys stay where they are
+	i = max(1, min(i, len(ks)-1))
+
+	c.Keys = append(ks[:i], append([]Key{{t, v}}, ks[i:]...)...)
+
+	return i
+}
+
+// Remove takes the ith key out, and reports whether it could: the first and
+// the last ones stay.
+func (c *Curve) Remove(i int) bool {
+	if i <= 0 || i >= len(c.Keys)-1 {
+		return false
+	}
+
+	c.Keys = append(c.Keys[:i], c.Keys[i+1:]...)
+
+	return true
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,72 @@
+// Package tween has small helpers to interpolate values over time.
+package tween
+
+// Easing maps a linear progress t in [0, 1] to an eased progress, also
+// (usually) in [0, 1].
+type Easing func(t float64) float64
+
+// Linear is the identity easing.
+func Linear(t float64) float64 {
+	return t
+}
+
+// InQuad starts slow and accelerates.
+func InQuad(t float64) float64 {
+	return t * t
+}
+
+// OutQuad starts fast and decelerates.
+func OutQuad(t float64) float64 {
+	return t * (2 - t)
+}
+
+// InOutQuad accelerates until halfway, then decelerates.
+func InOutQuad(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+
+	return -1 + (4-2*t)*t
+}
+
+// InOutCubic is like InOutQuad but with a more pronounced curve.
+func InOutCubic(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+
+	t = 2*t - 2
+
+	return 1 + t*t*t/2
+}
+
+// Clamp01 clamps t to [0, 1].
+func Clamp01(t float64) float64 {
+	if t < 0 {
+		return 0
+	}
+
+	if t > 1 {
+		return 1
+	}
+
+	return t
+}
+
+// Clamp clamps t to [lo, hi].
+func Clamp(t, lo, hi float64) float64 {
+	if t < lo {
+		return lo
+	}
+
+	if t > hi {
+		return hi
+	}
+
+	return t
+}
+
+// Lerp linearly interpolates between a and b.
+func Lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/antoniomo/ebiten-exercises/internal/buildinfo"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/antoniomo/ebiten-exercises/internal/scene"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	screenWidth  = 640
+	screenHeight = 480
+)
+
+var (
+	// Every exercise is its own module, next to this one
+	exercises = []string{
+		"hello-world",
+		"fill",
+		"render",
+		"geometry-matrix",
+		"basic-input",
+		"turns",
+		"polygon-making",
+		"shapes-gg",
+		"connect-lines",
+		"starfield",
+	}
+)
+
+type Game struct {
+	selected int
+	scenes   *scene.Manager
+}
+
+// menu lists the exercises.
+type menu struct {
+	g    *Game
+	keys keymap.Map
+}
+
+func newMenu(g *Game) *menu {
+	return &menu{g: g, keys: keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyUp, ebiten.KeyW), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			g.selected = (g.selected + len(exercises) - 1) % len(exercises)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyDown, ebiten.KeyS), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			g.selected = (g.selected + 1) % len(exercises)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEnter), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.scenes.Push(newRunning(g, exercises[g.selected]), scene.NewFade(500*time.Millisecond))
+		})},
+		{Keys: keymap.Keys(ebiten.KeyT), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.scenes.Push(newStatsScreen(g), scene.NewFade(500*time.Millisecond))
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Action: func() error { return run.ErrCleanExit }},
+	}}
+}
+
+func (m *menu) Update() error {
+	return m.keys.Update()
+}
+
+func (m *menu) Draw(screen *ebiten.Image) {
+	var sb strings.Builder
+
+	sb.WriteString("Ebiten exercises, Enter to launch, T for statistics, Esc to quit\n\n")
+
+	for i, e := range exercises {
+		if i == m.g.selected {
+			sb.WriteString("> ")
+		} else {
+			sb.WriteString("  ")
+		}
+
+		sb.WriteString(e + "\n")
+	}
+
+	ebitenutil.DebugPrint(screen, sb.String())
+}
+
+// running waits for a launched exercise to finish.
+type running struct {
+	g    *Game
+	name string
+	done chan error
+}
+
+func newRunning(g *Game, name string) *running {
+	r := &running{
+		g:    g,
+		name: name,
+		done: make(chan error, 1),
+	}
+
+	go func() {
+		// Exercises load their assets relative to their own directory, and
+		// come from the same checkout as the launcher, the same build
+		args := []string{"run", "."}
+		if flags := buildinfo.Flags(); flags != "" {
+			args = []string{"run", "-ldflags", flags, "."}
+		}
+
+		cmd := exec.Command("go", args...)
+		cmd.Dir = "../" + name
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		r.done <- cmd.Run()
+	}()
+
+	return r
+}
+
+func (r *running) Update() error {
+	select {
+	case err := <-r.done:
+		if err != nil {
+			log.Printf("%s: %v", r.name, err)
+		}
+
+		r.g.scenes.Pop(scene.NewPixelate(500 * time.Millisecond))
+	default:
+	}
+
+	return nil
+}
+
+func (r *running) Draw(screen *ebiten.Image) {
+	ebitenutil.DebugPrint(screen, "Running "+r.name+"...")
+}
+
+func (g *Game) Update(screen *ebiten.Image) error {
+	return g.scenes.Update()
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	g.scenes.Draw(screen)
+}
+
+func (g *Game) Layout(outsideWidth, outsideHeight int) (screenW, screenH int) {
+	return screenWidth, screenHeight
+}
+
+func main() {
+	flag.Parse()
+
+	g := &Game{}
+	g.scenes = scene.NewManager(newMenu(g))
+
+	ebiten.SetWindowSize(screenWidth, screenHeight)
+	ebiten.SetWindowTitle("Launcher")
+	// Keep ticking while the launched exercise has the focus, to notice when
+	// it's done
+	ebiten.SetRunnableOnUnfocused(true)
+
+	run.Exit(run.Game(g))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/scene"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// statsScreen shows the totals of everything done in the exercises.
+type statsScreen struct {
+	g      *Game
+	totals stats.Totals
+	err    error
+	keys   keymap.Map
+}
+
+func newStatsScreen(g *Game) *statsScreen {
+	// Read when shown, the exercises update the file as they run
+	totals, err := stats.Load()
+
+	return &statsScreen{g: g, totals: totals, err: err, keys: keymap.Map{
+		// Not Esc, holding it a bit too long would quit from the menu
+		{Keys: keymap.Keys(ebiten.KeyEnter, ebiten.KeyT), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.scenes.Pop(scene.NewFade(500 * time.Millisecond))
+		})},
+	}}
+}
+
+func (s *statsScreen) Update() error {
+	return s.keys.Update()
+}
+
+func (s *statsScreen) Draw(screen *ebiten.Image) {
+	var sb strings.Builder
+
+	sb.WriteString("Statistics of all exercises, Enter to go back\n\n")
+
+	if s.err != nil {
+		sb.WriteString("Can't read them: " + s.err.Error() + "\n")
+	}
+
+	for _, e := range stats.Events {
+		fmt.Fprintf(&sb, "%-20s %10.0f\n", e.Label, s.totals[e.Event])
+	}
+
+	ebitenutil.DebugPrint(screen, sb.String())
+}
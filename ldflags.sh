@@ -0,0 +1,15 @@
+#!/usr/bin/env bash
+# Prints the ldflags that stamp a build with the git commit and the date,
+# see internal/buildinfo. From an exercise directory:
+#
+#   go build -ldflags "$(../ldflags.sh)" .
+
+pkg=github.com/antoniomo/ebiten-exercises/internal/buildinfo
+commit="$(git rev-parse --short HEAD)"
+
+# Uncommitted changes are not the commit
+if ! git diff --quiet HEAD; then
+	commit="$commit-dirty"
+fi
+
+echo "-X $pkg.Commit=$commit -X $pkg.Date=$(date -u +%Y-%m-%d)"
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Half the size of the joint markers, in pixels
+	jointSize = 3
+)
+
+//nolint:gochecknoglobal
+var boneColor = color.RGBA{0xff, 0xa0, 0x20, 0xff}
+
+// bone links a polygon to its parent, forward kinematics style: the polygon
+// keeps its offset and rotation in the parent's frame, so moving or
+// rotating the parent carries it along, and rotating the polygon poses it
+// and whatever hangs from it.
+type bone struct {
+	parent   *Polygon
+	children []*Polygon
+	// Transform in the parent's frame
+	x, y, theta float64
+	// World transform as last posed, anything else means the polygon was
+	// moved or rotated by itself since, and the bone has to follow
+	posed transform
+}
+
+// ancestorOf reports whether p is q or one of its ancestors.
+func (p *Polygon) ancestorOf(q *Polygon) bool {
+	for ; q != nil; q = q.bone.parent {
+		if q == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// attach parents p to parent, keeping p where it is. It reports false,
+// leaving everything as it was, if that would make a loop.
+func (p *Polygon) attach(parent *Polygon) bool {
+	if p.ancestorOf(parent) {
+		return false
+	}
+
+	p.detach()
+	p.bone.parent = parent
+	parent.bone.children = append(parent.bone.children, p)
+	p.rebind()
+
+	return true
+}
+
+// detach takes p off its parent, if any, keeping it and its children where
+// they are.
+func (p *Polygon) detach() {
+	parent := p.bone.parent
+	if parent == nil {
+		return
+	}
+
+	for i, c := range parent.bone.children {
+		if c == p {
+			parent.bone.children = append(parent.bone.children[:i], parent.bone.children[i+1:]...)
+
+			break
+		}
+	}
+
+	p.bone.parent = nil
+}
+
+// rebind takes the current world transform of p as its transform in the
+// parent's frame.
+func (p *Polygon) rebind() {
+	parent := p.bone.parent
+	dx, dy := float64(p.x-parent.x), float64(p.y-parent.y)
+	s, c := math.Sincos(-parent.theta)
+
+	p.bone.x, p.bone.y = dx*c-dy*s, dx*s+dy*c
+	p.bone.theta = p.theta - parent.theta
+	p.bone.posed = transform{p.x, p.y, p.theta, false}
+}
+
+// pose places the children of p from its world transform, all the way
+// down. Children that moved by themselves since the last pose keep their
+// new place in the parent's frame.
+func (p *Polygon) pose() {
+	s, c := math.Sincos(p.theta)
+
+	for _, q := range p.bone.children {
+		if (transform{q.x, q.y, q.theta, false}) != q.bone.posed {
+			q.rebind()
+		}
+
+		q.x = p.x + int(math.Round(q.bone.x*c-q.bone.y*s))
+		q.y = p.y + int(math.Round(q.bone.x*s+q.bone.y*c))
+		q.theta = p.theta + q.bone.theta
+		q.bone.posed = transform{q.x, q.y, q.theta, false}
+		q.pose()
+	}
+}
+
+// updateBones poses every hierarchy from its root.
+func (g *Game) updateBones() {
+	for _, p := range g.p {
+		if p.bone.parent == nil {
+			p.pose()
+		}
+	}
+}
+
+// parentAtCursor parents the active polygon to the one under the mouse.
+func (g *Game) parentAtCursor() {
+	active := g.p[g.activePolygon]
+	cx, cy := keymap.Input().CursorPosition()
+
+	for i := len(g.p) - 1; i >= 0; i-- {
+		p := g.p[i]
+		if p == active || !g.hit(p, cx, cy) {
+			continue
+		}
+
+		if !active.attach(p) {
+			g.notify.Push("%s can't hang from its own child %s", active.id, p.id)
+
+			return
+		}
+
+		g.showBones = true
+		g.notify.Push("%s parented to %s", active.id, p.id)
+
+		return
+	}
+
+	g.notify.Push("Point at the polygon to parent %s to", active.id)
+}
+
+// unparent detaches the active polygon, which becomes a root.
+func (g *Game) unparent() {
+	active := g.p[g.activePolygon]
+	if active.bone.parent == nil {
+		return
+	}
+
+	g.notify.Push("%s detached from %s", active.id, active.bone.parent.id)
+	active.detach()
+}
+
+// drawBones draws a line from every parent to its children, with a marker
+// at each joint.
+func (g *Game) drawBones(screen *ebiten.Image) {
+	if !g.showBones {
+		return
+	}
+
+	for _, p := range g.p {
+		if p.bone.parent == nil && len(p.bone.children) == 0 {
+			continue
+		}
+
+		x, y := float64(p.x), float64(p.y)
+		ebitenutil.DrawRect(screen, x-jointSize, y-jointSize, 2*jointSize, 2*jointSize, boneColor)
+
+		for _, q := range p.bone.children {
+			ebitenutil.DrawLine(screen, x, y, float64(q.x), float64(q.y), boneColor)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Alpha of the red over the overlapping polygons
+const overlapAlpha = 0.6
+
+// collisions is collision mode (C): polygons overlapping each other are
+// drawn red, and the active one can't be moved into another one, with the
+// keys or dragging it. Polygons are tested triangle by triangle of their
+// meshes as drawn, each triangle against the other's with the separating
+// axis theorem, so concave ones collide by their actual shape.
+type collisions struct {
+	enabled bool
+	// Polygons overlapping another, this tick
+	overlapping map[*Polygon]bool
+}
+
+// triangles returns the triangles of the mesh where they're drawn on the
+// screen, but the hidden ones.
+func (p *Polygon) triangles() [][]geom.Point {
+	tris := p.screenTriangles()
+	if len(p.hidden) == 0 {
+		return tris
+	}
+
+	shown := tris[:0]
+	for t, tri := range tris {
+		if !p.hidden[t] {
+			shown = append(shown, tri)
+		}
+	}
+
+	return shown
+}
+
+// screenTriangles returns every triangle of the mesh where it's drawn on
+// the screen, the transform of drawAt applied, hidden or not.
+func (p *Polygon) screenTriangles() [][]geom.Point {
+	r := float64(p.radius)
+	sin, cos := math.Sincos(p.theta)
+	tris := make([][]geom.Point, 0, len(p.indices)/3)
+
+	for i := 0; i+2 < len(p.indices); i += 3 {
+		tri := make([]geom.Point, 3)
+
+		for j := range tri {
+			v := p.vs[p.indices[i+j]]
+			lx, ly := (float64(v.DstX)-r)*p.scale, (float64(v.DstY)-r)*p.scale
+			tri[j] = geom.Point{X: lx*cos - ly*sin + float64(p.x), Y: lx*sin + ly*cos + float64(p.y)}
+		}
+
+		tris = append(tris, tri)
+	}
+
+	return tris
+}
+
+// collides reports whether the polygons overlap. Those further apart than
+// the corners of their images can't, and aren't tested triangle by
+// triangle.
+func collides(p, q *Polygon) bool {
+	reach := (float64(p.radius)*p.scale + float64(q.radius)*q.scale) * math.Sqrt2
+	if math.Hypot(float64(p.x-q.x), float64(p.y-q.y)) >= reach {
+		return false
+	}
+
+	qt := q.triangles()
+
+	for _, a := range p.triangles() {
+		for _, b := range qt {
+			if geom.Overlap(a, b) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// overlaps returns the polygons p overlaps.
+func (g *Game) overlaps(p *Polygon) map[*Polygon]bool {
+	found := map[*Polygon]bool{}
+
+	for _, q := range g.p {
+		if q != p && collides(p, q) {
+			found[q] = true
+		}
+	}
+
+	return found
+}
+
+// moveBy is MoveBy for the active polygon, but in collision mode it doesn't
+// go into polygons it wasn't already overlapping. Blocked one way, it still
+// slides along the other, and blocked both it stays where it was, which
+// sounds like hitting the edge of the screen.
+func (g *Game) moveBy(p *Polygon, x, y int) {
+	if !g.collisions.enabled {
+		if p.MoveBy(x, y) {
+			g.clampFeedback()
+		}
+
+		return
+	}
+
+	before := g.overlaps(p)
+	blocked := false
+
+	for _, d := range [...][2]int{{x, 0}, {0, y}} {
+		if d[0] == 0 && d[1] == 0 {
+			continue
+		}
+
+		px, py := p.x, p.y
+		clamped := p.MoveBy(d[0], d[1])
+
+		for q := range g.overlaps(p) {
+			if !before[q] {
+				p.x, p.y = px, py
+				clamped = true
+
+				break
+			}
+		}
+
+		blocked = blocked || clamped
+	}
+
+	if blocked {
+		g.clampFeedback()
+	}
+}
+
+func (g *Game) toggleCollisions() {
+	g.collisions.enabled = !g.collisions.enabled
+	g.collisions.overlapping = nil
+
+	if g.collisions.enabled {
+		g.notify.Push("Collisions on")
+	} else {
+		g.notify.Push("Collisions off")
+	}
+}
+
+// Update finds the overlapping polygons, in collision mode.
+func (c *collisions) Update(g *Game) {
+	if !c.enabled {
+		return
+	}
+
+	c.overlapping = map[*Polygon]bool{}
+
+	for i, p := range g.p {
+		for _, q := range g.p[i+1:] {
+			if collides(p, q) {
+				c.overlapping[p] = true
+				c.overlapping[q] = true
+			}
+		}
+	}
+}
+
+// Draw draws the overlapping polygons over in red.
+func (c *collisions) Draw(screen *ebiten.Image) {
+	for p := range c.overlapping {
+		op := p.drawOptions(transform{p.x, p.y, p.theta, false})
+		op.ColorM.Scale(0, 0, 0, overlapAlpha)
+		op.ColorM.Translate(1, 0, 0, 0)
+		_ = screen.DrawImage(p.img, op)
+	}
+}
+
+// HUD describes collision mode.
+func (c *collisions) HUD() string {
+	if !c.enabled {
+		return "Collisions: off (C)"
+	}
+
+	return "Collisions: on (C), overlapping in red"
+}
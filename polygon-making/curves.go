@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/tween"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// The plot of the curve editor, and the room around it
+	curveX      = 16
+	curveY      = screenHeight - 24 - curveHeight
+	curveWidth  = 240
+	curveHeight = 120
+	curveMargin = 8
+	// Samples the curve is drawn with
+	curveSamples = 64
+	// How close to a key a click has to be to grab it, in pixels
+	keyGrab = 5
+	// Length of the loop, in ticks, how much Up and Down change it, and
+	// the shortest and longest
+	loopTicks    = 120
+	loopStep     = 30
+	minLoopTicks = 30
+	maxLoopTicks = 600
+	// Scales the values from 0 to 1 go to, animating the scale
+	minAnimScale = 0.5
+	maxAnimScale = 1.5
+)
+
+//nolint:gochecknoglobal
+var (
+	curveColor     = color.RGBA{0x40, 0xc0, 0xff, 0xff}
+	keyColor       = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	playheadColor  = color.RGBA{0xff, 0x60, 0x60, 0xff}
+	curveGridColor = color.RGBA{0x40, 0x40, 0x40, 0xff}
+)
+
+// animProp is what an animation drives.
+type animProp int
+
+const (
+	animNone animProp = iota
+	animRotation
+	animScale
+)
+
+func (a animProp) String() string {
+	return [...]string{"none", "rotation", "scale"}[a]
+}
+
+// animation loops a polygon property along a curve: the rotation, a whole
+// turn from 0 to 1 on top of however the polygon is turned otherwise, or
+// the scale, from minAnimScale to maxAnimScale.
+type animation struct {
+	curve *tween.Curve
+	prop  animProp
+	ticks int
+	tick  int
+	// Rotation the curve added last tick, so the keys and paths can still
+	// turn the polygon
+	turned float64
+}
+
+// Update moves the animation a tick along.
+func (a *animation) Update(p *Polygon) {
+	a.tick = (a.tick + 1) % a.ticks
+	v := a.curve.At(float64(a.tick) / float64(a.ticks))
+
+	switch a.prop {
+	case animRotation:
+		p.theta += v*2*math.Pi - a.turned
+		a.turned = v * 2 * math.Pi
+	case animScale:
+		p.scale = tween.Lerp(minAnimScale, maxAnimScale, v)
+	case animNone:
+	}
+}
+
+// stop undoes what the animation did to the polygon.
+func (a *animation) stop(p *Polygon) {
+	p.theta -= a.turned
+	p.scale = 1
+}
+
+// curveUI is the curve editor (R): a plot of the animation curve of the
+// active polygon, with its keys to drag around. Clicking the plot adds a
+// key, right clicking one removes it, Enter cycles what the curve drives,
+// and Up and Down change how long the loop is. Without an animation it
+// edits a draft, the one bound next.
+type curveUI struct {
+	open  bool
+	draft *tween.Curve
+	// Key being dragged, or -1
+	key int
+
+	// While closed, and while open
+	keys, openKeys keymap.Map
+}
+
+// curve returns the curve being edited.
+func (u *curveUI) curve(p *Polygon) *tween.Curve {
+	if p.anim != nil {
+		return p.anim.curve
+	}
+
+	if u.draft == nil {
+		u.draft = tween.NewCurve(tween.Key{T: 0, V: 0}, tween.Key{T: 0.5, V: 1}, tween.Key{T: 1, V: 0})
+	}
+
+	return u.draft
+}
+
+// toPlot returns where a point of the curve is on the screen.
+func toPlot(k tween.Key) geom.Point {
+	return geom.Point{X: curveX + k.T*curveWidth, Y: curveY + (1-k.V)*curveHeight}
+}
+
+// fromPlot returns the point of the curve at the screen coordinates.
+func fromPlot(x, y int) tween.Key {
+	return tween.Key{T: float64(x-curveX) / curveWidth, V: 1 - float64(y-curveY)/curveHeight}
+}
+
+// bindings are the keys of the curve editor, R toggles it, and the plot
+// while it's open.
+func (u *curveUI) bindings(g *Game) {
+	active := func() *Polygon { return g.p[g.activePolygon] }
+	toggle := keymap.Binding{Keys: keymap.Keys(ebiten.KeyR), Trigger: keymap.Pressed,
+		Action: keymap.Do(func() { u.open, u.key = !u.open, -1 })}
+
+	u.keys = keymap.Map{toggle}
+	u.openKeys = keymap.Map{
+		toggle,
+		{Keys: keymap.Keys(ebiten.KeyEscape), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.open = false })},
+		{Keys: keymap.Keys(ebiten.KeyEnter), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.cycleProp(g, active()) })},
+		{Keys: keymap.Keys(ebiten.KeyUp), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { u.changeLoop(g, active(), loopStep) })},
+		{Keys: keymap.Keys(ebiten.KeyDown), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { u.changeLoop(g, active(), -loopStep) })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { u.key = u.grab(active(), true) })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Action: keymap.Do(func() {
+			if u.key >= 0 {
+				k := fromPlot(keymap.Input().CursorPosition())
+				u.curve(active()).Move(u.key, tween.Clamp01(k.T), tween.Clamp01(k.V))
+			}
+		})},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Released,
+			Action: keymap.Do(func() { u.key = -1 })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonRight), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			if i := u.grab(active(), false); i >= 0 && !u.curve(active()).Remove(i) {
+				g.notify.Push("The first and the last keys stay")
+			}
+		})},
+	}
+}
+
+// Update handles the editor input, and reports whether it was consumed.
+func (u *curveUI) Update(g *Game) bool {
+	if u.keys == nil {
+		u.bindings(g)
+	}
+
+	if u.open {
+		_ = u.openKeys.Update()
+
+		return true
+	}
+
+	_ = u.keys.Update()
+
+	return u.open
+}
+
+// grab returns the key under the cursor, or the one it adds there if add
+// and it's on the plot, or -1.
+func (u *curveUI) grab(p *Polygon, add bool) int {
+	c := u.curve(p)
+	cx, cy := keymap.Input().CursorPosition()
+	at := geom.Point{X: float64(cx), Y: float64(cy)}
+
+	for i, k := range c.Keys {
+		if toPlot(k).Dist(at) <= keyGrab {
+			return i
+		}
+	}
+
+	if !add || cx < curveX || cx > curveX+curveWidth || cy < curveY || cy > curveY+curveHeight {
+		return -1
+	}
+
+	k := fromPlot(cx, cy)
+
+	return c.Insert(k.T, k.V)
+}
+
+// cycleProp binds the curve to the next property of the active polygon,
+// or unbinds it after the last, keeping it as the draft.
+func (u *curveUI) cycleProp(g *Game, p *Polygon) {
+	a := p.anim
+	if a == nil {
+		a = &animation{curve: u.curve(p).Copy(), ticks: loopTicks}
+	}
+
+	a.stop(p)
+	a.turned = 0
+	a.prop = (a.prop + 1) % (animScale + 1)
+
+	if a.prop == animNone {
+		u.draft = a.curve.Copy()
+		p.anim = nil
+	} else {
+		p.anim = a
+	}
+
+	g.notify.Push("%s animates its %s", p.id, a.prop)
+}
+
+// changeLoop makes the loop of the active polygon d ticks longer.
+func (u *curveUI) changeLoop(g *Game, p *Polygon, d int) {
+	if p.anim == nil {
+		g.notify.Push("Enter binds the curve to %s first", p.id)
+
+		return
+	}
+
+	p.anim.ticks = clampInt(p.anim.ticks+d, minLoopTicks, maxLoopTicks)
+	p.anim.tick %= p.anim.ticks
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+
+	if v > hi {
+		return hi
+	}
+
+	return v
+}
+
+// Draw draws the editor over the active polygon: the grid, the curve, its
+// keys, and where the loop is.
+func (u *curveUI) Draw(screen *ebiten.Image, p *Polygon) {
+	if !u.open {
+		return
+	}
+
+	c := u.curve(p)
+
+	ebitenutil.DrawRect(screen, curveX-curveMargin, curveY-curveMargin-16,
+		curveWidth+2*curveMargin, curveHeight+2*curveMargin+16, overlayColor)
+
+	for i := 0; i <= 4; i++ {
+		x := float64(curveX + i*curveWidth/4)
+		y := float64(curveY + i*curveHeight/4)
+		ebitenutil.DrawLine(screen, x, curveY, x, curveY+curveHeight, curveGridColor)
+		ebitenutil.DrawLine(screen, curveX, y, curveX+curveWidth, y, curveGridColor)
+	}
+
+	pts := make([]geom.Point, curveSamples+1)
+	for i := range pts {
+		t := float64(i) / curveSamples
+		pts[i] = toPlot(tween.Key{T: t, V: c.At(t)})
+	}
+
+	drawPolyline(screen, pts, curveColor)
+
+	for _, k := range c.Keys {
+		pt := toPlot(k)
+		ebitenutil.DrawRect(screen, pt.X-2, pt.Y-2, 5, 5, keyColor)
+	}
+
+	title := "Curve: draft, Enter binds it"
+	if a := p.anim; a != nil {
+		x := curveX + float64(a.tick)/float64(a.ticks)*curveWidth
+		ebitenutil.DrawLine(screen, x, curveY, x, curveY+curveHeight, playheadColor)
+		title = fmt.Sprintf("Curve: %s, %.1fs loop (Up/Down)", a.prop, float64(a.ticks)/float64(ebiten.MaxTPS()))
+	}
+
+	ebitenutil.DebugPrintAt(screen, title, curveX, curveY-curveMargin-16)
+	ebitenutil.DebugPrintAt(screen, "Curve editor: drag keys, click to add, right click removes, Enter binds, R or Esc closes",
+		0, screenHeight-16)
+}
+
+// HUD describes the animation of the active polygon, for the status line.
+func (u *curveUI) HUD(p *Polygon) string {
+	if p.anim == nil {
+		return "Animation: none (R edits a curve)"
+	}
+
+	return fmt.Sprintf("Animation: %s along a curve, %d keys (R edits it)", p.anim.prop, len(p.anim.curve.Keys))
+}
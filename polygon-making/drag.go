@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/antoniomo/ebiten-exercises/internal/entity"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/layer"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Dragged polygons go over the rest
+const dragLayer = layer.World + 1
+
+// grab is a polygon being dragged with the mouse, and where in it it was
+// picked up, so it keeps that spot under the cursor instead of jumping to
+// center on it.
+type grab struct {
+	p      *Polygon
+	dx, dy int
+	// Its layer before it was picked up
+	layer layer.Layer
+}
+
+// pickUp selects the polygon under the mouse and starts dragging it, if it
+// isn't locked.
+func (g *Game) pickUp() {
+	cx, cy := keymap.Input().CursorPosition()
+
+	g.selectAtCursor()
+
+	active := g.p[g.activePolygon]
+	if !g.hit(active, cx, cy) {
+		return
+	}
+
+	if !g.world.HasTag(active.eid, entity.Movable) {
+		g.notify.Push("%s is locked (L)", active.id)
+
+		return
+	}
+
+	g.drag = &grab{p: active, dx: active.x - cx, dy: active.y - cy, layer: active.layer}
+	active.layer = dragLayer
+}
+
+// updateDrag moves the dragged polygon with the mouse, and drops it once
+// the button is released.
+func (g *Game) updateDrag() {
+	if g.drag == nil {
+		return
+	}
+
+	p := g.drag.p
+
+	// Checked every tick, the release can come while a mode has the input
+	if keymap.Input().MouseButtonPressDuration(ebiten.MouseButtonLeft) == 0 {
+		p.layer = g.drag.layer
+		g.drag = nil
+
+		return
+	}
+
+	cx, cy := keymap.Input().CursorPosition()
+	g.moveBy(p, cx+g.drag.dx-p.x, cy+g.drag.dy-p.y)
+}
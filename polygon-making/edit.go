@@ -0,0 +1,252 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// Half the side of a vertex handle
+const handleSize = 3
+
+//nolint:gochecknoglobal
+var (
+	outlineColor    = color.RGBA{0xff, 0xff, 0xff, 0xc0}
+	handleColor     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	handleDragColor = color.RGBA{0xff, 0xd0, 0x20, 0xff}
+)
+
+// editUI is the vertex editing mode (Tab): the outline of the active
+// polygon gets a handle on each vertex to drag around, clicking an edge
+// adds a vertex there, and right clicking a handle removes it. The mesh is
+// cut into triangles again on every change, by ear clipping, so any
+// outline works, concave ones too, as long as it doesn't cross itself:
+// a handle won't go where it would.
+type editUI struct {
+	on bool
+	// Vertex being dragged, or -1
+	vertex int
+
+	// While off, and while on
+	keys, onKeys keymap.Map
+}
+
+// bindings are the keys of the edit mode, Tab toggles it, and the handles
+// while it's on.
+func (u *editUI) bindings(g *Game) {
+	active := func() *Polygon { return g.p[g.activePolygon] }
+
+	toggle := keymap.Binding{Keys: keymap.Keys(ebiten.KeyTab), Trigger: keymap.Pressed,
+		Action: keymap.Do(func() { u.toggle(active()) })}
+
+	u.keys = keymap.Map{toggle}
+	u.onKeys = keymap.Map{
+		toggle,
+		{Keys: keymap.Keys(ebiten.KeyEscape), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.on = false })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { u.vertex = u.grab(active()) })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Action: keymap.Do(func() {
+			if u.vertex >= 0 {
+				u.drag(active())
+			}
+		})},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Released,
+			Action: keymap.Do(func() { u.vertex = -1 })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonRight), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			if i := active().vertexAt(keymap.Input().CursorPosition()); i >= 0 {
+				u.remove(g, active(), i)
+			}
+		})},
+	}
+}
+
+// Update handles the edit keys and the handles, and reports whether the
+// input was consumed.
+func (u *editUI) Update(g *Game) bool {
+	if u.keys == nil {
+		u.bindings(g)
+	}
+
+	if u.on {
+		_ = u.onKeys.Update()
+
+		return true
+	}
+
+	_ = u.keys.Update()
+
+	return u.on
+}
+
+// toggle turns the edit mode on or off, turning p into an outline to edit.
+func (u *editUI) toggle(p *Polygon) {
+	u.on, u.vertex = !u.on, -1
+	if u.on {
+		toOutline(p)
+		mirror(p, true)
+	}
+}
+
+// grab returns the vertex under the cursor, or the one it adds there if
+// it's on an edge, or -1.
+func (u *editUI) grab(p *Polygon) int {
+	cx, cy := keymap.Input().CursorPosition()
+	if i := p.vertexAt(cx, cy); i >= 0 {
+		return i
+	}
+
+	x, y := p.toLocal(cx, cy)
+	q := geom.Point{X: x, Y: y}
+	pts := outlinePoints(p.vs)
+
+	for i := range pts {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		if geom.SegmentDist(q, a, b) > vertexGrab {
+			continue
+		}
+
+		// The new vertex goes between them, where it's a straight line, so
+		// the outline doesn't change until it's dragged
+		pts = append(pts[:i+1], append([]geom.Point{q}, pts[i+1:]...)...)
+		if setOutline(p, pts) {
+			mirror(p, true)
+
+			return i + 1
+		}
+
+		return -1
+	}
+
+	return -1
+}
+
+// drag moves the dragged vertex to the cursor, within the polygon image,
+// unless the outline would cross itself there.
+func (u *editUI) drag(p *Polygon) {
+	if u.vertex >= len(p.vs) {
+		u.vertex = -1
+
+		return
+	}
+
+	x, y := p.toLocal(keymap.Input().CursorPosition())
+	d := float64(2 * p.radius)
+	pts := outlinePoints(p.vs)
+	pts[u.vertex] = geom.Point{X: math.Max(0, math.Min(x, d)), Y: math.Max(0, math.Min(y, d))}
+
+	if setOutline(p, pts) {
+		mirror(p, true)
+	}
+}
+
+// remove takes a vertex out of the outline, down to a triangle.
+func (u *editUI) remove(g *Game, p *Polygon, i int) {
+	pts := outlinePoints(p.vs)
+	if len(pts) <= 3 {
+		g.notify.Push("A polygon needs at least 3 vertices")
+
+		return
+	}
+
+	pts = append(pts[:i], pts[i+1:]...)
+	if !setOutline(p, pts) {
+		g.notify.Push("The outline would cross itself")
+
+		return
+	}
+
+	mirror(p, true)
+}
+
+// toOutline replaces the mesh of p with its outline, cut into triangles,
+// so every vertex is on it: meshes made as fans have a vertex in the
+// middle, and others might have some inside. Of several loops, holes or
+// separate pieces, the biggest one stays.
+func toOutline(p *Polygon) {
+	indices := make([]int, len(p.indices))
+	for i, j := range p.indices {
+		indices[i] = int(j)
+	}
+
+	all := outlinePoints(p.vs)
+
+	var best []geom.Point
+
+	for _, loop := range geom.Outline(indices) {
+		pts := make([]geom.Point, len(loop))
+		for i, v := range loop {
+			pts[i] = all[v]
+		}
+
+		if math.Abs(geom.Area(pts)) > math.Abs(geom.Area(best)) {
+			best = pts
+		}
+	}
+
+	setOutline(p, best)
+}
+
+// setOutline makes the mesh of p the outline, cut into triangles, and
+// reports whether it could, it can't if the outline crosses itself.
+func setOutline(p *Polygon, pts []geom.Point) bool {
+	tris, ok := geom.Triangulate(pts)
+	if !ok {
+		return false
+	}
+
+	vs := make([]ebiten.Vertex, len(pts))
+	for i, pt := range pts {
+		vs[i] = ebiten.Vertex{DstX: float32(pt.X), DstY: float32(pt.Y), ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1}
+	}
+
+	indices := make([]uint16, len(tris))
+	for i, t := range tris {
+		indices[i] = uint16(t)
+	}
+
+	p.setMesh(vs, indices)
+
+	return true
+}
+
+// outlinePoints returns the positions of the mesh vertices in the polygon
+// image.
+func outlinePoints(vs []ebiten.Vertex) []geom.Point {
+	pts := make([]geom.Point, len(vs))
+	for i, v := range vs {
+		pts[i] = geom.Point{X: float64(v.DstX), Y: float64(v.DstY)}
+	}
+
+	return pts
+}
+
+// Draw draws the outline of the active polygon with its handles.
+func (u *editUI) Draw(screen *ebiten.Image, p *Polygon) {
+	if !u.on {
+		return
+	}
+
+	pts := make([]geom.Point, len(p.vs)+1)
+	for i, v := range p.vs {
+		pts[i] = p.screenPoint(v)
+	}
+
+	pts[len(p.vs)] = pts[0]
+	drawPolyline(screen, pts, outlineColor)
+
+	for i, pt := range pts[:len(p.vs)] {
+		clr := handleColor
+		if i == u.vertex {
+			clr = handleDragColor
+		}
+
+		ebitenutil.DrawRect(screen, pt.X-handleSize, pt.Y-handleSize, 2*handleSize, 2*handleSize, clr)
+	}
+
+	ebitenutil.DebugPrintAt(screen, "Editing vertices: drag them, click an edge to add one, right click removes, Tab or Esc to stop",
+		0, screenHeight-16)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+
+	"github.com/antoniomo/ebiten-exercises/internal/audio"
+)
+
+// Effects volume, under the music of the other exercises
+const effectsVolume = 0.5
+
+// feedback plays sounds on interactions: selecting a polygon, moving it into
+// another one, and pushing it against the edge of the screen. Without an
+// audio device it stays quiet, the editor works the same.
+//
+// Ebiten has no gamepad vibration yet, so there's no rumble to go with the
+// collisions.
+type feedback struct {
+	muted   bool
+	sounds  map[string]*audio.Sound
+	last    int
+	touched map[*Polygon]bool
+}
+
+func newFeedback(muted bool) *feedback {
+	f := &feedback{muted: muted, sounds: map[string]*audio.Sound{}, touched: map[*Polygon]bool{}}
+	tracks := map[string]*audio.Track{
+		"select":  audio.Blip(660, 990, 0.08),
+		"collide": audio.Thud(0.15),
+		"clamp":   audio.Blip(220, 110, 0.1),
+	}
+
+	for name, t := range tracks {
+		s, err := audio.NewSound(t)
+		if err != nil {
+			log.Printf("no sound effects: %v", err)
+
+			return f
+		}
+
+		s.SetVolume(effectsVolume)
+		f.sounds[name] = s
+	}
+
+	return f
+}
+
+func (f *feedback) play(name string) {
+	if s, ok := f.sounds[name]; ok && !f.muted {
+		s.Play()
+	}
+}
+
+// overlap reports whether two polygons touch. It goes by their bounding
+// circles, a separating axis test on the meshes would be exact.
+func overlap(p, q *Polygon) bool {
+	dx, dy := p.x-q.x, p.y-q.y
+	r := p.radius + q.radius
+
+	return dx*dx+dy*dy < r*r
+}
+
+// Update plays the sounds for what happened this tick: a new active
+// polygon, or the active one touching others it wasn't touching before.
+// Picking a polygon that was touching others already isn't a collision.
+func (f *feedback) Update(g *Game) {
+	selected := g.activePolygon != f.last
+	if selected {
+		f.last = g.activePolygon
+		f.touched = map[*Polygon]bool{}
+		f.play("select")
+	}
+
+	active := g.p[g.activePolygon]
+	hit := false
+
+	for _, p := range g.p {
+		if p == active {
+			continue
+		}
+
+		touching := overlap(active, p)
+		if touching && !f.touched[p] {
+			hit = true
+		}
+
+		f.touched[p] = touching
+	}
+
+	if hit && !selected {
+		f.play("collide")
+	}
+}
+
+func (f *feedback) toggleMute(g *Game) {
+	f.muted = !f.muted
+	if f.muted {
+		g.notify.Push("Sound off")
+	} else {
+		g.notify.Push("Sound on")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+)
+
+// In reports whether the screen point (x, y) is on the polygon, by testing
+// it against the triangles of its mesh as drawn, rotation and all. Unlike
+// reading the image back, it's exact and doesn't stall the GPU.
+func (p *Polygon) In(x, y int) bool {
+	dx, dy := float64(x-p.x)/p.scale, float64(y-p.y)/p.scale
+	r := float64(p.radius)
+
+	// Nothing in the image is further from the center than its corners
+	if dx*dx+dy*dy > 2*r*r {
+		return false
+	}
+
+	// Undo the rotation, into image coordinates
+	sin, cos := math.Sincos(-p.theta)
+	q := geom.Point{X: dx*cos - dy*sin + r, Y: dx*sin + dy*cos + r}
+
+	tri := make([]geom.Point, 3)
+	for i := 0; i+2 < len(p.indices); i += 3 {
+		if p.hidden[i/3] {
+			continue
+		}
+
+		for j := range tri {
+			v := p.vs[p.indices[i+j]]
+			tri[j] = geom.Point{X: float64(v.DstX), Y: float64(v.DstY)}
+		}
+
+		if geom.InPolygon(q, tri) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inPixels is the old test from the ebiten drag and drop (drag) example,
+// reading the pixel back from the image. It ignores the rotation.
+func (p *Polygon) inPixels(x, y int) bool {
+	return p.img.At(x-p.x+p.radius, y-p.y+p.radius).(color.RGBA).A > 0
+}
+
+// hit reports whether the screen point (x, y) is on the polygon, by
+// geometry, or by its pixels with -pixelhit.
+func (g *Game) hit(p *Polygon, x, y int) bool {
+	if g.pixelHit {
+		return p.inPixels(x, y)
+	}
+
+	return p.In(x, y)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestPolygonIn(t *testing.T) {
+	// A triangle pointing up in its 20 by 20 image: the apex at (10, 0),
+	// the base from (0, 20) to (20, 20)
+	inside := [][2]float64{{10, 14}, {10, 4}, {12, 10}, {3, 18}, {17, 18}}
+	outside := [][2]float64{{2, 2}, {18, 2}, {17, 8}, {3, 8}, {10, 23}}
+
+	tests := []struct {
+		name  string
+		theta float64
+		scale float64
+	}{
+		{"as is", 0, 1},
+		{"rotated", math.Pi / 3, 1},
+		{"scaled", 0, 2.5},
+		{"rotated and scaled", 2.2, 1.5},
+		{"upside down and shrunk", math.Pi, 0.8},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPolygon("test", 200, 150, tt.theta, 10, 3, color.White)
+			p.scale = tt.scale
+			// Where the drawing puts the image points on the screen
+			geoM := p.drawOptions(transform{p.x, p.y, p.theta, false}).GeoM
+
+			for _, pt := range inside {
+				x, y := geoM.Apply(pt[0], pt[1])
+				if !p.In(int(math.Round(x)), int(math.Round(y))) {
+					t.Errorf("image point %v, on the screen at (%.1f, %.1f), isn't in", pt, x, y)
+				}
+			}
+
+			for _, pt := range outside {
+				x, y := geoM.Apply(pt[0], pt[1])
+				if p.In(int(math.Round(x)), int(math.Round(y))) {
+					t.Errorf("image point %v, on the screen at (%.1f, %.1f), is in", pt, x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestPolygonInHidden(t *testing.T) {
+	p := NewPolygon("test", 100, 100, 0.5, 10, 4, color.White)
+	if !p.In(100, 100) {
+		t.Fatal("the center of the square isn't in")
+	}
+
+	p.hidden = map[int]bool{}
+	for i := 0; i < len(p.indices)/3; i++ {
+		p.hidden[i] = true
+	}
+
+	if p.In(100, 100) {
+		t.Error("the center is in with every triangle hidden")
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// The panel on the right, wide enough for its lines in the debug font
+	inspectorWidth = 216
+	inspectorX     = screenWidth - inspectorWidth
+	// Rows of vertices and of triangles listed, the rest are counted
+	inspectorRows = 8
+	// Of the debug font
+	lineHeight = 16
+)
+
+//nolint:gochecknoglobal
+var (
+	inspectorBackground = color.RGBA{0x10, 0x10, 0x10, 0xc0}
+	hoverColor          = color.RGBA{0xff, 0xd0, 0x20, 0xff}
+	hiddenColor         = color.RGBA{0xff, 0xff, 0xff, 0x40}
+)
+
+// inspector is the mesh inspector (F3): a panel with the vertices and
+// indices of the active polygon, as they're passed to DrawTriangles, every
+// three indices a triangle. The triangle under the cursor is outlined on
+// the polygon and marked in the lists, and clicking it leaves it out of the
+// image, or puts it back, to see what each one covers.
+type inspector struct {
+	open bool
+	// Triangle of the active polygon under the cursor, or -1
+	hover int
+
+	// While closed, and while open
+	keys, openKeys keymap.Map
+	// Whether a binding took the input this tick
+	consumed bool
+}
+
+// bindings are the keys of the inspector, F3 toggles it, and the clicks
+// on the triangles while it's open.
+func (u *inspector) bindings(g *Game) {
+	toggle := keymap.Binding{Keys: keymap.Keys(ebiten.KeyF3), Trigger: keymap.Pressed,
+		Action: keymap.Do(func() { u.open, u.consumed = !u.open, true })}
+
+	u.keys = keymap.Map{toggle}
+	u.openKeys = keymap.Map{
+		toggle,
+		{Keys: keymap.Keys(ebiten.KeyEscape), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { u.open, u.consumed = false, true })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			if u.hover >= 0 {
+				g.p[g.activePolygon].toggleTriangle(u.hover)
+				u.consumed = true
+			}
+		})},
+	}
+}
+
+// Update handles the inspector keys and clicks, and reports whether the
+// input was consumed. Only clicks on the active polygon are, so it can
+// still be moved around with the panel open.
+func (u *inspector) Update(g *Game) bool {
+	if u.keys == nil {
+		u.bindings(g)
+	}
+
+	u.hover = -1
+	u.consumed = false
+
+	if !u.open {
+		_ = u.keys.Update()
+
+		return u.consumed
+	}
+
+	u.hover = g.p[g.activePolygon].triangleAt(keymap.Input().CursorPosition())
+	_ = u.openKeys.Update()
+
+	return u.consumed
+}
+
+// triangleAt returns the triangle of the mesh, hidden or not, under the
+// screen point (x, y), or -1.
+func (p *Polygon) triangleAt(x, y int) int {
+	lx, ly := p.toLocal(x, y)
+	q := geom.Point{X: lx, Y: ly}
+
+	tri := make([]geom.Point, 3)
+	for t := 0; 3*t+2 < len(p.indices); t++ {
+		for j := range tri {
+			v := p.vs[p.indices[3*t+j]]
+			tri[j] = geom.Point{X: float64(v.DstX), Y: float64(v.DstY)}
+		}
+
+		if geom.InPolygon(q, tri) {
+			return t
+		}
+	}
+
+	return -1
+}
+
+// toggleTriangle hides triangle t of the mesh, or shows it again.
+func (p *Polygon) toggleTriangle(t int) {
+	if p.hidden == nil {
+		p.hidden = map[int]bool{}
+	}
+
+	if p.hidden[t] {
+		delete(p.hidden, t)
+	} else {
+		p.hidden[t] = true
+	}
+
+	p.redraw()
+}
+
+// Draw draws the panel, and outlines the hidden triangles and the one under
+// the cursor on the polygon.
+func (u *inspector) Draw(screen *ebiten.Image, p *Polygon) {
+	if !u.open {
+		return
+	}
+
+	tris := p.screenTriangles()
+	for t, tri := range tris {
+		switch {
+		case t == u.hover:
+			drawOutline(screen, tri, hoverColor)
+		case p.hidden[t]:
+			drawOutline(screen, tri, hiddenColor)
+		}
+	}
+
+	text := u.text(p)
+	h := float64((strings.Count(text, "\n") + 1) * lineHeight)
+
+	ebitenutil.DrawRect(screen, inspectorX, 0, inspectorWidth, h, inspectorBackground)
+	ebitenutil.DebugPrintAt(screen, text, inspectorX+4, 0)
+}
+
+// text lists the buffers of the mesh, with the triangle under the cursor
+// and its vertices marked with >.
+func (u *inspector) text(p *Polygon) string {
+	var sb strings.Builder
+
+	mark := func(on bool) string {
+		if on {
+			return ">"
+		}
+
+		return " "
+	}
+
+	inHover := func(v int) bool {
+		return u.hover >= 0 && (int(p.indices[3*u.hover]) == v ||
+			int(p.indices[3*u.hover+1]) == v || int(p.indices[3*u.hover+2]) == v)
+	}
+
+	fmt.Fprintf(&sb, "Mesh: %s (F3)\n%d vertices, %d indices\n\nVertices  DstX   DstY\n",
+		p.id, len(p.vs), len(p.indices))
+
+	for i, v := range p.vs {
+		if i == inspectorRows {
+			fmt.Fprintf(&sb, "  ... %d more\n", len(p.vs)-i)
+
+			break
+		}
+
+		fmt.Fprintf(&sb, "%s%3d    %6.1f %6.1f\n", mark(inHover(i)), i, v.DstX, v.DstY)
+	}
+
+	sb.WriteString("\nTriangles  indices\n")
+
+	for t := 0; 3*t+2 < len(p.indices); t++ {
+		if t == inspectorRows {
+			fmt.Fprintf(&sb, "  ... %d more\n", len(p.indices)/3-t)
+
+			break
+		}
+
+		hidden := ""
+		if p.hidden[t] {
+			hidden = "  hidden"
+		}
+
+		fmt.Fprintf(&sb, "%s%3d   %3d %3d %3d%s\n", mark(t == u.hover), t,
+			p.indices[3*t], p.indices[3*t+1], p.indices[3*t+2], hidden)
+	}
+
+	sb.WriteString("\nClick a triangle to hide it")
+
+	if u.hover >= 0 {
+		fmt.Fprintf(&sb, "\nUnder the cursor: triangle %d", u.hover)
+	}
+
+	return sb.String()
+}
+
+func drawOutline(screen *ebiten.Image, pts []geom.Point, clr color.Color) {
+	for i, a := range pts {
+		b := pts[(i+1)%len(pts)]
+		ebitenutil.DrawLine(screen, a.X, a.Y, b.X, b.Y, clr)
+	}
+}
+
+// HUD describes the inspector, for the status lines.
+func (u *inspector) HUD(p *Polygon) string {
+	if len(p.hidden) > 0 {
+		return fmt.Sprintf("Inspector: F3, %d of %d triangles hidden", len(p.hidden), len(p.indices)/3)
+	}
+
+	return "Inspector: F3 shows the vertex and index buffers"
+}
+
+// shownIndices returns the indices of the triangles not hidden, what the
+// image is drawn from.
+func (p *Polygon) shownIndices() []uint16 {
+	if len(p.hidden) == 0 {
+		return p.indices
+	}
+
+	indices := make([]uint16, 0, len(p.indices))
+	for t := 0; 3*t+2 < len(p.indices); t++ {
+		if !p.hidden[t] {
+			indices = append(indices, p.indices[3*t:3*t+3]...)
+		}
+	}
+
+	return indices
+}
+
+// redraw draws the image from the mesh, but the hidden triangles.
+func (p *Polygon) redraw() {
+	dto := &ebiten.DrawTrianglesOptions{}
+	dto.ColorM.Scale(shapes.ColorScale(p.clr))
+
+	_ = p.img.Clear()
+	p.img.DrawTriangles(p.vs, p.shownIndices(), shapes.EmptyImage, dto)
+}
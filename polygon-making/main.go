@@ -1,16 +1,23 @@
 package main
 
 import (
-	"errors"
+	"flag"
 	"fmt"
 	"image/color"
 	_ "image/png"
 	"log"
-	"math"
-
+	"os"
+
+	"github.com/antoniomo/ebiten-exercises/internal/entity"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/layer"
+	"github.com/antoniomo/ebiten-exercises/internal/notify"
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
-	"github.com/hajimehoshi/ebiten/inpututil"
 )
 
 const (
@@ -18,119 +25,39 @@ const (
 	rotateFactor    = 0.05
 	screenWidth     = 640
 	screenHeight    = 480
+	// Ticks the active polygon has to be off the edge to sound again
+	clampTicks = 15
 )
 
-var (
-	ErrCleanExit = errors.New("clean exit, no error")
-	emptyImage   *ebiten.Image
-)
-
-func init() {
-	emptyImage, _ = ebiten.NewImage(1, 1, ebiten.FilterDefault)
-	_ = emptyImage.Fill(color.White)
-}
-
-// colorScale taken from ebitenutil/shapes.go
-func colorScale(clr color.Color) (rf, gf, bf, af float64) {
-	r, g, b, a := clr.RGBA()
-	if a == 0 {
-		return 0, 0, 0, 0
-	}
-
-	rf = float64(r) / float64(a)
-	gf = float64(g) / float64(a)
-	bf = float64(b) / float64(a)
-	af = float64(a) / 0xffff
-
-	return
-}
-
-func genTriangle(width, height int) ([]ebiten.Vertex, []uint16) {
-	vs := []ebiten.Vertex{
-		{
-			DstX:   0,
-			DstY:   float32(height),
-			SrcX:   0,
-			SrcY:   0,
-			ColorR: 1,
-			ColorG: 1,
-			ColorB: 1,
-			ColorA: 1,
-		},
-		{
-			DstX:   float32(width) / 2,
-			DstY:   0,
-			SrcX:   0,
-			SrcY:   0,
-			ColorR: 1,
-			ColorG: 1,
-			ColorB: 1,
-			ColorA: 1,
-		},
-		{
-			DstX:   float32(width),
-			DstY:   float32(height),
-			SrcX:   0,
-			SrcY:   0,
-			ColorR: 1,
-			ColorG: 1,
-			ColorB: 1,
-			ColorA: 1,
-		},
-	}
-
-	indices := []uint16{0, 1, 2}
-
-	return vs, indices
-}
-
-// Based on ebiten polygons example. This is just approximate.
-// An alternative is with image.ReplacePixels like:
-// https://github.com/shnifer/nigiri/blob/master/circle.go
-func genPolygon(radius, num int) ([]ebiten.Vertex, []uint16) {
-	vs := make([]ebiten.Vertex, num+1)
-
-	for i := 0; i < num; i++ {
-		rate := float64(i) / float64(num)
-
-		vs[i] = ebiten.Vertex{
-			DstX:   float32(float64(radius)*math.Cos(2*math.Pi*rate)) + float32(radius),
-			DstY:   float32(float64(radius)*math.Sin(2*math.Pi*rate)) + float32(radius),
-			SrcX:   0,
-			SrcY:   0,
-			ColorR: 1,
-			ColorG: 1,
-			ColorB: 1,
-			ColorA: 1,
-		}
-	}
-
-	vs[len(vs)-1] = ebiten.Vertex{
-		DstX:   float32(radius),
-		DstY:   float32(radius),
-		SrcX:   0,
-		SrcY:   0,
-		ColorR: 1,
-		ColorG: 1,
-		ColorB: 1,
-		ColorA: 1,
-	}
-
-	indices := []uint16{}
-	for i := 0; i < num; i++ {
-		indices = append(indices, uint16(i), uint16(i+1)%uint16(num), uint16(num))
-	}
-
-	return vs, indices
-}
-
 type Polygon struct {
 	id     string
+	eid    entity.ID
 	x      int
 	y      int
 	radius int
 	theta  float64
-	img    *ebiten.Image
+	// Drawn this much bigger, see animation
+	scale float64
+	clr   color.Color
+	layer layer.Layer
+	// Mesh in image coordinates, the image being radius*2 wide and high
+	vs      []ebiten.Vertex
+	indices []uint16
+	img     *ebiten.Image
+	// Triangles of the mesh left out of the image, by number, see
+	// inspector
+	hidden map[int]bool
+	// Path it's following, if any
+	follow *follower
+	// Mirror images linked to it, and its symmetry within them
+	sym  *symmetrySet
+	elem mat2
+	// Place in a figure, if it's part of one
+	bone bone
+	// What the mesh was made for, for circles, nil for other polygons
+	circle *circleMesh
+	// Curve animating it, if any
+	anim *animation
 }
 
 func NewPolygon(id string, x, y int, theta float64, radius, sides int,
@@ -140,42 +67,65 @@ func NewPolygon(id string, x, y int, theta float64, radius, sides int,
 		indices []uint16
 	)
 	if sides == 3 {
-		vs, indices = genTriangle(radius*2, radius*2)
+		vs, indices = shapes.Triangle(radius*2, radius*2)
 	} else {
-		vs, indices = genPolygon(radius, sides)
+		vs, indices = shapes.RegularPolygon(radius, sides)
 	}
 
+	return NewPolygonFromMesh(id, x, y, theta, radius, vs, indices, clr)
+}
+
+// NewPolygonFromMesh builds a polygon out of an arbitrary triangle mesh that
+// fits in a radius*2 square.
+func NewPolygonFromMesh(id string, x, y int, theta float64, radius int,
+	vs []ebiten.Vertex, indices []uint16, clr color.Color) *Polygon {
 	p := &Polygon{
 		id:     id,
 		x:      x,
 		y:      y,
 		radius: radius,
 		theta:  theta,
+		scale:  1,
+		clr:    clr,
+		layer:  layer.World,
 	}
-	dto := &ebiten.DrawTrianglesOptions{}
-	dto.ColorM.Scale(colorScale(clr))
+	p.rebuild(vs, indices)
 
-	p.img, _ = ebiten.NewImage(radius*2, radius*2, ebiten.FilterDefault)
-	p.img.DrawTriangles(vs, indices, emptyImage, dto)
 	return p
 }
 
-// In is from the ebiten drag and drop (drag) example.
-func (p *Polygon) In(x, y int) bool {
-	// Rectangle approach, not precise for triangles but good enough here
-	// if x >= p.x-p.radius && x <= p.x+p.radius &&
-	// 	y >= p.y-p.radius && y <= p.y+p.radius {
-	// 	return true
-	// }
-	//
-	// return false
-	return p.img.At(x-p.x+p.radius, y-p.y+p.radius).(color.RGBA).A > 0
+// setMesh replaces the mesh, redrawing the image. Circles edited this way
+// aren't circles anymore, they're left as they are.
+func (p *Polygon) setMesh(vs []ebiten.Vertex, indices []uint16) {
+	p.circle, p.hidden = nil, nil
+	p.rebuild(vs, indices)
+}
+
+// rebuild replaces the mesh, redrawing the image, on a new one if the
+// radius changed.
+func (p *Polygon) rebuild(vs []ebiten.Vertex, indices []uint16) {
+	p.vs, p.indices = vs, indices
+
+	if p.img != nil {
+		if w, _ := p.img.Size(); w != p.radius*2 {
+			_ = p.img.Dispose()
+			p.img = nil
+		}
+	}
+
+	if p.img == nil {
+		p.img, _ = profile.NewImage(p.radius*2, p.radius*2, ebiten.FilterDefault)
+	}
+
+	p.redraw()
 }
 
-// MoveBy moves the polygon by (x, y).
-func (p *Polygon) MoveBy(x, y int) {
+// MoveBy moves the polygon by (x, y), and reports whether it was stopped
+// at the edge of the screen.
+func (p *Polygon) MoveBy(x, y int) bool {
 	p.x += x
 	p.y += y
+	wantX, wantY := p.x, p.y
 
 	if p.x < 0+p.radius {
 		p.x = 0 + p.radius
@@ -192,9 +142,29 @@ func (p *Polygon) MoveBy(x, y int) {
 	if p.y > screenHeight-p.radius {
 		p.y = screenHeight - p.radius
 	}
+
+	return p.x != wantX || p.y != wantY
+}
+
+func (p *Polygon) Layer() layer.Layer {
+	return p.layer
 }
 
 func (p *Polygon) Draw(screen *ebiten.Image) {
+	p.drawAt(screen, transform{p.x, p.y, p.theta, false}, 1)
+}
+
+// drawAt draws the polygon with the given transform and alpha instead of its
+// own, for ghosts and previews.
+func (p *Polygon) drawAt(screen *ebiten.Image, t transform, alpha float64) {
+	op := p.drawOptions(t)
+	op.ColorM.Scale(1, 1, 1, alpha)
+	screen.DrawImage(p.img, op)
+}
+
+// drawOptions returns the options drawing the polygon image with the
+// transform.
+func (p *Polygon) drawOptions(t transform) *ebiten.DrawImageOptions {
 	w, h := p.img.Size()
 
 	op := &ebiten.DrawImageOptions{}
@@ -203,78 +173,283 @@ func (p *Polygon) Draw(screen *ebiten.Image) {
 	// This is a preparation for rotating. When geometry matrices are applied,
 	// the origin point is the upper-left corner.
 	op.GeoM.Translate(-float64(w)/2, -float64(h)/2)
-	op.GeoM.Rotate(p.theta)
-	op.GeoM.Translate(float64(p.x), float64(p.y))
-	screen.DrawImage(p.img, op)
+	op.GeoM.Scale(p.scale, p.scale)
+	if t.flip {
+		op.GeoM.Scale(1, -1)
+	}
+	op.GeoM.Rotate(t.theta)
+	op.GeoM.Translate(float64(t.x), float64(t.y))
+
+	return op
 }
 
 type Game struct {
 	fullscreen    bool
+	world         *entity.World
 	p             []*Polygon
 	activePolygon int
+	onion         onionSkin
+	prefabs       prefabUI
+	paths         pathUI
+	sketch        sketchUI
+	settings      settings
+	edit          editUI
+	curves        curveUI
+	inspector     inspector
+	// Sides of the polygons spawned with N, and how many were
+	spawnSides int
+	spawned    int
+	renderer   layer.Renderer
+	// Number of mirror axes, 0 for no symmetry, and its index in
+	// symmetryAxes
+	symmetry     int
+	symmetryMode int
+	// Mesh vertex of the active polygon being dragged, or -1
+	dragVertex int
+	// Polygon being dragged with the mouse, if any
+	drag      *grab
+	stress    stress
+	tess      tessellation
+	showBones bool
+	feedback  *feedback
+	// Ticks until the next edge hit can sound, so holding a key against
+	// the edge doesn't buzz
+	clampCooldown int
+	keys          keymap.Map
+	notify        *notify.Notifier
+	// Hit test polygons by reading their pixels back, instead of by their
+	// meshes
+	pixelHit   bool
+	collisions collisions
+}
+
+// add registers the polygon as a selectable and movable entity.
+func (g *Game) add(p *Polygon) {
+	p.eid = g.world.Create(entity.Selectable, entity.Movable)
+	g.p = append(g.p, p)
+}
+
+// index returns the index in g.p of the polygon for the entity, or -1.
+func (g *Game) index(id entity.ID) int {
+	for i, p := range g.p {
+		if p.eid == id {
+			return i
+		}
+	}
+
+	return -1
 }
 
 func (g *Game) Update(screen *ebiten.Image) error {
-	if ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
-		g.p[g.activePolygon].MoveBy(0, -translateFactor)
+	for _, p := range g.p {
+		if p.follow != nil {
+			p.follow.Update(p)
+		}
+
+		if p.anim != nil {
+			p.anim.Update(p)
+		}
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
-		g.p[g.activePolygon].MoveBy(0, translateFactor)
+	if g.settings.Update(g) || g.edit.Update(g) || g.curves.Update(g) || g.inspector.Update(g) || g.prefabs.Update(g) || g.paths.Update(g) || g.sketch.Update(g) {
+		return nil
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
-		g.p[g.activePolygon].MoveBy(-translateFactor, 0)
+	if err := g.keys.Update(); err != nil {
+		return err
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
-		g.p[g.activePolygon].MoveBy(translateFactor, 0)
+	g.updateDrag()
+	g.collisions.Update(g)
+
+	active := g.p[g.activePolygon]
+
+	// Edits to a mirrored polygon go to all of its mirror images
+	mirror(active, g.updateSymmetry(active))
+	g.stress.Update()
+	g.updateBones()
+	g.tess.Update(g)
+	g.feedback.Update(g)
+
+	if g.clampCooldown > 0 {
+		g.clampCooldown--
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyQ) {
-		g.p[g.activePolygon].theta -= rotateFactor
+	g.onion.Record(g.p[g.activePolygon])
+	g.notify.Update()
+
+	return nil
+}
+
+// bindings is the input of the game. The modes that take over the input
+// while they're on, like the prefab, path and sketch ones, have tables of
+// their own.
+func (g *Game) bindings() keymap.Map {
+	move := func(x, y int) keymap.Action {
+		return keymap.Do(func() {
+			active := g.p[g.activePolygon]
+			if g.world.HasTag(active.eid, entity.Movable) {
+				g.moveBy(active, x, y)
+			}
+		})
+	}
+	rotate := func(d float64) keymap.Action {
+		return keymap.Do(func() { g.p[g.activePolygon].theta += d })
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyE) {
-		g.p[g.activePolygon].theta += rotateFactor
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyUp, ebiten.KeyW), Action: move(0, -translateFactor)},
+		{Keys: keymap.Keys(ebiten.KeyDown, ebiten.KeyS), Action: move(0, translateFactor)},
+		{Keys: keymap.Keys(ebiten.KeyLeft, ebiten.KeyA), Action: move(-translateFactor, 0)},
+		{Keys: keymap.Keys(ebiten.KeyRight, ebiten.KeyD), Action: move(translateFactor, 0)},
+		{Keys: keymap.Keys(ebiten.KeyL), Trigger: keymap.Pressed, Action: keymap.Do(g.toggleLock)},
+		{Keys: keymap.Keys(ebiten.KeyC), Trigger: keymap.Pressed, Action: keymap.Do(g.toggleCollisions)},
+		{Keys: keymap.Keys(ebiten.KeyQ), Action: rotate(-rotateFactor)},
+		{Keys: keymap.Keys(ebiten.KeyE), Action: rotate(rotateFactor)},
+		{Keys: keymap.Keys(ebiten.KeySpace), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.activePolygon = (g.activePolygon + 1) % len(g.p)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyF), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.fullscreen = !g.fullscreen
+			ebiten.SetFullscreen(g.fullscreen)
+		})},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(g.pickUp)},
+		{Keys: keymap.Keys(ebiten.KeyO), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.onion.enabled = !g.onion.enabled
+		})},
+		{Keys: keymap.Keys(ebiten.KeyB), Trigger: keymap.Pressed, Action: keymap.Do(g.parentAtCursor)},
+		{Keys: keymap.Keys(ebiten.KeyU), Trigger: keymap.Pressed, Action: keymap.Do(g.unparent)},
+		{Keys: keymap.Keys(ebiten.KeyH), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.showBones = !g.showBones
+		})},
+		{Keys: keymap.Keys(ebiten.KeyM), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.feedback.toggleMute(g)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyZ), Trigger: keymap.Pressed, Action: keymap.Do(g.tess.toggleMagnifier)},
+		{Keys: keymap.Keys(ebiten.KeyEqual), Trigger: keymap.Repeat, Action: keymap.Do(func() { g.tess.zoomBy(zoomStep) })},
+		{Keys: keymap.Keys(ebiten.KeyMinus), Trigger: keymap.Repeat, Action: keymap.Do(func() { g.tess.zoomBy(1 / zoomStep) })},
+		{Keys: keymap.Keys(ebiten.KeyPeriod), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			g.tess.scaleTolerance(toleranceStep)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyComma), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			g.tess.scaleTolerance(1 / toleranceStep)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyRightBracket), Trigger: keymap.Repeat, Action: keymap.Do(func() { g.resize(radiusStep) })},
+		{Keys: keymap.Keys(ebiten.KeyLeftBracket), Trigger: keymap.Repeat, Action: keymap.Do(func() { g.resize(-radiusStep) })},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Action: func() error { return run.ErrCleanExit }},
 	}
+}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
-		g.activePolygon = (g.activePolygon + 1) % len(g.p)
+// clampFeedback sounds the active polygon hitting the edge of the screen.
+func (g *Game) clampFeedback() {
+	if g.clampCooldown == 0 {
+		g.feedback.play("clamp")
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
-		g.fullscreen = !g.fullscreen
-		ebiten.SetFullscreen(g.fullscreen)
+	g.clampCooldown = clampTicks
+}
+
+// toggleLock locks or unlocks the active polygon in place.
+func (g *Game) toggleLock() {
+	active := g.p[g.activePolygon]
+	if g.world.HasTag(active.eid, entity.Movable) {
+		g.world.Untag(active.eid, entity.Movable)
+		g.notify.Push("%s locked", active.id)
+	} else {
+		g.world.Tag(active.eid, entity.Movable)
+		g.notify.Push("%s unlocked", active.id)
 	}
+}
 
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		cx, cy := ebiten.CursorPosition()
-		// Because we draw in slice order, the latest is the one on top,
-		// so check from latest to first
-		for i := len(g.p) - 1; i >= 0; i-- {
-			s := g.p[i]
-			if s.In(cx, cy) {
-				g.activePolygon = i
+func (g *Game) selectAtCursor() {
+	cx, cy := keymap.Input().CursorPosition()
+	// Because we draw in creation order, the latest is the one on top,
+	// so check from latest to first
+	g.world.ForEachWithTagReverse(entity.Selectable, func(id entity.ID) bool {
+		i := g.index(id)
+		if i >= 0 && g.hit(g.p[i], cx, cy) {
+			g.activePolygon = i
 
-				break
-			}
+			return false
 		}
+
+		return true
+	})
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	active := g.p[g.activePolygon]
+	msg := fmt.Sprintf("Active polygon: %s (%d of %d, Space cycles, Delete removes)", active.id, g.activePolygon+1, len(g.p))
+	if !g.world.HasTag(active.eid, entity.Movable) {
+		msg += " (locked)"
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		return ErrCleanExit
+	msg += fmt.Sprintf("\nN spawns a %s at the cursor (3..9 for the sides), T sketches one, Tab edits its vertices", shapeNames[g.spawnSides])
+
+	if g.symmetry > 0 {
+		msg += fmt.Sprintf("\nSymmetry: %d axes (Y), J to mirror, right drag vertices", g.symmetry)
+	} else {
+		msg += "\nSymmetry: off (Y)"
 	}
 
-	return nil
-}
+	if parent := active.bone.parent; parent != nil {
+		msg += "\nBones: child of " + parent.id + " (U detaches, H overlay)"
+	} else {
+		msg += "\nBones: root (B parents to the polygon under the mouse, H overlay)"
+	}
 
-func (g *Game) Draw(screen *ebiten.Image) {
-	ebitenutil.DebugPrint(screen, "Active polygon: "+g.p[g.activePolygon].id)
+	msg += "\n" + g.stress.HUD()
+	msg += "\n" + g.tess.HUD(g)
+	if g.feedback.muted {
+		msg += "\nSound: off (M)"
+	} else {
+		msg += "\nSound: on (M)"
+	}
+
+	msg += "\n" + g.settings.HUD()
+	msg += "\n" + g.curves.HUD(active)
+	msg += "\n" + g.collisions.HUD()
+	msg += "\n" + g.inspector.HUD(active)
+
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		ebitenutil.DebugPrint(screen, msg)
+	})
+	// Ghosts and paths go under the polygons
+	g.renderer.AddFunc(layer.World-1, g.onion.Draw)
+	g.renderer.AddFunc(layer.World-1, g.drawSymmetry)
+	g.renderer.AddFunc(layer.World-1, func(screen *ebiten.Image) {
+		g.paths.Draw(screen, g)
+	})
 
 	for _, p := range g.p {
-		p.Draw(screen)
+		g.renderer.AddLayered(p)
 	}
+
+	g.renderer.AddFunc(layer.World+1, g.collisions.Draw)
+	g.renderer.AddFunc(layer.World+1, g.drawBones)
+	g.renderer.AddFunc(layer.World+1, func(screen *ebiten.Image) {
+		g.settings.drawCues(screen, active)
+	})
+
+	// Clones over everything but the UI
+	g.renderer.AddFunc(layer.Effects, g.stress.Draw)
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		g.tess.Draw(screen, active)
+	})
+	g.renderer.AddFunc(layer.UI, g.prefabs.Draw)
+	g.renderer.AddFunc(layer.UI, g.sketch.Draw)
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		g.edit.Draw(screen, active)
+	})
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		g.curves.Draw(screen, active)
+	})
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		g.inspector.Draw(screen, active)
+	})
+	g.renderer.AddFunc(layer.UI, g.settings.Draw)
+	g.renderer.Add(layer.UI+1, g.notify)
+	g.renderer.Draw(screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenW, screenH int) {
@@ -282,24 +457,43 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenW, screenH int) {
 }
 
 func main() {
-	g := &Game{
-		p: []*Polygon{
-			NewPolygon("Triangle", 0, 10, 0, 20, 3, color.White),
-			NewPolygon("Pentagon", 50, 50, 0, 20, 5, color.RGBA{0xff, 0, 0, 0xff}),
-			NewPolygon("Circle", 100, 100, 0, 20, 8, color.RGBA{0, 0xff, 0, 0xff}),
-		},
+	prefabs := flag.String("prefabs", "prefabs.json", "prefab library file")
+	graph := flag.String("graph", "../connect-lines/graph.json", "connect-lines session to walk along with G")
+	clones := flag.Int("clones", 5000, "polygons in the stress test")
+	mute := flag.Bool("mute", false, "start with the sound effects off, M toggles them")
+	pixelHit := flag.Bool("pixelhit", false, "pick polygons by reading their pixels back, slower and blind to rotation")
+	flag.Parse()
+
+	lib, err := loadPrefabs(*prefabs)
+	if err != nil {
+		log.Fatal(err)
 	}
 
+	g := &Game{world: entity.NewWorld(), notify: notify.New(), dragVertex: -1, edit: editUI{vertex: -1}, spawnSides: 5, stress: stress{count: *clones},
+		feedback: newFeedback(*mute), tess: newTessellation(), pixelHit: *pixelHit}
+	g.prefabs.lib = lib
+	g.keys = append(g.bindings(), g.spawnBindings()...)
+	g.keys = append(g.keys, g.symmetryBindings()...)
+	g.keys = append(g.keys, g.stress.bindings(g)...)
+
+	g.paths.walk, err = loadGraphWalk(*graph)
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("no graph walk: %v", err)
+	}
+	g.add(NewPolygon("Triangle", 0, 10, 0, 20, 3, color.White))
+	// In the colors of the palette, so changing it recolors them
+	g.add(NewPolygon("Pentagon", 50, 50, 0, 20, 5, palettes[0].colors[0]))
+	g.add(NewCircle("Circle", 100, 100, 20, palettes[0].colors[1]))
+
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Polygon Making")
 
-	if err := ebiten.RunGame(g); err != nil {
-		if errors.Is(err, ErrCleanExit) {
-			fmt.Println("Good bye!")
-
-			return
-		}
+	err = run.Game(g)
 
-		log.Fatal(err)
+	// Whatever the periodic saves didn't get to
+	if err := stats.Flush(); err != nil {
+		log.Printf("saving stats: %v", err)
 	}
+
+	run.Exit(err)
 }
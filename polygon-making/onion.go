@@ -0,0 +1,94 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// Ticks of history kept for the onion skin
+	onionTicks = 30
+	// Draw one ghost every onionStride ticks of history
+	onionStride = 5
+	// Alpha of the most recent ghost, older ones fade linearly to 0
+	onionAlpha = 0.5
+)
+
+type transform struct {
+	x     int
+	y     int
+	theta float64
+	// Mirrored vertically, for the symmetry reflections
+	flip bool
+}
+
+// transformRing is a fixed size ring buffer of the latest transforms of a
+// polygon, oldest get overwritten.
+type transformRing struct {
+	buf  [onionTicks]transform
+	next int
+	size int
+}
+
+func (r *transformRing) Push(t transform) {
+	r.buf[r.next] = t
+	r.next = (r.next + 1) % len(r.buf)
+
+	if r.size < len(r.buf) {
+		r.size++
+	}
+}
+
+func (r *transformRing) Reset() {
+	r.next = 0
+	r.size = 0
+}
+
+// Len returns how many transforms are stored.
+func (r *transformRing) Len() int {
+	return r.size
+}
+
+// At returns the transform i ticks ago, 0 being the latest.
+func (r *transformRing) At(i int) transform {
+	return r.buf[(r.next-1-i+2*len(r.buf))%len(r.buf)]
+}
+
+// onionSkin records the active polygon transforms every tick, and draws
+// fading ghosts of the previous ones.
+type onionSkin struct {
+	enabled bool
+	p       *Polygon
+	history transformRing
+}
+
+func (o *onionSkin) Record(p *Polygon) {
+	if p != o.p {
+		o.p = p
+		o.history.Reset()
+	}
+
+	o.history.Push(transform{p.x, p.y, p.theta, false})
+}
+
+func (o *onionSkin) Draw(screen *ebiten.Image) {
+	if !o.enabled || o.p == nil {
+		return
+	}
+
+	// Oldest first so the most recent ghosts are on top. Index 0 is the
+	// current position, already drawn as the polygon itself.
+	for i := o.history.Len() - 1; i > 0; i-- {
+		if i%onionStride != 0 {
+			continue
+		}
+
+		t := o.history.At(i)
+		if t == o.history.At(0) {
+			// Didn't move, no point in a ghost under the polygon
+			continue
+		}
+
+		alpha := onionAlpha * (1 - float64(i)/float64(onionTicks))
+		o.p.drawAt(screen, t, alpha)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Pixels per tick along the path
+	followSpeed = 2
+	// The meshes point up, while a 0 angle on a path goes to the right
+	headingOffset = math.Pi / 2
+)
+
+var (
+	pathColor     = color.RGBA{0x80, 0x80, 0x80, 0xff}
+	waypointColor = color.RGBA{0xff, 0xff, 0, 0xff}
+)
+
+// follower moves a polygon along a path, facing where it goes.
+type follower struct {
+	path *geom.Path
+	s    float64
+}
+
+func (f *follower) Update(p *Polygon) {
+	f.s += followSpeed
+
+	pt, angle := f.path.At(f.s)
+	p.x, p.y = int(math.Round(pt.X)), int(math.Round(pt.Y))
+	p.theta = angle + headingOffset
+}
+
+// pathUI records waypoints (K, then click to add them, K again to attach
+// the active polygon), attaches polygons to a walk over a connect-lines
+// graph (G) and detaches them (X).
+type pathUI struct {
+	recording bool
+	waypoints []geom.Point
+	// Walk over the loaded graph, nil if there's none
+	walk *geom.Path
+
+	// While not recording, and while recording
+	keys, recordKeys keymap.Map
+}
+
+// bindings are the keys of the path UI, and the clicks that add waypoints
+// while recording.
+func (u *pathUI) bindings(g *Game) {
+	u.keys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyK), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.record(g) })},
+		{Keys: keymap.Keys(ebiten.KeyG), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			if u.walk != nil {
+				g.p[g.activePolygon].follow = &follower{path: u.walk}
+			}
+		})},
+		{Keys: keymap.Keys(ebiten.KeyX), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { g.p[g.activePolygon].follow = nil })},
+	}
+	u.recordKeys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyK), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.record(g) })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			cx, cy := keymap.Input().CursorPosition()
+			u.waypoints = append(u.waypoints, geom.Point{X: float64(cx), Y: float64(cy)})
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			u.recording = false
+			u.waypoints = nil
+		})},
+	}
+}
+
+// Update handles the path keys, and reports whether the input was consumed,
+// all of it while recording.
+func (u *pathUI) Update(g *Game) bool {
+	if u.keys == nil {
+		u.bindings(g)
+	}
+
+	if u.recording {
+		_ = u.recordKeys.Update()
+
+		return true
+	}
+
+	_ = u.keys.Update()
+
+	return u.recording
+}
+
+// record starts recording waypoints, or stops and attaches the active
+// polygon to them.
+func (u *pathUI) record(g *Game) {
+	if u.recording && len(u.waypoints) > 1 {
+		g.p[g.activePolygon].follow = &follower{path: geom.NewPath(u.waypoints, true)}
+	}
+
+	u.recording = !u.recording
+	u.waypoints = nil
+}
+
+func (u *pathUI) Draw(screen *ebiten.Image, g *Game) {
+	for _, p := range g.p {
+		if p.follow != nil {
+			drawPolyline(screen, p.follow.path.Points(), pathColor)
+		}
+	}
+
+	if u.recording {
+		drawPolyline(screen, u.waypoints, waypointColor)
+
+		for _, pt := range u.waypoints {
+			ebitenutil.DrawRect(screen, pt.X-2, pt.Y-2, 4, 4, waypointColor)
+		}
+
+		ebitenutil.DebugPrintAt(screen, "Recording path: click to add waypoints, K to follow it, Esc to cancel", 0, screenHeight-16)
+	}
+}
+
+func drawPolyline(screen *ebiten.Image, pts []geom.Point, clr color.Color) {
+	for i := 1; i < len(pts); i++ {
+		ebitenutil.DrawLine(screen, pts[i-1].X, pts[i-1].Y, pts[i].X, pts[i].Y, clr)
+	}
+}
+
+// graphFile is the part of a connect-lines session we care about.
+type graphFile struct {
+	Graph struct {
+		Blocks []struct {
+			X    int `json:"x"`
+			Y    int `json:"y"`
+			Size int `json:"size"`
+		} `json:"blocks"`
+		Connections [][2]int `json:"connections"`
+	} `json:"graph"`
+}
+
+// loadGraphWalk reads a connect-lines session, and returns a depth first
+// walk over the graph from the first connected block, going back over the
+// connections when it gets stuck so it's a continuous path ending where it
+// started.
+func loadGraphWalk(path string) (*geom.Path, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var gf graphFile
+	if err := json.Unmarshal(data, &gf); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	blocks := gf.Graph.Blocks
+	adj := make([][]int, len(blocks))
+
+	for _, c := range gf.Graph.Connections {
+		if c[0] < 0 || c[0] >= len(blocks) || c[1] < 0 || c[1] >= len(blocks) {
+			return nil, fmt.Errorf("%s: connection %v: no such block", path, c)
+		}
+
+		adj[c[0]] = append(adj[c[0]], c[1])
+		adj[c[1]] = append(adj[c[1]], c[0])
+	}
+
+	start := -1
+
+	for i := range adj {
+		if len(adj[i]) > 0 {
+			start = i
+
+			break
+		}
+	}
+
+	if start < 0 {
+		return nil, fmt.Errorf("%s: the graph has no connections", path)
+	}
+
+	center := func(i int) geom.Point {
+		b := blocks[i]
+
+		return geom.Point{X: float64(b.X + b.Size/2), Y: float64(b.Y + b.Size/2)}
+	}
+
+	visited := make([]bool, len(blocks))
+	pts := []geom.Point{}
+
+	var dfs func(i int)
+	dfs = func(i int) {
+		visited[i] = true
+		pts = append(pts, center(i))
+
+		for _, j := range adj[i] {
+			if !visited[j] {
+				dfs(j)
+				pts = append(pts, center(i))
+			}
+		}
+	}
+	dfs(start)
+
+	return geom.NewPath(pts, true), nil
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+var overlayColor = color.RGBA{0, 0, 0, 0xc0}
+
+// Prefab is a polygon saved to the library, to spawn copies of it later.
+type Prefab struct {
+	Name   string `json:"name"`
+	Radius int    `json:"radius"`
+	Color  string `json:"color"`
+	// Relative to the center and divided by the radius, so the shape is
+	// independent of its scale
+	Vertices [][2]float32 `json:"vertices"`
+	Indices  []uint16     `json:"indices"`
+}
+
+// NewPrefab captures the polygon shape, color and scale.
+func NewPrefab(name string, p *Polygon) Prefab {
+	pf := Prefab{
+		Name:    name,
+		Radius:  p.radius,
+		Color:   hexColor(p.clr),
+		Indices: append([]uint16(nil), p.indices...),
+	}
+
+	r := float32(p.radius)
+	for _, v := range p.vs {
+		pf.Vertices = append(pf.Vertices, [2]float32{(v.DstX - r) / r, (v.DstY - r) / r})
+	}
+
+	return pf
+}
+
+// Spawn builds a new polygon out of the prefab.
+func (pf Prefab) Spawn(id string, x, y int) (*Polygon, error) {
+	clr, err := parseHexColor(pf.Color)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range pf.Indices {
+		if int(i) >= len(pf.Vertices) {
+			return nil, fmt.Errorf("prefab %s: index %d out of range", pf.Name, i)
+		}
+	}
+
+	r := float32(pf.Radius)
+	vs := make([]ebiten.Vertex, len(pf.Vertices))
+
+	for i, v := range pf.Vertices {
+		vs[i] = ebiten.Vertex{
+			DstX:   v[0]*r + r,
+			DstY:   v[1]*r + r,
+			ColorR: 1,
+			ColorG: 1,
+			ColorB: 1,
+			ColorA: 1,
+		}
+	}
+
+	indices := append([]uint16(nil), pf.Indices...)
+
+	return NewPolygonFromMesh(id, x, y, 0, pf.Radius, vs, indices, clr), nil
+}
+
+// prefabLibrary is the set of prefabs, stored as a JSON file.
+type prefabLibrary struct {
+	path    string
+	Prefabs []Prefab `json:"prefabs"`
+}
+
+// loadPrefabs reads the library, a missing file is just an empty library.
+func loadPrefabs(path string) (*prefabLibrary, error) {
+	lib := &prefabLibrary{path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lib, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, lib); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return lib, nil
+}
+
+// Put adds the prefab to the library, replacing any with the same name, and
+// saves the library.
+func (l *prefabLibrary) Put(pf Prefab) error {
+	replaced := false
+
+	for i := range l.Prefabs {
+		if l.Prefabs[i].Name == pf.Name {
+			l.Prefabs[i] = pf
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		l.Prefabs = append(l.Prefabs, pf)
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(l.path, data, 0644)
+}
+
+// prefabUI is the name prompt to save a prefab (P) and the picker overlay to
+// spawn them (I).
+type prefabUI struct {
+	lib *prefabLibrary
+
+	naming bool
+	name   []rune
+
+	picking bool
+	pick    int
+	spawned int
+
+	// While closed, naming and picking
+	keys, nameKeys, pickKeys keymap.Map
+}
+
+// bindings are the keys of the prefab UI: P and I open the prompt and the
+// picker while they're closed, and each has its own keys while open.
+func (u *prefabUI) bindings(g *Game) {
+	u.keys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyP), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			u.naming = true
+			u.name = []rune(g.p[g.activePolygon].id)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyI), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			u.picking = true
+			u.pick = 0
+		})},
+	}
+	u.nameKeys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyBackspace), Trigger: keymap.Repeat, Action: keymap.Do(u.erase)},
+		{Keys: keymap.Keys(ebiten.KeyEnter), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.save(g) })},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.naming = false })},
+	}
+	u.pickKeys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyUp), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.step(-1) })},
+		{Keys: keymap.Keys(ebiten.KeyDown), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.step(1) })},
+		{Keys: keymap.Keys(ebiten.KeyEnter), Trigger: keymap.Pressed, Action: keymap.Do(func() { u.spawn(g) })},
+		{Keys: keymap.Keys(ebiten.KeyEscape, ebiten.KeyI), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { u.picking = false })},
+	}
+}
+
+// Update handles the prefab keys, and reports whether the input was consumed
+// by an open prompt or overlay.
+func (u *prefabUI) Update(g *Game) bool {
+	if u.keys == nil {
+		u.bindings(g)
+	}
+
+	switch {
+	case u.naming:
+		for _, r := range ebiten.InputChars() {
+			if unicode.IsPrint(r) {
+				u.name = append(u.name, r)
+			}
+		}
+
+		_ = u.nameKeys.Update()
+	case u.picking:
+		_ = u.pickKeys.Update()
+	default:
+		_ = u.keys.Update()
+
+		return u.naming || u.picking
+	}
+
+	return true
+}
+
+// erase deletes the last rune of the name.
+func (u *prefabUI) erase() {
+	if len(u.name) > 0 {
+		u.name = u.name[:len(u.name)-1]
+	}
+}
+
+// save puts the active polygon in the library under the typed name, unless
+// it's blank.
+func (u *prefabUI) save(g *Game) {
+	name := strings.TrimSpace(string(u.name))
+	if name == "" {
+		return
+	}
+
+	if err := u.lib.Put(NewPrefab(name, g.p[g.activePolygon])); err != nil {
+		log.Printf("saving prefab %s: %v", name, err)
+	}
+
+	u.naming = false
+}
+
+// step moves the picker selection by d, wrapping around.
+func (u *prefabUI) step(d int) {
+	if n := len(u.lib.Prefabs); n > 0 {
+		u.pick = (u.pick + n + d) % n
+	}
+}
+
+// spawn adds a copy of the picked prefab at the cursor.
+func (u *prefabUI) spawn(g *Game) {
+	if len(u.lib.Prefabs) == 0 {
+		return
+	}
+
+	pf := u.lib.Prefabs[u.pick]
+	u.spawned++
+	cx, cy := keymap.Input().CursorPosition()
+
+	p, err := pf.Spawn(fmt.Sprintf("%s #%d", pf.Name, u.spawned), cx, cy)
+	if err != nil {
+		log.Printf("spawning prefab %s: %v", pf.Name, err)
+
+		return
+	}
+
+	p.MoveBy(0, 0) // Clamp it to the screen
+	g.add(p)
+	g.activePolygon = len(g.p) - 1
+	stats.Add(stats.PolygonsCreated, 1)
+	// New polygons come mirrored right away while drawing with symmetry
+	g.stamp(p)
+	u.picking = false
+}
+
+func (u *prefabUI) Draw(screen *ebiten.Image) {
+	var sb strings.Builder
+
+	switch {
+	case u.naming:
+		sb.WriteString("Save prefab as: " + string(u.name) + "_\n(Enter to save, Esc to cancel)")
+	case u.picking:
+		sb.WriteString("Prefabs (Up/Down, Enter to spawn at the cursor, Esc to close)\n\n")
+
+		if len(u.lib.Prefabs) == 0 {
+			sb.WriteString("  none yet, save one with P")
+		}
+
+		for i, pf := range u.lib.Prefabs {
+			if i == u.pick {
+				sb.WriteString("> ")
+			} else {
+				sb.WriteString("  ")
+			}
+
+			sb.WriteString(fmt.Sprintf("%s (%d vertices)\n", pf.Name, len(pf.Vertices)))
+		}
+	default:
+		return
+	}
+
+	w, _ := screen.Size()
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(w-40), float64(16*(strings.Count(sb.String(), "\n")+2)))
+	op.GeoM.Translate(20, 30)
+	op.ColorM.Scale(shapes.ColorScale(overlayColor))
+	_ = screen.DrawImage(shapes.EmptyImage, op)
+
+	ebitenutil.DebugPrintAt(screen, sb.String(), 28, 36)
+}
+
+func hexColor(clr color.Color) string {
+	c := color.RGBAModel.Convert(clr).(color.RGBA)
+
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	var c color.RGBA
+
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A); err != nil {
+		return c, fmt.Errorf("bad color %q: %w", s, err)
+	}
+
+	return c, nil
+}
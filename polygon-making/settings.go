@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/antoniomo/ebiten-exercises/internal/input"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Marching ants, in pixels, and how far they march per tick
+	antDash  = 4
+	antGap   = 4
+	antSpeed = 0.25
+	// Side of the tile of the hatch pattern, and how wide its stripes are
+	hatchSize  = 8
+	hatchWidth = 2
+	// Colors the polygons on screen keep when changing palettes, the ones
+	// they start with, before those new polygons take in turn
+	startColors = 2
+)
+
+// palette is a set of colors for the polygons, the first startColors for
+// the ones on screen at start, and the rest for new ones.
+type palette struct {
+	name   string
+	colors []color.RGBA
+}
+
+//nolint:gochecknoglobal
+var (
+	palettes = []palette{
+		{"default", []color.RGBA{
+			{0xff, 0, 0, 0xff},
+			{0, 0xff, 0, 0xff},
+			{0x40, 0xa0, 0xff, 0xff},
+			{0xff, 0xa0, 0x40, 0xff},
+			{0xa0, 0xff, 0x40, 0xff},
+			{0xff, 0x40, 0xa0, 0xff},
+		}},
+		// Okabe and Ito's, tells apart for every kind of color blindness
+		{"Okabe-Ito", []color.RGBA{
+			{0xd5, 0x5e, 0, 0xff},
+			{0, 0x72, 0xb2, 0xff},
+			{0x56, 0xb4, 0xe9, 0xff},
+			{0xe6, 0x9f, 0, 0xff},
+			{0, 0x9e, 0x73, 0xff},
+			{0xcc, 0x79, 0xa7, 0xff},
+		}},
+		// Paul Tol's bright, same idea, a bit softer
+		{"Tol bright", []color.RGBA{
+			{0xee, 0x66, 0x77, 0xff},
+			{0x44, 0x77, 0xaa, 0xff},
+			{0x66, 0xcc, 0xee, 0xff},
+			{0xcc, 0xbb, 0x44, 0xff},
+			{0x22, 0x88, 0x33, 0xff},
+			{0xaa, 0x33, 0x77, 0xff},
+		}},
+	}
+	antColor    = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	antGapColor = color.RGBA{0, 0, 0, 0xff}
+	hatchColor  = color.RGBA{0, 0, 0, 0x90}
+)
+
+// settings are the accessibility options, a panel F2 opens: the palette,
+// for color blind players, and cues for the active polygon that don't go
+// by color, marching ants around it and stripes over it.
+type settings struct {
+	open bool
+	row  int
+
+	palette int
+	ants    bool
+	pattern bool
+
+	tick  int
+	hatch *ebiten.Image
+	// Keys and gamepads both work the panel
+	input *input.Map
+}
+
+// settingRows are the names of the rows of the panel, in order.
+//
+//nolint:gochecknoglobal
+var settingRows = []string{"Palette", "Marching ants", "Stripes"}
+
+// Update handles the panel input and marches the ants, and reports whether
+// the input was consumed.
+func (s *settings) Update(g *Game) bool {
+	s.tick++
+
+	if s.input == nil {
+		// Tab is the vertex editing mode, see editUI
+		s.input = input.New()
+		s.input.Set(input.Menu, input.Inputs{Keys: keymap.Keys(ebiten.KeyF2), Pads: keymap.Pads(keymap.PadStart)})
+	}
+
+	in := s.input
+	in.Update()
+
+	switch {
+	case in.Pressed(input.Menu):
+		s.open = !s.open
+	case !s.open:
+		return false
+	case in.Pressed(input.Cancel):
+		s.open = false
+	case in.Pressed(input.MoveUp):
+		s.row = (s.row + len(settingRows) - 1) % len(settingRows)
+	case in.Pressed(input.MoveDown):
+		s.row = (s.row + 1) % len(settingRows)
+	case in.Pressed(input.MoveLeft):
+		s.change(g, -1)
+	case in.Pressed(input.MoveRight), in.Pressed(input.Select):
+		s.change(g, 1)
+	}
+
+	return true
+}
+
+// change steps the setting of the current row.
+func (s *settings) change(g *Game, d int) {
+	switch s.row {
+	case 0:
+		s.setPalette(g, (s.palette+len(palettes)+d)%len(palettes))
+	case 1:
+		s.ants = !s.ants
+	case 2:
+		s.pattern = !s.pattern
+	}
+}
+
+// setPalette switches to another palette, recoloring the polygons with a
+// color of the old one to the same color of the new one.
+func (s *settings) setPalette(g *Game, i int) {
+	from, to := palettes[s.palette], palettes[i]
+	s.palette = i
+
+	for _, p := range g.p {
+		for j, c := range from.colors {
+			if hexColor(p.clr) == hexColor(c) {
+				p.clr = to.colors[j]
+				p.rebuild(p.vs, p.indices)
+
+				break
+			}
+		}
+	}
+
+	g.notify.Push("Palette: %s", to.name)
+}
+
+// color returns the color of the nth new polygon, counting from 1.
+func (s *settings) color(n int) color.RGBA {
+	cs := palettes[s.palette].colors
+
+	return cs[startColors+(n-1)%(len(cs)-startColors)]
+}
+
+// screenPoint returns where a vertex of the mesh is drawn on the screen.
+func (p *Polygon) screenPoint(v ebiten.Vertex) geom.Point {
+	r := float64(p.radius)
+	x, y := (float64(v.DstX)-r)*p.scale, (float64(v.DstY)-r)*p.scale
+	sin, cos := math.Sincos(p.theta)
+
+	return geom.Point{X: float64(p.x) + x*cos - y*sin, Y: float64(p.y) + x*sin + y*cos}
+}
+
+// drawCues draws the selection cues on the active polygon.
+func (s *settings) drawCues(screen *ebiten.Image, p *Polygon) {
+	if s.pattern {
+		s.drawHatch(screen, p)
+	}
+
+	if !s.ants {
+		return
+	}
+
+	indices := make([]int, len(p.indices))
+	for i, j := range p.indices {
+		indices[i] = int(j)
+	}
+
+	for _, loop := range geom.Outline(indices) {
+		pts := make([]geom.Point, len(loop))
+		for i, v := range loop {
+			pts[i] = p.screenPoint(p.vs[v])
+		}
+
+		path := geom.NewPath(pts, true)
+		drawPolyline(screen, path.Points(), antGapColor)
+
+		for _, dash := range path.Dashes(antDash, antGap, float64(s.tick)*antSpeed) {
+			drawPolyline(screen, dash, antColor)
+		}
+	}
+}
+
+// drawHatch draws the mesh of the polygon again in diagonal stripes, fixed
+// to the screen so they don't turn with it.
+func (s *settings) drawHatch(screen *ebiten.Image, p *Polygon) {
+	if s.hatch == nil {
+		pix := image.NewRGBA(image.Rect(0, 0, hatchSize, hatchSize))
+
+		for y := 0; y < hatchSize; y++ {
+			for x := 0; x < hatchSize; x++ {
+				if (x+y)%hatchSize < hatchWidth {
+					pix.SetRGBA(x, y, hatchColor)
+				}
+			}
+		}
+
+		s.hatch, _ = ebiten.NewImageFromImage(pix, ebiten.FilterDefault)
+	}
+
+	vs := make([]ebiten.Vertex, len(p.vs))
+	for i, v := range p.vs {
+		pt := p.screenPoint(v)
+		vs[i] = v
+		vs[i].DstX, vs[i].DstY = float32(pt.X), float32(pt.Y)
+		vs[i].SrcX, vs[i].SrcY = vs[i].DstX, vs[i].DstY
+		vs[i].ColorR, vs[i].ColorG, vs[i].ColorB, vs[i].ColorA = 1, 1, 1, 1
+	}
+
+	op := &ebiten.DrawTrianglesOptions{Address: ebiten.AddressRepeat}
+	screen.DrawTriangles(vs, p.indices, s.hatch, op)
+}
+
+// value describes the setting of a row.
+func (s *settings) value(row int) string {
+	switch row {
+	case 0:
+		return palettes[s.palette].name
+	case 1:
+		return onOff(s.ants)
+	default:
+		return onOff(s.pattern)
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+
+	return "off"
+}
+
+func (s *settings) Draw(screen *ebiten.Image) {
+	if !s.open {
+		return
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("Settings (Up/Down, Left/Right to change, F2 or Esc to close, or a gamepad)\n\n")
+
+	for i, name := range settingRows {
+		if i == s.row {
+			sb.WriteString("> ")
+		} else {
+			sb.WriteString("  ")
+		}
+
+		sb.WriteString(fmt.Sprintf("%s: %s\n", name, s.value(i)))
+	}
+
+	w, _ := screen.Size()
+	ebitenutil.DrawRect(screen, 20, 30, float64(w-40), float64(16*(len(settingRows)+4)), overlayColor)
+	ebitenutil.DebugPrintAt(screen, sb.String(), 28, 36)
+
+	// A swatch of the palette, under the text
+	cs := palettes[s.palette].colors
+	for i, c := range cs {
+		ebitenutil.DrawRect(screen, float64(28+i*20), float64(36+16*(len(settingRows)+2)+4), 16, 8, c)
+	}
+}
+
+// HUD describes the settings, for the status line.
+func (s *settings) HUD() string {
+	return fmt.Sprintf("Settings (F2): palette %s, marching ants %s, stripes %s",
+		palettes[s.palette].name, onOff(s.ants), onOff(s.pattern))
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/geom"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Simplification tolerance, in pixels, for the slowest and the fastest
+	// strokes, and how fast, in pixels per tick, counts as fastest
+	sketchMinTolerance = 1
+	sketchMaxTolerance = 8
+	sketchFastSpeed    = 16
+	// Smallest area a sketch has to close to make a polygon
+	sketchMinArea = 50
+)
+
+// sketchUI is the freehand mode (T, for trace): dragging the mouse draws a
+// stroke, and letting go closes it into a new polygon. The mouse has no
+// pressure, so the speed of the stroke stands in for it: slow, careful
+// strokes keep their detail, and quick ones are simplified more.
+type sketchUI struct {
+	on     bool
+	stroke []geom.Point
+	// Ticks the stroke took
+	ticks    int
+	sketched int
+
+	// While off, and while on
+	keys, onKeys keymap.Map
+}
+
+// bindings are the keys of the sketch mode, T toggles it, and the strokes
+// while it's on.
+func (u *sketchUI) bindings(g *Game) {
+	toggle := keymap.Binding{Keys: keymap.Keys(ebiten.KeyT), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+		u.on = !u.on
+		u.stroke = nil
+	})}
+
+	u.keys = keymap.Map{toggle}
+	u.onKeys = keymap.Map{
+		toggle,
+		{Keys: keymap.Keys(ebiten.KeyEscape), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			u.on = false
+			u.stroke = nil
+		})},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Action: keymap.Do(u.draw)},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Released,
+			Action: keymap.Do(func() { u.finish(g) })},
+	}
+}
+
+// Update handles the sketch keys and strokes, and reports whether the
+// input was consumed.
+func (u *sketchUI) Update(g *Game) bool {
+	if u.keys == nil {
+		u.bindings(g)
+	}
+
+	if u.on {
+		_ = u.onKeys.Update()
+
+		return true
+	}
+
+	_ = u.keys.Update()
+
+	return u.on
+}
+
+// draw adds the cursor to the stroke, unless it hasn't moved.
+func (u *sketchUI) draw() {
+	cx, cy := keymap.Input().CursorPosition()
+	pt := geom.Point{X: float64(cx), Y: float64(cy)}
+	u.ticks++
+
+	if n := len(u.stroke); n == 0 || u.stroke[n-1] != pt {
+		u.stroke = append(u.stroke, pt)
+	}
+}
+
+// tolerance is how far the simplified stroke can stray from the one drawn,
+// by how fast it was drawn.
+func (u *sketchUI) tolerance() float64 {
+	if u.ticks == 0 {
+		return sketchMinTolerance
+	}
+
+	speed := geom.NewPath(u.stroke, false).Length() / float64(u.ticks)
+	k := math.Min(speed/sketchFastSpeed, 1)
+
+	return sketchMinTolerance + (sketchMaxTolerance-sketchMinTolerance)*k
+}
+
+// finish simplifies and closes the stroke, and adds it as a polygon.
+func (u *sketchUI) finish(g *Game) {
+	tol := u.tolerance()
+	pts := geom.Simplify(u.stroke, tol)
+	u.stroke, u.ticks = nil, 0
+
+	// The stroke is closed anyway, an end right on its start is just
+	// that same vertex again
+	if n := len(pts); n > 3 && pts[0].Dist(pts[n-1]) <= tol {
+		pts = pts[:n-1]
+	}
+
+	if len(pts) < 3 || math.Abs(geom.Area(pts)) < sketchMinArea {
+		g.notify.Push("Too small to make a polygon")
+
+		return
+	}
+
+	tris, ok := geom.Triangulate(pts)
+	if !ok {
+		g.notify.Push("The sketch crosses itself, try again")
+
+		return
+	}
+
+	u.sketched++
+	p := newSketchPolygon(fmt.Sprintf("Sketch #%d", u.sketched), pts, tris,
+		g.settings.color(u.sketched))
+	p.MoveBy(0, 0) // Clamp it to the screen
+	g.add(p)
+	g.activePolygon = len(g.p) - 1
+	stats.Add(stats.PolygonsCreated, 1)
+	g.stamp(p)
+	g.notify.Push("%s: %d vertices (tolerance %.1f)", p.id, len(pts), tol)
+}
+
+// newSketchPolygon makes a polygon out of a triangulated outline in screen
+// coordinates, centered on its bounds.
+func newSketchPolygon(id string, pts []geom.Point, tris []int, clr color.Color) *Polygon {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, pt := range pts {
+		minX, maxX = math.Min(minX, pt.X), math.Max(maxX, pt.X)
+		minY, maxY = math.Min(minY, pt.Y), math.Max(maxY, pt.Y)
+	}
+
+	cx, cy := math.Round((minX+maxX)/2), math.Round((minY+maxY)/2)
+	r := int(math.Ceil(math.Max(maxX-cx, math.Max(cx-minX, math.Max(maxY-cy, cy-minY))))) + 1
+
+	vs := make([]ebiten.Vertex, len(pts))
+	for i, pt := range pts {
+		vs[i] = ebiten.Vertex{
+			DstX:   float32(pt.X - cx + float64(r)),
+			DstY:   float32(pt.Y - cy + float64(r)),
+			ColorR: 1,
+			ColorG: 1,
+			ColorB: 1,
+			ColorA: 1,
+		}
+	}
+
+	indices := make([]uint16, len(tris))
+	for i, t := range tris {
+		indices[i] = uint16(t)
+	}
+
+	return NewPolygonFromMesh(id, int(cx), int(cy), 0, r, vs, indices, clr)
+}
+
+func (u *sketchUI) Draw(screen *ebiten.Image) {
+	if !u.on {
+		return
+	}
+
+	drawPolyline(screen, u.stroke, waypointColor)
+
+	if len(u.stroke) > 2 {
+		first, last := u.stroke[0], u.stroke[len(u.stroke)-1]
+		ebitenutil.DrawLine(screen, last.X, last.Y, first.X, first.Y, pathColor)
+	}
+
+	ebitenutil.DebugPrintAt(screen, "Sketching: drag to draw a polygon, slower keeps more detail, T or Esc to stop", 0, screenHeight-16)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// Radius of the polygons spawned with N
+	spawnRadius = 20
+	// Sides the number keys pick for them
+	minSides = 3
+	maxSides = 9
+)
+
+//nolint:gochecknoglobal
+var shapeNames = map[int]string{
+	3: "Triangle",
+	4: "Square",
+	5: "Pentagon",
+	6: "Hexagon",
+	7: "Heptagon",
+	8: "Octagon",
+	9: "Nonagon",
+}
+
+// spawnBindings picks the sides of new polygons with 3..9, spawns one at
+// the cursor with N and deletes the active one with Delete.
+func (g *Game) spawnBindings() keymap.Map {
+	m := keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyN), Trigger: keymap.Pressed, Action: keymap.Do(g.spawn)},
+		{Keys: keymap.Keys(ebiten.KeyDelete), Trigger: keymap.Pressed, Action: keymap.Do(g.deleteActive)},
+	}
+
+	for n := minSides; n <= maxSides; n++ {
+		n := n
+		m = append(m, keymap.Binding{Keys: keymap.Keys(ebiten.Key0 + ebiten.Key(n)), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() {
+				g.spawnSides = n
+				g.notify.Push("N spawns a %s", shapeNames[n])
+			})})
+	}
+
+	return m
+}
+
+// spawn adds a polygon with the chosen sides at the cursor, and makes it
+// the active one.
+func (g *Game) spawn() {
+	g.spawned++
+	cx, cy := keymap.Input().CursorPosition()
+	p := NewPolygon(fmt.Sprintf("%s #%d", shapeNames[g.spawnSides], g.spawned), cx, cy, 0, spawnRadius,
+		g.spawnSides, g.settings.color(g.spawned))
+	p.MoveBy(0, 0) // Clamp it to the screen
+	g.add(p)
+	g.activePolygon = len(g.p) - 1
+	stats.Add(stats.PolygonsCreated, 1)
+	// New polygons come mirrored right away while drawing with symmetry
+	g.stamp(p)
+}
+
+// deleteActive removes the active polygon, making the one before it
+// active. The last polygon stays, there's always an active one.
+func (g *Game) deleteActive() {
+	if len(g.p) == 1 {
+		g.notify.Push("Can't delete the last polygon")
+
+		return
+	}
+
+	i := g.activePolygon
+	p := g.p[i]
+
+	// Its children stay where they are, on their own
+	for _, c := range append([]*Polygon(nil), p.bone.children...) {
+		c.detach()
+	}
+
+	p.detach()
+
+	// The mirror images left stay linked, if there's more than one
+	if s := p.sym; s != nil {
+		for j, q := range s.members {
+			if q == p {
+				s.members = append(s.members[:j], s.members[j+1:]...)
+
+				break
+			}
+		}
+
+		if len(s.members) == 1 {
+			s.members[0].sym = nil
+		}
+	}
+
+	if g.drag != nil && g.drag.p == p {
+		g.drag = nil
+	}
+
+	if g.stress.src == p {
+		g.stress.src, g.stress.clones = nil, nil
+	}
+
+	g.world.Destroy(p.eid)
+	g.p = append(g.p[:i], g.p[i+1:]...)
+
+	if i > 0 {
+		g.activePolygon = i - 1
+	}
+
+	_ = p.img.Dispose()
+	g.notify.Push("%s deleted", p.id)
+}
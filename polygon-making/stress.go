@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Indices are uint16, so a batch can't go over this many vertices either
+const maxBatchVertices = 1 << 16
+
+// clone is a copy of the stress polygon, drifting and spinning around.
+type clone struct {
+	x, y   float64
+	vx, vy float64
+	theta  float64
+	spin   float64
+	scale  float64
+}
+
+// stress draws thousands of clones of a polygon, either batched, with the
+// meshes of all the clones transformed on the CPU and concatenated into as
+// few DrawTriangles calls as the index limit allows, or naively, with a
+// DrawImage of the polygon image per clone, to compare them.
+type stress struct {
+	count  int
+	src    *Polygon
+	clones []clone
+	naive  bool
+	// Draw calls of the last frame
+	calls int
+	// Reused between frames
+	vs      []ebiten.Vertex
+	indices []uint16
+}
+
+// bindings start and stop the stress test with the active polygon on
+// Ctrl+C, and switch the rendering with V.
+func (s *stress) bindings(g *Game) keymap.Map {
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyC), Ctrl: true, Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			if s.src == nil {
+				s.start(g.p[g.activePolygon])
+			} else {
+				s.src = nil
+				s.clones = nil
+			}
+		})},
+		{Keys: keymap.Keys(ebiten.KeyV), Trigger: keymap.Pressed, Action: keymap.Do(func() { s.naive = !s.naive })},
+	}
+}
+
+// Update moves the clones.
+func (s *stress) Update() {
+	for i := range s.clones {
+		c := &s.clones[i]
+		c.x += c.vx
+		c.y += c.vy
+		c.theta += c.spin
+
+		// Bounce off the screen edges
+		if c.x < 0 || c.x > screenWidth {
+			c.vx = -c.vx
+		}
+
+		if c.y < 0 || c.y > screenHeight {
+			c.vy = -c.vy
+		}
+	}
+}
+
+func (s *stress) start(p *Polygon) {
+	s.src = p
+	s.clones = make([]clone, s.count)
+
+	for i := range s.clones {
+		s.clones[i] = clone{
+			x:     rand.Float64() * screenWidth,
+			y:     rand.Float64() * screenHeight,
+			vx:    rand.Float64()*2 - 1,
+			vy:    rand.Float64()*2 - 1,
+			theta: rand.Float64() * 2 * math.Pi,
+			spin:  (rand.Float64() - 0.5) * 0.1,
+			scale: 0.3 + rand.Float64()*0.9,
+		}
+	}
+}
+
+func (s *stress) Draw(screen *ebiten.Image) {
+	s.calls = 0
+
+	if s.src == nil {
+		return
+	}
+
+	if s.naive {
+		s.drawNaive(screen)
+	} else {
+		s.drawBatched(screen)
+	}
+}
+
+func (s *stress) drawNaive(screen *ebiten.Image) {
+	w, h := s.src.img.Size()
+
+	for _, c := range s.clones {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+		op.GeoM.Scale(c.scale, c.scale)
+		op.GeoM.Rotate(c.theta)
+		op.GeoM.Translate(c.x, c.y)
+		_ = screen.DrawImage(s.src.img, op)
+		s.calls++
+	}
+}
+
+func (s *stress) drawBatched(screen *ebiten.Image) {
+	mesh, indices := s.src.vs, s.src.indices
+	r := float64(s.src.radius)
+	cr, cg, cb, ca := shapes.ColorScale(s.src.clr)
+
+	s.vs = s.vs[:0]
+	s.indices = s.indices[:0]
+
+	for _, c := range s.clones {
+		if len(s.vs)+len(mesh) > maxBatchVertices || len(s.indices)+len(indices) > ebiten.MaxIndicesNum {
+			s.flush(screen)
+		}
+
+		sin, cos := math.Sincos(c.theta)
+		base := uint16(len(s.vs))
+
+		for _, v := range mesh {
+			// The mesh is in image coordinates, centered on (r, r)
+			x, y := (float64(v.DstX)-r)*c.scale, (float64(v.DstY)-r)*c.scale
+			v.DstX = float32(c.x + x*cos - y*sin)
+			v.DstY = float32(c.y + x*sin + y*cos)
+			v.ColorR *= float32(cr)
+			v.ColorG *= float32(cg)
+			v.ColorB *= float32(cb)
+			v.ColorA *= float32(ca)
+			s.vs = append(s.vs, v)
+		}
+
+		for _, i := range indices {
+			s.indices = append(s.indices, base+i)
+		}
+	}
+
+	s.flush(screen)
+}
+
+func (s *stress) flush(screen *ebiten.Image) {
+	if len(s.indices) == 0 {
+		return
+	}
+
+	screen.DrawTriangles(s.vs, s.indices, shapes.EmptyImage, nil)
+	s.calls++
+	s.vs = s.vs[:0]
+	s.indices = s.indices[:0]
+}
+
+// HUD describes the stress test, for the status line.
+func (s *stress) HUD() string {
+	if s.src == nil {
+		return fmt.Sprintf("Stress: off (Ctrl+C for %d clones)", s.count)
+	}
+
+	how := "batched"
+	if s.naive {
+		how = "naive"
+	}
+
+	return fmt.Sprintf("Stress: %d clones %s (V), %d draw calls, %.1f FPS",
+		len(s.clones), how, s.calls, ebiten.CurrentFPS())
+}
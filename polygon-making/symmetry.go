@@ -0,0 +1,256 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Alpha of the mirror previews
+	ghostAlpha = 0.35
+	// How close to a vertex a drag has to start, in pixels
+	vertexGrab = 6
+)
+
+var (
+	axisColor = color.RGBA{0x60, 0x60, 0x60, 0xff}
+	// Symmetry modes Y cycles through, as the number of mirror axes
+	symmetryAxes = []int{0, 1, 2, 4}
+)
+
+// mat2 is a 2x2 matrix, rows first. The symmetries are all rotations and
+// reflections around the screen center, so they're orthogonal: inverting
+// them is transposing them.
+type mat2 [4]float64
+
+func (m mat2) mul(n mat2) mat2 {
+	return mat2{
+		m[0]*n[0] + m[1]*n[2], m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2], m[2]*n[1] + m[3]*n[3],
+	}
+}
+
+func (m mat2) transpose() mat2 {
+	return mat2{m[0], m[2], m[1], m[3]}
+}
+
+// flips reports whether m is a reflection.
+func (m mat2) flips() bool {
+	return m[0]*m[3]-m[1]*m[2] < 0
+}
+
+// apply transforms a polygon transform around the screen center. Any
+// symmetry is a rotation by some angle, after flipping the y axis for
+// reflections, so the polygon ends up rotated by that angle, and by minus
+// its own rotation if flipped.
+func (m mat2) apply(t transform) transform {
+	cx, cy := screenWidth/2.0, screenHeight/2.0
+	dx, dy := float64(t.x)-cx, float64(t.y)-cy
+	angle := math.Atan2(m[2], m[0])
+
+	r := transform{
+		x:     int(math.Round(cx + m[0]*dx + m[1]*dy)),
+		y:     int(math.Round(cy + m[2]*dx + m[3]*dy)),
+		theta: angle + t.theta,
+		flip:  t.flip != m.flips(),
+	}
+
+	if m.flips() {
+		r.theta = angle - t.theta
+	}
+
+	return r
+}
+
+// symmetryGroup returns the symmetries for n mirror axes through the screen
+// center, the first one vertical: n rotations, the identity first, and n
+// reflections.
+func symmetryGroup(n int) []mat2 {
+	group := make([]mat2, 0, 2*n)
+
+	for k := 0; k < n; k++ {
+		b := 2 * math.Pi * float64(k) / float64(n)
+		group = append(group, mat2{math.Cos(b), -math.Sin(b), math.Sin(b), math.Cos(b)})
+	}
+
+	for k := 0; k < n; k++ {
+		// Rotating by pi after flipping the y axis mirrors across the
+		// vertical axis
+		b := math.Pi + 2*math.Pi*float64(k)/float64(n)
+		group = append(group, mat2{math.Cos(b), math.Sin(b), math.Sin(b), -math.Cos(b)})
+	}
+
+	return group
+}
+
+// symmetrySet links polygons that are mirror images of each other, so
+// editing one edits all of them. Each member has its symmetry from the
+// first one in elem.
+type symmetrySet struct {
+	members []*Polygon
+}
+
+// flipMesh mirrors the mesh vertically within the polygon image.
+func flipMesh(vs []ebiten.Vertex, radius int) []ebiten.Vertex {
+	flipped := append([]ebiten.Vertex(nil), vs...)
+	for i := range flipped {
+		flipped[i].DstY = float32(2*radius) - flipped[i].DstY
+	}
+
+	return flipped
+}
+
+// mirror updates the other members of the set of src after src was edited,
+// moving the edit through the symmetries: each member q is q.elem times the
+// inverse of src.elem applied to src.
+func mirror(src *Polygon, meshChanged bool) {
+	if src.sym == nil {
+		return
+	}
+
+	for _, q := range src.sym.members {
+		if q == src {
+			continue
+		}
+
+		m := q.elem.mul(src.elem.transpose())
+		t := m.apply(transform{src.x, src.y, src.theta, false})
+		q.x, q.y, q.theta = t.x, t.y, t.theta
+
+		if meshChanged {
+			vs := src.vs
+			if m.flips() {
+				vs = flipMesh(vs, src.radius)
+			}
+
+			q.setMesh(vs, src.indices)
+		}
+	}
+}
+
+// stamp turns the mirror previews of p into real polygons, linked to it.
+func (g *Game) stamp(p *Polygon) {
+	if g.symmetry == 0 || p.sym != nil {
+		return
+	}
+
+	group := symmetryGroup(g.symmetry)
+	p.sym = &symmetrySet{members: []*Polygon{p}}
+	p.elem = group[0]
+
+	for i, m := range group[1:] {
+		t := m.apply(transform{p.x, p.y, p.theta, false})
+
+		vs := append([]ebiten.Vertex(nil), p.vs...)
+		if t.flip {
+			vs = flipMesh(vs, p.radius)
+		}
+
+		q := NewPolygonFromMesh(p.id+" mirror "+string(rune('a'+i)), t.x, t.y, t.theta,
+			p.radius, vs, append([]uint16(nil), p.indices...), p.clr)
+		q.sym = p.sym
+		q.elem = m
+		p.sym.members = append(p.sym.members, q)
+		g.add(q)
+		stats.Add(stats.PolygonsCreated, 1)
+	}
+
+	g.notify.Push("%d mirror images of %s stamped", len(group)-1, p.id)
+}
+
+// symmetryBindings cycles the number of axes with Y, stamps the previews of
+// the active polygon with J, and grabs one of its vertices with the right
+// mouse button.
+func (g *Game) symmetryBindings() keymap.Map {
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyY), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.symmetryMode = (g.symmetryMode + 1) % len(symmetryAxes)
+			g.symmetry = symmetryAxes[g.symmetryMode]
+		})},
+		{Keys: keymap.Keys(ebiten.KeyJ), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { g.stamp(g.p[g.activePolygon]) })},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonRight), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.dragVertex = g.p[g.activePolygon].vertexAt(keymap.Input().CursorPosition())
+		})},
+	}
+}
+
+// updateSymmetry drags the vertex grabbed with the right mouse button, see
+// symmetryBindings, editing the mesh of the active polygon. It reports
+// whether the mesh changed.
+func (g *Game) updateSymmetry(active *Polygon) bool {
+	in := keymap.Input()
+	cx, cy := in.CursorPosition()
+
+	// Checked every tick, the release can come while a mode has the input
+	if in.MouseButtonPressDuration(ebiten.MouseButtonRight) == 0 {
+		g.dragVertex = -1
+	}
+
+	if g.dragVertex < 0 || g.dragVertex >= len(active.vs) {
+		return false
+	}
+
+	x, y := active.toLocal(cx, cy)
+	d := float32(2 * active.radius)
+	vs := append([]ebiten.Vertex(nil), active.vs...)
+	vs[g.dragVertex].DstX = float32(math.Max(0, math.Min(x, float64(d))))
+	vs[g.dragVertex].DstY = float32(math.Max(0, math.Min(y, float64(d))))
+	active.setMesh(vs, active.indices)
+
+	return true
+}
+
+// toLocal converts screen coordinates to the polygon image ones.
+func (p *Polygon) toLocal(x, y int) (float64, float64) {
+	dx, dy := float64(x-p.x)/p.scale, float64(y-p.y)/p.scale
+	s, c := math.Sincos(-p.theta)
+	r := float64(p.radius)
+
+	return dx*c - dy*s + r, dx*s + dy*c + r
+}
+
+// vertexAt returns the index of the mesh vertex close to the screen
+// coordinates, or -1.
+func (p *Polygon) vertexAt(x, y int) int {
+	lx, ly := p.toLocal(x, y)
+
+	for i, v := range p.vs {
+		if math.Hypot(lx-float64(v.DstX), ly-float64(v.DstY)) <= vertexGrab {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// drawSymmetry draws the mirror axes and, unless the active polygon is
+// already stamped, its mirror previews.
+func (g *Game) drawSymmetry(screen *ebiten.Image) {
+	if g.symmetry == 0 {
+		return
+	}
+
+	cx, cy := screenWidth/2.0, screenHeight/2.0
+	l := math.Hypot(screenWidth, screenHeight)
+
+	for k := 0; k < g.symmetry; k++ {
+		a := math.Pi/2 + math.Pi*float64(k)/float64(g.symmetry)
+		s, c := math.Sincos(a)
+		ebitenutil.DrawLine(screen, cx-c*l, cy-s*l, cx+c*l, cy+s*l, axisColor)
+	}
+
+	active := g.p[g.activePolygon]
+	if active.sym != nil {
+		return
+	}
+
+	for _, m := range symmetryGroup(g.symmetry)[1:] {
+		active.drawAt(screen, m.apply(transform{active.x, active.y, active.theta, false}), ghostAlpha)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Bounds for the segments of a circle, the most keeps the indices well
+	// within uint16
+	minSegments = 8
+	maxSegments = 512
+	// Curvature tolerance, in screen pixels, and how much , and . change it
+	defaultTolerance = 0.5
+	minTolerance     = 0.01
+	maxTolerance     = 8
+	toleranceStep    = 1.25
+	// Radius steps of [ and ], and how big circles get
+	radiusStep = 2
+	minRadius  = 4
+	maxRadius  = 200
+	// The magnifier in the corner, and its zoom
+	magnifierSize = 180
+	zoomStep      = 1.25
+	maxZoom       = 16
+)
+
+//nolint:gochecknoglobal
+var (
+	magnifierBackground = color.RGBA{0x10, 0x10, 0x10, 0xff}
+	wireColor           = color.RGBA{0xff, 0xff, 0xff, 0x60}
+)
+
+// circleMesh is what a circle's mesh was last made for, so it's only made
+// again when any of it changes.
+type circleMesh struct {
+	radius    int
+	zoom      float64
+	tolerance float64
+}
+
+// tessellation is how finely circles are cut into segments: enough that no
+// segment strays from the true circle by more than the tolerance, in screen
+// pixels, so bigger circles and closer looks get more of them. The
+// magnifier (Z) shows the active polygon zoomed in, wireframe and all, and
+// the active circle gets the segments it needs there.
+type tessellation struct {
+	tolerance float64
+	magnifier bool
+	zoom      float64
+	// The magnifier is drawn here first, to clip it
+	view *ebiten.Image
+}
+
+func newTessellation() tessellation {
+	return tessellation{tolerance: defaultTolerance, zoom: 4}
+}
+
+// segments returns how many segments a circle of radius r, in pixels as
+// drawn, needs for its sagitta to stay within tol.
+func segments(r, tol float64) int {
+	n := minSegments
+	if tol < r {
+		n = int(math.Ceil(math.Pi / math.Acos(1-tol/r)))
+	}
+
+	if n < minSegments {
+		n = minSegments
+	}
+
+	if n > maxSegments {
+		n = maxSegments
+	}
+
+	return n
+}
+
+// NewCircle makes a polygon that stays round, tessellated as the view
+// needs.
+func NewCircle(id string, x, y, radius int, clr color.Color) *Polygon {
+	vs, indices := shapes.RegularPolygon(radius, segments(float64(radius), defaultTolerance))
+	p := NewPolygonFromMesh(id, x, y, 0, radius, vs, indices, clr)
+	p.circle = &circleMesh{radius, 1, defaultTolerance}
+
+	return p
+}
+
+// tessellate makes the mesh of a circle again if it's shown at another
+// zoom, or the radius or the tolerance changed.
+func (p *Polygon) tessellate(zoom, tol float64) {
+	want := circleMesh{p.radius, zoom, tol}
+	if p.circle == nil || *p.circle == want {
+		return
+	}
+
+	*p.circle = want
+	p.hidden = nil
+	vs, indices := shapes.RegularPolygon(p.radius, segments(float64(p.radius)*zoom, tol))
+	p.rebuild(vs, indices)
+}
+
+// Update makes again the meshes that need it, the active circle for the
+// magnifier if it's on.
+func (t *tessellation) Update(g *Game) {
+	for i, p := range g.p {
+		zoom := 1.0
+		if t.magnifier && i == g.activePolygon {
+			zoom = t.zoom
+		}
+
+		p.tessellate(zoom, t.tolerance)
+	}
+}
+
+func (t *tessellation) toggleMagnifier() {
+	t.magnifier = !t.magnifier
+}
+
+func (t *tessellation) zoomBy(k float64) {
+	t.zoom = math.Max(1, math.Min(t.zoom*k, maxZoom))
+}
+
+func (t *tessellation) scaleTolerance(k float64) {
+	t.tolerance = math.Max(minTolerance, math.Min(t.tolerance*k, maxTolerance))
+}
+
+// resize grows or shrinks the active polygon, if it's a circle.
+func (g *Game) resize(d int) {
+	active := g.p[g.activePolygon]
+	if active.circle == nil {
+		g.notify.Push("Only circles can be resized")
+
+		return
+	}
+
+	r := active.radius + d
+	if r < minRadius || r > maxRadius {
+		return
+	}
+
+	active.radius = r
+	// Keep it on the screen at its new size
+	active.MoveBy(0, 0)
+}
+
+// Draw draws the active polygon in the magnifier, as the mesh it is,
+// zoomed in around its center.
+func (t *tessellation) Draw(screen *ebiten.Image, p *Polygon) {
+	if !t.magnifier {
+		return
+	}
+
+	x0, y0 := float64(screenWidth-magnifierSize), float64(screenHeight-magnifierSize)
+	ebitenutil.DrawRect(screen, x0, y0, magnifierSize, magnifierSize, magnifierBackground)
+
+	// A zoomed view of a big polygon goes out of the box, so it's drawn
+	// on its own image and clipped
+	if t.view == nil {
+		t.view, _ = profile.NewImage(magnifierSize, magnifierSize, ebiten.FilterDefault)
+	}
+
+	view := t.view
+	_ = view.Clear()
+
+	r := float64(p.radius)
+	sin, cos := math.Sincos(p.theta)
+	at := func(v ebiten.Vertex) (float32, float32) {
+		x, y := float64(v.DstX)-r, float64(v.DstY)-r
+		x, y = x*cos-y*sin, x*sin+y*cos
+
+		return float32(magnifierSize/2 + x*t.zoom), float32(magnifierSize/2 + y*t.zoom)
+	}
+
+	vs := make([]ebiten.Vertex, len(p.vs))
+	for i, v := range p.vs {
+		vs[i] = v
+		vs[i].DstX, vs[i].DstY = at(v)
+	}
+
+	dto := &ebiten.DrawTrianglesOptions{}
+	dto.ColorM.Scale(shapes.ColorScale(p.clr))
+	view.DrawTriangles(vs, p.indices, shapes.EmptyImage, dto)
+
+	for i := 0; i+2 < len(p.indices); i += 3 {
+		for j := 0; j < 3; j++ {
+			a, b := vs[p.indices[i+j]], vs[p.indices[i+(j+1)%3]]
+			ebitenutil.DrawLine(view, float64(a.DstX), float64(a.DstY), float64(b.DstX), float64(b.DstY), wireColor)
+		}
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(x0, y0)
+	_ = screen.DrawImage(view, op)
+}
+
+// HUD describes the tessellation and the vertices it takes, for the status
+// lines.
+func (t *tessellation) HUD(g *Game) string {
+	total := 0
+	for _, p := range g.p {
+		total += len(p.vs)
+	}
+
+	zoom := "off"
+	if t.magnifier {
+		zoom = fmt.Sprintf("%.1fx", t.zoom)
+	}
+
+	msg := fmt.Sprintf("Tessellation: tolerance %.2fpx (, and .), magnifier %s (Z, - and =), %d vertices in all",
+		t.tolerance, zoom, total)
+
+	if active := g.p[g.activePolygon]; active.circle != nil {
+		msg += fmt.Sprintf("\n%s: radius %d ([ and ]), %d segments, %d vertices",
+			active.id, active.radius, len(active.vs)-1, len(active.vs))
+	}
+
+	return msg
+}
@@ -2,4 +2,9 @@ module github.com/antoniomo/ebiten-exercises/render
 
 go 1.14
 
-require github.com/hajimehoshi/ebiten v1.11.7
+require (
+	github.com/antoniomo/ebiten-exercises v0.0.0-00010101000000-000000000000
+	github.com/hajimehoshi/ebiten v1.11.7
+)
+
+replace github.com/antoniomo/ebiten-exercises => ../
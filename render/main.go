@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"image/color"
 	_ "image/png"
 	"log"
 
+	"github.com/antoniomo/ebiten-exercises/internal/run"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
 )
@@ -36,10 +38,10 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 }
 
 func main() {
+	flag.Parse()
+
 	ebiten.SetWindowSize(640, 480)
 	ebiten.SetWindowTitle("Render an image")
 
-	if err := ebiten.RunGame(&Game{}); err != nil {
-		log.Fatal(err)
-	}
+	run.Exit(run.Game(&Game{}))
 }
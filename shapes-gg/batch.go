@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/antoniomo/ebiten-exercises/internal/layer"
+)
+
+// Shapes uploaded to ebiten per tick, so the game keeps running smoothly
+// while a big batch comes in
+const uploadsPerTick = 16
+
+// shapeSpec is a shape waiting to be generated.
+type shapeSpec struct {
+	id  string
+	x   int
+	y   int
+	geo geometry
+}
+
+type rasterized struct {
+	spec shapeSpec
+	img  image.Image
+	k    float64
+}
+
+// batch generates shapes with a pool of workers rasterizing them with gg,
+// while the uploads to ebiten images, which aren't safe off the main thread,
+// happen in Upload, called from Update.
+type batch struct {
+	total    int
+	uploaded int
+	results  chan rasterized
+}
+
+func startBatch(specs []shapeSpec, workers int) *batch {
+	b := &batch{
+		total: len(specs),
+		// Buffered so the workers never wait on the uploads
+		results: make(chan rasterized, len(specs)),
+	}
+
+	jobs := make(chan shapeSpec)
+	// The workers can't ask ebiten, take the pixel scale as it is now
+	k := dpi.k()
+
+	var wg sync.WaitGroup
+
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for spec := range jobs {
+				b.results <- rasterized{spec, spec.geo.raster(k), k}
+			}
+		}()
+	}
+
+	go func() {
+		for _, spec := range specs {
+			jobs <- spec
+		}
+
+		close(jobs)
+		wg.Wait()
+		close(b.results)
+	}()
+
+	return b
+}
+
+// Upload turns up to uploadsPerTick of the rasterized shapes into ebiten
+// images, returning them as shapes. It never blocks.
+func (b *batch) Upload() []*Shape {
+	var shapes []*Shape
+
+	for len(shapes) < uploadsPerTick {
+		select {
+		case r, ok := <-b.results:
+			if !ok {
+				return shapes
+			}
+
+			dpi.put(r.spec.geo.key(), r.k, r.img)
+			s := NewShape(r.spec.id, r.spec.x, r.spec.y, 0, r.spec.geo)
+			// A crowd under the hand made shapes
+			s.layer = layer.World - 1
+			shapes = append(shapes, s)
+			b.uploaded++
+		default:
+			return shapes
+		}
+	}
+
+	return shapes
+}
+
+func (b *batch) Done() bool {
+	return b.uploaded == b.total
+}
+
+// Progress is the uploaded fraction of the batch.
+func (b *batch) Progress() float64 {
+	if b.total == 0 {
+		return 1
+	}
+
+	return float64(b.uploaded) / float64(b.total)
+}
+
+// randomSpecs returns n small random shapes all over the screen.
+func randomSpecs(n int) []shapeSpec {
+	specs := make([]shapeSpec, n)
+
+	for i := range specs {
+		r := 6 + rand.Intn(14)
+		clr := color.RGBA{uint8(rand.Intn(256)), uint8(rand.Intn(256)), uint8(rand.Intn(256)), 0xff}
+		start := rand.Float64() * 2 * math.Pi
+
+		var geo geometry
+
+		switch rand.Intn(5) {
+		case 0:
+			geo = genCircle(r, clr)
+		case 1:
+			geo = genRectangle(r*2, r, clr)
+		case 2:
+			geo = genPolygon(3+rand.Intn(5), r, clr)
+		case 3:
+			geo = genArc(r, 1+r/3, start, start+math.Pi, clr)
+		default:
+			geo = genPie(r, start, start+3*math.Pi/2, clr)
+		}
+
+		specs[i] = shapeSpec{
+			id:  fmt.Sprintf("Shape #%d", i+1),
+			x:   r*2 + rand.Intn(screenWidth-r*4),
+			y:   r*2 + rand.Intn(screenHeight-r*4),
+			geo: geo,
+		}
+	}
+
+	return specs
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/notify"
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/fogleman/gg"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	brushMinSize = 8
+	brushMaxSize = 120
+	// Stamps Z can take back
+	undoDepth = 16
+)
+
+// stampOp is how a stamp combines with what's on the canvas.
+type stampOp int
+
+const (
+	opAdd stampOp = iota
+	opSubtract
+	opIntersect
+	numOps
+)
+
+func (o stampOp) String() string {
+	return [...]string{"add", "subtract", "intersect"}[o]
+}
+
+// brush is a gg shape that can be stamped on the canvas. path adds it to
+// the current path, centered on (x, y) with radius r and rotated by theta.
+type brush struct {
+	name string
+	path func(dc *gg.Context, x, y, r, theta float64)
+}
+
+//nolint:gochecknoglobal
+var (
+	brushes = []brush{
+		{"circle", func(dc *gg.Context, x, y, r, theta float64) {
+			dc.DrawCircle(x, y, r)
+		}},
+		{"square", func(dc *gg.Context, x, y, r, theta float64) {
+			dc.DrawRegularPolygon(4, x, y, r*math.Sqrt2, theta+math.Pi/4)
+		}},
+		{"triangle", func(dc *gg.Context, x, y, r, theta float64) {
+			dc.DrawRegularPolygon(3, x, y, r, theta)
+		}},
+		{"pentagon", func(dc *gg.Context, x, y, r, theta float64) {
+			dc.DrawRegularPolygon(5, x, y, r, theta)
+		}},
+		{"pie", func(dc *gg.Context, x, y, r, theta float64) {
+			dc.MoveTo(x, y)
+			dc.DrawArc(x, y, r, theta, theta+3*math.Pi/2)
+			dc.ClosePath()
+		}},
+	}
+	brushKeys   = []ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4, ebiten.Key5}
+	brushColors = []color.Color{
+		color.White,
+		color.RGBA{0xff, 0, 0, 0xff},
+		color.RGBA{0, 0xff, 0, 0xff},
+		color.RGBA{0, 0xc0, 0xff, 0xff},
+		color.RGBA{0xff, 0x80, 0, 0xff},
+	}
+)
+
+// canvas is a creative mode where the brushes are stamped on a shared
+// image, adding to it, cutting from it or keeping only what's under them.
+// Compositing is all gg: subtract and intersect redraw the canvas clipped
+// by the brush path, inverted for subtract.
+type canvas struct {
+	active bool
+	out    string
+	img    *image.RGBA
+	undo   []*image.RGBA
+	// Uploaded copy of img, replaced after every stamp
+	eimg  *ebiten.Image
+	brush int
+	clr   int
+	op    stampOp
+	size  float64
+	theta float64
+	// Outline of the brush following the cursor, and what it was made for
+	outline    *ebiten.Image
+	outlineFor [3]float64
+	notify     *notify.Notifier
+	keys       keymap.Map
+}
+
+func newCanvas(out string, n *notify.Notifier) *canvas {
+	c := &canvas{
+		out:    out,
+		notify: n,
+		img:    image.NewRGBA(image.Rect(0, 0, screenWidth, screenHeight)),
+		size:   30,
+	}
+	c.eimg, _ = profile.NewImage(screenWidth, screenHeight, ebiten.FilterDefault)
+	c.keys = c.bindings()
+
+	return c
+}
+
+// bindings is the input of the canvas: 1 to 5 pick the brush, C its color,
+// O the operation, -/= and Q/E size and rotate it, left click stamps it, Z
+// undoes and P exports the canvas.
+func (c *canvas) bindings() keymap.Map {
+	m := make(keymap.Map, 0, len(brushKeys)+8)
+
+	for i, k := range brushKeys {
+		i := i
+		m = append(m, keymap.Binding{Keys: keymap.Keys(k), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			c.brush = i
+		})})
+	}
+
+	return append(m,
+		keymap.Binding{Keys: keymap.Keys(ebiten.KeyC), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			c.clr = (c.clr + 1) % len(brushColors)
+		})},
+		keymap.Binding{Keys: keymap.Keys(ebiten.KeyO), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			c.op = (c.op + 1) % numOps
+		})},
+		keymap.Binding{Keys: keymap.Keys(ebiten.KeyMinus), Action: keymap.Do(func() {
+			c.size = math.Max(brushMinSize, c.size-1)
+		})},
+		keymap.Binding{Keys: keymap.Keys(ebiten.KeyEqual), Action: keymap.Do(func() {
+			c.size = math.Min(brushMaxSize, c.size+1)
+		})},
+		keymap.Binding{Keys: keymap.Keys(ebiten.KeyQ), Action: keymap.Do(func() { c.theta -= rotateFactor })},
+		keymap.Binding{Keys: keymap.Keys(ebiten.KeyE), Action: keymap.Do(func() { c.theta += rotateFactor })},
+		keymap.Binding{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			cx, cy := dpi.cursor()
+			c.stamp(float64(cx), float64(cy))
+		})},
+		// Held down, Z keeps going back
+		keymap.Binding{Keys: keymap.Keys(ebiten.KeyZ), Trigger: keymap.Repeat, Delay: 20, Interval: 6, Action: keymap.Do(c.undoStamp)},
+		keymap.Binding{Keys: keymap.Keys(ebiten.KeyP), Trigger: keymap.Pressed, Action: keymap.Do(c.export)},
+	)
+}
+
+func (c *canvas) undoStamp() {
+	if len(c.undo) == 0 {
+		return
+	}
+
+	c.img = c.undo[len(c.undo)-1]
+	c.undo = c.undo[:len(c.undo)-1]
+	c.upload()
+}
+
+func (c *canvas) export() {
+	if err := gg.SavePNG(c.out, c.img); err != nil {
+		c.notify.Push("Export failed: %v", err)
+	} else {
+		c.notify.Push("Canvas exported to %s", c.out)
+	}
+}
+
+// stamp composes the current brush at (x, y) into a new canvas image.
+func (c *canvas) stamp(x, y float64) {
+	dc := gg.NewContext(screenWidth, screenHeight)
+	b := brushes[c.brush]
+
+	switch c.op {
+	case opAdd:
+		dc.DrawImage(c.img, 0, 0)
+		b.path(dc, x, y, c.size, c.theta)
+		dc.SetColor(brushColors[c.clr])
+		dc.Fill()
+	case opSubtract:
+		b.path(dc, x, y, c.size, c.theta)
+		dc.Clip()
+		dc.InvertMask()
+		dc.DrawImage(c.img, 0, 0)
+	case opIntersect:
+		b.path(dc, x, y, c.size, c.theta)
+		dc.Clip()
+		dc.DrawImage(c.img, 0, 0)
+	}
+
+	c.undo = append(c.undo, c.img)
+	if len(c.undo) > undoDepth {
+		c.undo = c.undo[1:]
+	}
+
+	c.img = dc.Image().(*image.RGBA)
+	c.upload()
+}
+
+func (c *canvas) upload() {
+	_ = c.eimg.ReplacePixels(c.img.Pix)
+}
+
+// Draw draws the canvas and the brush outline under the cursor.
+func (c *canvas) Draw(screen *ebiten.Image) {
+	_ = screen.DrawImage(c.eimg, nil)
+
+	key := [3]float64{float64(c.brush), c.size, c.theta}
+	if c.outline == nil || c.outlineFor != key {
+		if c.outline != nil {
+			_ = c.outline.Dispose()
+		}
+
+		c.outline = c.rasterOutline()
+		c.outlineFor = key
+	}
+
+	w, h := c.outline.Size()
+	cx, cy := dpi.cursor()
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(cx-w/2), float64(cy-h/2))
+	op.ColorM.Scale(1, 1, 1, 0.6)
+	_ = screen.DrawImage(c.outline, op)
+}
+
+func (c *canvas) rasterOutline() *ebiten.Image {
+	// Room for the corners of the rotated square and the line width
+	d := int(2*c.size*math.Sqrt2) + 4
+	dc := gg.NewContext(d, d)
+	brushes[c.brush].path(dc, float64(d)/2, float64(d)/2, c.size, c.theta)
+	dc.SetColor(color.White)
+	dc.SetLineWidth(1)
+	dc.Stroke()
+
+	return upload(dc.Image())
+}
+
+// HUD describes the canvas mode, for the status line.
+func (c *canvas) HUD() string {
+	if !c.active {
+		return "Canvas: off (K)"
+	}
+
+	return fmt.Sprintf("Canvas (K): %s brush (1-5), %s (O), color (C), size %.0f (-/=)\nClick stamps, Z undoes, P exports",
+		brushes[c.brush].name, c.op, c.size)
+}
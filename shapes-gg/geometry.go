@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/antoniomo/ebiten-exercises/internal/shapes/raster"
+)
+
+// Kinds of geometry
+const (
+	kindCircle    = "circle"
+	kindRectangle = "rectangle"
+	kindPolygon   = "polygon"
+	kindArc       = "arc"
+	kindPie       = "pie"
+)
+
+// geometry is what a rasterized shape looks like, apart from where it is,
+// so it can be rasterized again at another pixel scale, and saved. Which of
+// the sizes matter depends on the kind.
+type geometry struct {
+	kind string
+	// Radius, or size for rectangles
+	r    int
+	w, h int
+	// Polygon sides, arc thickness
+	sides     int
+	thickness int
+	// Arc and pie angles
+	start float64
+	end   float64
+	clr   color.Color
+}
+
+func genCircle(r int, clr color.Color) geometry {
+	return geometry{kind: kindCircle, r: r, clr: clr}
+}
+
+func genRectangle(w, h int, clr color.Color) geometry {
+	return geometry{kind: kindRectangle, w: w, h: h, clr: clr}
+}
+
+func genPolygon(n, r int, clr color.Color) geometry {
+	return geometry{kind: kindPolygon, sides: n, r: r, clr: clr}
+}
+
+func genArc(r, thickness int, start, end float64, clr color.Color) geometry {
+	return geometry{kind: kindArc, r: r, thickness: thickness, start: start, end: end, clr: clr}
+}
+
+func genPie(r int, start, end float64, clr color.Color) geometry {
+	return geometry{kind: kindPie, r: r, start: start, end: end, clr: clr}
+}
+
+// raster is the rasterFunc of the geometry.
+func (g geometry) raster(k float64) image.Image {
+	switch g.kind {
+	case kindCircle:
+		return raster.Circle(g.r, g.clr, k)
+	case kindRectangle:
+		return raster.Rectangle(g.w, g.h, g.clr, k)
+	case kindPolygon:
+		return raster.RegularPolygon(g.r, g.sides, g.clr, k)
+	case kindArc:
+		return rasterArc(g.r, g.thickness, g.start, g.end, g.clr, k)
+	case kindPie:
+		return rasterPie(g.r, g.start, g.end, g.clr, k)
+	}
+
+	panic("unknown geometry kind " + g.kind)
+}
+
+// key tells geometries apart for the image cache, the same for shapes that
+// look the same.
+func (g geometry) key() string {
+	r, gr, b, a := g.clr.RGBA()
+
+	return fmt.Sprintf("%s %d %d %d %d %d %g %g %04x%04x%04x%04x",
+		g.kind, g.r, g.w, g.h, g.sides, g.thickness, g.start, g.end, r, gr, b, a)
+}
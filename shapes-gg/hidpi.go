@@ -0,0 +1,84 @@
+package main
+
+import (
+	"image"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// rasterFunc rasterizes a shape k times bigger than its size in screen
+// units. It must only use gg, as the batch runs it on worker goroutines.
+type rasterFunc func(k float64) image.Image
+
+type rasterKey struct {
+	geo string
+	k   float64
+}
+
+// hiDPI keeps the shapes crisp on HiDPI displays: the screen is as big as
+// the device pixels, and shapes are rasterized at the device scale factor,
+// times the supersampling level, and scaled down when drawn. The rest, text
+// and the canvas, is drawn in screen units and scaled up.
+type hiDPI struct {
+	// Device scale factor, as of the last Layout
+	scale       float64
+	supersample int
+	// Shape images by geometry key and pixel scale, moving the window to a
+	// screen with another scale factor rasterizes them again
+	cache map[rasterKey]*ebiten.Image
+}
+
+//nolint:gochecknoglobal
+var dpi = &hiDPI{scale: 1, supersample: 1, cache: make(map[rasterKey]*ebiten.Image)}
+
+// k returns the pixels per screen unit to rasterize shapes at.
+func (d *hiDPI) k() float64 {
+	return d.scale * float64(d.supersample)
+}
+
+// setScale changes the device scale factor, dropping the images rasterized
+// for the previous one.
+func (d *hiDPI) setScale(scale float64) {
+	if scale == d.scale {
+		return
+	}
+
+	d.scale = scale
+
+	for key, img := range d.cache {
+		if key.k != d.k() {
+			_ = img.Dispose()
+			delete(d.cache, key)
+		}
+	}
+}
+
+// image returns the image of a shape geometry, by its key, at the current
+// pixel scale, rasterizing it if it's not cached.
+func (d *hiDPI) image(geo string, raster rasterFunc) *ebiten.Image {
+	key := rasterKey{geo, d.k()}
+	if img, ok := d.cache[key]; ok {
+		return img
+	}
+
+	img := upload(raster(key.k))
+	d.cache[key] = img
+
+	return img
+}
+
+// put caches an image of a shape geometry rasterized at pixel scale k,
+// unless k is not the current one anymore.
+func (d *hiDPI) put(geo string, k float64, img image.Image) {
+	if k == d.k() {
+		d.cache[rasterKey{geo, k}] = upload(img)
+	}
+}
+
+// cursor returns the cursor position in screen units.
+func (d *hiDPI) cursor() (int, int) {
+	x, y := keymap.Input().CursorPosition()
+
+	return int(float64(x) / d.scale), int(float64(y) / d.scale)
+}
@@ -1,16 +1,22 @@
 package main
 
 import (
-	"errors"
+	"flag"
 	"fmt"
+	"image"
 	"image/color"
 	_ "image/png"
 	"log"
-
-	"github.com/fogleman/gg"
+	"math"
+	"runtime"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/layer"
+	"github.com/antoniomo/ebiten-exercises/internal/notify"
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
-	"github.com/hajimehoshi/ebiten/inpututil"
 )
 
 const (
@@ -18,43 +24,19 @@ const (
 	rotateFactor    = 0.05
 	screenWidth     = 640
 	screenHeight    = 480
+	// Fraction of the progress ring filled per tick
+	ringSpeed = 0.004
 )
 
-var (
-	ErrCleanExit = errors.New("clean exit, no error")
-)
-
-func genCircle(r int, clr color.Color) *ebiten.Image {
-	dc := gg.NewContext(r*2, r*2)
-	dc.DrawCircle(float64(r), float64(r), float64(r))
-	dc.SetColor(clr)
-	dc.Fill()
-
-	img, _ := ebiten.NewImageFromImage(dc.Image(), ebiten.FilterDefault)
-
-	return img
-}
-
-func genRectangle(w, h int, clr color.Color) *ebiten.Image {
-	dc := gg.NewContext(w, h)
-	dc.DrawRectangle(0, 0, float64(w), float64(h))
-	dc.SetColor(clr)
-	dc.Fill()
+// The raster functions, here and in the raster package, only use gg, so
+// they're safe to call from any goroutine. Turning the result into an
+// ebiten image has to happen on the main thread. The gen* functions return
+// the geometry of a shape, to rasterize at whatever the pixel scale is.
 
-	img, _ := ebiten.NewImageFromImage(dc.Image(), ebiten.FilterDefault)
+func upload(img image.Image) *ebiten.Image {
+	eimg, _ := ebiten.NewImageFromImage(img, ebiten.FilterDefault)
 
-	return img
-}
-
-func genPolygon(n, r int, clr color.Color) *ebiten.Image {
-	dc := gg.NewContext(r*2, r*2)
-	dc.DrawRegularPolygon(n, float64(r), float64(r), float64(r), 0)
-	dc.SetColor(clr)
-	dc.Fill()
-
-	img, _ := ebiten.NewImageFromImage(dc.Image(), ebiten.FilterDefault)
-
-	return img
+	return eimg
 }
 
 type Shape struct {
@@ -62,33 +44,63 @@ type Shape struct {
 	x     int
 	y     int
 	theta float64
-	img   *ebiten.Image
+	layer layer.Layer
+	// Rasterized again when the pixel scale changes, nil for shapes with an
+	// image of their own
+	geo *geometry
+	img *ebiten.Image
+	// Pixels per screen unit of img
+	k float64
 }
 
-func NewShape(id string, x, y int, theta float64, img *ebiten.Image) *Shape {
+func NewShape(id string, x, y int, theta float64, geo geometry) *Shape {
 	s := &Shape{
 		id:    id,
 		x:     x,
 		y:     y,
 		theta: theta,
-		img:   img,
+		layer: layer.World,
+		geo:   &geo,
+		img:   dpi.image(geo.key(), geo.raster),
+		k:     dpi.k(),
 	}
 
 	return s
 }
 
+// NewImageShape makes a shape out of an image with k pixels per screen
+// unit, that it keeps whatever the pixel scale.
+func NewImageShape(id string, x, y int, theta float64, img *ebiten.Image, k float64) *Shape {
+	return &Shape{
+		id:    id,
+		x:     x,
+		y:     y,
+		theta: theta,
+		layer: layer.World,
+		img:   img,
+		k:     k,
+	}
+}
+
+// size returns the size of the shape in screen units.
+func (s *Shape) size() (int, int) {
+	w, h := s.img.Size()
+
+	return int(float64(w) / s.k), int(float64(h) / s.k)
+}
+
 // In is from the ebiten drag and drop (drag) example.
 func (s *Shape) In(x, y int) bool {
-	w, h := s.img.Size()
+	w, h := s.size()
 
-	return s.img.At(x-s.x+w, y-s.y+h).(color.RGBA).A > 0
+	return s.img.At(int(float64(x-s.x+w)*s.k), int(float64(y-s.y+h)*s.k)).(color.RGBA).A > 0
 }
 
 // MoveBy moves the shape by (x, y).
 func (s *Shape) MoveBy(x, y int) {
 	s.x += x
 	s.y += y
-	w, h := s.img.Size()
+	w, h := s.size()
 
 	if s.x < 0+w {
 		s.x = 0 + w
@@ -107,7 +119,16 @@ func (s *Shape) MoveBy(x, y int) {
 	}
 }
 
+func (s *Shape) Layer() layer.Layer {
+	return s.layer
+}
+
 func (s *Shape) Draw(screen *ebiten.Image) {
+	if s.geo != nil && s.k != dpi.k() {
+		s.img = dpi.image(s.geo.key(), s.geo.raster)
+		s.k = dpi.k()
+	}
+
 	w, h := s.img.Size()
 
 	op := &ebiten.DrawImageOptions{}
@@ -116,8 +137,11 @@ func (s *Shape) Draw(screen *ebiten.Image) {
 	// This is a preparation for rotating. When geometry matrices are applied,
 	// the origin point is the upper-left corner.
 	op.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+	// Down to device pixels, averaging the supersampled ones
+	op.GeoM.Scale(dpi.scale/s.k, dpi.scale/s.k)
 	op.GeoM.Rotate(s.theta)
-	op.GeoM.Translate(float64(s.x), float64(s.y))
+	op.GeoM.Translate(float64(s.x)*dpi.scale, float64(s.y)*dpi.scale)
+	op.Filter = ebiten.FilterLinear
 	screen.DrawImage(s.img, op)
 }
 
@@ -125,95 +149,244 @@ type Game struct {
 	fullscreen  bool
 	s           []*Shape
 	activeShape int
+	ring        *ProgressRing
+	progress    float64
+	// Shapes being generated in the background, and its progress
+	batch   *batch
+	loading *ProgressRing
+	canvas  *canvas
+	panel   panel
+	// Where Ctrl+S saves the shapes, and Ctrl+L loads them from
+	scenePath string
+	notify    *notify.Notifier
+	keys      keymap.Map
+	common    keymap.Map
+	// Shapes are drawn on the screen at device pixels, and the rest on the
+	// overlay, in screen units, which is then scaled up over them
+	renderer layer.Renderer
+	ui       layer.Renderer
+	overlay  *ebiten.Image
 }
 
 func (g *Game) Update(screen *ebiten.Image) error {
-	if ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
-		g.s[g.activeShape].MoveBy(0, -translateFactor)
+	// The canvas takes over the keys and the mouse while on
+	keys := g.keys
+	if g.canvas.active {
+		keys = g.canvas.keys
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
-		g.s[g.activeShape].MoveBy(0, translateFactor)
+	if err := keys.Update(); err != nil {
+		return err
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
-		g.s[g.activeShape].MoveBy(-translateFactor, 0)
+	if err := g.common.Update(); err != nil {
+		return err
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
-		g.s[g.activeShape].MoveBy(translateFactor, 0)
+	if g.panel.open && !g.canvas.active {
+		if err := g.panel.keys.Update(); err != nil {
+			return err
+		}
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyQ) {
-		g.s[g.activeShape].theta -= rotateFactor
-	}
+	if g.batch != nil && !g.batch.Done() {
+		g.s = append(g.s, g.batch.Upload()...)
+		g.loading.SetProgress(g.batch.Progress())
 
-	if ebiten.IsKeyPressed(ebiten.KeyE) {
-		g.s[g.activeShape].theta += rotateFactor
+		if g.batch.Done() {
+			g.notify.Push("%d shapes generated", g.batch.total)
+		}
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
-		g.activeShape = (g.activeShape + 1) % len(g.s)
+	// Animate the ring, as a cooldown indicator would
+	g.progress += ringSpeed
+	if g.progress > 1 {
+		g.progress = 0
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
-		g.fullscreen = !g.fullscreen
-		ebiten.SetFullscreen(g.fullscreen)
+	g.ring.SetProgress(g.progress)
+	g.notify.Update()
+
+	return nil
+}
+
+// bind sets up the input tables: moving, rotating and picking the shapes,
+// and the keys that work in canvas mode too.
+func (g *Game) bind() {
+	move := func(x, y int) keymap.Action {
+		return keymap.Do(func() { g.s[g.activeShape].MoveBy(x, y) })
 	}
+	rotate := func(d float64) keymap.Action {
+		return keymap.Do(func() { g.s[g.activeShape].theta += d })
+	}
+
+	g.keys = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyUp, ebiten.KeyW), Action: move(0, -translateFactor)},
+		{Keys: keymap.Keys(ebiten.KeyDown, ebiten.KeyS), Action: move(0, translateFactor)},
+		{Keys: keymap.Keys(ebiten.KeyLeft, ebiten.KeyA), Action: move(-translateFactor, 0)},
+		{Keys: keymap.Keys(ebiten.KeyRight, ebiten.KeyD), Action: move(translateFactor, 0)},
+		{Keys: keymap.Keys(ebiten.KeyQ), Action: rotate(-rotateFactor)},
+		{Keys: keymap.Keys(ebiten.KeyE), Action: rotate(rotateFactor)},
+		{Keys: keymap.Keys(ebiten.KeySpace), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.activeShape = (g.activeShape + 1) % len(g.s)
+		})},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(g.selectAtCursor)},
+		{Keys: keymap.Keys(ebiten.KeyP), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.panel.open = !g.panel.open })},
+		{Keys: keymap.Keys(ebiten.KeyS), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(func() {
+			if err := g.Save(g.scenePath); err != nil {
+				log.Printf("saving %s: %v", g.scenePath, err)
+				g.notify.Push("Saving failed, see the log")
+
+				return
+			}
 
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		cx, cy := ebiten.CursorPosition()
-		// Because we draw in slice order, the latest is the one on top,
-		// so check from latest to first
-		for i := len(g.s) - 1; i >= 0; i-- {
-			s := g.s[i]
-			if s.In(cx, cy) {
-				g.activeShape = i
+			g.notify.Push("Scene saved to %s", g.scenePath)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyL), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(func() {
+			if err := g.Load(g.scenePath); err != nil {
+				log.Printf("loading %s: %v", g.scenePath, err)
+				g.notify.Push("Loading failed, see the log")
 
-				break
+				return
 			}
-		}
+
+			g.notify.Push("Scene loaded from %s", g.scenePath)
+		})},
 	}
+	g.panel.keys = g.panelBindings()
 
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		return ErrCleanExit
+	g.common = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyK), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.canvas.active = !g.canvas.active
+			if g.canvas.active {
+				g.notify.Push("Canvas mode, click to stamp")
+			}
+		})},
+		{Keys: keymap.Keys(ebiten.KeyF), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.fullscreen = !g.fullscreen
+			ebiten.SetFullscreen(g.fullscreen)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Action: func() error { return run.ErrCleanExit }},
 	}
+}
 
-	return nil
+func (g *Game) selectAtCursor() {
+	cx, cy := dpi.cursor()
+	// Because we draw in slice order, the latest is the one on top,
+	// so check from latest to first
+	for i := len(g.s) - 1; i >= 0; i-- {
+		s := g.s[i]
+		if s.In(cx, cy) {
+			g.activeShape = i
+
+			break
+		}
+	}
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	ebitenutil.DebugPrint(screen, "Active shape: "+g.s[g.activeShape].id)
+	g.ui.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("Active shape: %s\nProgress: %3.0f%%\nPixel scale: %.2f (%dx supersampling)\n%s\n%s",
+			g.s[g.activeShape].id, g.ring.Progress()*100, dpi.k(), dpi.supersample, g.canvas.HUD(), g.panel.HUD()))
+	})
+
+	if g.canvas.active {
+		g.ui.AddFunc(layer.World, g.canvas.Draw)
+	} else {
+		for _, s := range g.s {
+			g.renderer.AddLayered(s)
+		}
+
+		g.ui.AddFunc(layer.UI, func(screen *ebiten.Image) {
+			g.panel.Draw(screen, g.s[g.activeShape])
+		})
+	}
 
-	for _, s := range g.s {
-		s.Draw(screen)
+	if g.batch != nil && !g.batch.Done() {
+		g.ui.AddFunc(layer.UI, g.drawLoading)
 	}
+
+	g.ui.Add(layer.UI+1, g.notify)
+	g.renderer.Draw(screen)
+
+	_ = g.overlay.Clear()
+	g.ui.Draw(g.overlay)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(dpi.scale, dpi.scale)
+	_ = screen.DrawImage(g.overlay, op)
 }
 
+// drawLoading shows how the shape generation goes, in the middle of the
+// screen.
+func (g *Game) drawLoading(screen *ebiten.Image) {
+	w, h := g.loading.Image().Size()
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(screenWidth-w)/2, float64(screenHeight-h)/2)
+	_ = screen.DrawImage(g.loading.Image(), op)
+
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Generating shapes %d/%d", g.batch.uploaded, g.batch.total),
+		screenWidth/2-66, screenHeight/2+h/2+8)
+}
+
+// Layout makes the screen as big as the window in device pixels, so the
+// shapes can be drawn crisp on HiDPI displays.
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenW, screenH int) {
-	return screenWidth, screenHeight
+	dpi.setScale(ebiten.DeviceScaleFactor())
+
+	return int(screenWidth * dpi.scale), int(screenHeight * dpi.scale)
 }
 
 func main() {
+	shapes := flag.Int("shapes", 500, "number of extra random shapes to generate")
+	workers := flag.Int("workers", runtime.NumCPU(), "goroutines rasterizing the extra shapes")
+	out := flag.String("canvas", "canvas.png", "where P exports the composition canvas")
+	scenePath := flag.String("scene", "scene.json", "file to save (Ctrl+S) and load (Ctrl+L) the shapes")
+	supersample := flag.Int("supersample", 1, "rasterize the shapes this many times bigger than the device pixels, and average them down")
+	flag.Parse()
+
+	if *shapes < 0 {
+		log.Fatal("the number of shapes can't be negative")
+	}
+
+	if *workers < 1 {
+		log.Fatal("there must be at least one worker")
+	}
+
+	if *supersample < 1 {
+		log.Fatal("the supersampling level must be at least 1")
+	}
+
+	dpi.supersample = *supersample
+	dpi.setScale(ebiten.DeviceScaleFactor())
+
+	ring := NewProgressRing(30, 8, color.RGBA{0, 0xc0, 0xff, 0xff}, color.RGBA{0x40, 0x40, 0x40, 0xff}, dpi.k())
+	overlay, _ := profile.NewImage(screenWidth, screenHeight, ebiten.FilterDefault)
+
+	n := notify.New()
 	g := &Game{
+		ring: ring,
 		s: []*Shape{
 			NewShape("Triangle", 50, 50, 0, genPolygon(3, 30, color.White)),
 			NewShape("Pentagon", 100, 100, 0, genPolygon(5, 30, color.RGBA{0xff, 0, 0, 0xff})),
 			NewShape("Rectangle", 200, 200, 0, genRectangle(30, 30, color.RGBA{0xff, 0, 0, 0xff})),
 			NewShape("Circle", 300, 300, 0, genCircle(30, color.RGBA{0, 0xff, 0, 0xff})),
+			NewShape("Arc", 400, 100, 0, genArc(30, 6, -math.Pi/2, math.Pi/2, color.RGBA{0xff, 0xff, 0, 0xff})),
+			NewShape("Pie", 400, 200, 0, genPie(30, 0, 3*math.Pi/2, color.RGBA{0xff, 0x80, 0, 0xff})),
+			NewImageShape("Ring", 400, 300, 0, ring.Image(), ring.k),
 		},
+		batch:     startBatch(randomSpecs(*shapes), *workers),
+		loading:   NewProgressRing(40, 6, color.White, color.RGBA{0x40, 0x40, 0x40, 0xff}, 1),
+		overlay:   overlay,
+		notify:    n,
+		canvas:    newCanvas(*out, n),
+		scenePath: *scenePath,
 	}
+	g.bind()
 
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Shapes gg")
 
-	if err := ebiten.RunGame(g); err != nil {
-		if errors.Is(err, ErrCleanExit) {
-			fmt.Println("Good bye!")
-
-			return
-		}
-
-		log.Fatal(err)
-	}
+	run.Exit(run.Game(g))
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// How much -/= change the size of a shape, and the smallest and biggest
+	// it can get, in screen units
+	sizeStep    = 2
+	minShapeDim = 4
+	maxShapeDim = 120
+	// Where the panel goes, along the right edge
+	panelWidth = 264
+	panelX     = screenWidth - panelWidth - 8
+	panelY     = 8
+)
+
+//nolint:gochecknoglobal
+var (
+	panelColor = color.RGBA{0, 0, 0, 0xc0}
+	// Colors C cycles the shapes through
+	shapeColors = []color.Color{
+		color.White,
+		color.RGBA{0xff, 0, 0, 0xff},
+		color.RGBA{0, 0xff, 0, 0xff},
+		color.RGBA{0, 0xc0, 0xff, 0xff},
+		color.RGBA{0xff, 0xff, 0, 0xff},
+		color.RGBA{0xff, 0x80, 0, 0xff},
+		color.RGBA{0xc0, 0x40, 0xff, 0xff},
+	}
+)
+
+// panel lists the properties of the active shape (P), and edits the ones
+// the moving keys don't: -/= resize it and C cycles its color, rasterizing
+// it again. The ring has an image of its own, it can only be moved.
+type panel struct {
+	open bool
+	keys keymap.Map
+}
+
+func (g *Game) panelBindings() keymap.Map {
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyMinus, ebiten.KeyKPSubtract), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			g.resize(-sizeStep)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEqual, ebiten.KeyKPAdd), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			g.resize(sizeStep)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyC), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleColor)},
+	}
+}
+
+// editable returns the geometry of the active shape, or nil, telling why,
+// if it has an image of its own.
+func (g *Game) editable() *geometry {
+	s := g.s[g.activeShape]
+	if s.geo == nil {
+		g.notify.Push("%s has an image of its own, it can't be changed", s.id)
+	}
+
+	return s.geo
+}
+
+// resize makes the active shape d screen units bigger, all its sizes, within
+// minShapeDim and maxShapeDim. Arcs keep the thickness they had for the
+// radius.
+func (g *Game) resize(d int) {
+	geo := g.editable()
+	if geo == nil {
+		return
+	}
+
+	grow := func(v int) int {
+		return clampDim(v + d)
+	}
+
+	switch geo.kind {
+	case kindRectangle:
+		geo.w, geo.h = grow(geo.w), grow(geo.h)
+	case kindArc:
+		r := grow(geo.r)
+		geo.thickness = int(math.Max(1, math.Round(float64(geo.thickness*r)/float64(geo.r))))
+		geo.r = r
+	default:
+		geo.r = grow(geo.r)
+	}
+
+	g.rasterize()
+}
+
+func clampDim(v int) int {
+	if v < minShapeDim {
+		return minShapeDim
+	}
+
+	if v > maxShapeDim {
+		return maxShapeDim
+	}
+
+	return v
+}
+
+// cycleColor gives the active shape the next of shapeColors, or the first
+// if its color is none of them.
+func (g *Game) cycleColor() {
+	geo := g.editable()
+	if geo == nil {
+		return
+	}
+
+	next := 0
+
+	for i, clr := range shapeColors {
+		if hexColor(clr) == hexColor(geo.clr) {
+			next = (i + 1) % len(shapeColors)
+
+			break
+		}
+	}
+
+	geo.clr = shapeColors[next]
+	g.rasterize()
+}
+
+// rasterize gets the image of the active shape for its geometry, as it
+// changed, and keeps it on the screen if it grew.
+func (g *Game) rasterize() {
+	s := g.s[g.activeShape]
+	s.img = dpi.image(s.geo.key(), s.geo.raster)
+	s.k = dpi.k()
+	s.MoveBy(0, 0)
+}
+
+// Draw draws the panel, if open, with the properties of the active shape.
+func (p *panel) Draw(screen *ebiten.Image, s *Shape) {
+	if !p.open {
+		return
+	}
+
+	w, h := s.size()
+	deg := math.Mod(s.theta*180/math.Pi, 360)
+
+	if deg < 0 {
+		deg += 360
+	}
+
+	text := fmt.Sprintf("%s (P closes)\nPosition: %d, %d\nRotation: %.0f deg (Q/E)\nSize: %dx%d",
+		s.id, s.x, s.y, deg, w, h)
+
+	if geo := s.geo; geo != nil {
+		switch geo.kind {
+		case kindRectangle:
+		case kindArc:
+			text += fmt.Sprintf(", radius %d, thickness %d", geo.r, geo.thickness)
+		default:
+			text += fmt.Sprintf(", radius %d", geo.r)
+		}
+
+		text += fmt.Sprintf(" (-/=)\nColor: %s (C)", hexColor(geo.clr))
+	} else {
+		text += "\nColor: its own image"
+	}
+
+	ebitenutil.DrawRect(screen, panelX, panelY, panelWidth, 5*16+8, panelColor)
+	ebitenutil.DebugPrintAt(screen, text, panelX+4, panelY+4)
+}
+
+// HUD describes the panel, for the status line.
+func (p *panel) HUD() string {
+	if !p.open {
+		return "Properties: off (P)"
+	}
+
+	return "Properties (P): -/= resize, C cycles the color"
+}
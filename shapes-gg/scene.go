@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+
+	"github.com/antoniomo/ebiten-exercises/internal/buildinfo"
+	"github.com/antoniomo/ebiten-exercises/internal/layer"
+)
+
+// sceneVersion is bumped whenever the scene format changes in a way that
+// older files can't be read anymore.
+const sceneVersion = 1
+
+// kindRing is the progress ring in a scene, it's not a geometry but an
+// image of its own, and there's only the one.
+const kindRing = "ring"
+
+// scene is what gets saved: every shape, where it is and what it looks
+// like, the random ones too.
+type scene struct {
+	Version int `json:"version"`
+	// What saved it, for reference
+	Build  buildinfo.Info `json:"build"`
+	Shapes []shapeData    `json:"shapes"`
+	Active int            `json:"active"`
+}
+
+type shapeData struct {
+	ID    string      `json:"id"`
+	Kind  string      `json:"kind"`
+	X     int         `json:"x"`
+	Y     int         `json:"y"`
+	Theta float64     `json:"theta"`
+	Layer layer.Layer `json:"layer"`
+	// The sizes that matter for the kind
+	R         int     `json:"r,omitempty"`
+	W         int     `json:"w,omitempty"`
+	H         int     `json:"h,omitempty"`
+	Sides     int     `json:"sides,omitempty"`
+	Thickness int     `json:"thickness,omitempty"`
+	Start     float64 `json:"start,omitempty"`
+	End       float64 `json:"end,omitempty"`
+	Color     string  `json:"color,omitempty"`
+}
+
+func (g *Game) scene() scene {
+	sc := scene{Version: sceneVersion, Build: buildinfo.Get(), Active: g.activeShape}
+
+	for _, s := range g.s {
+		d := shapeData{ID: s.id, Kind: kindRing, X: s.x, Y: s.y, Theta: s.theta, Layer: s.layer}
+
+		if geo := s.geo; geo != nil {
+			d.Kind, d.R, d.W, d.H = geo.kind, geo.r, geo.w, geo.h
+			d.Sides, d.Thickness, d.Start, d.End = geo.sides, geo.thickness, geo.start, geo.end
+			d.Color = hexColor(geo.clr)
+		}
+
+		sc.Shapes = append(sc.Shapes, d)
+	}
+
+	return sc
+}
+
+// restore replaces the shapes with the ones in the scene, rasterizing them
+// again.
+func (g *Game) restore(sc scene) error {
+	if sc.Version != sceneVersion {
+		return fmt.Errorf("unsupported scene version %d", sc.Version)
+	}
+
+	if len(sc.Shapes) == 0 {
+		return fmt.Errorf("the scene has no shapes")
+	}
+
+	shapes := make([]*Shape, len(sc.Shapes))
+
+	for i, d := range sc.Shapes {
+		if d.Kind == kindRing {
+			shapes[i] = NewImageShape(d.ID, d.X, d.Y, d.Theta, g.ring.Image(), g.ring.k)
+			shapes[i].layer = d.Layer
+
+			continue
+		}
+
+		clr, err := parseHexColor(d.Color)
+		if err != nil {
+			return fmt.Errorf("shape %q: %w", d.ID, err)
+		}
+
+		geo := geometry{kind: d.Kind, r: d.R, w: d.W, h: d.H, sides: d.Sides, thickness: d.Thickness,
+			start: d.Start, end: d.End, clr: clr}
+		if err := geo.check(); err != nil {
+			return fmt.Errorf("shape %q: %w", d.ID, err)
+		}
+
+		shapes[i] = NewShape(d.ID, d.X, d.Y, d.Theta, geo)
+		shapes[i].layer = d.Layer
+	}
+
+	if sc.Active < 0 || sc.Active >= len(shapes) {
+		sc.Active = 0
+	}
+
+	// The scene has the random shapes already, the rest of the batch
+	// would only pile up on them
+	g.batch = nil
+	g.s = shapes
+	g.activeShape = sc.Active
+
+	return nil
+}
+
+// check makes sure the geometry can be rasterized, so a hand edited scene
+// doesn't panic on load.
+func (g geometry) check() error {
+	switch g.kind {
+	case kindCircle, kindArc, kindPie:
+		if g.r <= 0 {
+			return fmt.Errorf("%s radius %d", g.kind, g.r)
+		}
+	case kindPolygon:
+		if g.r <= 0 || g.sides < 3 {
+			return fmt.Errorf("polygon radius %d with %d sides", g.r, g.sides)
+		}
+	case kindRectangle:
+		if g.w <= 0 || g.h <= 0 {
+			return fmt.Errorf("rectangle %dx%d", g.w, g.h)
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", g.kind)
+	}
+
+	return nil
+}
+
+// Save writes the scene to a JSON file.
+func (g *Game) Save(path string) error {
+	data, err := json.MarshalIndent(g.scene(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Load replaces the scene with the one in the JSON file.
+func (g *Game) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var sc scene
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return err
+	}
+
+	return g.restore(sc)
+}
+
+func hexColor(clr color.Color) string {
+	c := color.RGBAModel.Convert(clr).(color.RGBA)
+
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	var c color.RGBA
+
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A); err != nil {
+		return c, fmt.Errorf("bad color %q: %w", s, err)
+	}
+
+	return c, nil
+}
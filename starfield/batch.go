@@ -0,0 +1,84 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Quads a DrawTriangles call can take, six indices each
+const batchQuads = ebiten.MaxIndicesNum / 6
+
+// starBatch draws many tinted rectangles of the white pixel in as few
+// DrawTriangles calls as it can, their color in the vertices instead of a
+// color matrix, so stars of every tint go together. The vertices and
+// indices are kept from frame to frame, so drawing allocates nothing once
+// they've grown to the size of the field.
+type starBatch struct {
+	vertices []ebiten.Vertex
+	indices  []uint16
+	op       ebiten.DrawTrianglesOptions
+	// DrawTriangles calls this frame, and the frame before, for the HUD
+	calls, lastCalls int
+}
+
+// quad adds a w by h rectangle, placed by m, in the color. The color is
+// taken as an NRGBA, the straight alpha the vertices want, as any other
+// color.Color would be boxed into an allocation for every star.
+func (b *starBatch) quad(dst *ebiten.Image, m *ebiten.GeoM, w, h float64, clr color.NRGBA) {
+	if len(b.vertices) == 4*batchQuads {
+		b.Flush(dst)
+	}
+
+	r, g, bl, a := float32(clr.R)/0xff, float32(clr.G)/0xff, float32(clr.B)/0xff, float32(clr.A)/0xff
+	n := uint16(len(b.vertices))
+
+	for _, c := range [4][2]float64{{0, 0}, {w, 0}, {0, h}, {w, h}} {
+		x, y := m.Apply(c[0], c[1])
+		b.vertices = append(b.vertices, ebiten.Vertex{
+			DstX: float32(x), DstY: float32(y),
+			ColorR: r, ColorG: g, ColorB: bl, ColorA: a,
+		})
+	}
+
+	b.indices = append(b.indices, n, n+1, n+2, n+1, n+2, n+3)
+}
+
+// Flush draws what was added since the last flush onto dst. Whatever is
+// drawn on dst in between has to flush first, to stay in order. A nil dst
+// drops the vertices instead, for BenchmarkStarfield to build them without
+// a window to draw on.
+func (b *starBatch) Flush(dst *ebiten.Image) {
+	if len(b.indices) == 0 {
+		return
+	}
+
+	if dst != nil {
+		dst.DrawTriangles(b.vertices, b.indices, shapes.EmptyImage, &b.op)
+	}
+
+	b.vertices, b.indices = b.vertices[:0], b.indices[:0]
+	b.calls++
+}
+
+// frame starts counting the calls of a new frame.
+func (b *starBatch) frame() {
+	b.lastCalls, b.calls = b.calls, 0
+}
+
+// streak adds a segment from (x1, y1) to (x2, y2), thickness wide, as
+// shapes.Line has it.
+func (b *starBatch) streak(dst *ebiten.Image, x1, y1, x2, y2, thickness float64, clr color.NRGBA) {
+	l := math.Hypot(x2-x1, y2-y1)
+	if l == 0 {
+		return
+	}
+
+	var m ebiten.GeoM
+	m.Translate(0, -thickness/2)
+	m.Rotate(math.Atan2(y2-y1, x2-x1))
+	m.Translate(x1, y1)
+	b.quad(dst, &m, l, thickness, clr)
+}
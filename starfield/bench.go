@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log"
+	"runtime"
+	"time"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// A frame at 60 FPS
+const frameBudget = time.Second / 60
+
+// bench is the benchmark (-bench): the field scrolls by for a while, in
+// warp the second half of it, as fast as it can draw, and then how it went
+// is logged and the starfield quits. Run it with -stars 10000 to see the
+// batching hold up. BenchmarkStarfield times the star work of a frame
+// without a window, this times whole frames, drawing and all.
+type bench struct {
+	duration time.Duration
+	start    time.Time
+	last     time.Time
+	frames   int
+	// Frames that took longer than frameBudget, and the longest one
+	slow  int
+	worst time.Duration
+	calls int
+	// Allocations when it started
+	mallocs uint64
+}
+
+func newBench(d time.Duration) *bench {
+	// Vsync would cap it at the refresh rate, hiding the headroom
+	ebiten.SetVsyncEnabled(false)
+
+	return &bench{duration: d}
+}
+
+// Update drives the field, and reports whether the time is up.
+func (b *bench) Update(g *Game) bool {
+	g.autoscroll = true
+	if b.frames > 0 && time.Since(b.start) > b.duration/2 {
+		g.warp.engaged = true
+	}
+
+	return b.frames > 0 && time.Since(b.start) >= b.duration
+}
+
+// frame times a frame, called from Draw. The first one only starts the
+// clock, it has the loading in it.
+func (b *bench) frame(calls int) {
+	now := time.Now()
+
+	if b.start.IsZero() {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		b.start, b.last, b.mallocs = now, now, ms.Mallocs
+
+		return
+	}
+
+	d := now.Sub(b.last)
+	b.last = now
+	b.frames++
+	b.calls += calls
+
+	if d > frameBudget {
+		b.slow++
+	}
+
+	if d > b.worst {
+		b.worst = d
+	}
+}
+
+// report logs how it went.
+func (b *bench) report(stars int) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	elapsed := b.last.Sub(b.start)
+	frames := float64(b.frames)
+
+	log.Printf("bench: %d stars, %d frames in %s, %.1f FPS, %d over %s (worst %s), %.1f draw calls and %.1f allocations a frame",
+		stars, b.frames, elapsed.Round(time.Millisecond), frames/elapsed.Seconds(), b.slow, frameBudget.Round(time.Microsecond),
+		b.worst.Round(time.Microsecond), float64(b.calls)/frames, float64(ms.Mallocs-b.mallocs)/frames)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/antoniomo/ebiten-exercises/internal/camera"
+)
+
+// benchGame is a game with a field of n stars, all of them drawn one by one
+// rather than some merged into the far layer textures.
+func benchGame(b *testing.B, n int) (*Game, field) {
+	b.Helper()
+
+	g := &Game{cam: camera.New(screenWidth, screenHeight), lod: newStarLOD(0)}
+	f := field{
+		Seed:      1,
+		Placement: "uniform",
+		Stars:     starConfig{Count: n, Distribution: 2, Layers: 8, Twinkle: 0.5, Temperature: 0.5},
+	}
+
+	if err := g.generate(f); err != nil {
+		b.Fatal(err)
+	}
+
+	return g, f
+}
+
+// BenchmarkStarfield times the star work of a frame without a window: the
+// level of detail pass over the pool and building the batched vertices of
+// every star, scrolling and twinkling as they go. The batches are dropped
+// instead of drawn, see starBatch.Flush. It also times generating new
+// fields into the pool of the last one.
+func BenchmarkStarfield(b *testing.B) {
+	for _, n := range []int{10000, 50000} {
+		n := n
+
+		b.Run(fmt.Sprintf("frame/%d", n), func(b *testing.B) {
+			g, _ := benchGame(b, n)
+			// The first frame grows the slices of the batch and the level
+			// of detail, the rest should allocate nothing
+			benchFrame(g)
+
+			calls := 0
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				calls += benchFrame(g)
+			}
+
+			b.ReportMetric(float64(calls)/float64(b.N), "draws/frame")
+		})
+
+		b.Run(fmt.Sprintf("generate/%d", n), func(b *testing.B) {
+			g, f := benchGame(b, n)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				f.Seed = int64(i)
+				if err := g.generate(f); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// benchFrame does the star work of a frame, and returns the draw calls it
+// took.
+func benchFrame(g *Game) int {
+	g.clock.Steps()
+	g.MoveView(-1, 0.5)
+	g.lod.Update(g)
+	g.batch.frame()
+
+	for i, l := range g.layers {
+		g.drawLayer(nil, l, g.lod.drawn[i], 0.5)
+	}
+
+	g.batch.Flush(nil)
+
+	return g.batch.calls
+}
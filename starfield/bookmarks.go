@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/antoniomo/ebiten-exercises/internal/buildinfo"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/place"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	bookmarkCount = 5
+	// snapshotVersion is bumped whenever the snapshot format changes in a
+	// way that older files can't be read anymore.
+	snapshotVersion = 2
+)
+
+//nolint:gochecknoglobal
+var bookmarkKeys = [bookmarkCount]ebiten.Key{
+	ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4, ebiten.Key5,
+}
+
+// field is what's needed to generate a starfield again.
+type field struct {
+	Seed      int64      `json:"seed"`
+	Stars     starConfig `json:"stars"`
+	Placement string     `json:"placement"`
+}
+
+// view is a field and where the camera is on it.
+type view struct {
+	Field field   `json:"field"`
+	CamX  float64 `json:"cam_x"`
+	CamY  float64 `json:"cam_y"`
+	Zoom  float64 `json:"zoom"`
+}
+
+// bookmarks are views to jump back to, kept in a JSON file so they're there
+// across runs.
+type bookmarks struct {
+	path  string
+	Views [bookmarkCount]*view `json:"views"`
+}
+
+// loadBookmarks reads the bookmarks, a missing file is just no bookmarks.
+func loadBookmarks(path string) (*bookmarks, error) {
+	b := &bookmarks{path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	// Fields from before the stars had depths had near and far counts
+	// instead, they can't be generated again
+	for i, v := range b.Views {
+		if v != nil && v.Field.Stars.Count == 0 {
+			log.Printf("%s: dropping bookmark %d, from an older version", path, i+1)
+			b.Views[i] = nil
+		}
+	}
+
+	return b, nil
+}
+
+// Set stores the view in bookmark i, and saves the bookmarks.
+func (b *bookmarks) Set(i int, v view) error {
+	b.Views[i] = &v
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(b.path, data, 0644)
+}
+
+func (g *Game) view() view {
+	return view{Field: g.field, CamX: g.camX, CamY: g.camY, Zoom: g.cam.Zoom}
+}
+
+// jump goes to the view, regenerating the field only if it's a different
+// one.
+func (g *Game) jump(v view) error {
+	if v.Field != g.field {
+		if err := g.generate(v.Field); err != nil {
+			return err
+		}
+	}
+
+	g.MoveView(v.CamX-g.camX, v.CamY-g.camY)
+	g.cam.Zoom = clampZoom(v.Zoom)
+
+	return nil
+}
+
+// bookmarkBindings store the view on Ctrl+1..5, and jump to it on 1..5.
+func (g *Game) bookmarkBindings() keymap.Map {
+	m := make(keymap.Map, 0, 2*bookmarkCount)
+
+	for i, k := range bookmarkKeys {
+		i := i
+		m = append(m,
+			keymap.Binding{Keys: keymap.Keys(k), Trigger: keymap.Pressed, Ctrl: true, Action: func() error {
+				g.notify.Push("Bookmark %d set", i+1)
+
+				return g.bookmarks.Set(i, g.view())
+			}},
+			keymap.Binding{Keys: keymap.Keys(k), Trigger: keymap.Pressed, Action: func() error {
+				v := g.bookmarks.Views[i]
+				if v == nil {
+					return nil
+				}
+
+				g.notify.Push("Jumped to bookmark %d", i+1)
+
+				return g.jump(*v)
+			}},
+		)
+	}
+
+	return m
+}
+
+// snapshot is the full field state, every star as it is, rather than how to
+// generate it again.
+type snapshot struct {
+	Version int `json:"version"`
+	// What saved it, for reference
+	Build buildinfo.Info `json:"build"`
+	View  view           `json:"view"`
+	Stars []starData     `json:"stars"`
+}
+
+// starData is where a star is with the camera at 0,0, and its depth.
+type starData struct {
+	X     int     `json:"x"`
+	Y     int     `json:"y"`
+	Depth float64 `json:"depth"`
+}
+
+func (g *Game) starData() []starData {
+	stars := make([]starData, 0, len(g.pool))
+
+	for _, l := range g.layers {
+		for i := range l.stars {
+			s := &l.stars[i]
+			stars = append(stars, starData{s.x, s.y, s.depth})
+		}
+	}
+
+	return stars
+}
+
+// SaveSnapshot writes the field state to a JSON file.
+func (g *Game) SaveSnapshot(path string) error {
+	data, err := json.MarshalIndent(snapshot{
+		Version: snapshotVersion,
+		Build:   buildinfo.Get(),
+		View:    g.view(),
+		Stars:   g.starData(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// LoadSnapshot replaces the field with the one in the JSON file.
+func (g *Game) LoadSnapshot(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+
+	if _, err := place.ByName(s.View.Field.Placement); err != nil {
+		return err
+	}
+
+	g.layers, g.pool = newLayers(s.View.Field.Stars, s.Stars, g.pool)
+	vary(g.layers, s.View.Field.Stars, s.View.Field.Seed)
+
+	g.field = s.View.Field
+	g.camX, g.camY = s.View.CamX, s.View.CamY
+	g.cam.Zoom = clampZoom(s.View.Zoom)
+
+	return nil
+}
@@ -1,6 +1,6 @@
 #!/usr/bin/env bash
 
-GOOS=js GOARCH=wasm go build -o starfield.wasm .
+GOOS=js GOARCH=wasm go build -ldflags "$(../ldflags.sh)" -o starfield.wasm .
 
 export GOPHERJS_GOROOT="$(go1.12.16 env GOROOT)"
 gopherjs build -o starfield.js
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/antoniomo/ebiten-exercises/internal/camera"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/lod"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// How fast the nearest and the farthest stars go by, per MoveView step
+	translateNear = 3
+	translateFar  = 1
+	// Radius of the farthest stars, the nearest are starRadius
+	farRadius = 1
+	// Alpha of the farthest stars, the nearest are opaque
+	farAlpha = 0x80
+	// How far off a star a click still picks it, in screen pixels
+	pickSlack = 2
+)
+
+// starConfig is how the stars of a field are generated: how many, how they
+// spread in depth, and in how many layers.
+type starConfig struct {
+	Count int `json:"count"`
+	// Depths are a uniform random number to this power, so over 1 there
+	// are more far stars than near ones, as in the sky
+	Distribution float64 `json:"distribution"`
+	// Stars are binned into this many layers by their depth, and each layer
+	// moves and is drawn as one. More layers is smoother parallax, fewer is
+	// cheaper
+	Layers int `json:"layers"`
+	// How much the stars dim when they twinkle, from 0 for not at all to 1
+	// for all the way
+	Twinkle float64 `json:"twinkle"`
+	// How far the color temperature of the stars strays from white, from
+	// 0 for all white to 1 for reds to blues
+	Temperature float64 `json:"temperature"`
+}
+
+// depth returns a random depth, from 0 for the farthest to 1 for the
+// nearest.
+func (c starConfig) depth(rnd *rand.Rand) float64 {
+	return math.Pow(rnd.Float64(), c.Distribution)
+}
+
+// bin returns the layer of a star at depth d.
+func (c starConfig) bin(d float64) int {
+	if c.Layers <= 1 {
+		return 0
+	}
+
+	return int(math.Round(math.Max(0, math.Min(d, 1)) * float64(c.Layers-1)))
+}
+
+// starLayer is the stars binned at a depth. The depth decides everything
+// about them: nearer layers go by faster, zoom more, are bigger and
+// brighter, and pulse more with the music. They're all drawn together, see
+// starBatch.
+type starLayer struct {
+	depth  float64
+	speed  float64
+	radius int
+	clr    color.RGBA
+	// Its part of the stars of the field, see newLayers
+	stars []Star
+	// The texture of the stars too small to draw one by one, see starLOD
+	static lod.Static
+}
+
+// newLayers makes the layers of a config, from the farthest to the
+// nearest, with the stars binned into them in the order they come. The
+// stars of every layer are kept in pool, each layer a part of it, so a
+// field is one allocation however many stars it has, and the next one
+// reuses it if it fits.
+func newLayers(c starConfig, stars []starData, pool []Star) ([]*starLayer, []Star) {
+	n := c.Layers
+	if n < 1 {
+		n = 1
+	}
+
+	layers := make([]*starLayer, n)
+
+	for i := range layers {
+		d := 1.0
+		if n > 1 {
+			d = float64(i) / float64(n-1)
+		}
+
+		l := &starLayer{
+			depth:  d,
+			speed:  translateFar + (translateNear-translateFar)*d,
+			radius: int(math.Round(farRadius + (starRadius-farRadius)*d)),
+			clr:    color.RGBA{0xff, 0xff, 0xff, uint8(farAlpha + (0xff-farAlpha)*d)},
+		}
+
+		layers[i] = l
+	}
+
+	// Where each layer starts in the pool, and where the next star of it
+	// goes
+	start := make([]int, n+1)
+	for _, st := range stars {
+		start[c.bin(st.Depth)+1]++
+	}
+
+	for i := 1; i <= n; i++ {
+		start[i] += start[i-1]
+	}
+
+	if cap(pool) < len(stars) {
+		pool = make([]Star, len(stars))
+	}
+
+	pool = pool[:len(stars)]
+	next := append([]int(nil), start[:n]...)
+
+	for _, st := range stars {
+		i := c.bin(st.Depth)
+		pool[next[i]] = Star{x: st.X, y: st.Y, radius: layers[i].radius, depth: st.Depth}
+		next[i]++
+	}
+
+	for i, l := range layers {
+		l.stars = pool[start[i]:start[i+1]:start[i+1]]
+	}
+
+	return layers, pool
+}
+
+// zoom returns the zoom of the layer for the zoom of the view: the nearest
+// layer zooms as much, the farthest half as much.
+func (l *starLayer) zoom(zoom float64) float64 {
+	return 1 + (zoom-1)*(0.5+l.depth/2)
+}
+
+// camera returns the camera of the view as the layer sees it, with its own
+// zoom.
+func (l *starLayer) camera(cam camera.Camera) camera.Camera {
+	cam.Zoom = l.zoom(cam.Zoom)
+
+	return cam
+}
+
+// pulse returns the pulse of the layer for the music level, near stars
+// react more than far ones.
+func (l *starLayer) pulse(level float64) float64 {
+	return 1 + level*(0.5+l.depth/2)
+}
+
+// offset returns how far the layer moved for the camera, wrapped around
+// the screen.
+func (l *starLayer) offset(camX, camY float64) (float64, float64) {
+	return wrapf(camX*l.speed, screenWidth), wrapf(camY*l.speed, screenHeight)
+}
+
+// position returns where the star is drawn with the layer offset, wrapping
+// around the screen.
+func (s *Star) position(offX, offY float64) (float64, float64) {
+	return wrapf(float64(s.x)+offX, screenWidth), wrapf(float64(s.y)+offY, screenHeight)
+}
+
+func wrapf(v, n float64) float64 {
+	v = math.Mod(v, n)
+	if v < 0 {
+		v += n
+	}
+
+	return v
+}
+
+// drawLayer adds the stars of the layer picked by the level of detail to
+// the batch.
+func (g *Game) drawLayer(screen *ebiten.Image, l *starLayer, stars []*Star, level float64) {
+	offX, offY := l.offset(g.camX, g.camY)
+	pulse, view := l.pulse(level), l.camera(g.cam).GeoM()
+	now := g.clock.Now()
+
+	for _, s := range stars {
+		x, y := s.position(offX, offY)
+		s.batch(&g.batch, screen, x, y, pulse, &view, s.shade(l.clr, s.twinkle(g.field.Stars, now)))
+	}
+}
+
+// starAt returns the star under the screen point (x, y), and its layer,
+// trying the nearest layers first as they're drawn on top, or nil.
+func (g *Game) starAt(x, y int) (*starLayer, *Star) {
+	for i := len(g.layers) - 1; i >= 0; i-- {
+		l := g.layers[i]
+		cam := l.camera(g.cam)
+		offX, offY := l.offset(g.camX, g.camY)
+		wx, wy := cam.ScreenToWorld(float64(x), float64(y))
+
+		for j := range l.stars {
+			s := &l.stars[j]
+			sx, sy := s.position(offX, offY)
+			r := float64(s.radius)
+
+			if math.Hypot(wx-sx-r, wy-sy-r) <= r+pickSlack/cam.Zoom {
+				return l, s
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// pickStar tells about the star under the cursor.
+func (g *Game) pickStar() {
+	l, s := g.starAt(keymap.Input().CursorPosition())
+	if s == nil {
+		return
+	}
+
+	g.notify.Push("Star at %d,%d, depth %.2f (layer %.2f)", s.x, s.y, s.depth, l.depth)
+}
+
+// drawAt draws the star at (x, y) as Draw does, tinted.
+func (s *Star) drawAt(screen *ebiten.Image, x, y, pulse float64, cam camera.Camera, clr color.Color) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(s.radius), -float64(s.radius))
+	op.GeoM.Scale(pulse, pulse)
+	op.GeoM.Translate(x+float64(s.radius), y+float64(s.radius))
+	cam.Apply(op)
+	op.ColorM.Scale(shapes.ColorScale(clr))
+	op.ColorM.Scale(1, 1, 1, pulse)
+	_ = screen.DrawImage(s.img, op)
+}
+
+// batch adds the star at (x, y) to the batch as drawAt draws it, view being
+// the GeoM of the camera, worked out once for the layer.
+func (s *Star) batch(b *starBatch, dst *ebiten.Image, x, y, pulse float64, view *ebiten.GeoM, clr color.NRGBA) {
+	r := float64(s.radius)
+
+	var m ebiten.GeoM
+	m.Translate(-r, -r)
+	m.Scale(pulse, pulse)
+	m.Translate(x+r, y+r)
+	m.Concat(*view)
+	b.quad(dst, &m, 2*r, 2*r, clr)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/antoniomo/ebiten-exercises/internal/camera"
+	"github.com/antoniomo/ebiten-exercises/internal/particle"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Dust is closer than the near stars, so it goes by faster
+	translateDust = 6
+	// Streak length per pixel per tick of speed
+	streakFactor = 3
+	// Life of a dust particle, in ticks
+	dustMinLife = 120
+	dustMaxLife = 360
+)
+
+//nolint:gochecknoglobal
+var dustColor = color.NRGBA{0xc0, 0xc0, 0xd0, 0xff}
+
+// dust is a foreground layer of particles, streaking by opposite to where
+// the view goes, longer the faster it goes. Particles fade in and out over
+// their life, and are recycled all over the screen.
+type dust struct {
+	pool *particle.Pool
+	// How much the dust moved this tick, for the streaks
+	vx float64
+	vy float64
+}
+
+func newDust(n int) *dust {
+	return &dust{pool: particle.NewPool(n)}
+}
+
+// Update moves the dust with the view, which moved by (dx, dy) this tick in
+// MoveView steps, lets it drift and age for the simulation steps of the
+// tick, and tops it up.
+func (d *dust) Update(dx, dy float64, steps int) {
+	d.vx, d.vy = dx*translateDust, dy*translateDust
+
+	for i := 0; i < steps; i++ {
+		d.pool.Update()
+	}
+
+	d.pool.Each(func(p *particle.Particle) bool {
+		p.X += d.vx
+		p.Y += d.vy
+
+		// Gone off screen, there's more on the other side
+		return p.X >= 0 && p.X < screenWidth && p.Y >= 0 && p.Y < screenHeight
+	})
+
+	for p := d.pool.Spawn(); p != nil; p = d.pool.Spawn() {
+		p.X = rand.Float64() * screenWidth
+		p.Y = rand.Float64() * screenHeight
+		// A slow drift of their own
+		p.VX = (rand.Float64() - 0.5) * 0.2
+		p.VY = (rand.Float64() - 0.5) * 0.2
+		p.Life = dustMinLife + rand.Intn(dustMaxLife-dustMinLife)
+	}
+}
+
+// Draw draws the dust as streaks trailing behind its motion, or just specks
+// when still, through the camera like the stars.
+func (d *dust) Draw(screen *ebiten.Image, cam camera.Camera) {
+	speed := math.Hypot(d.vx, d.vy)
+
+	d.pool.Each(func(p *particle.Particle) bool {
+		// Fade in and out
+		alpha := math.Sin(p.Fade() * math.Pi)
+		clr := dustColor
+		clr.A = uint8(float64(clr.A) * alpha)
+
+		x, y := cam.WorldToScreen(p.X, p.Y)
+
+		if speed == 0 {
+			ebitenutil.DrawRect(screen, x, y, 1, 1, clr)
+
+			return true
+		}
+
+		// Motion blur, fainter than the speck itself
+		l := speed * streakFactor * cam.Zoom
+		tx, ty := x-d.vx/speed*l, y-d.vy/speed*l
+		blur := clr
+		blur.A /= 2
+		ebitenutil.DrawLine(screen, tx, ty, x, y, blur)
+		ebitenutil.DrawRect(screen, x, y, 1, 1, clr)
+
+		return true
+	})
+}
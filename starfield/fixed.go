@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/antoniomo/ebiten-exercises/internal/fixed"
+)
+
+//nolint:gochecknoglobal
+var (
+	fixedThrust   = fixed.Float(shipThrust)
+	fixedMaxSpeed = fixed.Int(shipMaxSpeed)
+	fixedDrag     = fixed.Float(shipDrag)
+	fixedBrake    = fixed.Float(shipBrake)
+	fixedWidth    = fixed.Int(screenWidth)
+	fixedHeight   = fixed.Int(screenHeight)
+)
+
+// fixedSim runs the motion of the field in fixed-point (X, or -fixed), so
+// it goes bit for bit the same on every platform for the same input: the
+// camera, the velocity of the ship, and so where every star is. The float
+// state follows it, for drawing and the rest, and a hash of it all goes in
+// the HUD, to compare runs. Jumps, loads and new fields move the camera
+// with floats, which it picks up from there.
+type fixedSim struct {
+	camX, camY fixed.Num
+	vx, vy     fixed.Num
+	// The float camera as it was left, to notice it moved otherwise
+	lastX, lastY float64
+	hash         uint64
+}
+
+// newFixedSim starts the fixed-point simulation where the float one is.
+func newFixedSim(g *Game) *fixedSim {
+	f := &fixedSim{}
+	f.sync(g)
+	f.vx, f.vy = fixed.Float(g.ship.vx), fixed.Float(g.ship.vy)
+	f.hash = f.sum(g)
+
+	return f
+}
+
+// sync takes the camera from the floats.
+func (f *fixedSim) sync(g *Game) {
+	f.camX, f.camY = fixed.Float(g.camX), fixed.Float(g.camY)
+	f.lastX, f.lastY = g.camX, g.camY
+}
+
+// step runs what fly, autoscroll and MoveView do for the simulation steps
+// of the tick, and returns how far the view moved, as MoveView would.
+func (f *fixedSim) step(g *Game, steps int) (float64, float64) {
+	if g.camX != f.lastX || g.camY != f.lastY {
+		f.sync(g)
+	}
+
+	s := g.ship
+	// The thrust asked for is whole steps each way, exactly as fixed-point
+	ax, ay := fixed.Float(s.ax), fixed.Float(s.ay)
+	if l := fixed.Hypot(ax, ay); l > 0 {
+		ax, ay = ax.Mul(fixedThrust).Div(l), ay.Mul(fixedThrust).Div(l)
+	}
+
+	drag := fixedDrag
+	if s.braking {
+		drag = fixedBrake
+	}
+
+	s.ax, s.ay, s.braking = 0, 0, false
+
+	var dx, dy fixed.Num
+
+	for i := 0; i < steps; i++ {
+		f.vx = (f.vx + ax).Mul(fixed.One - drag)
+		f.vy = (f.vy + ay).Mul(fixed.One - drag)
+
+		if v := fixed.Hypot(f.vx, f.vy); v > fixedMaxSpeed {
+			f.vx, f.vy = f.vx.Mul(fixedMaxSpeed).Div(v), f.vy.Mul(fixedMaxSpeed).Div(v)
+		}
+
+		dx -= f.vx
+		dy -= f.vy
+	}
+
+	if g.autoscroll {
+		dx -= fixed.Int(steps)
+	}
+
+	f.camX += dx
+	f.camY += dy
+	g.camX, g.camY = f.camX.Float(), f.camY.Float()
+	f.lastX, f.lastY = g.camX, g.camY
+	s.vx, s.vy = f.vx.Float(), f.vy.Float()
+	f.hash = f.sum(g)
+
+	return dx.Float(), dy.Float()
+}
+
+// layerSpeed returns how fast layer i of n goes by, as newLayers has it, from
+// integers.
+func layerSpeed(i, n int) fixed.Num {
+	if n <= 1 {
+		return fixed.Int(translateNear)
+	}
+
+	return fixed.Int(translateFar) + fixed.Ratio((translateNear-translateFar)*i, n-1)
+}
+
+// sum hashes the state: the camera, the velocity, and where every star is
+// for the camera, layer by layer.
+func (f *fixedSim) sum(g *Game) uint64 {
+	h := fixed.NewHasher()
+	h.Add(f.camX, f.camY, f.vx, f.vy)
+
+	for i, l := range g.layers {
+		speed := layerSpeed(i, len(g.layers))
+		offX, offY := f.camX.Mul(speed).Mod(fixedWidth), f.camY.Mul(speed).Mod(fixedHeight)
+
+		for j := range l.stars {
+			s := &l.stars[j]
+			h.Add((fixed.Int(s.x) + offX).Mod(fixedWidth), (fixed.Int(s.y) + offY).Mod(fixedHeight))
+		}
+	}
+
+	return h.Sum()
+}
+
+// toggleFixed switches between the fixed-point simulation and the float
+// one, carrying the state over.
+func (g *Game) toggleFixed() {
+	if g.fixed != nil {
+		g.fixed = nil
+		g.notify.Push("Floating point simulation")
+
+		return
+	}
+
+	g.fixed = newFixedSim(g)
+	g.notify.Push("Fixed-point simulation")
+}
+
+// fixedHUD describes the simulation mode, with the state hash if it's the
+// fixed-point one.
+func (g *Game) fixedHUD() string {
+	if g.fixed == nil {
+		return "Simulation: floating point (X for fixed-point)"
+	}
+
+	return fmt.Sprintf("Simulation: fixed-point (X), state hash %016x", g.fixed.hash)
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/camera"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+	"github.com/hajimehoshi/ebiten/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	minClusters = 4
+	maxClusters = 6
+	// Systems per cluster
+	minSystems = 4
+	maxSystems = 8
+	// Spread of the systems around their cluster center, and how close two
+	// can be
+	clusterSpread = 35
+	minSystemGap  = 24
+	// Systems stay this far from the screen edges, so the labels fit
+	mapMargin = 40
+	// Lanes besides the spanning tree, between systems this close
+	laneRange = 70
+	// Systems a click picks, how far off it can be
+	systemPick   = 8
+	systemRadius = 4
+	// Offsets of the labels from their system
+	labelX = 6
+	labelY = 14
+)
+
+//nolint:gochecknoglobal
+var (
+	laneColor          = color.RGBA{0x40, 0x60, 0x90, 0xff}
+	selectedLaneColor  = color.RGBA{0x80, 0xc0, 0xff, 0xff}
+	labelColor         = color.RGBA{0xa0, 0xa0, 0xa0, 0xff}
+	selectedLabelColor = color.RGBA{0xff, 0xff, 0x80, 0xff}
+	// Star classes, from hot to cool, and their colors
+	starClasses = []struct {
+		name string
+		clr  color.Color
+	}{
+		{"O", color.RGBA{0x9b, 0xb0, 0xff, 0xff}},
+		{"B", color.RGBA{0xaa, 0xbf, 0xff, 0xff}},
+		{"A", color.RGBA{0xca, 0xd7, 0xff, 0xff}},
+		{"F", color.RGBA{0xf8, 0xf7, 0xff, 0xff}},
+		{"G", color.RGBA{0xff, 0xf4, 0xea, 0xff}},
+		{"K", color.RGBA{0xff, 0xd2, 0xa1, 0xff}},
+		{"M", color.RGBA{0xff, 0xcc, 0x6f, 0xff}},
+	}
+	nameSyllables = []string{
+		"al", "be", "cor", "da", "el", "fen", "ga", "hel", "ix", "jo",
+		"ka", "lor", "ma", "nev", "or", "pra", "qua", "ri", "sol", "tau",
+		"ul", "ve", "wy", "xe", "yr", "zan",
+	}
+	nameSuffixes = []string{"", "", "", " Prime", " Major", " Minor", " II", " III", " IV"}
+)
+
+type system struct {
+	name    string
+	class   string
+	cluster int
+	x, y    int
+	star    *Star
+	// Indices of the systems there are lanes to
+	lanes []int
+}
+
+// galaxy is the map mode: star systems in clusters, with lanes between
+// them, all generated from the field seed.
+type galaxy struct {
+	seed     int64
+	systems  []system
+	selected int
+}
+
+// newGalaxy generates the map for a seed, the same one for the same seed.
+func newGalaxy(seed int64) *galaxy {
+	rnd := rand.New(rand.NewSource(seed))
+	gx := &galaxy{seed: seed, selected: -1}
+	names := make(map[string]bool)
+
+	clusters := minClusters + rnd.Intn(maxClusters-minClusters+1)
+	for c := 0; c < clusters; c++ {
+		cx := mapMargin + clusterSpread + rnd.Float64()*(screenWidth-2*(mapMargin+clusterSpread))
+		cy := mapMargin + clusterSpread + rnd.Float64()*(screenHeight-2*(mapMargin+clusterSpread))
+
+		n := minSystems + rnd.Intn(maxSystems-minSystems+1)
+		for i, tries := 0, 0; i < n && tries < 10*n; tries++ {
+			x := int(cx + rnd.NormFloat64()*clusterSpread)
+			y := int(cy + rnd.NormFloat64()*clusterSpread)
+
+			if x < mapMargin || x > screenWidth-mapMargin || y < mapMargin || y > screenHeight-mapMargin ||
+				gx.near(x, y, minSystemGap) >= 0 {
+				continue
+			}
+
+			class := starClasses[rnd.Intn(len(starClasses))]
+			gx.systems = append(gx.systems, system{
+				name:    systemName(rnd, names),
+				class:   class.name,
+				cluster: c,
+				x:       x,
+				y:       y,
+				star:    NewStar(x-systemRadius, y-systemRadius, systemRadius, class.clr),
+			})
+			i++
+		}
+	}
+
+	gx.connect()
+
+	return gx
+}
+
+// systemName makes up a name that isn't taken yet.
+func systemName(rnd *rand.Rand, taken map[string]bool) string {
+	for {
+		var b strings.Builder
+
+		for i := 2 + rnd.Intn(2); i > 0; i-- {
+			b.WriteString(nameSyllables[rnd.Intn(len(nameSyllables))])
+		}
+
+		name := strings.Title(b.String()) + nameSuffixes[rnd.Intn(len(nameSuffixes))]
+		if !taken[name] {
+			taken[name] = true
+
+			return name
+		}
+	}
+}
+
+func (gx *galaxy) dist(i, j int) float64 {
+	a, b := gx.systems[i], gx.systems[j]
+
+	return math.Hypot(float64(a.x-b.x), float64(a.y-b.y))
+}
+
+// near returns the index of a system within d of the point, or -1.
+func (gx *galaxy) near(x, y int, d float64) int {
+	for i, s := range gx.systems {
+		if math.Hypot(float64(s.x-x), float64(s.y-y)) <= d {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (gx *galaxy) lane(i, j int) {
+	for _, k := range gx.systems[i].lanes {
+		if k == j {
+			return
+		}
+	}
+
+	gx.systems[i].lanes = append(gx.systems[i].lanes, j)
+	gx.systems[j].lanes = append(gx.systems[j].lanes, i)
+}
+
+// connect lays the lanes: a minimum spanning tree, so every system can be
+// reached, and then the shorter ones left out of it, so clusters get denser
+// networks than the long hauls between them.
+func (gx *galaxy) connect() {
+	n := len(gx.systems)
+	if n == 0 {
+		return
+	}
+
+	// Prim's, the graph is complete and small
+	in := make([]bool, n)
+	best := make([]float64, n)
+	from := make([]int, n)
+
+	for i := range best {
+		best[i] = math.Inf(1)
+	}
+
+	best[0] = 0
+	from[0] = -1
+
+	for range gx.systems {
+		u := -1
+
+		for i := range gx.systems {
+			if !in[i] && (u < 0 || best[i] < best[u]) {
+				u = i
+			}
+		}
+
+		in[u] = true
+		if from[u] >= 0 {
+			gx.lane(from[u], u)
+		}
+
+		for v := range gx.systems {
+			if d := gx.dist(u, v); !in[v] && d < best[v] {
+				best[v], from[v] = d, u
+			}
+		}
+	}
+
+	for i := range gx.systems {
+		for j := i + 1; j < n; j++ {
+			if gx.dist(i, j) <= laneRange {
+				gx.lane(i, j)
+			}
+		}
+	}
+}
+
+// selectAtCursor selects the system under the mouse, if any.
+func (gx *galaxy) selectAtCursor() {
+	cx, cy := keymap.Input().CursorPosition()
+	gx.selected = gx.near(cx, cy, systemPick)
+}
+
+func (gx *galaxy) Draw(screen *ebiten.Image) {
+	for i, s := range gx.systems {
+		for _, j := range s.lanes {
+			if j < i {
+				continue
+			}
+
+			clr := laneColor
+			if i == gx.selected || j == gx.selected {
+				clr = selectedLaneColor
+			}
+
+			t := gx.systems[j]
+			ebitenutil.DrawLine(screen, float64(s.x), float64(s.y), float64(t.x), float64(t.y), clr)
+		}
+	}
+
+	for i, s := range gx.systems {
+		s.star.Draw(screen, 1, camera.New(screenWidth, screenHeight))
+
+		clr := labelColor
+		if i == gx.selected {
+			clr = selectedLabelColor
+		}
+
+		text.Draw(screen, s.name, basicfont.Face7x13, s.x+labelX, s.y+labelY, clr)
+	}
+}
+
+// info describes the selected system, for the HUD.
+func (gx *galaxy) info() string {
+	if gx.selected < 0 {
+		return fmt.Sprintf("%d systems, click one for details", len(gx.systems))
+	}
+
+	s := gx.systems[gx.selected]
+	lanes := make([]string, len(s.lanes))
+
+	for i, j := range s.lanes {
+		lanes[i] = fmt.Sprintf("%s (%.0f)", gx.systems[j].name, gx.dist(gx.selected, j))
+	}
+
+	return fmt.Sprintf("%s, class %s star, cluster %d at %d,%d\nLanes to %s",
+		s.name, s.class, s.cluster+1, s.x, s.y, strings.Join(lanes, ", "))
+}
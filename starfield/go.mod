@@ -3,11 +3,13 @@ module github.com/antoniomo/ebiten-exercises/starfield
 go 1.14
 
 require (
+	github.com/antoniomo/ebiten-exercises v0.0.0-00010101000000-000000000000
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20200707082815-5321531c36a2 // indirect
 	github.com/hajimehoshi/ebiten v1.11.7
 	golang.org/x/exp v0.0.0-20200901203048-c4f52b2c50aa // indirect
-	golang.org/x/image v0.0.0-20200801110659-972c09e46d76 // indirect
+	golang.org/x/image v0.0.0-20200801110659-972c09e46d76
 	golang.org/x/mobile v0.0.0-20200801112145-973feb4309de // indirect
 	golang.org/x/sys v0.0.0-20200905004654-be1d3432aa8f // indirect
-	golang.org/x/xerrors v0.0.0-20191011141410-1b5146add898
 )
+
+replace github.com/antoniomo/ebiten-exercises => ../
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/antoniomo/ebiten-exercises/internal/colorutil"
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Side of the cells stars are counted in, in screen pixels
+	heatCell  = 32
+	heatCols  = (screenWidth + heatCell - 1) / heatCell
+	heatRows  = (screenHeight + heatCell - 1) / heatCell
+	heatAlpha = 0.45
+	// The legend, in the bottom right corner
+	legendWidth  = 128
+	legendHeight = 8
+)
+
+// heatmap is the star density overlay (H): the stars on the screen counted
+// in a coarse grid, each cell colored by how many it has against the
+// average, blue for none, red for twice as many or more. An even
+// placement comes out mostly the same color, clumps and gaps stand out.
+// The counts are written one pixel a cell to an image of their own, which
+// is drawn scaled up to the screen with linear filtering, blending the
+// cells into each other.
+type heatmap struct {
+	enabled bool
+	counts  [heatCols * heatRows]int
+	total   int
+	max     int
+	grid    *ebiten.Image
+	pixels  []byte
+	legend  *ebiten.Image
+}
+
+func (h *heatmap) toggle() {
+	h.enabled = !h.enabled
+}
+
+// Update counts the stars of every layer where they're drawn, by the
+// center of each, the ones merged into the background too.
+func (h *heatmap) Update(g *Game) {
+	if !h.enabled {
+		return
+	}
+
+	h.counts = [heatCols * heatRows]int{}
+	h.total, h.max = 0, 0
+
+	for _, l := range g.layers {
+		view := l.camera(g.cam).GeoM()
+		offX, offY := l.offset(g.camX, g.camY)
+
+		for i := range l.stars {
+			s := &l.stars[i]
+			x, y := s.position(offX, offY)
+			sx, sy := view.Apply(x+float64(s.radius), y+float64(s.radius))
+
+			if sx < 0 || sy < 0 || sx >= screenWidth || sy >= screenHeight {
+				continue
+			}
+
+			i := int(sy)/heatCell*heatCols + int(sx)/heatCell
+			h.counts[i]++
+			h.total++
+
+			if h.counts[i] > h.max {
+				h.max = h.counts[i]
+			}
+		}
+	}
+}
+
+// mean returns the stars of an average cell.
+func (h *heatmap) mean() float64 {
+	return float64(h.total) / float64(len(h.counts))
+}
+
+// Draw draws the overlay over the field, and its legend.
+func (h *heatmap) Draw(screen *ebiten.Image) {
+	if !h.enabled {
+		return
+	}
+
+	if h.grid == nil {
+		h.grid, _ = profile.NewImage(heatCols, heatRows, ebiten.FilterLinear)
+		h.pixels = make([]byte, 4*heatCols*heatRows)
+		h.legend, _ = profile.NewImage(legendWidth, 1, ebiten.FilterDefault)
+
+		legend := make([]byte, 4*legendWidth)
+		for x := 0; x < legendWidth; x++ {
+			setPixel(legend, x, colorutil.Heat.At(float64(x)/(legendWidth-1)))
+		}
+
+		_ = h.legend.ReplacePixels(legend)
+	}
+
+	mean := h.mean()
+
+	for i, n := range h.counts {
+		t := 0.0
+		if mean > 0 {
+			t = float64(n) / (2 * mean)
+		}
+
+		setPixel(h.pixels, i, colorutil.Heat.At(t))
+	}
+
+	_ = h.grid.ReplacePixels(h.pixels)
+
+	// A pixel a cell, blended into the next ones by the filter
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(heatCell, heatCell)
+	op.ColorM.Scale(1, 1, 1, heatAlpha)
+	_ = screen.DrawImage(h.grid, op)
+
+	x, y := float64(screenWidth-legendWidth-8), float64(screenHeight-legendHeight-8)
+	op = &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(1, legendHeight)
+	op.GeoM.Translate(x, y)
+	_ = screen.DrawImage(h.legend, op)
+	ebitenutil.DebugPrintAt(screen, "0", int(x)-8, int(y)-6)
+	ebitenutil.DebugPrintAt(screen, "2x avg", int(x)+legendWidth-36, int(y)-20)
+}
+
+// setPixel sets pixel i of RGBA bytes to the color, opaque as the ramp
+// colors are, so it's the same premultiplied.
+func setPixel(pixels []byte, i int, clr color.RGBA) {
+	pixels[4*i], pixels[4*i+1], pixels[4*i+2], pixels[4*i+3] = clr.R, clr.G, clr.B, clr.A
+}
+
+// HUD describes the overlay, for the status line.
+func (h *heatmap) HUD() string {
+	if !h.enabled {
+		return "Heatmap: off (H)"
+	}
+
+	return fmt.Sprintf("Heatmap: %dpx cells (H), %d stars on screen, %.1f a cell on average, %d at most",
+		heatCell, h.total, h.mean(), h.max)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/antoniomo/ebiten-exercises/internal/camera"
+	"github.com/antoniomo/ebiten-exercises/internal/lod"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// starLOD is the level of detail of the field: stars out of view aren't
+// drawn, and in the far layers stars too small to make out are drawn once
+// into a texture of the whole layer, redrawn when the zoom changes, and
+// that texture is drawn instead of them.
+type starLOD struct {
+	policy lod.Policy
+	counts lod.Counters
+	// The stars of each layer to draw one by one this frame
+	drawn [][]*Star
+}
+
+func newStarLOD(minSize float64) *starLOD {
+	return &starLOD{policy: lod.Policy{Width: screenWidth, Height: screenHeight, MinSize: minSize}}
+}
+
+// bounds returns the center and size of the star on the screen, drawn at
+// (x, y) with pulse through the camera, view being its GeoM.
+func (s *Star) bounds(x, y, pulse float64, cam camera.Camera, view *ebiten.GeoM) (float64, float64, float64) {
+	x, y = view.Apply(x+float64(s.radius), y+float64(s.radius))
+
+	return x, y, float64(2*s.radius) * pulse * cam.Zoom
+}
+
+// Update picks the stars of each layer to draw. Stars are merged by their
+// size without the pulse, so they don't flicker in and out of the texture
+// with the music.
+func (l *starLOD) Update(g *Game) {
+	l.counts = lod.Counters{}
+
+	for len(l.drawn) < len(g.layers) {
+		l.drawn = append(l.drawn, nil)
+	}
+
+	for i, sl := range g.layers {
+		l.drawn[i] = l.pick(l.drawn[i][:0], g, sl)
+	}
+}
+
+func (l *starLOD) pick(dst []*Star, g *Game, sl *starLayer) []*Star {
+	offX, offY := sl.offset(g.camX, g.camY)
+	cam := sl.camera(g.cam)
+	view := cam.GeoM()
+
+	for i := range sl.stars {
+		s := &sl.stars[i]
+		x, y := s.position(offX, offY)
+
+		level := l.policy.Level(s.bounds(x, y, 1, cam, &view))
+		if level == lod.Merged && sl.depth >= 0.5 {
+			level = lod.Drawn
+		}
+
+		l.counts.Add(level)
+
+		if level == lod.Drawn {
+			dst = append(dst, s)
+		}
+	}
+
+	return dst
+}
+
+// drawMerged draws the texture of the tiny stars of a far layer, offset as
+// far as the layer moved and wrapping around as it does. The texture is
+// already zoomed, so it's drawn as is.
+func (l *starLOD) drawMerged(screen *ebiten.Image, g *Game, sl *starLayer) {
+	zoom := sl.zoom(g.cam.Zoom)
+	// The stars of a layer are all the same size, so all of them are
+	// merged or none
+	if !l.policy.Tiny(float64(2*sl.radius) * zoom) {
+		return
+	}
+
+	w, h := screenWidth*zoom, screenHeight*zoom
+	offX, offY := sl.offset(g.camX, g.camY)
+
+	// What's in the batch goes under the texture
+	g.batch.Flush(screen)
+
+	img := sl.static.Image(int(w)+1, int(h)+1, zoom, func(dst *ebiten.Image) {
+		for i := range sl.stars {
+			s := &sl.stars[i]
+			x := (float64(s.x) + float64(s.radius)) * zoom
+			y := (float64(s.y) + float64(s.radius)) * zoom
+			drawDot(dst, x, y, float64(2*s.radius)*zoom, s.tint)
+		}
+	})
+
+	// Once where it moved to, and once more on each side it wrapped
+	// around from
+	for _, dx := range []float64{offX * zoom, offX*zoom - w} {
+		for _, dy := range []float64{offY * zoom, offY*zoom - h} {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(dx, dy)
+			op.GeoM.Translate(-screenWidth/2*zoom, -screenHeight/2*zoom)
+			op.GeoM.Translate(screenWidth/2, screenHeight/2)
+			op.ColorM.Scale(shapes.ColorScale(sl.clr))
+			_ = screen.DrawImage(img, op)
+		}
+	}
+}
+
+// drawDot draws a merged star, size pixels wide centered at (x, y), in its
+// tint. Under a pixel it's a pixel as bright as the part of it the star
+// covers. Merged stars don't twinkle, the texture would be redrawn every
+// frame.
+func drawDot(dst *ebiten.Image, x, y, size float64, tint color.RGBA) {
+	alpha := 1.0
+	if size < 1 {
+		alpha = size * size
+		size = 1
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(size, size)
+	op.GeoM.Translate(x-size/2, y-size/2)
+	op.ColorM.Scale(shapes.ColorScale(tint))
+	op.ColorM.Scale(1, 1, 1, alpha)
+	_ = dst.DrawImage(shapes.EmptyImage, op)
+}
+
+// HUD describes the level of detail, for the status line.
+func (l *starLOD) HUD() string {
+	return "Stars: " + l.counts.String()
+}
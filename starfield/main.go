@@ -1,68 +1,65 @@
 package main
 
 import (
-	"errors"
+	"flag"
 	"fmt"
 	"image/color"
 	_ "image/png"
 	"log"
+	"math"
 	"math/rand"
 	"time"
 
-	// gopherjs uses go 1.12, therefore we don't have errors.Is out
-	// of the box
-	"golang.org/x/xerrors"
-
+	"github.com/antoniomo/ebiten-exercises/internal/audio"
+	"github.com/antoniomo/ebiten-exercises/internal/camera"
+	"github.com/antoniomo/ebiten-exercises/internal/clock"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/layer"
+	"github.com/antoniomo/ebiten-exercises/internal/notify"
+	"github.com/antoniomo/ebiten-exercises/internal/place"
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
 	"github.com/hajimehoshi/ebiten"
-	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
 )
 
 const (
 	screenWidth  = 640
 	screenHeight = 480
-	// Very simplistic 2-layer parallax. Of course this could be fully
-	// dynamic with translate speed based on distance, and each star it's
-	// own distance...
-	translateNear = 3
-	translateFar  = 1
-	nearStars     = 50
-	farStars      = 100
-)
-
-var (
-	ErrCleanExit = errors.New("clean exit, no error")
-	//nolint:gochecknoglobal
-	emptyImage *ebiten.Image
+	// Music analysis window, about a frame
+	levelWindow = 20 * time.Millisecond
+	// Envelope follower, fast attack and slow release, per tick
+	envelopeAttack  = 0.5
+	envelopeRelease = 0.08
+	sensitivityStep = 0.25
+	maxSensitivity  = 4
+	starRadius      = 3
+	zoomStep        = 1.02
+	minZoom         = 0.5
+	maxZoom         = 3
 )
 
 //nolint:gochecknoinit
 func init() {
 	rand.Seed(time.Now().UnixNano())
-
-	emptyImage, _ = ebiten.NewImage(1, 1, ebiten.FilterDefault)
-	_ = emptyImage.Fill(color.White)
-}
-
-// colorScale taken from ebitenutil/shapes.go.
-func colorScale(clr color.Color) (rf, gf, bf, af float64) {
-	r, g, b, a := clr.RGBA()
-	if a == 0 {
-		return 0, 0, 0, 0
-	}
-
-	rf = float64(r) / float64(a)
-	gf = float64(g) / float64(a)
-	bf = float64(b) / float64(a)
-	af = float64(a) / 0xffff
-
-	return
 }
 
 type Star struct {
 	x      int
 	y      int
 	radius int
-	img    *ebiten.Image
+	// Only the systems of the galaxy map have an image of their own, the
+	// field stars are drawn in batches
+	img *ebiten.Image
+	// From 0 for the farthest to 1 for the nearest, for the field stars
+	depth float64
+	// Twinkling, where in the cycle it starts and how fast it goes, and
+	// the color of its temperature, see vary
+	phase float64
+	freq  float64
+	tint  color.RGBA
 }
 
 func NewStar(x, y, radius int, clr color.Color) *Star {
@@ -74,10 +71,10 @@ func NewStar(x, y, radius int, clr color.Color) *Star {
 
 	op := &ebiten.DrawImageOptions{}
 	op.GeoM.Scale(float64(radius*2), float64(radius*2))
-	op.ColorM.Scale(colorScale(clr))
+	op.ColorM.Scale(shapes.ColorScale(clr))
 
-	s.img, _ = ebiten.NewImage(radius*2, radius*2, ebiten.FilterDefault)
-	_ = s.img.DrawImage(emptyImage, op)
+	s.img, _ = profile.NewImage(radius*2, radius*2, ebiten.FilterDefault)
+	_ = s.img.DrawImage(shapes.EmptyImage, op)
 
 	return s
 }
@@ -94,139 +91,428 @@ func (s *Star) In(x, y int) bool {
 	return s.img.At(x-s.x+s.radius, y-s.y+s.radius).(color.RGBA).A > 0
 }
 
-// MoveBy moves the star by (x, y).
-func (s *Star) MoveBy(x, y int) {
-	s.x += x
-	s.y += y
-
-	// Circular stars
-	if s.x > screenWidth {
-		s.x = 0
-	}
-
-	if s.x < 0 {
-		s.x = screenWidth
-	}
-
-	if s.y > screenHeight {
-		s.y = 0
-	}
-
-	if s.y < 0 {
-		s.y = screenHeight
-	}
+// Draw draws the star scaled by pulse around its center, with its alpha
+// scaled too, so a pulse of 1 is the star as is, through the camera.
+func (s *Star) Draw(screen *ebiten.Image, pulse float64, cam camera.Camera) {
+	s.drawAt(screen, float64(s.x), float64(s.y), pulse, cam, color.White)
 }
 
-func (s *Star) Draw(screen *ebiten.Image) {
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(s.x), float64(s.y))
-	_ = screen.DrawImage(s.img, op)
+func clampZoom(z float64) float64 {
+	return math.Max(minZoom, math.Min(z, maxZoom))
 }
 
 type Game struct {
 	fullscreen bool
 	autoscroll bool
-	nearStars  []*Star
-	farStars   []*Star
+	// The stars by depth, from the farthest layer to the nearest, all of
+	// them kept in the pool
+	layers []*starLayer
+	pool   []Star
+	batch  starBatch
+
+	// The field being shown, and the camera on it: how far the view moved
+	// (in MoveView steps), and the zoom around the center of the screen
+	field        field
+	camX         float64
+	camY         float64
+	cam          camera.Camera
+	bookmarks    *bookmarks
+	snapshotPath string
+	skybox       skybox
+	ship         *ship
+	dust         *dust
+	// The galaxy map, for the seed of the field, and whether it's shown
+	// instead of the field
+	galaxy  *galaxy
+	mapMode bool
+	mapKeys keymap.Map
+	lod     *starLOD
+	warp    warp
+	heat    heatmap
+	// Simulation time, for the ship, autoscroll and the dust, jumps and
+	// the UI go on regardless
+	clock clock.Clock
+	// The fixed-point simulation, if on
+	fixed *fixedSim
+	bench *bench
+
+	music       *audio.Music
+	envelope    float64
+	sensitivity float64
+	notify      *notify.Notifier
+	keys        keymap.Map
+	// Bookmarks go after the motion of the tick, see Update
+	marks    keymap.Map
+	renderer layer.Renderer
 }
 
-func (g *Game) MoveView(x, y int) {
-	for _, s := range g.nearStars {
-		s.MoveBy(x*translateNear, y*translateNear)
+// updateEnvelope follows the music amplitude, rising fast on beats and
+// decaying slowly, so stars pulse instead of flicker.
+func (g *Game) updateEnvelope() {
+	level := 0.0
+	if g.music != nil {
+		level = g.music.Level(levelWindow)
 	}
-	for _, s := range g.farStars {
-		s.MoveBy(x*translateFar, y*translateFar)
+
+	k := envelopeRelease
+	if level > g.envelope {
+		k = envelopeAttack
 	}
+
+	g.envelope += (level - g.envelope) * k
+}
+
+// MoveView moves the camera. The stars aren't moved, they're drawn where
+// their layer has moved to for the camera, wrapping around the screen, so
+// jumping to a bookmark ends up the same as scrolling there.
+func (g *Game) MoveView(x, y float64) {
+	g.camX += x
+	g.camY += y
 }
 
 func (g *Game) Update(screen *ebiten.Image) error {
-	if ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
-		g.MoveView(0, -1)
+	if g.mapMode {
+		g.notify.Update()
+
+		return g.mapKeys.Update()
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
-		g.MoveView(0, 1)
+	if err := g.keys.Update(); err != nil {
+		return err
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) || g.autoscroll {
-		g.MoveView(-1, 0)
+	if g.bench != nil && g.bench.Update(g) {
+		g.bench.report(len(g.pool))
+
+		return run.ErrCleanExit
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
-		g.MoveView(1, 0)
+	steps := g.clock.Steps()
+
+	// The view goes the other way from the ship, and autoscroll can go
+	// many steps in a tick
+	var dx, dy float64
+
+	if g.fixed != nil {
+		dx, dy = g.fixed.step(g, steps)
+	} else {
+		dx, dy = g.ship.fly(steps)
+		dx, dy = -dx, -dy
+
+		if g.autoscroll {
+			dx -= float64(steps)
+		}
+
+		g.MoveView(dx, dy)
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyG) {
-		// "go", toggle autoscroll
-		g.autoscroll = !g.autoscroll
+	// Jumps aren't motion, the dust shouldn't streak across the screen
+	if err := g.marks.Update(); err != nil {
+		log.Printf("bookmarks: %v", err)
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
-		g.fullscreen = !g.fullscreen
-		ebiten.SetFullscreen(g.fullscreen)
+	g.ship.Update(g.camX, g.camY)
+	g.dust.Update(dx, dy, steps)
+	g.warp.Update()
+	g.heat.Update(g)
+
+	g.updateEnvelope()
+	g.notify.Update()
+
+	return nil
+}
+
+// toggleMap switches between the field and the galaxy map, generating the
+// map first if the field changed.
+func (g *Game) toggleMap() {
+	g.mapMode = !g.mapMode
+	if !g.mapMode {
+		return
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		return ErrCleanExit
+	if g.galaxy == nil || g.galaxy.seed != g.field.Seed {
+		g.galaxy = newGalaxy(g.field.Seed)
 	}
 
-	return nil
+	g.notify.Push("Galaxy map, seed %d", g.galaxy.seed)
+}
+
+// mapBindings is the input of the galaxy map.
+func (g *Game) mapBindings() keymap.Map {
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyTab), Trigger: keymap.Pressed, Action: keymap.Do(g.toggleMap)},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.galaxy.selectAtCursor()
+		})},
+		{Keys: keymap.Keys(ebiten.KeyF), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.fullscreen = !g.fullscreen
+			ebiten.SetFullscreen(g.fullscreen)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Action: func() error { return run.ErrCleanExit }},
+	}
+}
+
+// bindings is the input of the game, but for the bookmarks, see
+// bookmarkBindings.
+func (g *Game) bindings() keymap.Map {
+	thrust := func(x, y float64) keymap.Action {
+		return keymap.Do(func() { g.ship.thrust(x, y) })
+	}
+
+	return keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyUp, ebiten.KeyW), Action: thrust(0, -1)},
+		{Keys: keymap.Keys(ebiten.KeyDown, ebiten.KeyS), Action: thrust(0, 1)},
+		{Keys: keymap.Keys(ebiten.KeyLeft, ebiten.KeyA), Action: thrust(-1, 0)},
+		{Keys: keymap.Keys(ebiten.KeyRight, ebiten.KeyD), Action: thrust(1, 0)},
+		{Keys: keymap.Keys(ebiten.KeyB), Action: keymap.Do(g.ship.brake)},
+		{Keys: keymap.Keys(ebiten.KeyShift), Action: keymap.Do(func() { g.warp.engaged = true })},
+		// "go", toggle autoscroll
+		{Keys: keymap.Keys(ebiten.KeyG), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.autoscroll = !g.autoscroll
+			if g.autoscroll {
+				g.notify.Push("Autoscroll on")
+			} else {
+				g.notify.Push("Autoscroll off")
+			}
+		})},
+		{Keys: keymap.Keys(ebiten.KeyF), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.fullscreen = !g.fullscreen
+			ebiten.SetFullscreen(g.fullscreen)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyTab), Trigger: keymap.Pressed, Action: keymap.Do(g.toggleMap)},
+		{Keys: keymap.Keys(ebiten.KeyX), Trigger: keymap.Pressed, Action: keymap.Do(g.toggleFixed)},
+		{Keys: keymap.Keys(ebiten.KeyH), Trigger: keymap.Pressed, Action: keymap.Do(g.heat.toggle)},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Trigger: keymap.Pressed, Action: keymap.Do(g.pickStar)},
+		{Keys: keymap.Keys(ebiten.KeyM), Trigger: keymap.Pressed, Action: func() error {
+			if g.music == nil {
+				return nil
+			}
+
+			return g.music.Toggle()
+		}},
+		{Keys: keymap.Keys(ebiten.KeyRightBracket), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			g.sensitivity = math.Min(g.sensitivity+sensitivityStep, maxSensitivity)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyLeftBracket), Trigger: keymap.Repeat, Action: keymap.Do(func() {
+			g.sensitivity = math.Max(g.sensitivity-sensitivityStep, 0)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEqual), Action: keymap.Do(func() { g.cam.Zoom = clampZoom(g.cam.Zoom * zoomStep) })},
+		{Keys: keymap.Keys(ebiten.KeyMinus), Action: keymap.Do(func() { g.cam.Zoom = clampZoom(g.cam.Zoom / zoomStep) })},
+		{Keys: keymap.Keys(ebiten.KeyN), Trigger: keymap.Pressed, Action: func() error {
+			// A whole new field
+			f := g.field
+			f.Seed = rand.Int63()
+			g.notify.Push("New field, seed %d", f.Seed)
+
+			return g.generate(f)
+		}},
+		{Keys: keymap.Keys(ebiten.KeyS), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(func() {
+			if err := g.SaveSnapshot(g.snapshotPath); err != nil {
+				log.Printf("saving %s: %v", g.snapshotPath, err)
+				g.notify.Push("Saving failed, see the log")
+
+				return
+			}
+
+			g.notify.Push("Snapshot saved to %s", g.snapshotPath)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyL), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(func() {
+			if err := g.LoadSnapshot(g.snapshotPath); err != nil {
+				log.Printf("loading %s: %v", g.snapshotPath, err)
+				g.notify.Push("Loading failed, see the log")
+
+				return
+			}
+
+			g.notify.Push("Snapshot loaded from %s", g.snapshotPath)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyE), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(func() {
+			if err := g.exportSkybox(); err != nil {
+				log.Printf("exporting %s: %v", g.skybox.path, err)
+				g.notify.Push("Exporting failed, see the log")
+
+				return
+			}
+
+			g.notify.Push("Skybox exported to %s", g.skybox.path)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Action: func() error { return run.ErrCleanExit }},
+	}
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	for _, s := range g.nearStars {
-		s.Draw(screen)
+	if g.mapMode {
+		g.drawMap(screen)
+
+		return
 	}
-	for _, s := range g.farStars {
-		s.Draw(screen)
+
+	level := g.envelope * g.sensitivity
+	g.lod.Update(g)
+	g.batch.frame()
+
+	if g.bench != nil {
+		g.bench.frame(g.batch.lastCalls)
+	}
+
+	// Farther layers behind the nearer ones, the far half in the
+	// background
+	g.renderer.AddFunc(layer.Background, func(screen *ebiten.Image) {
+		for i, l := range g.layers {
+			switch {
+			case l.depth >= 0.5:
+			case g.warp.active():
+				g.drawWarpLayer(screen, l, level)
+			default:
+				g.lod.drawMerged(screen, g, l)
+				g.drawLayer(screen, l, g.lod.drawn[i], level)
+			}
+		}
+
+		g.batch.Flush(screen)
+	})
+	g.renderer.AddFunc(layer.World, func(screen *ebiten.Image) {
+		for i, l := range g.layers {
+			switch {
+			case l.depth < 0.5:
+			case g.warp.active():
+				g.drawWarpLayer(screen, l, level)
+			default:
+				g.drawLayer(screen, l, g.lod.drawn[i], level)
+			}
+		}
+
+		g.batch.Flush(screen)
+	})
+
+	g.renderer.AddFunc(layer.Effects, func(screen *ebiten.Image) {
+		g.ship.Draw(screen, g.cam)
+	})
+	// Dust goes in front of everything but the HUD
+	g.renderer.AddFunc(layer.Effects+1, func(screen *ebiten.Image) {
+		g.dust.Draw(screen, g.cam)
+	})
+	g.renderer.AddFunc(layer.Effects+2, g.heat.Draw)
+
+	msg := fmt.Sprintf("Seed %d (N for a new one)  Camera %.0f,%.0f  Zoom %.2f (- and =)\n"+
+		"Ctrl+1..5 bookmarks the view, 1..5 jumps to it, Tab for the galaxy map, Ctrl+E exports a skybox\n"+
+		"Time %s (P pauses, , and . change the speed, Backspace resets it)\n%s  %s\n%s in %d draw calls\n%s\n%s",
+		g.field.Seed, g.camX, g.camY, g.cam.Zoom, &g.clock, g.ship.HUD(), g.warp.HUD(), g.lod.HUD(), g.batch.lastCalls,
+		g.fixedHUD(), g.heat.HUD())
+	if g.music != nil && g.music.IsPlaying() {
+		msg += fmt.Sprintf("\nSensitivity: %.2f ([ and ] to change)", g.sensitivity)
 	}
+
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		ebitenutil.DebugPrint(screen, msg)
+	})
+
+	g.renderer.Add(layer.UI+1, g.notify)
+	g.renderer.Draw(screen)
+}
+
+// drawMap draws the galaxy map instead of the field.
+func (g *Game) drawMap(screen *ebiten.Image) {
+	g.renderer.Add(layer.World, g.galaxy)
+	g.renderer.AddFunc(layer.UI, func(screen *ebiten.Image) {
+		ebitenutil.DebugPrint(screen, "Galaxy map (Tab back to the field)\n"+g.galaxy.info())
+	})
+	g.renderer.Add(layer.UI+1, g.notify)
+	g.renderer.Draw(screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenW, screenH int) {
 	return screenWidth, screenHeight
 }
 
-func (g *Game) initStarfield() {
+// generate replaces the starfield with a new one, the same stars for the
+// same field, and resets the camera.
+func (g *Game) generate(f field) error {
 	// NewStar(3, 3, 5, color.White)
 	// NewStar(15, 15, 10, color.RGBA{0xff, 0, 0, 0xff})
 	// NewStar(100, 100, 15, color.RGBA{0, 0xff, 0, 0xff})
+	placement, err := place.ByName(f.Placement)
+	if err != nil {
+		return err
+	}
 
-	// x and y coordinates, randomized
-	xs := rand.Perm(screenWidth)[:nearStars]
-	ys := rand.Perm(screenHeight)[:nearStars]
+	rnd := rand.New(rand.NewSource(f.Seed))
+	points := placement(rnd, f.Stars.Count, screenWidth, screenHeight)
+	stars := make([]starData, len(points))
 
-	g.nearStars = make([]*Star, nearStars)
-	for i, x := range xs {
-		g.nearStars[i] = NewStar(x, ys[i], 3, color.White)
+	for i, p := range points {
+		stars[i] = starData{int(p.X), int(p.Y), f.Stars.depth(rnd)}
 	}
 
-	// x and y coordinates, randomized
-	xs = rand.Perm(screenWidth)[:farStars]
-	ys = rand.Perm(screenHeight)[:farStars]
+	g.layers, g.pool = newLayers(f.Stars, stars, g.pool)
+	vary(g.layers, f.Stars, f.Seed)
 
-	g.farStars = make([]*Star, farStars)
-	for i, x := range xs {
-		// Dim farther stars with alpha channel
-		g.farStars[i] = NewStar(x, ys[i], 3, color.RGBA{0xff, 0xff, 0xff, 0x80})
-	}
+	g.field = f
+	g.camX, g.camY = 0, 0
+
+	return nil
 }
 
 func main() {
-	g := &Game{}
-	g.initStarfield()
+	stars := flag.Int("stars", 150, "number of stars")
+	distribution := flag.Float64("distribution", 2, "depth distribution, over 1 makes far stars more common")
+	layers := flag.Int("layers", 8, "number of depth layers the stars are binned into")
+	placement := flag.String("placement", "uniform", "star placement: uniform, poisson or grid")
+	seed := flag.Int64("seed", 0, "starfield seed, random if 0")
+	bookmarksPath := flag.String("bookmarks", "bookmarks.json", "file to keep the view bookmarks in")
+	snapshotPath := flag.String("snapshot", "starfield.json", "file to save (Ctrl+S) and load (Ctrl+L) the field")
+	dustParticles := flag.Int("dust", 150, "number of space dust particles")
+	twinkle := flag.Float64("twinkle", 0.5, "how much stars dim when they twinkle, from 0 to 1")
+	temperature := flag.Float64("temperature", 0.5, "how far star colors stray from white, from 0 to 1")
+	lodSize := flag.Float64("lod", 3, "far stars smaller than this, in pixels, are merged into a background texture")
+	skyboxPath := flag.String("skybox", "skybox.png", "file to export the field to as a tileable background (Ctrl+E)")
+	skyboxZoom := flag.Float64("skybox-zoom", 2, "zoom of the exported skybox, 0 for the zoom of the view")
+	fixedPoint := flag.Bool("fixed", false, "run the motion in fixed-point, the same on every platform (X toggles it)")
+	benchFor := flag.Duration("bench", 0, "scroll and warp through the field for this long, log the frame rate and quit, try it with -stars 10000")
+	flag.Parse()
+
+	if *seed == 0 {
+		*seed = rand.Int63()
+	}
+
+	bms, err := loadBookmarks(*bookmarksPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := &Game{sensitivity: 1, cam: camera.New(screenWidth, screenHeight), bookmarks: bms, snapshotPath: *snapshotPath, ship: newShip(),
+		notify: notify.New(), dust: newDust(*dustParticles), skybox: skybox{path: *skyboxPath, zoom: *skyboxZoom},
+		lod: newStarLOD(*lodSize)}
+	g.keys = append(g.bindings(), g.clock.Bindings()...)
+	g.marks = g.bookmarkBindings()
+	g.mapKeys = g.mapBindings()
+	cfg := starConfig{Count: *stars, Distribution: *distribution, Layers: *layers, Twinkle: *twinkle, Temperature: *temperature}
+	if err := g.generate(field{Seed: *seed, Stars: cfg, Placement: *placement}); err != nil {
+		log.Fatal(err)
+	}
+
+	if *fixedPoint {
+		g.fixed = newFixedSim(g)
+	}
+
+	if *benchFor > 0 {
+		g.bench = newBench(*benchFor)
+	}
+
+	// The starfield is fine without music, M just won't do anything
+	if g.music, err = audio.NewMusic(audio.Beat(120, 4)); err != nil {
+		log.Printf("no music: %v", err)
+	}
 
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Starfield")
 
-	if err := ebiten.RunGame(g); err != nil {
-		// gopherjs uses go 1.12, therefore we don't have errors.Is out
-		// of the box
-		if xerrors.Is(err, ErrCleanExit) {
-			fmt.Println("Good bye!")
+	err = run.Game(g)
 
-			return
-		}
-
-		log.Fatal(err)
+	// Whatever the periodic saves didn't get to
+	if err := stats.Flush(); err != nil {
+		log.Printf("saving stats: %v", err)
 	}
+
+	run.Exit(err)
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/camera"
+	"github.com/antoniomo/ebiten-exercises/internal/clock"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
+	"github.com/antoniomo/ebiten-exercises/internal/trail"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	shipSize = 10
+	// Positions kept in the trail, in ticks
+	trailLength = 40
+	trailWidth  = 8
+	// Acceleration of the engine, in MoveView steps per tick per tick, the
+	// most speed it gets to, and how much of it is lost per tick, to
+	// coasting and to braking (B)
+	shipThrust   = 0.04
+	shipMaxSpeed = 2
+	shipDrag     = 0.005
+	shipBrake    = 0.08
+	// Moving farther than this in a tick is a jump (a bookmark, a new
+	// field), which leaves no trail, even fast-forwarding with autoscroll
+	jumpDistance = (shipMaxSpeed + 2) * clock.MaxScale * translateNear
+)
+
+var (
+	shipColor  = color.RGBA{0x80, 0xc0, 0xff, 0xff}
+	trailColor = color.RGBA{0x40, 0x90, 0xff, 0xc0}
+)
+
+// ship flies through the near stars, always at the center of the screen:
+// moving the view is moving the ship, the other way around. The input
+// fires the engine rather than moving it, so it speeds up, coasts and
+// takes a while to turn around.
+type ship struct {
+	// Position among the near stars, and where it's headed
+	x       float64
+	y       float64
+	heading float64
+	// Velocity, in MoveView steps per tick, and the thrust asked for this
+	// tick
+	vx, vy  float64
+	ax, ay  float64
+	braking bool
+	trail   *trail.Trail
+}
+
+func newShip() *ship {
+	// Headed right, where autoscroll goes
+	return &ship{trail: trail.New(trailLength, trailWidth, trailColor)}
+}
+
+// thrust fires the engine towards (x, y) this tick.
+func (s *ship) thrust(x, y float64) {
+	s.ax += x
+	s.ay += y
+}
+
+// brake slows the ship down this tick.
+func (s *ship) brake() {
+	s.braking = true
+}
+
+// fly runs the engine for the simulation steps of the tick, and returns how
+// far the ship went, in MoveView steps.
+func (s *ship) fly(steps int) (float64, float64) {
+	ax, ay := s.ax, s.ay
+	// Diagonals are no faster
+	if l := math.Hypot(ax, ay); l > 0 {
+		ax, ay = ax/l*shipThrust, ay/l*shipThrust
+	}
+
+	drag := shipDrag
+	if s.braking {
+		drag = shipBrake
+	}
+
+	s.ax, s.ay, s.braking = 0, 0, false
+
+	var dx, dy float64
+
+	for i := 0; i < steps; i++ {
+		s.vx = (s.vx + ax) * (1 - drag)
+		s.vy = (s.vy + ay) * (1 - drag)
+
+		if v := math.Hypot(s.vx, s.vy); v > shipMaxSpeed {
+			s.vx, s.vy = s.vx/v*shipMaxSpeed, s.vy/v*shipMaxSpeed
+		}
+
+		dx += s.vx
+		dy += s.vy
+	}
+
+	return dx, dy
+}
+
+// HUD describes the speed of the ship.
+func (s *ship) HUD() string {
+	return fmt.Sprintf("Speed %.2f (arrows fire the engine, B brakes)", math.Hypot(s.vx, s.vy))
+}
+
+// Update follows the camera, which is how far the view moved.
+func (s *ship) Update(camX, camY float64) {
+	x, y := -camX*translateNear, -camY*translateNear
+	dx, dy := x-s.x, y-s.y
+	s.x, s.y = x, y
+
+	d := math.Hypot(dx, dy)
+
+	switch {
+	case d > jumpDistance:
+		s.trail.Reset()
+	case d > 0:
+		s.heading = math.Atan2(dy, dx)
+		stats.Add(stats.DistanceFlown, d)
+	}
+
+	s.trail.Push(s.x, s.y)
+}
+
+// Draw draws the ship and its trail at the center of the screen, zoomed
+// with the near stars.
+func (s *ship) Draw(screen *ebiten.Image, cam camera.Camera) {
+	var geoM ebiten.GeoM
+	geoM.Translate(cam.X-s.x, cam.Y-s.y)
+	geoM.Concat(cam.GeoM())
+
+	s.trail.Draw(screen, geoM)
+
+	// A dart pointing along the heading
+	var body ebiten.GeoM
+	body.Rotate(s.heading)
+	body.Translate(cam.X, cam.Y)
+	body.Concat(cam.GeoM())
+
+	r, g, b, a := shapes.ColorScale(shipColor)
+	points := [][2]float64{{shipSize, 0}, {-shipSize / 2, -shipSize / 2}, {-shipSize / 4, 0}, {-shipSize / 2, shipSize / 2}}
+	vs := make([]ebiten.Vertex, len(points))
+
+	for i, p := range points {
+		x, y := body.Apply(p[0], p[1])
+		vs[i] = ebiten.Vertex{
+			DstX:   float32(x),
+			DstY:   float32(y),
+			ColorR: float32(r),
+			ColorG: float32(g),
+			ColorB: float32(b),
+			ColorA: float32(a),
+		}
+	}
+
+	screen.DrawTriangles(vs, []uint16{0, 1, 2, 0, 2, 3}, shapes.EmptyImage, nil)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Zooms a skybox can be exported at, the largest keeps it under 4096
+// pixels wide, a texture size every GPU takes
+const (
+	minSkyboxZoom = 0.5
+	maxSkyboxZoom = 6
+)
+
+// skybox is where the field is exported to as a background texture, and at
+// what zoom, 0 for the zoom of the view.
+type skybox struct {
+	path string
+	zoom float64
+}
+
+// renderSkybox draws the field as it's in view, but with every layer at the
+// same zoom and without the music pulse or twinkling, so the layers repeat together.
+// Each layer wraps around the screen, so the field is one screen scaled by
+// the zoom, and stars crossing the right or bottom edge are drawn again on
+// the left or top, so the image tiles without seams. The size is rounded to
+// whole pixels, and the zoom stretched a hair to fit, or the fraction would
+// add up tile after tile.
+func (g *Game) renderSkybox(zoom float64) (*ebiten.Image, error) {
+	w, h := math.Round(screenWidth*zoom), math.Round(screenHeight*zoom)
+	zx, zy := w/screenWidth, h/screenHeight
+
+	img, err := profile.NewImage(int(w), int(h), ebiten.FilterDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	// Opaque, to go behind anything
+	_ = img.Fill(color.Black)
+
+	var b starBatch
+
+	for _, l := range g.layers {
+		offX, offY := l.offset(g.camX, g.camY)
+
+		for i := range l.stars {
+			s := &l.stars[i]
+			x, y := s.position(offX, offY)
+			d := float64(2 * s.radius)
+
+			for _, dx := range []float64{0, -w} {
+				for _, dy := range []float64{0, -h} {
+					var m ebiten.GeoM
+					m.Scale(zx, zy)
+					m.Translate(x*zx+dx, y*zy+dy)
+					b.quad(img, &m, d, d, s.shade(l.clr, 1))
+				}
+			}
+		}
+	}
+
+	b.Flush(img)
+
+	return img, nil
+}
+
+// exportSkybox renders the field and saves it as a PNG.
+func (g *Game) exportSkybox() error {
+	zoom := g.skybox.zoom
+	if zoom == 0 {
+		zoom = g.cam.Zoom
+	}
+
+	if zoom < minSkyboxZoom || zoom > maxSkyboxZoom {
+		return fmt.Errorf("skybox zoom %.2f out of %g to %d", zoom, minSkyboxZoom, maxSkyboxZoom)
+	}
+
+	img, err := g.renderSkybox(zoom)
+	if err != nil {
+		return err
+	}
+	defer img.Dispose()
+
+	f, err := os.Create(g.skybox.path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
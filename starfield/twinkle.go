@@ -0,0 +1,76 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+const (
+	// Radians per simulation step the stars twinkle at, each its own
+	minTwinkleSpeed = 0.02
+	maxTwinkleSpeed = 0.12
+	// Color temperature of white, in kelvin, the others go from half to
+	// double at the most variance
+	whiteKelvin = 6600
+	// Mixed into the seed of the field, for a stream apart from the one
+	// that placed the stars
+	varySalt = 0x7f4a7c15
+)
+
+// vary gives the stars of the layers their twinkle and color. They come from
+// a random source of their own, so the stars of a seed stay where they
+// were, and a field gets the same ones whether it's generated or loaded.
+func vary(layers []*starLayer, c starConfig, seed int64) {
+	rnd := rand.New(rand.NewSource(seed ^ varySalt))
+
+	for _, l := range layers {
+		for i := range l.stars {
+			s := &l.stars[i]
+			s.phase = rnd.Float64() * 2 * math.Pi
+			s.freq = minTwinkleSpeed + rnd.Float64()*(maxTwinkleSpeed-minTwinkleSpeed)
+			s.tint = blackbody(whiteKelvin * math.Pow(2, (rnd.Float64()*2-1)*c.Temperature))
+		}
+	}
+}
+
+// twinkle returns how much of its alpha the star has at simulation step
+// now, dimming by up to the config's twinkle and back.
+func (s *Star) twinkle(c starConfig, now int64) float64 {
+	return 1 - c.Twinkle*(0.5+0.5*math.Sin(s.phase+s.freq*float64(now)))
+}
+
+// shade returns the color the star is drawn with: its tint, with the alpha
+// of its layer scaled by alpha.
+func (s *Star) shade(clr color.RGBA, alpha float64) color.NRGBA {
+	return color.NRGBA{s.tint.R, s.tint.G, s.tint.B, uint8(float64(clr.A) * alpha)}
+}
+
+// blackbody returns about the color of a black body at a temperature in
+// kelvin, after Tanner Helland's fit of the blackbody color tables: red
+// under whiteKelvin, white at it, and blue over it.
+func blackbody(kelvin float64) color.RGBA {
+	t := kelvin / 100
+
+	r, g, b := 255.0, 255.0, 255.0
+
+	if t <= 66 {
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+
+	switch {
+	case t <= 19:
+		b = 0
+	case t < 66:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+
+	clamp := func(v float64) uint8 {
+		return uint8(math.Max(0, math.Min(v, 255)))
+	}
+
+	return color.RGBA{clamp(r), clamp(g), clamp(b), 0xff}
+}
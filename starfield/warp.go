@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/tween"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// How much of the way into warp, and back out of it, a tick goes
+	warpRamp = 1.0 / 90
+	// How far along its flight a star goes per tick at full warp, the
+	// farthest half as far and the nearest one and a half
+	warpSpeed = 0.025
+	// How long the flight of a star is, from where it rests to where it
+	// wraps back there, in e-folds of its distance from the center: at 2
+	// it gets over 7 times farther out
+	warpSpan = 2
+	// Length of the streaks at full warp, as a part of the flight, and the
+	// part a star fades in over after wrapping
+	warpStreak = 0.4
+	warpFadeIn = 0.3
+	// Width of the streaks, from the size of the star
+	streakWidth = 0.7
+)
+
+// warp is hyperspace (hold Shift): the stars fly out from the center of the
+// screen, faster the nearer, drawn as streaks as long as they're fast, and
+// wrap back to where they rest once they're far out. Going in and out of it
+// eases between the two, so at the edges the stars are where parallax
+// scrolling has them.
+type warp struct {
+	// Asked for this tick
+	engaged bool
+	// How far into warp it is, eased, from 0 to 1
+	ramp  float64
+	level float64
+	// How far the stars flew so far
+	travel float64
+}
+
+// Update ramps warp in while it's asked for, and out otherwise.
+func (w *warp) Update() {
+	if w.engaged {
+		w.ramp = math.Min(1, w.ramp+warpRamp)
+	} else {
+		w.ramp = math.Max(0, w.ramp-warpRamp)
+	}
+
+	w.engaged = false
+	w.level = tween.InOutQuad(w.ramp)
+	w.travel += w.level * warpSpeed
+}
+
+// active reports whether the stars are drawn warped.
+func (w *warp) active() bool {
+	return w.level > 0
+}
+
+// flight returns how far along its flight the star is, from 0 to warpSpan,
+// each star starting at its own place.
+func (w *warp) flight(s *Star) float64 {
+	return math.Mod(w.travel*(0.5+s.depth)+s.phase/(2*math.Pi)*warpSpan, warpSpan)
+}
+
+// drawWarpLayer is drawLayer in warp, for every star of the layer: merged
+// ones go by as fast as the rest. Streaks go in the batch too.
+func (g *Game) drawWarpLayer(screen *ebiten.Image, l *starLayer, level float64) {
+	w := &g.warp
+	offX, offY := l.offset(g.camX, g.camY)
+	pulse, cam := l.pulse(level), l.camera(g.cam)
+	cx, cy := float64(screenWidth)/2, float64(screenHeight)/2
+	now := g.clock.Now()
+	view := cam.GeoM()
+	inv := view
+	inv.Invert()
+
+	for i := range l.stars {
+		s := &l.stars[i]
+		x, y := s.position(offX, offY)
+		sx, sy, size := s.bounds(x, y, pulse, cam, &view)
+
+		// Out from the center, by e-folds of the distance, so a star goes
+		// faster the farther out it gets
+		u := w.flight(s)
+		head := math.Exp(w.level * u)
+		tail := math.Exp(w.level * math.Max(0, u-warpStreak*w.level*(0.5+s.depth)))
+		hx, hy := cx+(sx-cx)*head, cy+(sy-cy)*head
+
+		alpha := s.twinkle(g.field.Stars, now) * (1 - w.level*(1-math.Min(1, u/warpFadeIn)))
+		clr := s.shade(l.clr, alpha)
+		g.batch.streak(screen, cx+(sx-cx)*tail, cy+(sy-cy)*tail, hx, hy, size*streakWidth, clr)
+
+		// The star itself leads the streak
+		wx, wy := inv.Apply(hx, hy)
+		s.batch(&g.batch, screen, wx-float64(s.radius), wy-float64(s.radius), pulse, &view, clr)
+	}
+}
+
+// HUD describes the warp.
+func (w *warp) HUD() string {
+	if !w.active() {
+		return "Warp: hold Shift"
+	}
+
+	return fmt.Sprintf("Warp: %.0f%%", w.level*100)
+}
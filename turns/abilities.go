@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+)
+
+var (
+	rangeColor = color.RGBA{0x40, 0x80, 0xff, 0x40}
+	areaColor  = color.RGBA{0x40, 0xff, 0x80, 0x80}
+	badColor   = color.RGBA{0xff, 0x30, 0x30, 0x60}
+	// Keys aiming each of sim.Abilities, after the ones of planModes
+	abilityKeys = []ebiten.Key{ebiten.Key6, ebiten.Key7, ebiten.Key8}
+	// The modes picked with 1 to 5, in order
+	planModes = []sim.Mode{sim.ModeMove, sim.ModeExplode, sim.ModeBridge, sim.ModeAttack, sim.ModeFace}
+)
+
+// drawAbilityPreview shows the ability reach and, under the cursor, the
+// tiles it would affect, in red if it can't be used there.
+func (g *Game) drawAbilityPreview(screen *ebiten.Image) {
+	a := sim.Abilities[g.ability]
+	pos := g.state.PlannedPos(g.selected)
+	b := g.state.Board
+
+	for y := pos.Y - a.Reach; y <= pos.Y+a.Reach; y++ {
+		for x := pos.X - a.Reach; x <= pos.X+a.Reach; x++ {
+			if b.In(x, y) {
+				g.drawTile(screen, sim.Tile{X: x, Y: y}, 0, rangeColor)
+			}
+		}
+	}
+
+	clr := areaColor
+	if !g.state.CanUse(g.selected, g.ability, g.cursor) {
+		clr = badColor
+	}
+
+	c := g.cursor
+
+	for y := c.Y - a.Radius; y <= c.Y+a.Radius; y++ {
+		for x := c.X - a.Radius; x <= c.X+a.Radius; x++ {
+			if b.In(x, y) {
+				g.drawTile(screen, sim.Tile{X: x, Y: y}, 2, clr)
+			}
+		}
+	}
+}
+
+// abilitiesHUD lists the abilities of the unit, with their cooldowns.
+func (g *Game) abilitiesHUD(u sim.Unit) string {
+	parts := make([]string, len(sim.Abilities))
+
+	for i, a := range sim.Abilities {
+		// On a gamepad they're reached with the shoulder buttons, like the
+		// modes
+		s := fmt.Sprintf("%s %s (%d MP)", abilityKeys[i], a.Name, a.Cost)
+		if g.device.Pad {
+			s = fmt.Sprintf("%s (%d MP)", a.Name, a.Cost)
+		}
+
+		if u.Cooldowns[i] > 0 {
+			s += fmt.Sprintf(" %d turns", u.Cooldowns[i])
+		}
+
+		parts[i] = s
+	}
+
+	return strings.Join(parts, ", ")
+}
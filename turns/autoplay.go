@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/antoniomo/ebiten-exercises/internal/buildinfo"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+)
+
+// Games without a turn limit that go on for this long are called a draw,
+// like when both sides end up waiting out of sight of each other
+const autoplayMaxTurns = 200
+
+// autoplayResult is how a game played by the AI on both sides went.
+type autoplayResult struct {
+	outcome sim.Outcome
+	turns   int
+}
+
+// autoplay plays games AI vs AI, the enemies with the strategy, each with
+// its own seed counting up from seed, and writes a CSV summary to w: how
+// many games each side won, the win rates, the average game length in
+// turns, the build and the strategy. Nothing is drawn, so it runs as fast
+// as the sim does, for balance testing.
+func autoplay(w io.Writer, games int, seed uint64, scenarioPath string, ai sim.Strategy) error {
+	var (
+		won, lost, drawn int
+		turns            int
+	)
+
+	for i := 0; i < games; i++ {
+		s := newState(seed + uint64(i))
+		if err := loadMission(scenarioPath, &s); err != nil {
+			return err
+		}
+
+		r := autoplayGame(&s, ai)
+		turns += r.turns
+
+		switch r.outcome {
+		case sim.Won:
+			won++
+		case sim.Lost:
+			lost++
+		case sim.Ongoing:
+			drawn++
+		}
+	}
+
+	rate := func(n int) string {
+		return strconv.FormatFloat(float64(n)/float64(games), 'f', 3, 64)
+	}
+
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"games", "seed", "won", "lost", "drawn", "win_rate", "loss_rate", "draw_rate", "avg_turns", "build", "enemy_ai"})
+	_ = out.Write([]string{
+		strconv.Itoa(games), strconv.FormatUint(seed, 10),
+		strconv.Itoa(won), strconv.Itoa(lost), strconv.Itoa(drawn),
+		rate(won), rate(lost), rate(drawn),
+		strconv.FormatFloat(float64(turns)/float64(games), 'f', 2, 64),
+		buildinfo.Get().String(), ai.Name(),
+	})
+	out.Flush()
+
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("writing the results: %w", err)
+	}
+
+	return nil
+}
+
+// autoplayGame plays s to the end, the player side winning if it completes
+// the mission, or takes out every enemy when there's no mission, and losing
+// if it fails it or all its units are down. Games that don't end in time
+// stay Ongoing.
+func autoplayGame(s *sim.State, ai sim.Strategy) autoplayResult {
+	for s.Outcome == sim.Ongoing && s.Turn < autoplayMaxTurns {
+		s.PlanUnits()
+		ai.Plan(s)
+		s.Resolve()
+
+		switch {
+		case s.Outcome != sim.Ongoing:
+		case !anyAlive(s.Units):
+			s.Outcome = sim.Lost
+		case len(s.Mission.Objectives) == 0 && !anyAlive(s.Enemies):
+			s.Outcome = sim.Won
+		}
+	}
+
+	return autoplayResult{outcome: s.Outcome, turns: s.Turn}
+}
+
+func anyAlive(us []sim.Unit) bool {
+	for _, u := range us {
+		if u.HP > 0 {
+			return true
+		}
+	}
+
+	return false
+}
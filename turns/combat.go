@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+var (
+	forecastColor = color.RGBA{0, 0, 0, 0xd0}
+	hpColor       = color.RGBA{0x30, 0xd0, 0x30, 0xff}
+	hpRiskColor   = color.RGBA{0xd0, 0x30, 0x30, 0xff}
+	hpEmptyColor  = color.RGBA{0x40, 0x40, 0x40, 0xff}
+	downColor     = color.RGBA{0x80, 0x6c, 0, 0xff}
+)
+
+// drawFacing draws a notch on the side of the tile the unit faces.
+func (g *Game) drawFacing(screen *ebiten.Image, u sim.Unit, clr color.Color) {
+	const notch = 6
+
+	v := u.Facing.Vec()
+	half := u.Side() * tileSize / 2
+	cx := float64(u.Pos.X*tileSize+half) + float64(v.X*(half-notch))
+	cy := float64(u.Pos.Y*tileSize+mapTop+half) + float64(v.Y*(half-notch))
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(notch, notch)
+	op.GeoM.Translate(cx-notch/2, cy-notch/2)
+	op.ColorM.Scale(shapes.ColorScale(clr))
+	_ = screen.DrawImage(shapes.EmptyImage, op)
+}
+
+// drawForecast shows, in a popup next to the cursor, what attacking the
+// enemy under it would do. It's the same forecast the sim resolves the
+// attack with.
+func (g *Game) drawForecast(screen *ebiten.Image) {
+	u := g.state.Units[g.selected]
+	from, ok := sim.Contact(g.state.PlannedPos(g.selected), u.Side(), g.cursor)
+
+	e := g.state.EnemyAt(g.cursor)
+	if e == nil || !ok || !g.visible(e.Pos.X, e.Pos.Y) {
+		return
+	}
+
+	fc := sim.ForecastAttack(u, from, *e)
+
+	counter := "No counterattack"
+	counterMax := 0
+
+	if fc.Counter {
+		counter = fmt.Sprintf("Counter %2.0f%%  damage %d-%d",
+			fc.CounterHit.Chance*100, fc.CounterHit.Min, fc.CounterHit.Max)
+		counterMax = fc.CounterHit.Max
+	}
+
+	text := fmt.Sprintf("Attack on the %s\nHit %2.0f%%  damage %d-%d\n%s\nUnit %d\nEnemy %d",
+		fc.Attack.Flank, fc.Attack.Chance*100, fc.Attack.Min, fc.Attack.Max, counter, u.ID, e.ID)
+
+	const (
+		w    = 180
+		h    = 5*16 + 8
+		barX = 64
+		barW = w - barX - 8
+	)
+
+	x := (g.cursor.X+1)*tileSize + 4
+	if x+w > screenWidth {
+		x = g.cursor.X*tileSize - w - 4
+	}
+
+	y := clamp(g.cursor.Y*tileSize+mapTop, 0, mapBottom-h)
+
+	ebitenutil.DrawRect(screen, float64(x), float64(y), w, h, forecastColor)
+	ebitenutil.DebugPrintAt(screen, text, x+4, y+4)
+
+	drawHPBar(screen, x+barX, y+4+3*16+4, barW, u.HP, counterMax)
+	drawHPBar(screen, x+barX, y+4+4*16+4, barW, e.HP, fc.Attack.Max)
+}
+
+// drawHPBar draws the hit points, with the part that can be lost in the
+// worst case in red.
+func drawHPBar(screen *ebiten.Image, x, y, w, hp, risk int) {
+	const h = 8
+
+	px := func(v int) float64 {
+		return float64(clamp(v, 0, sim.UnitHP)*w) / sim.UnitHP
+	}
+
+	ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), h, hpEmptyColor)
+	ebitenutil.DrawRect(screen, float64(x), float64(y), px(hp), h, hpRiskColor)
+	ebitenutil.DrawRect(screen, float64(x), float64(y), px(hp-risk), h, hpColor)
+}
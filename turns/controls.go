@@ -0,0 +1,128 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/hint"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// hinted is a scene with control hints in the footer: the bindings it's
+// using right now, and what they do.
+type hinted interface {
+	hints() (keymap.Map, []hint.Hint)
+}
+
+// drawFooter draws the hints of the current scene along the bottom of the
+// screen, for the keyboard and mouse or for the gamepad, whichever the
+// player used last. While a transition runs there are none, the scene
+// coming in isn't taking input yet.
+func (g *Game) drawFooter(screen *ebiten.Image) {
+	h, ok := g.scenes.Current().(hinted)
+	if !ok || g.scenes.Transitioning() {
+		return
+	}
+
+	m, hints := h.hints()
+	g.footer.Hints, g.footer.Pad = hints, g.device.Pad
+	g.footer.Draw(screen, m)
+}
+
+// inputs returns the inputs of the binding of m with the name, for the
+// device the player is using, as the hints label them.
+func (g *Game) inputs(m keymap.Map, name string) string {
+	for i := range m {
+		if m[i].Name != name {
+			continue
+		}
+
+		if g.device.Pad {
+			return m[i].PadLabel(g.footer.Locale)
+		}
+
+		return m[i].Label(g.footer.Locale)
+	}
+
+	return ""
+}
+
+func (t *title) hints() (keymap.Map, []hint.Hint) {
+	return t.keys, []hint.Hint{
+		{Binding: "play", What: "Play"},
+		{Binding: "new_map", What: "Random map"},
+		{Binding: "quit", What: "Quit"},
+	}
+}
+
+func (s *setup) hints() (keymap.Map, []hint.Hint) {
+	return s.keys, []hint.Hint{
+		{Binding: "setup_up", What: "Parameter", Keys: "Up/Down", Pad: "D-pad Up/Down"},
+		{Binding: "setup_less", What: "Change", Keys: "Left/Right", Pad: "D-pad Left/Right"},
+		{Binding: "reroll", What: "New seed"},
+		{Binding: "play", What: "Play"},
+		{Binding: "back", What: "Back"},
+	}
+}
+
+func (p *planning) hints() (keymap.Map, []hint.Hint) {
+	g := p.g
+	if g.mixer.open {
+		return g.mixer.keys, []hint.Hint{
+			{Binding: "volume_up", What: "Volume", Keys: "Up/Down", Pad: "D-pad Up/Down"},
+			{Binding: "volume_less", What: "Change", Keys: "Left/Right", Pad: "D-pad Left/Right"},
+			{Binding: "volume_close", What: "Close"},
+		}
+	}
+
+	return g.keys, []hint.Hint{
+		{Binding: "cursor_up", What: "Cursor", Keys: "Arrows", Pad: "D-pad/Stick"},
+		{Binding: "declare", What: g.declareHint()},
+		{Binding: "undo", What: "Undo"},
+		{Binding: "wait", What: "Wait"},
+		{Binding: "next_unit", What: "Next unit"},
+		{Binding: "mode_1", What: "Mode", Keys: "1-8"},
+		{Binding: "mode_prev", What: "Mode", Pad: "LB/RB"},
+		{Binding: "group", What: "Group"},
+		{Binding: "zoom", What: "Zoom"},
+		{Binding: "pan", What: "Pan", Pad: "Right stick"},
+		{Binding: "end_turn", What: "End turn"},
+		{Binding: "pause", What: "Menu"},
+	}
+}
+
+// declareHint is what declaring does in the mode, for the hints.
+func (g *Game) declareHint() string {
+	switch {
+	case g.leading():
+		return "Move the group"
+	case g.mode == sim.ModeAbility:
+		return sim.Abilities[g.ability].Name
+	}
+
+	return strings.Title(g.mode.String())
+}
+
+func (r *resolution) hints() (keymap.Map, []hint.Hint) {
+	if !r.g.director.Playing() {
+		return nil, nil
+	}
+
+	return r.g.director.keys, []hint.Hint{{Binding: "skip", What: "Skip"}}
+}
+
+func (e *ended) hints() (keymap.Map, []hint.Hint) {
+	return e.keys, []hint.Hint{
+		{Binding: "new_map", What: "Random map"},
+		{Binding: "quit", What: "Quit"},
+	}
+}
+
+func (p *pause) hints() (keymap.Map, []hint.Hint) {
+	return p.keys, []hint.Hint{
+		{Binding: "menu_up", What: "Pick", Keys: "Up/Down", Pad: "D-pad Up/Down"},
+		{Binding: "menu_choose", What: "Choose"},
+		{Binding: "resume", What: "Resume"},
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/internal/tween"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Ticks to pan and zoom between shots, and to play a blow
+	panTicks    = 30
+	strikeTicks = 45
+	// Tiles of room around a clash, and how close the camera gets
+	frameMargin = 3
+	maxZoom     = 2.5
+	// How far an attacker lunges, in tiles
+	lunge = 0.35
+)
+
+//nolint:gochecknoglobal
+var (
+	hitColor     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	missileColor = color.RGBA{0xff, 0xff, 0x80, 0xff}
+)
+
+// camera is a view on the screen as drawn by the scenes: the point in the
+// middle of the view, and the zoom.
+type camera struct {
+	x, y float64
+	zoom float64
+}
+
+//nolint:gochecknoglobal
+var homeCamera = camera{screenWidth / 2, screenHeight / 2, 1}
+
+func (c camera) lerp(to camera, t float64) camera {
+	return camera{tween.Lerp(c.x, to.x, t), tween.Lerp(c.y, to.y, t), tween.Lerp(c.zoom, to.zoom, t)}
+}
+
+func (c camera) GeoM() ebiten.GeoM {
+	var m ebiten.GeoM
+	m.Translate(-c.x, -c.y)
+	m.Scale(c.zoom, c.zoom)
+	m.Translate(screenWidth/2, screenHeight/2)
+
+	return m
+}
+
+// tileCenter returns the middle of the tile, on the screen as drawn.
+func tileCenter(t sim.Tile) (float64, float64) {
+	return float64(t.X*tileSize + tileSize/2), float64(t.Y*tileSize + mapTop + tileSize/2)
+}
+
+// frame returns the camera framing both sides of a clash, with some room,
+// without showing past the edges of the screen.
+func frame(c sim.Clash) camera {
+	x1, y1 := tileCenter(c.From)
+	x2, y2 := tileCenter(c.To)
+	w := math.Abs(x2-x1) + 2*frameMargin*tileSize
+	h := math.Abs(y2-y1) + 2*frameMargin*tileSize
+	zoom := math.Max(1, math.Min(maxZoom, math.Min(screenWidth/w, screenHeight/h)))
+
+	hw, hh := screenWidth/(2*zoom), screenHeight/(2*zoom)
+
+	return camera{
+		x:    math.Max(hw, math.Min((x1+x2)/2, screenWidth-hw)),
+		y:    math.Max(hh, math.Min((y1+y2)/2, screenHeight-hh)),
+		zoom: zoom,
+	}
+}
+
+// cameraMove is a step of the director: moving the camera to a spot over
+// some ticks, and playing a clash if there's one.
+type cameraMove struct {
+	to    camera
+	ticks int
+	clash *sim.Clash
+}
+
+// director sequences the camera through the clashes of a resolution: it
+// closes in on each one, plays it, and goes back to the whole board once
+// they're done. Any of the skip keys jumps to the end.
+type director struct {
+	enabled bool
+	cam     camera
+	// Where the current move started from, and how far into it it is
+	from  camera
+	moves []cameraMove
+	ticks int
+	keys  keymap.Map
+	// Called as an attacker strikes, and as the blow lands, for the sound
+	onStrike func(c sim.Clash, landed bool)
+}
+
+func newDirector(enabled bool) *director {
+	d := &director{enabled: enabled, cam: homeCamera}
+	d.keys = keymap.Map{
+		{Name: "skip", Keys: keymap.Keys(ebiten.KeySpace, ebiten.KeyEnter, ebiten.KeyEscape), Pads: keymap.Pads(keymap.PadA, keymap.PadB),
+			Trigger: keymap.Pressed, Action: keymap.Do(d.skip)},
+	}
+
+	return d
+}
+
+// start plans the moves for the clashes of a resolution.
+func (d *director) start(clashes []sim.Clash) {
+	d.moves = nil
+	d.from, d.ticks = d.cam, 0
+
+	if !d.enabled || len(clashes) == 0 {
+		return
+	}
+
+	for i := range clashes {
+		c := &clashes[i]
+		d.moves = append(d.moves, cameraMove{frame(*c), panTicks, nil}, cameraMove{frame(*c), strikeTicks, c})
+	}
+
+	d.moves = append(d.moves, cameraMove{homeCamera, panTicks, nil})
+}
+
+func (d *director) skip() {
+	d.moves = nil
+	d.cam = homeCamera
+}
+
+// Playing reports whether there are moves left.
+func (d *director) Playing() bool {
+	return len(d.moves) > 0
+}
+
+func (d *director) Update() {
+	if !d.Playing() {
+		return
+	}
+
+	_ = d.keys.Update()
+	if !d.Playing() {
+		return
+	}
+
+	d.ticks++
+	m := d.moves[0]
+	d.cam = d.from.lerp(m.to, tween.InOutQuad(tween.Clamp01(float64(d.ticks)/float64(m.ticks))))
+
+	// The blow lands halfway through, see Draw
+	if m.clash != nil && d.onStrike != nil {
+		switch d.ticks {
+		case 1:
+			d.onStrike(*m.clash, false)
+		case (m.ticks + 1) / 2:
+			d.onStrike(*m.clash, true)
+		}
+	}
+
+	if d.ticks >= m.ticks {
+		d.moves = d.moves[1:]
+		d.from, d.ticks = d.cam, 0
+	}
+}
+
+// Shown returns how many events of the resolution were shown already, so
+// the log follows the camera.
+func (d *director) Shown(events int) int {
+	for i, m := range d.moves {
+		if m.clash == nil {
+			continue
+		}
+
+		if i == 0 {
+			return m.clash.Event + 1
+		}
+
+		return m.clash.Event
+	}
+
+	return events
+}
+
+// Draw draws the blow being played, if any, over the board.
+func (d *director) Draw(screen *ebiten.Image) {
+	if !d.Playing() || d.moves[0].clash == nil {
+		return
+	}
+
+	c := d.moves[0].clash
+	t := float64(d.ticks) / float64(d.moves[0].ticks)
+	x1, y1 := tileCenter(c.From)
+	x2, y2 := tileCenter(c.To)
+
+	clr := unitColor
+	if c.Enemy {
+		clr = enemyColor
+	}
+
+	// The attacker lunges at the defender and back, or fires at it
+	if c.Ranged {
+		if t < 0.5 {
+			mx, my := tween.Lerp(x1, x2, 2*t), tween.Lerp(y1, y2, 2*t)
+			ebitenutil.DrawRect(screen, mx-3, my-3, 6, 6, missileColor)
+		}
+	} else {
+		k := lunge * math.Sin(math.Pi*tween.Clamp01(2*t))
+		drawBlock(screen, tween.Lerp(x1, x2, k), tween.Lerp(y1, y2, k), tileSize-12, clr)
+	}
+
+	if t < 0.5 {
+		return
+	}
+
+	// Then the defender flashes, and the damage floats up
+	text := "miss"
+
+	if c.Damage > 0 {
+		a := 1 - 2*(t-0.5)
+		drawBlock(screen, x2, y2, tileSize-4, color.NRGBA{hitColor.R, hitColor.G, hitColor.B, uint8(0xc0 * a)})
+
+		text = "-" + strconv.Itoa(c.Damage)
+		if c.Fatal {
+			text += " down!"
+		}
+	}
+
+	ebitenutil.DebugPrintAt(screen, text, int(x2)-len(text)*3, int(y2)-tileSize/2-int(16*(t-0.5)))
+}
+
+// drawBlock draws a square of side s centered at (x, y).
+func drawBlock(screen *ebiten.Image, x, y, s float64, clr color.Color) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(s, s)
+	op.GeoM.Translate(x-s/2, y-s/2)
+	op.ColorM.Scale(shapes.ColorScale(clr))
+	_ = screen.DrawImage(shapes.EmptyImage, op)
+}
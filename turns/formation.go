@@ -0,0 +1,108 @@
+package main
+
+import (
+	"image/color"
+	"strconv"
+
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+)
+
+//nolint:gochecknoglobal
+var groupColor = color.RGBA{0x60, 0xff, 0x60, 0xff}
+
+// formation is the group of units that move together (G adds or takes out
+// the selected one). Moving with the selected unit in a group of two or
+// more moves them all, led by it, keeping where they stand from it.
+type formation struct {
+	units []int
+	// Pending actions declared by each formation move, as the range in
+	// Pending, so they're undone all at once
+	declared [][2]int
+}
+
+func (f *formation) has(unit int) bool {
+	for _, u := range f.units {
+		if u == unit {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *Game) toggleGroup() {
+	f := &g.formation
+
+	for i, u := range f.units {
+		if u == g.selected {
+			f.units = append(f.units[:i], f.units[i+1:]...)
+			g.notify.Push("Unit %d leaves the group", g.state.Units[u].ID)
+
+			return
+		}
+	}
+
+	f.units = append(f.units, g.selected)
+	g.notify.Push("Unit %d joins the group", g.state.Units[g.selected].ID)
+}
+
+// leading reports whether a move by the selected unit is a formation move.
+func (g *Game) leading() bool {
+	return g.mode == sim.ModeMove && len(g.formation.units) > 1 && g.formation.has(g.selected)
+}
+
+// declareFormation moves the group to the cursor, led by the selected unit.
+func (g *Game) declareFormation() {
+	units := []int{g.selected}
+
+	for _, u := range g.formation.units {
+		if u != g.selected {
+			units = append(units, u)
+		}
+	}
+
+	start := len(g.state.Pending)
+
+	n := g.state.DeclareFormation(units, g.cursor)
+	if n == 0 {
+		g.notify.Push("The group can't move there")
+
+		return
+	}
+
+	if n < len(units) {
+		g.notify.Push("%d of %d units could move", n, len(units))
+	}
+
+	g.formation.declared = append(g.formation.declared, [2]int{start, len(g.state.Pending)})
+	g.tutorial.Do("formation")
+}
+
+// undo takes back the last action, or the whole of the last formation move.
+func (g *Game) undo() {
+	d := g.formation.declared
+	if n := len(d); n > 0 && d[n-1][1] == len(g.state.Pending) {
+		for len(g.state.Pending) > d[n-1][0] {
+			g.state.Undo()
+		}
+
+		g.formation.declared = d[:n-1]
+
+		return
+	}
+
+	g.state.Undo()
+}
+
+// drawGroup frames the units in the group.
+func (g *Game) drawGroup(screen *ebiten.Image) {
+	for _, i := range g.formation.units {
+		u := g.state.Units[i]
+		g.drawFrame(screen, u.Pos, u.Side(), groupColor)
+	}
+}
+
+func (f *formation) HUD() string {
+	return "Group: " + strconv.Itoa(len(f.units)) + " (G)"
+}
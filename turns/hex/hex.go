@@ -0,0 +1,120 @@
+// Package hex is the math of a grid of flat topped hexagons, in axial
+// coordinates: q goes along the columns and r down and to the left, so a
+// hex has its six neighbors one step away in every direction. See Red Blob
+// Games' guide to hexagonal grids, where this comes from.
+package hex
+
+import "math"
+
+// Hex is a hexagon on the grid, by its axial coordinates.
+type Hex struct {
+	Q, R int
+}
+
+//nolint:gochecknoglobal
+var directions = [6]Hex{{1, 0}, {1, -1}, {0, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+
+// Add returns h moved by d.
+func (h Hex) Add(d Hex) Hex {
+	return Hex{h.Q + d.Q, h.R + d.R}
+}
+
+// Neighbors returns the six hexes around h.
+func (h Hex) Neighbors() [6]Hex {
+	var ns [6]Hex
+	for i, d := range directions {
+		ns[i] = h.Add(d)
+	}
+
+	return ns
+}
+
+// Distance is how many steps there are from a to b, going through
+// neighbors.
+func Distance(a, b Hex) int {
+	dq, dr := a.Q-b.Q, a.R-b.R
+
+	return (abs(dq) + abs(dq+dr) + abs(dr)) / 2
+}
+
+// Range returns the hexes within n steps of center, center included.
+func Range(center Hex, n int) []Hex {
+	var hs []Hex
+
+	for q := -n; q <= n; q++ {
+		for r := max(-n, -q-n); r <= min(n, -q+n); r++ {
+			hs = append(hs, center.Add(Hex{q, r}))
+		}
+	}
+
+	return hs
+}
+
+// FromOffset returns the hex at a column and row of a rectangular map,
+// where odd columns are shifted down half a hex.
+func FromOffset(col, row int) Hex {
+	return Hex{col, row - (col-col&1)/2}
+}
+
+// Offset returns the column and row of h on a rectangular map, see
+// FromOffset.
+func (h Hex) Offset() (col, row int) {
+	return h.Q, h.R + (h.Q-h.Q&1)/2
+}
+
+// Center returns where the center of h is, for hexes of size, the distance
+// from their center to a corner, with the one at 0, 0 centered at the
+// origin.
+func (h Hex) Center(size float64) (x, y float64) {
+	return size * 3 / 2 * float64(h.Q), size * math.Sqrt(3) * (float64(h.R) + float64(h.Q)/2)
+}
+
+// At returns the hex that has the point (x, y), like the one under the
+// cursor, see Center.
+func At(x, y, size float64) Hex {
+	q := x * 2 / 3 / size
+	r := (-x/3 + math.Sqrt(3)/3*y) / size
+
+	return round(q, r)
+}
+
+// round returns the hex a fractional position is in, rounding in cube
+// coordinates, where q+r+s is 0, and fixing the one that was off the most.
+func round(q, r float64) Hex {
+	s := -q - r
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+
+	switch {
+	case dq > dr && dq > ds:
+		rq = -rr - rs
+	case dr > ds:
+		rr = -rq - rs
+	}
+
+	return Hex{int(rq), int(rr)}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
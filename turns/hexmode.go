@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/notify"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
+	"github.com/antoniomo/ebiten-exercises/turns/hex"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Center to corner, so the level fits under the HUD lines
+	hexSize = 17
+	// Steps a unit moves per turn on the hex board
+	hexMoveRange = 3
+)
+
+//nolint:gochecknoglobal
+var (
+	hexRangeColor   = color.RGBA{0xff, 0xff, 0xff, 0x50}
+	hexPlannedColor = color.RGBA{0xff, 0xd7, 0, 0x80}
+	hexCursorColor  = color.RGBA{0, 0xff, 0xff, 0x60}
+)
+
+// hexUnit is a unit on the hex board, and where it's told to go this turn,
+// if anywhere.
+type hexUnit struct {
+	id      int
+	pos     hex.Hex
+	planned *hex.Hex
+}
+
+// hexGame is the level on a grid of hexagons (-hex), a minimal take on the
+// game with only moving: pick a unit, pick a hex in its movement range, and
+// the moves are made when the turn ends. The range goes by hex distance,
+// leaving out the hexes it can't stand on.
+type hexGame struct {
+	board    sim.Board
+	units    []hexUnit
+	selected int
+	turn     int
+	// Mesh of a hexagon, a bit smaller than the grid so the lines show
+	vs      []ebiten.Vertex
+	indices []uint16
+	keys    keymap.Map
+	notify  *notify.Notifier
+	// The hex cursor, where the mouse last moved it or the arrows and the
+	// gamepad stepped it
+	cur            hex.Hex
+	mouseX, mouseY int
+	device         keymap.Device
+}
+
+func newHexGame() *hexGame {
+	g := &hexGame{
+		board:    sim.ParseBoard(level),
+		selected: -1,
+		turn:     1,
+		notify:   notify.New(),
+	}
+	g.vs, g.indices = shapes.RegularPolygon(hexSize-1, 6)
+
+	// Where the square board has them, the vehicle is a hex like the rest
+	for i, t := range []sim.Tile{{X: 1, Y: 1}, {X: 3, Y: 12}, {X: 0, Y: 5}} {
+		g.units = append(g.units, hexUnit{id: i + 1, pos: hex.FromOffset(t.X, t.Y)})
+	}
+
+	// The cursor steps a hex per press in offset coordinates, and keeps
+	// going when held, like the square board's
+	step := func(k ebiten.Key, p ebiten.GamepadButton, ax keymap.Axis, dc, dr int) keymap.Binding {
+		return keymap.Binding{
+			Keys: keymap.Keys(k), Pads: keymap.Pads(p), Axes: keymap.Sticks(ax),
+			Trigger: keymap.Repeat, Delay: 15, Interval: 4,
+			Action: keymap.Do(func() { g.step(dc, dr) }),
+		}
+	}
+
+	g.keys = keymap.Map{
+		step(ebiten.KeyUp, keymap.PadUp, keymap.StickUp, 0, -1),
+		step(ebiten.KeyDown, keymap.PadDown, keymap.StickDown, 0, 1),
+		step(ebiten.KeyLeft, keymap.PadLeft, keymap.StickLeft, -1, 0),
+		step(ebiten.KeyRight, keymap.PadRight, keymap.StickRight, 1, 0),
+		{Buttons: keymap.Buttons(ebiten.MouseButtonLeft), Pads: keymap.Pads(keymap.PadA), Trigger: keymap.Pressed, Action: keymap.Do(g.click)},
+		{Keys: keymap.Keys(ebiten.KeySpace), Trigger: keymap.Pressed, Action: keymap.Do(g.click)},
+		{Buttons: keymap.Buttons(ebiten.MouseButtonRight), Pads: keymap.Pads(keymap.PadB), Trigger: keymap.Pressed, Action: keymap.Do(g.cancel)},
+		{Keys: keymap.Keys(ebiten.KeyBackspace), Trigger: keymap.Pressed, Action: keymap.Do(g.cancel)},
+		{Keys: keymap.Keys(ebiten.KeyTab), Pads: keymap.Pads(keymap.PadY), Trigger: keymap.Pressed, Action: keymap.Do(g.cycle)},
+		{Keys: keymap.Keys(ebiten.KeyEnter), Pads: keymap.Pads(keymap.PadStart), Trigger: keymap.Pressed, Action: keymap.Do(g.endTurn)},
+		{Keys: keymap.Keys(ebiten.KeyEscape), Pads: keymap.Pads(keymap.PadBack), Action: func() error { return run.ErrCleanExit }},
+	}
+
+	return g
+}
+
+// center returns where the center of h is on the screen.
+func (g *hexGame) center(h hex.Hex) (float64, float64) {
+	x, y := h.Center(hexSize)
+
+	return x + hexSize, y + mapTop + hexSize*math.Sqrt(3)/2
+}
+
+// updateCursor moves the cursor to the hex under the mouse, when the mouse
+// moves onto the board.
+func (g *hexGame) updateCursor() {
+	cx, cy := keymap.Input().CursorPosition()
+	if cx == g.mouseX && cy == g.mouseY {
+		return
+	}
+
+	g.mouseX, g.mouseY = cx, cy
+	if h := hex.At(float64(cx)-hexSize, float64(cy)-mapTop-hexSize*math.Sqrt(3)/2, hexSize); g.onBoard(h) {
+		g.cur = h
+	}
+}
+
+// step moves the cursor by columns and rows, if it stays on the board.
+func (g *hexGame) step(dc, dr int) {
+	col, row := g.cur.Offset()
+	if h := hex.FromOffset(col+dc, row+dr); g.onBoard(h) {
+		g.cur = h
+	}
+}
+
+// onBoard reports whether h is on the board.
+func (g *hexGame) onBoard(h hex.Hex) bool {
+	_, ok := g.terrain(h)
+
+	return ok
+}
+
+// terrain returns the terrain of h, and whether it's on the board.
+func (g *hexGame) terrain(h hex.Hex) (sim.Terrain, bool) {
+	col, row := h.Offset()
+	if !g.board.In(col, row) {
+		return sim.Grass, false
+	}
+
+	return g.board.At(col, row), true
+}
+
+// unitAt returns the index of the unit on h, or going there, or -1.
+func (g *hexGame) unitAt(h hex.Hex) int {
+	for i, u := range g.units {
+		if u.pos == h || (u.planned != nil && *u.planned == h) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// reachable returns the hexes the unit can be told to go to: within its
+// range and on the board, but not on walls or water, or where another unit
+// is or is going.
+func (g *hexGame) reachable(i int) []hex.Hex {
+	var hs []hex.Hex
+
+	for _, h := range hex.Range(g.units[i].pos, hexMoveRange) {
+		t, ok := g.terrain(h)
+		if !ok || sim.Terrains[t].Cost == 0 || h == g.units[i].pos {
+			continue
+		}
+
+		if j := g.unitAt(h); j >= 0 && j != i {
+			continue
+		}
+
+		hs = append(hs, h)
+	}
+
+	return hs
+}
+
+// click selects the unit under the cursor, or tells the selected one to go
+// there if it can.
+func (g *hexGame) click() {
+	h := g.cur
+
+	if i := g.unitAt(h); i >= 0 && g.units[i].pos == h {
+		g.selected = i
+
+		return
+	}
+
+	if g.selected < 0 {
+		return
+	}
+
+	for _, r := range g.reachable(g.selected) {
+		if r == h {
+			g.units[g.selected].planned = &h
+
+			return
+		}
+	}
+
+	g.notify.Push("Out of range")
+}
+
+// cancel forgets where the selected unit was told to go.
+func (g *hexGame) cancel() {
+	if g.selected >= 0 {
+		g.units[g.selected].planned = nil
+	}
+}
+
+// cycle selects the next unit.
+func (g *hexGame) cycle() {
+	g.selected = (g.selected + 1) % len(g.units)
+}
+
+// endTurn makes the planned moves and starts the next turn.
+func (g *hexGame) endTurn() {
+	moved := 0
+
+	for i := range g.units {
+		if u := &g.units[i]; u.planned != nil {
+			u.pos, u.planned = *u.planned, nil
+			moved++
+		}
+	}
+
+	g.turn++
+	stats.Add(stats.TurnsPlayed, 1)
+	g.notify.Push("Turn %d, %d units moved", g.turn, moved)
+}
+
+func (g *hexGame) Update(screen *ebiten.Image) error {
+	g.notify.Update()
+	g.device.Update()
+	g.updateCursor()
+
+	return g.keys.Update()
+}
+
+// drawHex fills h with the color.
+func (g *hexGame) drawHex(screen *ebiten.Image, h hex.Hex, clr color.Color) {
+	x, y := g.center(h)
+
+	// The mesh is in a box from the origin, put it on the center
+	vs := append([]ebiten.Vertex(nil), g.vs...)
+	for i := range vs {
+		vs[i].DstX += float32(x) - hexSize + 1
+		vs[i].DstY += float32(y) - hexSize + 1
+	}
+
+	shapes.Draw(screen, vs, g.indices, clr, nil)
+}
+
+func (g *hexGame) Draw(screen *ebiten.Image) {
+	for row := 0; row < g.board.H; row++ {
+		for col := 0; col < g.board.W; col++ {
+			g.drawHex(screen, hex.FromOffset(col, row), terrainColors[g.board.At(col, row)])
+		}
+	}
+
+	if g.selected >= 0 {
+		for _, h := range g.reachable(g.selected) {
+			g.drawHex(screen, h, hexRangeColor)
+		}
+	}
+
+	g.drawHex(screen, g.cur, hexCursorColor)
+
+	for i, u := range g.units {
+		x, y := g.center(u.pos)
+
+		if u.planned != nil {
+			g.drawHex(screen, *u.planned, hexPlannedColor)
+			px, py := g.center(*u.planned)
+			ebitenutil.DrawLine(screen, x, y, px, py, unitColor)
+		}
+
+		clr := color.Color(unitColor)
+		if i == g.selected {
+			clr = cursorColor
+		}
+
+		ebitenutil.DrawRect(screen, x-hexSize/2, y-hexSize/2, hexSize, hexSize, clr)
+		ebitenutil.DebugPrintAt(screen, fmt.Sprint(u.id), int(x)-3, int(y)-8)
+	}
+
+	help := "Hex board, turn %d: click a unit or Tab, then a hex in its range (%d steps), or the arrows and Space\n" +
+		"Right click or Backspace cancels its move, Enter ends the turn and makes the moves"
+	if g.device.Pad {
+		help = "Hex board, turn %d: A on a unit or Y, then A on a hex in its range (%d steps)\n" +
+			"B cancels its move, Start ends the turn and makes the moves, Back quits"
+	}
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf(help, g.turn, hexMoveRange))
+	g.notify.Draw(screen)
+}
+
+func (g *hexGame) Layout(outsideWidth, outsideHeight int) (screenW, screenH int) {
+	return screenWidth, screenHeight
+}
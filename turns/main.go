@@ -1,40 +1,708 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
 	"log"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/antoniomo/ebiten-exercises/internal/hint"
+	"github.com/antoniomo/ebiten-exercises/internal/i18n"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/notify"
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/antoniomo/ebiten-exercises/internal/scene"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/internal/stats"
+	"github.com/antoniomo/ebiten-exercises/internal/tutorial"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
-	"github.com/hajimehoshi/ebiten/inpututil"
 )
 
+const (
+	screenWidth = 640
+	tileSize    = 32
+	// The map is drawn below the HUD lines, and above the control hints
+	mapTop       = 32
+	mapBottom    = mapTop + sim.MaxMapH*tileSize
+	footerHeight = 2*16 + 4
+	screenHeight = mapBottom + footerHeight
+	// How long the resolution phase stays on screen
+	resolutionTime = time.Second
+)
+
+var (
+	//nolint:gochecknoglobal
+	fogColor    = color.RGBA{0, 0, 0, 0x90}
+	pathColor   = color.RGBA{0xff, 0xff, 0xff, 0x60}
+	unitColor   = color.RGBA{0xff, 0xd7, 0, 0xff}
+	blastColor  = color.RGBA{0xff, 0x30, 0x30, 0x80}
+	focusColor  = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	cursorColor = color.RGBA{0, 0xff, 0xff, 0xff}
+	enemyColor  = color.RGBA{0xd0, 0x20, 0x20, 0xff}
+	facingColor = color.RGBA{0, 0, 0, 0xff}
+	routedColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+
+	level = []string{
+		"....................",
+		"..##......~.....TT..",
+		"..#.......~......T..",
+		"..#...TT..~.........",
+		"......TT..~...####..",
+		"..........~......#..",
+		"....###...=......#..",
+		"..........~.........",
+		"..TT......~....TT...",
+		"..TT......~....TT...",
+		".....#....~.........",
+		".....#....~...###...",
+		"..........~.........",
+		"..........~.........",
+	}
+)
+
+// Game is the presentation of the sim: it renders the state, and turns the
+// input into actions for it.
 type Game struct {
-	turn int
+	scenes *scene.Manager
+	state  sim.State
+
+	// Caches of data derived from the board, for drawing
+	board  *Tilemap
+	layer  *mapLayer
+	paths  *pathCache
+	sights []*visibility
+
+	// Keyboard focus: the selected unit and the tile cursor
+	selected  int
+	cursor    sim.Tile
+	mouseX    int
+	mouseY    int
+	formation formation
+
+	mode    sim.Mode
+	ability int
+	// What happened on the last resolution
+	events []string
+	// Resolutions are drawn on world, for the director to move the camera
+	// around
+	world    *ebiten.Image
+	director *director
+	// The camera on the board while planning
+	view view
+
+	tutorial *tutorial.Tutorial
+	notify   *notify.Notifier
+	mixer    *mixer
+	keys     keymap.Map
+	// The control hints, for the device the player is using
+	footer hint.Footer
+	device keymap.Device
+	// Where Ctrl+S saves the game, and Ctrl+L loads it from
+	savePath string
+	// What random maps are made from, as last set up
+	mapParams sim.MapParams
+	// The level with its mission, as the title starts it
+	level sim.State
+	// The computer player, planning the enemies' turn once the player
+	// ends theirs
+	ai sim.Strategy
 }
 
-func (g *Game) Update(screen *ebiten.Image) error {
-	// As a turn-based strategy, just register the player's declared
-	// "actions" first, then trigger world update only if the "next turn"
-	// trigger applies, otherwise skip
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
-		g.turn++
+func NewGame(tut *tutorial.Tutorial, seed uint64, cinematics bool, savePath string, ai sim.Strategy) *Game {
+	g := &Game{
+		ai:        ai,
+		state:     newState(seed),
+		tutorial:  tut,
+		notify:    notify.New(),
+		director:  newDirector(cinematics),
+		mixer:     newMixer(),
+		savePath:  savePath,
+		mapParams: sim.DefaultMapParams(seed),
+		footer:    hint.Footer{Locale: i18n.Detect()},
+		view:      newView(),
+	}
+	g.director.onStrike = func(c sim.Clash, landed bool) {
+		g.mixer.strike(c, g.director.cam, landed)
+	}
+	g.world, _ = profile.NewImage(screenWidth, screenHeight, ebiten.FilterDefault)
+
+	if tut != nil {
+		tut.TileRect = func(x, y int) image.Rectangle {
+			return g.view.toView(tileRect(sim.Tile{X: x, Y: y}))
+		}
+	}
+
+	g.newCaches()
+	g.cursor = g.state.Units[0].Pos
+	g.keys = g.bindings()
+	g.scenes = scene.NewManager(newTitle(g))
+
+	return g
+}
+
+// newCaches makes the caches of data derived from the board, empty, for a
+// new state.
+func (g *Game) newCaches() {
+	g.board = newTilemap(&g.state)
+	g.layer = newMapLayer(g.board)
+	g.paths = newPathCache(g.board)
+	g.sights = nil
+
+	for range g.state.Units {
+		g.sights = append(g.sights, newVisibility(g.board, sim.SightRange))
+	}
+}
+
+// newState starts the game on the level, with its units and enemies.
+func newState(seed uint64) sim.State {
+	s := sim.New(sim.ParseBoard(level),
+		[]sim.Tile{{X: 1, Y: 1}, {X: 3, Y: 12}, {X: 0, Y: 5}},
+		[]sim.Tile{{X: 8, Y: 3}, {X: 7, Y: 9}},
+		seed)
+	// The last one is a vehicle, taking 2x2 tiles
+	s.Units[2].Size = 2
+
+	return s
+}
+
+// updateCursor makes the tile cursor, moved with the arrow keys, follow the
+// mouse when it moves over the board, and keeps it on the board and in the
+// view.
+func (g *Game) updateCursor() {
+	b := g.state.Board
+
+	if cx, cy := keymap.Input().CursorPosition(); cx != g.mouseX || cy != g.mouseY {
+		g.mouseX, g.mouseY = cx, cy
+		wx, wy := g.view.toWorld(cx, cy)
+
+		if t := (sim.Tile{X: wx / tileSize, Y: (wy - mapTop) / tileSize}); wx >= 0 && wy >= mapTop && b.In(t.X, t.Y) {
+			g.cursor = t
+		}
+	}
+
+	g.cursor.X = clamp(g.cursor.X, 0, b.W-1)
+	g.cursor.Y = clamp(g.cursor.Y, 0, b.H-1)
+	g.view.follow(g.cursor)
+}
+
+// visible reports whether any unit sees the tile.
+func (g *Game) visible(x, y int) bool {
+	for i, u := range g.state.Units {
+		if g.sights[i].Visible(u.Pos, x, y) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// declare hands an action over to the sim.
+func (g *Game) declare(a sim.Action) {
+	if !g.state.Declare(a) {
+		what := a.Mode.String()
+		if a.Mode == sim.ModeAbility {
+			what = "use " + sim.Abilities[a.Ability].Name
+		}
+
+		g.notify.Push("Can't %s there", what)
+
+		return
+	}
+
+	g.tutorial.Do(a.Mode.String())
+}
+
+// resolve has the computer player declare the enemies' actions, resolves
+// the turn in the sim, updates the caches with the tiles that changed, and
+// has the director show the clashes.
+func (g *Game) resolve() {
+	g.ai.Plan(&g.state)
+	r := g.state.Resolve()
+	g.formation.declared = nil
+	g.events = r.Events
+	g.board.Changed(r.Changes)
+	g.director.start(r.Clashes)
+	g.mixer.resolving = true
+
+	// Without the combat camera the clashes all sound at once
+	if !g.director.Playing() {
+		for _, c := range r.Clashes {
+			g.mixer.strike(c, homeCamera, false)
+			g.mixer.strike(c, homeCamera, true)
+		}
+	}
+
+	stats.Add(stats.TurnsPlayed, 1)
+}
+
+func (g *Game) drawTile(screen *ebiten.Image, t sim.Tile, inset int, clr color.Color) {
+	g.drawArea(screen, t, 1, inset, clr)
+}
+
+// drawArea is drawTile for the size by size tiles from t, as units take.
+func (g *Game) drawArea(screen *ebiten.Image, t sim.Tile, size, inset int, clr color.Color) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(size*tileSize-1-2*inset), float64(size*tileSize-1-2*inset))
+	op.GeoM.Translate(float64(t.X*tileSize+inset), float64(t.Y*tileSize+mapTop+inset))
+	op.ColorM.Scale(shapes.ColorScale(clr))
+	_ = screen.DrawImage(shapes.EmptyImage, op)
+}
+
+// drawFrame draws the outline of size by size tiles from t, to show the
+// keyboard focus.
+func (g *Game) drawFrame(screen *ebiten.Image, t sim.Tile, size int, clr color.Color) {
+	x, y := float64(t.X*tileSize), float64(t.Y*tileSize+mapTop)
+	s := float64(size*tileSize - 1)
+
+	for _, r := range [...][4]float64{
+		{x, y, s, 2}, {x, y + s - 2, s, 2}, {x, y, 2, s}, {x + s - 2, y, 2, s},
+	} {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(r[2], r[3])
+		op.GeoM.Translate(r[0], r[1])
+		op.ColorM.Scale(shapes.ColorScale(clr))
+		_ = screen.DrawImage(shapes.EmptyImage, op)
+	}
+}
+
+func (g *Game) drawBoard(screen *ebiten.Image) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(0, mapTop)
+	g.layer.Draw(screen, op)
+
+	for y := 0; y < g.state.Board.H; y++ {
+		for x := 0; x < g.state.Board.W; x++ {
+			if !g.visible(x, y) {
+				g.drawTile(screen, sim.Tile{X: x, Y: y}, 0, fogColor)
+			}
+		}
+	}
+
+	for _, a := range g.state.Pending {
+		switch a.Mode {
+		case sim.ModeMove:
+			g.drawArea(screen, a.Target, g.state.Units[a.Unit].Side(), 10, unitColor)
+		case sim.ModeExplode:
+			g.drawTile(screen, a.Target, 4, blastColor)
+		case sim.ModeBridge:
+			g.drawTile(screen, a.Target, 8, terrainColors[sim.Bridge])
+		case sim.ModeAbility:
+			g.drawTile(screen, a.Target, 10, areaColor)
+		case sim.ModeAttack, sim.ModeFace, sim.ModeRetreat, sim.ModeWait:
+		}
+	}
+
+	g.drawObjectives(screen)
+
+	for _, u := range g.state.Units {
+		clr := unitColor
+		if u.HP <= 0 {
+			clr = downColor
+		}
+
+		g.drawArea(screen, u.Pos, u.Side(), 6, clr)
+		g.drawRouted(screen, u)
+		g.drawFacing(screen, u, facingColor)
+	}
+
+	for _, e := range g.state.Enemies {
+		if e.HP > 0 && g.visible(e.Pos.X, e.Pos.Y) {
+			g.drawArea(screen, e.Pos, e.Side(), 6, enemyColor)
+			g.drawRouted(screen, e)
+			g.drawFacing(screen, e, facingColor)
+		}
+	}
+}
+
+// drawRouted marks the units that are fleeing.
+func (g *Game) drawRouted(screen *ebiten.Image, u sim.Unit) {
+	if u.HP > 0 && u.Routed {
+		g.drawArea(screen, u.Pos, u.Side(), 12, routedColor)
+	}
+}
+
+func moraleHUD(u sim.Unit) string {
+	if u.Routed {
+		return fmt.Sprintf("morale %d routed", u.Morale)
 	}
 
+	return fmt.Sprintf("morale %d", u.Morale)
+}
+
+// screenLayout is the Layout of the scenes on the game's screen.
+type screenLayout struct{}
+
+func (screenLayout) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenWidth, screenHeight
+}
+
+// planning is where the player declares its actions for the turn.
+type planning struct {
+	screenLayout
+	g *Game
+}
+
+func (p *planning) Update() error {
+	g := p.g
+
+	if g.tutorial.Update() {
+		return nil
+	}
+
+	if g.mixer.UpdatePanel() {
+		return nil
+	}
+
+	if err := g.keys.Update(); err != nil {
+		return err
+	}
+
+	g.updateCursor()
+
 	return nil
 }
 
+// bindings is the input while planning, but for the mouse moving the
+// cursor, see updateCursor. The gamepad does it all, but what the pause
+// menu has: saving, loading, new maps, the combat camera and the enemy AI.
+func (g *Game) bindings() keymap.Map {
+	// The cursor steps a tile per press, and keeps going when held
+	cursor := func(name string, k ebiten.Key, p ebiten.GamepadButton, ax keymap.Axis, x, y int) keymap.Binding {
+		return keymap.Binding{
+			Name: name, Keys: keymap.Keys(k), Pads: keymap.Pads(p), Axes: keymap.Sticks(ax),
+			Trigger: keymap.Repeat, Delay: 15, Interval: 4,
+			Action: keymap.Do(func() {
+				g.cursor.X += x
+				g.cursor.Y += y
+			}),
+		}
+	}
+
+	m := keymap.Map{
+		cursor("cursor_up", ebiten.KeyUp, keymap.PadUp, keymap.StickUp, 0, -1),
+		cursor("cursor_down", ebiten.KeyDown, keymap.PadDown, keymap.StickDown, 0, 1),
+		cursor("cursor_left", ebiten.KeyLeft, keymap.PadLeft, keymap.StickLeft, -1, 0),
+		cursor("cursor_right", ebiten.KeyRight, keymap.PadRight, keymap.StickRight, 1, 0),
+	}
+
+	// 1 to 5 pick the modes, and the keys after them the abilities, the
+	// shoulder buttons go through them all
+	for i, md := range planModes {
+		md := md
+		m = append(m, keymap.Binding{Name: fmt.Sprintf("mode_%d", i+1), Keys: keymap.Keys(ebiten.Key1 + ebiten.Key(i)),
+			Trigger: keymap.Pressed, Action: keymap.Do(func() { g.mode = md })})
+	}
+
+	for i, k := range abilityKeys {
+		i := i
+		m = append(m, keymap.Binding{Name: fmt.Sprintf("ability_%d", i+1), Keys: keymap.Keys(k), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { g.mode, g.ability = sim.ModeAbility, i })})
+	}
+
+	m = append(m, g.viewBindings()...)
+
+	return append(m, keymap.Map{
+		{Name: "mode_prev", Pads: keymap.Pads(keymap.PadLB), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.cycleMode(-1) })},
+		{Name: "mode_next", Pads: keymap.Pads(keymap.PadRB), Trigger: keymap.Pressed, Action: keymap.Do(func() { g.cycleMode(1) })},
+		{Name: "next_unit", Keys: keymap.Keys(ebiten.KeyTab), Pads: keymap.Pads(keymap.PadY), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.selected = (g.selected + 1) % len(g.state.Units)
+			g.cursor = g.state.Units[g.selected].Pos
+			g.tutorial.Do("select")
+		})},
+		{
+			Name:    "declare",
+			Keys:    keymap.Keys(ebiten.KeyEnter),
+			Buttons: keymap.Buttons(ebiten.MouseButtonLeft),
+			Pads:    keymap.Pads(keymap.PadA),
+			Trigger: keymap.Pressed,
+			Action: keymap.Do(func() {
+				if g.leading() {
+					g.declareFormation()
+
+					return
+				}
+
+				g.declare(sim.Action{Unit: g.selected, Mode: g.mode, Target: g.cursor, Ability: g.ability})
+			}),
+		},
+		{
+			Name:    "undo",
+			Keys:    keymap.Keys(ebiten.KeyEscape),
+			Buttons: keymap.Buttons(ebiten.MouseButtonRight),
+			Pads:    keymap.Pads(keymap.PadB),
+			Trigger: keymap.Pressed,
+			Action:  keymap.Do(g.undo),
+		},
+		{Name: "group", Keys: keymap.Keys(ebiten.KeyG), Pads: keymap.Pads(keymap.PadRS), Trigger: keymap.Pressed, Action: keymap.Do(g.toggleGroup)},
+		{Name: "wait", Keys: keymap.Keys(ebiten.KeyW), Pads: keymap.Pads(keymap.PadX), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.declare(sim.Action{Unit: g.selected, Mode: sim.ModeWait, Target: g.state.Units[g.selected].Pos})
+		})},
+		{Keys: keymap.Keys(ebiten.KeyS), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(g.saveGame)},
+		{Keys: keymap.Keys(ebiten.KeyL), Trigger: keymap.Pressed, Ctrl: true, Action: keymap.Do(g.loadGame)},
+		{Keys: keymap.Keys(ebiten.KeyN), Trigger: keymap.Pressed, Action: keymap.Do(g.newMap)},
+		{Name: "pause", Keys: keymap.Keys(ebiten.KeyP), Pads: keymap.Pads(keymap.PadStart), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.scenes.Push(newPause(g), nil)
+		})},
+		{Keys: keymap.Keys(ebiten.KeyI), Trigger: keymap.Pressed, Action: keymap.Do(g.cycleAI)},
+		{Keys: keymap.Keys(ebiten.KeyC), Trigger: keymap.Pressed, Action: keymap.Do(g.toggleDirector)},
+		// As a turn-based strategy, just register the player's declared
+		// "actions" first, then trigger world update only if the "next turn"
+		// trigger applies, otherwise skip. The enemy declares its own then,
+		// see resolve
+		{Name: "end_turn", Keys: keymap.Keys(ebiten.KeySpace), Pads: keymap.Pads(keymap.PadBack), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.tutorial.Do("end_turn")
+			g.scenes.Replace(&resolution{g: g}, scene.NewFade(300*time.Millisecond))
+		})},
+	}...)
+}
+
+// cycleMode goes d modes over, through the abilities after the rest.
+func (g *Game) cycleMode(d int) {
+	n := len(planModes) + len(sim.Abilities)
+
+	i := len(planModes) + g.ability
+	if g.mode != sim.ModeAbility {
+		for j, md := range planModes {
+			if md == g.mode {
+				i = j
+			}
+		}
+	}
+
+	if i = (i + d + n) % n; i < len(planModes) {
+		g.mode = planModes[i]
+	} else {
+		g.mode, g.ability = sim.ModeAbility, i-len(planModes)
+	}
+}
+
+// newMap goes to the random map setup.
+func (g *Game) newMap() {
+	g.scenes.Push(newSetup(g), scene.NewFade(300*time.Millisecond))
+}
+
+// toggleDirector turns the combat camera on or off.
+func (g *Game) toggleDirector() {
+	g.director.enabled = !g.director.enabled
+	if g.director.enabled {
+		g.notify.Push("Combat camera on")
+	} else {
+		g.notify.Push("Combat camera off")
+	}
+}
+
+func (p *planning) Draw(screen *ebiten.Image) {
+	g := p.g
+
+	// The board goes through the view, the panels and the HUD stay put
+	world := g.world
+	_ = world.Clear()
+	g.drawBoard(world)
+
+	u := g.state.Units[g.selected]
+	if t := g.cursor; g.mode == sim.ModeMove && !u.Moved {
+		if d := g.paths.Distance(u, t.X, t.Y); d > 0 && d <= u.MP {
+			for _, pt := range g.paths.Path(u, t.X, t.Y) {
+				g.drawArea(world, pt, u.Side(), 12, pathColor)
+			}
+		}
+	}
+
+	g.drawGroup(world)
+
+	if g.mode == sim.ModeAbility {
+		g.drawAbilityPreview(world)
+	}
+
+	g.drawFrame(world, u.Pos, u.Side(), focusColor)
+	g.drawFrame(world, g.cursor, 1, cursorColor)
+
+	if g.mode == sim.ModeAttack {
+		g.drawForecast(world)
+	}
+
+	_ = screen.DrawImage(world, &ebiten.DrawImageOptions{GeoM: g.view.cam.GeoM()})
+	g.drawMissionPanel(screen)
+
+	ebitenutil.DebugPrint(screen, g.planningHUD(u))
+	g.mixer.DrawPanel(screen)
+}
+
+// planningHUD is the two lines above the map, naming the inputs of the
+// device the player is using.
+func (g *Game) planningHUD(u sim.Unit) string {
+	// The abilities replace the mode help while aiming them, as there's only
+	// room for two lines above the map
+	help := fmt.Sprintf("Mode: %s (1 move, 2 explode, 3 bridge, 4 attack, 5 face, 6-8 abilities, W waits, O volume, N new map)", g.mode)
+	ai := fmt.Sprintf("enemy AI: %s (I)", g.ai.Name())
+
+	if g.device.Pad {
+		help = fmt.Sprintf("Mode: %s (%s changes it, %s waits, %s zooms, the menu has the rest)",
+			g.mode, g.inputs(g.keys, "mode_prev")+"/"+g.inputs(g.keys, "mode_next"),
+			g.inputs(g.keys, "wait"), g.inputs(g.keys, "zoom"))
+		ai = "enemy AI: " + g.ai.Name()
+	}
+
+	if g.mode == sim.ModeAbility {
+		help = fmt.Sprintf("Ability: %s (%s)", sim.Abilities[g.ability].Name, g.abilitiesHUD(u))
+	}
+
+	return fmt.Sprintf("Turn: %d  Unit: %d/%d (%s) MP %d %s facing %s  %s  Actions: %d  %s ends the turn, %s\n%s",
+		g.state.Turn, g.selected+1, len(g.state.Units), g.inputs(g.keys, "next_unit"), u.MP, moraleHUD(u), u.Facing,
+		g.formation.HUD(), len(g.state.Pending), g.inputs(g.keys, "end_turn"), ai, help)
+}
+
+// resolution is where the world updates with the declared actions.
+type resolution struct {
+	screenLayout
+	g     *Game
+	ticks int
+}
+
+// Enter resolves the turn, once the transition into the scene is done.
+func (r *resolution) Enter() {
+	r.g.resolve()
+}
+
+func (r *resolution) Update() error {
+	// The resolution only starts counting once the camera is done
+	if r.g.director.Playing() {
+		r.g.director.Update()
+
+		return nil
+	}
+
+	r.ticks++
+	if r.ticks < int(resolutionTime.Seconds()*float64(ebiten.MaxTPS())) {
+		return nil
+	}
+
+	r.g.mixer.resolving = false
+
+	if r.g.state.Outcome != sim.Ongoing {
+		r.g.scenes.Replace(newEnded(r.g), scene.NewFade(time.Second))
+
+		return nil
+	}
+
+	r.g.scenes.Replace(&planning{g: r.g}, scene.NewWipe(400*time.Millisecond))
+	r.g.notify.Push("Turn %d begins", r.g.state.Turn)
+
+	return nil
+}
+
+func (r *resolution) Draw(screen *ebiten.Image) {
+	g := r.g
+
+	_ = g.world.Clear()
+	g.drawBoard(g.world)
+	g.director.Draw(g.world)
+	_ = screen.DrawImage(g.world, &ebiten.DrawImageOptions{GeoM: g.director.cam.GeoM()})
+
+	status := "  Resolving..."
+	if g.director.Playing() {
+		status = fmt.Sprintf("  %s skips the combat camera", g.inputs(g.director.keys, "skip"))
+	}
+
+	ebitenutil.DebugPrint(screen, "Turn: "+strconv.Itoa(g.state.Turn)+status+
+		fmt.Sprintf(" (path recomputes %d, sight recomputes %d, tiles redrawn %d)",
+			g.paths.recomputes, g.sightRecomputes(), g.layer.redrawn))
+	ebitenutil.DebugPrintAt(screen, strings.Join(g.events[:g.director.Shown(len(g.events))], "\n"), 0, 16)
+}
+
+// cycleAI switches the computer player to the next strategy, from the next
+// turn it plans.
+func (g *Game) cycleAI() {
+	for i, st := range sim.Strategies {
+		if st.Name() == g.ai.Name() {
+			g.ai = sim.Strategies[(i+1)%len(sim.Strategies)]
+
+			break
+		}
+	}
+
+	g.notify.Push("Enemy AI: %s", g.ai.Name())
+}
+
+func (g *Game) sightRecomputes() int {
+	n := 0
+	for _, v := range g.sights {
+		n += v.recomputes
+	}
+
+	return n
+}
+
+func (g *Game) Update(screen *ebiten.Image) error {
+	g.notify.Update()
+	g.mixer.Update()
+	g.device.Update()
+
+	return g.scenes.Update()
+}
+
 func (g *Game) Draw(screen *ebiten.Image) {
-	ebitenutil.DebugPrint(screen, "Turn: "+strconv.Itoa(g.turn))
+	g.scenes.Draw(screen)
+	g.drawFooter(screen)
+
+	// The tutorial is about playing, its boxes go by the game's screen
+	if _, ok := g.scenes.Current().(*title); !ok && g.tutorial != nil {
+		g.tutorial.Pad = g.device.Pad
+		g.tutorial.Draw(screen)
+	}
+
+	g.notify.Draw(screen)
 }
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
-	return 320, 240
+// Layout is the one of the scene, most of them on the game's screen.
+func (g *Game) Layout(outsideWidth, outsideHeight int) (screenW, screenH int) {
+	return g.scenes.Layout(outsideWidth, outsideHeight)
 }
 
 func main() {
-	ebiten.SetWindowSize(640, 480)
-	ebiten.SetWindowTitle("Hello, World!")
+	tutorialPath := flag.String("tutorial", "tutorial.json", "tutorial script, F1 skips it")
+	seed := flag.Uint64("seed", 0, "seed for the dice rolls, random if 0")
+	scenarioPath := flag.String("scenario", "scenario.json", "mission to play, objectives and turn limit, none if missing")
+	cinematics := flag.Bool("cinematics", true, "move the camera in on the clashes while resolving, C toggles it")
+	games := flag.Int("autoplay", 0, "play this many AI vs AI games without a window, and print the results as CSV")
+	hexBoard := flag.Bool("hex", false, "play the level on a grid of hexagons, only moving units around")
+	savePath := flag.String("save", "savegame.json", "file to save (Ctrl+S) and load (Ctrl+L) the game, while planning")
+	random := flag.Bool("random", false, "set up a random map to play, instead of the level, N does it while planning")
+	aiName := flag.String("ai", "rules", "strategy of the enemy side: rules, random, greedy or minimax, I cycles them while planning")
+	flag.Parse()
+
+	ai, err := sim.StrategyByName(*aiName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *seed == 0 {
+		*seed = uint64(time.Now().UnixNano())
+	}
+
+	if *games > 0 {
+		if err := autoplay(os.Stdout, *games, *seed, *scenarioPath, ai); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	tut, err := tutorial.Load(*tutorialPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ebiten.SetWindowSize(screenWidth, screenHeight)
+	ebiten.SetWindowTitle("Turns")
 	// It seems tempting to reduce TPS to use lower CPU on turn based games,
 	// but unless the update logic is very heavy, it won't make much
 	// difference and it might actually feel awkward with the player input
@@ -43,7 +711,27 @@ func main() {
 	// that on separate goroutines and keep TPS at the default anyway.
 	// ebiten.SetMaxTPS(20)
 
-	if err := ebiten.RunGame(&Game{}); err != nil {
-		log.Fatal(err)
+	if *hexBoard {
+		err = run.Game(newHexGame())
+	} else {
+		g := NewGame(tut, *seed, *cinematics, *savePath, ai)
+		if err := loadMission(*scenarioPath, &g.state); err != nil {
+			log.Fatal(err)
+		}
+
+		g.level = g.state.Clone()
+
+		if *random {
+			g.scenes.Push(newSetup(g), nil)
+		}
+
+		err = run.Game(g)
 	}
+
+	// Whatever the periodic saves didn't get to
+	if err := stats.Flush(); err != nil {
+		log.Printf("saving stats: %v", err)
+	}
+
+	run.Exit(err)
 }
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/scene"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// Size of the tiles of the preview, and where it goes
+	previewTile = 16
+	previewX    = screenWidth - sim.MaxMapW*previewTile
+	previewY    = mapTop
+)
+
+//nolint:gochecknoglobal
+var rowColor = color.RGBA{0xff, 0xff, 0xff, 0x30}
+
+// setupRow is a parameter of the map generator, as the setup scene shows and
+// changes it.
+type setupRow struct {
+	name   string
+	value  func(p sim.MapParams) string
+	change func(p *sim.MapParams, d int)
+}
+
+// percent is a row for a parameter from 0 to 1, in steps of 5%.
+func percent(name string, v func(p *sim.MapParams) *float64) setupRow {
+	return setupRow{
+		name:  name,
+		value: func(p sim.MapParams) string { return fmt.Sprintf("%.0f%%", *v(&p)*100) },
+		change: func(p *sim.MapParams, d int) {
+			*v(p) = math.Round(math.Max(0, math.Min(1, *v(p)+float64(d)*0.05))*20) / 20
+		},
+	}
+}
+
+// count is a row for a whole parameter from lo to hi.
+func count(name string, v func(p *sim.MapParams) *int, lo, hi int) setupRow {
+	return setupRow{
+		name:   name,
+		value:  func(p sim.MapParams) string { return fmt.Sprint(*v(&p)) },
+		change: func(p *sim.MapParams, d int) { *v(p) = clamp(*v(p)+d, lo, hi) },
+	}
+}
+
+//nolint:gochecknoglobal
+var setupRows = []setupRow{
+	{
+		name:   "Seed",
+		value:  func(p sim.MapParams) string { return fmt.Sprint(p.Seed) },
+		change: func(p *sim.MapParams, d int) { p.Seed += uint64(d) },
+	},
+	count("Width", func(p *sim.MapParams) *int { return &p.W }, sim.MinMapW, sim.MaxMapW),
+	count("Height", func(p *sim.MapParams) *int { return &p.H }, sim.MinMapH, sim.MaxMapH),
+	percent("Sea level", func(p *sim.MapParams) *float64 { return &p.Sea }),
+	percent("Peaks above", func(p *sim.MapParams) *float64 { return &p.Peaks }),
+	percent("Forest", func(p *sim.MapParams) *float64 { return &p.Forest }),
+	count("Rivers", func(p *sim.MapParams) *int { return &p.Rivers }, 0, sim.MaxRivers),
+	count("Roughness", func(p *sim.MapParams) *int { return &p.Roughness }, sim.MinRoughness, sim.MaxRoughness),
+}
+
+// setup is the pre-game scene of a random map (N, or -random): the
+// parameters of the map generator, with a preview of the map they make.
+// It's pushed over the scene it's set up from, Enter plays the map, Esc
+// goes back.
+type setup struct {
+	screenLayout
+	g   *Game
+	row int
+	// The game the parameters make, if they make one
+	preview sim.State
+	err     error
+	keys    keymap.Map
+}
+
+func newSetup(g *Game) *setup {
+	s := &setup{g: g}
+	s.generate()
+
+	change := func(d int) keymap.Action {
+		return keymap.Do(func() {
+			setupRows[s.row].change(&g.mapParams, d)
+			s.generate()
+		})
+	}
+
+	s.keys = keymap.Map{
+		{Name: "setup_up", Keys: keymap.Keys(ebiten.KeyUp), Pads: keymap.Pads(keymap.PadUp), Axes: keymap.Sticks(keymap.StickUp),
+			Trigger: keymap.Repeat, Action: keymap.Do(func() {
+				s.row = (s.row + len(setupRows) - 1) % len(setupRows)
+			})},
+		{Name: "setup_down", Keys: keymap.Keys(ebiten.KeyDown), Pads: keymap.Pads(keymap.PadDown), Axes: keymap.Sticks(keymap.StickDown),
+			Trigger: keymap.Repeat, Action: keymap.Do(func() {
+				s.row = (s.row + 1) % len(setupRows)
+			})},
+		{Name: "setup_less", Keys: keymap.Keys(ebiten.KeyLeft), Pads: keymap.Pads(keymap.PadLeft), Axes: keymap.Sticks(keymap.StickLeft),
+			Trigger: keymap.Repeat, Action: change(-1)},
+		{Name: "setup_more", Keys: keymap.Keys(ebiten.KeyRight), Pads: keymap.Pads(keymap.PadRight), Axes: keymap.Sticks(keymap.StickRight),
+			Trigger: keymap.Repeat, Action: change(1)},
+		{Name: "reroll", Keys: keymap.Keys(ebiten.KeyR), Pads: keymap.Pads(keymap.PadY), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.mapParams.Seed = uint64(time.Now().UnixNano())
+			s.generate()
+		})},
+		{Name: "play", Keys: keymap.Keys(ebiten.KeyEnter), Pads: keymap.Pads(keymap.PadA, keymap.PadStart), Trigger: keymap.Pressed, Action: keymap.Do(s.play)},
+		{Name: "back", Keys: keymap.Keys(ebiten.KeyEscape), Pads: keymap.Pads(keymap.PadB), Trigger: keymap.Pressed, Action: keymap.Do(func() {
+			g.scenes.Pop(scene.NewFade(300 * time.Millisecond))
+		})},
+	}
+
+	return s
+}
+
+// generate makes the map of the parameters, for the preview.
+func (s *setup) generate() {
+	s.preview, s.err = sim.Generate(s.g.mapParams)
+}
+
+// play starts the game on the map previewed.
+func (s *setup) play() {
+	g := s.g
+	if s.err != nil {
+		g.notify.Push("No map to play, change the parameters")
+
+		return
+	}
+
+	g.reset(s.preview)
+	g.scenes.Reset(&planning{g: g}, scene.NewFade(time.Second))
+	g.notify.Push("Random map, seed %d", g.mapParams.Seed)
+}
+
+func (s *setup) Update() error {
+	return s.keys.Update()
+}
+
+func (s *setup) Draw(screen *ebiten.Image) {
+	p := s.g.mapParams
+
+	lines := make([]string, len(setupRows))
+	for i, r := range setupRows {
+		lines[i] = fmt.Sprintf("%-12s %s", r.name, r.value(p))
+	}
+
+	ebitenutil.DrawRect(screen, 0, float64(mapTop+s.row*16), previewX-8, 16, rowColor)
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), 8, mapTop)
+	help := "Random map: Up/Down picks, Left/Right changes, R rerolls the seed\nEnter plays it, Esc goes back"
+	if s.g.device.Pad {
+		help = "Random map: D-pad Up/Down picks, Left/Right changes, Y rerolls the seed\nA plays it, B goes back"
+	}
+
+	ebitenutil.DebugPrint(screen, help)
+
+	if s.err != nil {
+		ebitenutil.DebugPrintAt(screen, "No playable map, change the parameters", previewX, previewY)
+
+		return
+	}
+
+	drawPreview(screen, s.preview)
+}
+
+// drawPreview draws the board of the state small, with where the units and
+// enemies start and the objectives.
+func drawPreview(screen *ebiten.Image, s sim.State) {
+	square := func(t sim.Tile, size, inset int, clr color.Color) {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(float64(size*previewTile-1-2*inset), float64(size*previewTile-1-2*inset))
+		op.GeoM.Translate(float64(previewX+t.X*previewTile+inset), float64(previewY+t.Y*previewTile+inset))
+		op.ColorM.Scale(shapes.ColorScale(clr))
+		_ = screen.DrawImage(shapes.EmptyImage, op)
+	}
+
+	for y := 0; y < s.Board.H; y++ {
+		for x := 0; x < s.Board.W; x++ {
+			square(sim.Tile{X: x, Y: y}, 1, 0, terrainColors[s.Board.At(x, y)])
+		}
+	}
+
+	for _, o := range s.Mission.Objectives {
+		square(o.Tile, 1, 4, objectiveColor)
+	}
+
+	for _, u := range s.Units {
+		square(u.Pos, u.Side(), 3, unitColor)
+	}
+
+	for _, e := range s.Enemies {
+		square(e.Pos, e.Side(), 3, enemyColor)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/internal/shapes"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+)
+
+// mapLayer is the tilemap rendered to an offscreen image. Only the tiles
+// that changed since the last Draw are rendered again.
+type mapLayer struct {
+	m     *Tilemap
+	img   *ebiten.Image
+	dirty map[sim.Tile]bool
+	all   bool
+
+	// Total tiles drawn, for the HUD
+	redrawn int
+}
+
+func newMapLayer(m *Tilemap) *mapLayer {
+	l := &mapLayer{
+		m:     m,
+		dirty: map[sim.Tile]bool{},
+		all:   true,
+	}
+	b := m.Board()
+	l.img, _ = profile.NewImage(b.W*tileSize, b.H*tileSize, ebiten.FilterDefault)
+
+	m.OnChange(func(x, y int, _, _ sim.Terrain) {
+		l.dirty[sim.Tile{X: x, Y: y}] = true
+	})
+
+	return l
+}
+
+func (l *mapLayer) Draw(screen *ebiten.Image, op *ebiten.DrawImageOptions) {
+	if l.all {
+		b := l.m.Board()
+
+		for y := 0; y < b.H; y++ {
+			for x := 0; x < b.W; x++ {
+				l.drawTile(x, y)
+			}
+		}
+
+		l.all = false
+	}
+
+	for t := range l.dirty {
+		l.drawTile(t.X, t.Y)
+		delete(l.dirty, t)
+	}
+
+	_ = screen.DrawImage(l.img, op)
+}
+
+func (l *mapLayer) drawTile(x, y int) {
+	op := &ebiten.DrawImageOptions{}
+	// Leave a 1px gap as the grid lines
+	op.GeoM.Scale(tileSize-1, tileSize-1)
+	op.GeoM.Translate(float64(x*tileSize), float64(y*tileSize))
+	op.ColorM.Scale(shapes.ColorScale(terrainColors[l.m.Board().At(x, y)]))
+	// Replace whatever the tile had before
+	op.CompositeMode = ebiten.CompositeModeCopy
+	_ = l.img.DrawImage(shapes.EmptyImage, op)
+
+	l.redrawn++
+}
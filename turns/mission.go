@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+//nolint:gochecknoglobal
+var (
+	objectiveColor = color.RGBA{0xff, 0x60, 0xff, 0xff}
+	doneColor      = color.RGBA{0x60, 0xff, 0x60, 0xff}
+	panelColor     = color.RGBA{0, 0, 0, 0xb0}
+)
+
+// scenario is the mission part of a scenario file, like:
+//
+//	{"turnLimit": 12, "objectives": [
+//		{"kind": "capture", "name": "Hold the ford", "tile": [10, 6], "turns": 2},
+//		{"kind": "escort", "name": "Bring the vehicle", "unit": 3, "tile": [17, 12]}
+//	]}
+//
+// Units go by the number the HUD shows for them.
+type scenario struct {
+	TurnLimit  int `json:"turnLimit"`
+	Objectives []struct {
+		Kind  string `json:"kind"`
+		Name  string `json:"name"`
+		Tile  [2]int `json:"tile"`
+		Turns int    `json:"turns,omitempty"`
+		Unit  int    `json:"unit,omitempty"`
+	} `json:"objectives"`
+}
+
+// loadMission sets up the mission of the game from a scenario file, a
+// missing file is no mission at all.
+func loadMission(path string, s *sim.State) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var sc scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	m := sim.Mission{TurnLimit: sc.TurnLimit}
+
+	for i, o := range sc.Objectives {
+		obj := sim.Objective{Name: o.Name, Tile: sim.Tile{X: o.Tile[0], Y: o.Tile[1]}, Turns: o.Turns, Unit: o.Unit - 1}
+		if !s.Board.In(obj.Tile.X, obj.Tile.Y) {
+			return fmt.Errorf("%s: objective %d: tile %v off the board", path, i+1, o.Tile)
+		}
+
+		switch o.Kind {
+		case "capture":
+			obj.Kind = sim.Capture
+			if obj.Turns < 1 {
+				obj.Turns = 1
+			}
+		case "escort":
+			obj.Kind = sim.Escort
+			if obj.Unit < 0 || obj.Unit >= len(s.Units) {
+				return fmt.Errorf("%s: objective %d: no unit %d", path, i+1, o.Unit)
+			}
+		default:
+			return fmt.Errorf("%s: objective %d: unknown kind %q", path, i+1, o.Kind)
+		}
+
+		m.Objectives = append(m.Objectives, obj)
+	}
+
+	s.Mission = m
+
+	return nil
+}
+
+// drawObjectives marks the objective tiles, with a bar for how long a
+// capture point has been held, and the units to escort.
+func (g *Game) drawObjectives(screen *ebiten.Image) {
+	for _, o := range g.state.Mission.Objectives {
+		clr := objectiveColor
+		if o.Done {
+			clr = doneColor
+		}
+
+		g.drawFrame(screen, o.Tile, 1, clr)
+
+		x, y := o.Tile.X*tileSize, o.Tile.Y*tileSize+mapTop
+		ebitenutil.DebugPrintAt(screen, strings.ToUpper(o.Kind.String()[:1]), x+4, y)
+
+		switch o.Kind {
+		case sim.Capture:
+			w := float64(tileSize - 8)
+			ebitenutil.DrawRect(screen, float64(x+4), float64(y+tileSize-8), w, 4, hpEmptyColor)
+			ebitenutil.DrawRect(screen, float64(x+4), float64(y+tileSize-8), w*float64(clamp(o.Held, 0, o.Turns))/float64(o.Turns), 4, clr)
+		case sim.Escort:
+			if u := g.state.Units[o.Unit]; !o.Done && u.HP > 0 {
+				g.drawFrame(screen, u.Pos, u.Side(), clr)
+			}
+		}
+	}
+}
+
+// missionHUD describes the objectives and the turns left, a line each.
+func (g *Game) missionHUD() []string {
+	var lines []string
+
+	if left, ok := g.state.TurnsLeft(); ok {
+		lines = append(lines, fmt.Sprintf("Turns left: %d", left))
+	}
+
+	for _, o := range g.state.Mission.Objectives {
+		check := " "
+		if o.Done {
+			check = "x"
+		}
+
+		progress := ""
+
+		switch o.Kind {
+		case sim.Capture:
+			progress = fmt.Sprintf(" (held %d/%d)", o.Held, o.Turns)
+		case sim.Escort:
+			progress = fmt.Sprintf(" (unit %d)", g.state.Units[o.Unit].ID)
+		}
+
+		lines = append(lines, fmt.Sprintf("[%s] %s%s", check, o.Name, progress))
+	}
+
+	return lines
+}
+
+// drawMissionPanel shows the mission HUD in the bottom left corner, over
+// the map.
+func (g *Game) drawMissionPanel(screen *ebiten.Image) {
+	lines := g.missionHUD()
+	if len(lines) == 0 {
+		return
+	}
+
+	w := 0
+	for _, l := range lines {
+		if len(l) > w {
+			w = len(l)
+		}
+	}
+
+	h := len(lines) * 16
+	ebitenutil.DrawRect(screen, 0, float64(mapBottom-h-8), float64(w*6+8), float64(h+8), panelColor)
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), 4, mapBottom-h-4)
+}
+
+// ended is where the game stays once the mission is won or lost.
+type ended struct {
+	screenLayout
+	g    *Game
+	keys keymap.Map
+}
+
+func newEnded(g *Game) *ended {
+	return &ended{g: g, keys: keymap.Map{
+		{Name: "quit", Keys: keymap.Keys(ebiten.KeyEscape), Pads: keymap.Pads(keymap.PadBack), Trigger: keymap.Pressed,
+			Action: func() error { return run.ErrCleanExit }},
+		{Name: "new_map", Keys: keymap.Keys(ebiten.KeyN), Pads: keymap.Pads(keymap.PadX), Trigger: keymap.Pressed, Action: keymap.Do(g.newMap)},
+	}}
+}
+
+func (e *ended) Update() error {
+	return e.keys.Update()
+}
+
+func (e *ended) Draw(screen *ebiten.Image) {
+	g := e.g
+	g.drawBoard(screen)
+	g.drawMissionPanel(screen)
+
+	msg := "Mission accomplished!"
+	if g.state.Outcome == sim.Lost {
+		msg = "Mission failed"
+	}
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("Turn: %d  %s  %s plays a random map, %s quits",
+		g.state.Turn, msg, g.inputs(e.keys, "new_map"), g.inputs(e.keys, "quit")))
+}
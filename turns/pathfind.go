@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+)
+
+// pathCache keeps the distance field from an origin tile, for a unit of a
+// size, for the previews drawn every frame. It's only recomputed when the
+// origin or the size change or when a tile change could make a difference:
+// one under or next to a reachable position.
+type pathCache struct {
+	m      *Tilemap
+	origin sim.Tile
+	size   int
+	valid  bool
+	field  sim.Field
+
+	// Number of full recomputes, for the HUD
+	recomputes int
+}
+
+func newPathCache(m *Tilemap) *pathCache {
+	c := &pathCache{m: m}
+	m.OnChange(c.onChange)
+
+	return c
+}
+
+func (c *pathCache) onChange(x, y int, old, new sim.Terrain) {
+	if !c.valid || sim.Terrains[old].Cost == sim.Terrains[new].Cost {
+		return
+	}
+
+	// A change far away from anything reachable can't open or close any
+	// path, everything else invalidates the field. Positions are the top
+	// left of the footprint, so the ones with the tile under them are up
+	// and left of it
+	b := c.m.Board()
+
+	for ty := y - c.size; ty <= y+1; ty++ {
+		for tx := x - c.size; tx <= x+1; tx++ {
+			if b.In(tx, ty) && c.field.Distance(tx, ty) != sim.Unreachable {
+				c.valid = false
+
+				return
+			}
+		}
+	}
+}
+
+// Distance returns the cost for the unit to go to (x, y), or unreachable.
+func (c *pathCache) Distance(u sim.Unit, x, y int) int {
+	c.ensure(u.Pos, u.Side())
+
+	return c.field.Distance(x, y)
+}
+
+// Path returns the tiles from the unit (excluded) to (x, y), or nil if
+// unreachable.
+func (c *pathCache) Path(u sim.Unit, x, y int) []sim.Tile {
+	c.ensure(u.Pos, u.Side())
+
+	return c.field.Path(x, y)
+}
+
+func (c *pathCache) ensure(origin sim.Tile, size int) {
+	if c.valid && c.origin == origin && c.size == size {
+		return
+	}
+
+	c.origin, c.size = origin, size
+	c.valid = true
+	c.recomputes++
+
+	defer profile.Region("paths")()
+
+	c.field = sim.DistancesFor(c.m.Board(), origin, size)
+}
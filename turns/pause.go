@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/scene"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// pauseItem is a row of the pause menu: what it says, and what choosing it
+// does.
+type pauseItem struct {
+	label func() string
+	do    func()
+}
+
+// pause is pushed over planning with P, or Start: the game stays on screen,
+// dimmed and frozen, and so does the music. It's a menu with what planning
+// only has keys for, so the gamepad gets to all of it. P or Esc goes back
+// to planning, Q to the title.
+type pause struct {
+	screenLayout
+	g *Game
+	// The scene paused, drawn under
+	under scene.Scene
+	items []pauseItem
+	row   int
+	keys  keymap.Map
+}
+
+func newPause(g *Game) *pause {
+	p := &pause{g: g, under: g.scenes.Current()}
+	resume := func() { g.scenes.Pop(nil) }
+	title := func() { g.scenes.Reset(newTitle(g), scene.NewFade(500*time.Millisecond)) }
+	fixed := func(s string) func() string { return func() string { return s } }
+
+	p.items = []pauseItem{
+		{fixed("Resume"), resume},
+		{fixed("Volume"), func() {
+			resume()
+			g.mixer.open = true
+		}},
+		{func() string {
+			if g.director.enabled {
+				return "Combat camera: on"
+			}
+
+			return "Combat camera: off"
+		}, g.toggleDirector},
+		{func() string { return fmt.Sprintf("Enemy AI: %s", g.ai.Name()) }, g.cycleAI},
+		{fixed("Save the game"), g.saveGame},
+		{fixed("Load the game"), func() {
+			resume()
+			g.loadGame()
+		}},
+		{fixed("Random map"), func() {
+			resume()
+			g.newMap()
+		}},
+		{fixed("Back to the title"), title},
+	}
+
+	// Gamepads have no F1 to skip the tutorial with
+	if !g.tutorial.Done() {
+		p.items = append(p.items, pauseItem{fixed("Skip the tutorial"), func() {
+			resume()
+			g.tutorial.Skip()
+		}})
+	}
+
+	row := func(d int) keymap.Action {
+		return keymap.Do(func() { p.row = (p.row + len(p.items) + d) % len(p.items) })
+	}
+
+	p.keys = keymap.Map{
+		{Name: "resume", Keys: keymap.Keys(ebiten.KeyP, ebiten.KeyEscape), Pads: keymap.Pads(keymap.PadStart, keymap.PadB),
+			Trigger: keymap.Pressed, Action: keymap.Do(resume)},
+		{Name: "menu_up", Keys: keymap.Keys(ebiten.KeyUp), Pads: keymap.Pads(keymap.PadUp), Axes: keymap.Sticks(keymap.StickUp),
+			Trigger: keymap.Repeat, Action: row(-1)},
+		{Name: "menu_down", Keys: keymap.Keys(ebiten.KeyDown), Pads: keymap.Pads(keymap.PadDown), Axes: keymap.Sticks(keymap.StickDown),
+			Trigger: keymap.Repeat, Action: row(1)},
+		{Name: "menu_choose", Keys: keymap.Keys(ebiten.KeyEnter, ebiten.KeySpace), Pads: keymap.Pads(keymap.PadA),
+			Trigger: keymap.Pressed, Action: keymap.Do(func() { p.items[p.row].do() })},
+		{Keys: keymap.Keys(ebiten.KeyQ), Trigger: keymap.Pressed, Action: keymap.Do(title)},
+	}
+
+	return p
+}
+
+func (p *pause) Enter() {
+	p.g.mixer.setPaused(true)
+}
+
+func (p *pause) Leave() {
+	p.g.mixer.setPaused(false)
+}
+
+func (p *pause) Update() error {
+	return p.keys.Update()
+}
+
+func (p *pause) Draw(screen *ebiten.Image) {
+	p.under.Draw(screen)
+	ebitenutil.DrawRect(screen, 0, 0, screenWidth, screenHeight, panelColor)
+
+	lines := []string{"Paused", ""}
+
+	for i, it := range p.items {
+		cursor := "  "
+		if i == p.row {
+			cursor = "> "
+		}
+
+		lines = append(lines, cursor+it.label())
+	}
+
+	ebitenutil.DebugPrintAt(screen, strings.Join(lines, "\n"), screenWidth/2-72, screenHeight/2-80)
+}
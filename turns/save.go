@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+)
+
+// saveGame writes the game as it is to the save file, the actions declared
+// so far this turn too.
+func (g *Game) saveGame() {
+	data, err := g.state.Save()
+	if err == nil {
+		err = ioutil.WriteFile(g.savePath, data, 0644)
+	}
+
+	if err != nil {
+		log.Printf("saving %s: %v", g.savePath, err)
+		g.notify.Push("Saving failed, see the log")
+
+		return
+	}
+
+	g.notify.Push("Turn %d saved to %s", g.state.Turn, g.savePath)
+}
+
+// loadGame replaces the game with the one in the save file, back to
+// planning its turn with the first unit selected. Saves from older
+// versions are upgraded, and if it can't be loaded the game goes on.
+func (g *Game) loadGame() {
+	data, err := ioutil.ReadFile(g.savePath)
+
+	var s sim.State
+	if err == nil {
+		s, err = sim.Load(data)
+	}
+
+	switch {
+	case errors.Is(err, sim.ErrNewerSave):
+		g.notify.Push("%s is from a newer version of the game", g.savePath)
+
+		return
+	case err != nil:
+		log.Printf("loading %s: %v", g.savePath, err)
+		g.notify.Push("Loading failed, see the log")
+
+		return
+	}
+
+	g.reset(s)
+	g.notify.Push("Turn %d loaded from %s", g.state.Turn, g.savePath)
+}
+
+// reset replaces the game with s, back to planning its turn with the first
+// unit selected.
+func (g *Game) reset(s sim.State) {
+	g.state = s
+	g.newCaches()
+	g.selected, g.mode, g.events = 0, sim.ModeMove, nil
+	g.formation = formation{}
+	g.cursor = g.state.Units[0].Pos
+	g.view = newView()
+}
@@ -0,0 +1,144 @@
+package sim
+
+// Targeting is what an ability is aimed at.
+type Targeting int
+
+const (
+	// An empty tile
+	TargetTile Targeting = iota
+	// An enemy
+	TargetUnit
+	// Every friendly unit in the radius around a tile
+	TargetArea
+)
+
+// Ability is something a unit can do on top of moving and attacking. Using
+// it costs movement points, and it can't be used again until its cooldown
+// (in turns) is over.
+type Ability struct {
+	Name      string
+	Targeting Targeting
+	// Distance from the unit to the target, in tiles
+	Reach  int
+	Radius int
+	Cost   int
+	// Turns it can't be used after using it
+	Cooldown int
+	// Damage dealt or hit points healed
+	Amount int
+}
+
+//nolint:gochecknoglobal
+var Abilities = []Ability{
+	{Name: "shot", Targeting: TargetUnit, Reach: 4, Cost: 2, Cooldown: 2, Amount: 3},
+	{Name: "heal", Targeting: TargetArea, Reach: 3, Radius: 1, Cost: 2, Cooldown: 3, Amount: 4},
+	{Name: "dash", Targeting: TargetTile, Reach: 3, Cost: 0, Cooldown: 3},
+}
+
+// Reach is the distance used for ability ranges, diagonals counting as one.
+func Reach(a, b Tile) int {
+	dx, dy := abs(a.X-b.X), abs(a.Y-b.Y)
+	if dx > dy {
+		return dx
+	}
+
+	return dy
+}
+
+// usedThisTurn reports whether the unit already declared the ability.
+func (s *State) usedThisTurn(unit, ab int) bool {
+	for _, a := range s.Pending {
+		if a.Unit == unit && a.Mode == ModeAbility && a.Ability == ab {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanUse validates an ability target, from where the unit will be when the
+// ability goes off.
+func (s *State) CanUse(unit, ab int, target Tile) bool {
+	u := &s.Units[unit]
+	a := Abilities[ab]
+	pos := s.PlannedPos(unit)
+
+	if u.Cooldowns[ab] > 0 || s.usedThisTurn(unit, ab) || u.MP < a.Cost ||
+		!s.Board.In(target.X, target.Y) || Reach(pos, target) > a.Reach {
+		return false
+	}
+
+	switch a.Targeting {
+	case TargetUnit:
+		return s.EnemyAt(target) != nil && CanSee(s.Board, pos, target, SightRange)
+	case TargetArea:
+		return CanSee(s.Board, pos, target, SightRange)
+	case TargetTile:
+		// Dashing goes around obstacles, as far as its reach in movement
+		// cost
+		d := DistancesFor(s.Board, pos, u.Side()).Distance(target.X, target.Y)
+
+		return d > 0 && d <= a.Reach && !s.occupied(target, u.Side(), u)
+	}
+
+	return false
+}
+
+// use resolves an ability.
+func (s *State) use(u *Unit, ab int, target Tile, r *Result) {
+	a := Abilities[ab]
+	u.Cooldowns[ab] = a.Cooldown + 1 // This turn's tick is still to come
+
+	switch a.Targeting {
+	case TargetUnit:
+		e := s.EnemyAt(target)
+		if e == nil {
+			r.logf("unit %d %s at %d,%d misses", u.ID, a.Name, target.X, target.Y)
+
+			return
+		}
+
+		e.HP -= a.Amount
+		shake(e, flankOf(u.Pos, *e).moraleLoss())
+		r.logf("unit %d shoots enemy %d for %d", u.ID, e.ID, a.Amount)
+		r.clash(Clash{From: u.Pos, To: target, Ranged: true, Damage: a.Amount, Fatal: e.HP <= 0})
+
+		if e.HP <= 0 {
+			r.logf("enemy %d is destroyed", e.ID)
+		}
+	case TargetArea:
+		for i := range s.Units {
+			v := &s.Units[i]
+			if Reach(v.Pos, target) <= a.Radius && v.HP < UnitHP {
+				healed := min(a.Amount, UnitHP-v.HP)
+				v.HP += healed
+				r.logf("unit %d heals unit %d for %d", u.ID, v.ID, healed)
+			}
+		}
+	case TargetTile:
+		if s.occupied(target, u.Side(), u) {
+			r.logf("unit %d dash to %d,%d is blocked", u.ID, target.X, target.Y)
+
+			return
+		}
+
+		path := DistancesFor(s.Board, u.Pos, u.Side()).Path(target.X, target.Y)
+		if len(path) > 1 {
+			u.Facing, _ = FacingTo(path[len(path)-2], target)
+		}
+
+		u.Pos = target
+		r.logf("unit %d dashes to %d,%d", u.ID, target.X, target.Y)
+	}
+}
+
+// tickCooldowns counts down the cooldowns, at the end of every turn.
+func (s *State) tickCooldowns() {
+	for i := range s.Units {
+		for j := range s.Units[i].Cooldowns {
+			if s.Units[i].Cooldowns[j] > 0 {
+				s.Units[i].Cooldowns[j]--
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package sim
+
+// Indexes in Abilities the player AI knows how to use
+const (
+	abilityShot = 0
+	abilityHeal = 1
+)
+
+// PlanUnits declares the actions of the player side for this turn, like
+// PlanEnemies does for the enemies, so the sim can play itself: each unit
+// heals if friends around it are hurt, attacks an enemy next to it or
+// shoots one in reach, or else heads for the tile it escorts itself to, or
+// the nearest enemy it sees, or the next tile to capture, and attacks or
+// shoots from there, bridging the water in the way if stuck. Units with
+// nothing to do wait. It only goes by the state, so the same state always
+// gets the same plan.
+func (s *State) PlanUnits() {
+	for i := range s.Units {
+		of := Action{Unit: i}
+
+		u := &s.Units[i]
+		if u.HP <= 0 || u.Routed || s.declared(of) {
+			continue
+		}
+
+		s.heal(i)
+
+		if s.strike(of) || s.shoot(i) {
+			continue
+		}
+
+		// Escorted units go for their tile whatever they see on the way
+		target, ok := s.escort(i)
+		next := false
+
+		if !ok {
+			target, ok = s.nearestSeen(u, s.Enemies)
+			next = ok
+		}
+
+		if !ok {
+			target, ok = s.capture()
+		}
+
+		if ok && s.closeIn(of, target, next) {
+			if !s.strike(of) {
+				s.shoot(i)
+			}
+
+			continue
+		}
+
+		// Stuck on the way, most likely at the river
+		if ok && s.bridge(i) {
+			continue
+		}
+
+		if !s.declared(of) {
+			s.Declare(Action{Unit: i, Mode: ModeWait, Target: u.Pos})
+		}
+	}
+}
+
+// heal declares a heal by unit i where it stands, if it would heal at least
+// one friend, itself included, for the full amount.
+func (s *State) heal(i int) bool {
+	pos := s.PlannedPos(i)
+	a := Abilities[abilityHeal]
+
+	for _, v := range s.Units {
+		if v.HP > 0 && v.HP <= UnitHP-a.Amount && Reach(v.Pos, pos) <= a.Radius {
+			return s.Declare(Action{Unit: i, Mode: ModeAbility, Ability: abilityHeal, Target: pos})
+		}
+	}
+
+	return false
+}
+
+// shoot declares a shot by unit i at the weakest enemy it can, and reports
+// whether there was one.
+func (s *State) shoot(i int) bool {
+	best := -1
+
+	for j, e := range s.Enemies {
+		if e.HP > 0 && s.CanUse(i, abilityShot, e.Pos) && (best < 0 || e.HP < s.Enemies[best].HP) {
+			best = j
+		}
+	}
+
+	return best >= 0 && s.Declare(Action{Unit: i, Mode: ModeAbility, Ability: abilityShot, Target: s.Enemies[best].Pos})
+}
+
+// bridge declares bridges on all the water next to unit i, and reports
+// whether there was any.
+func (s *State) bridge(i int) bool {
+	u := &s.Units[i]
+	pos := s.PlannedPos(i)
+	built := false
+
+	for y := pos.Y - 1; y <= pos.Y+u.Side(); y++ {
+		for x := pos.X - 1; x <= pos.X+u.Side(); x++ {
+			if s.Board.In(x, y) && s.Board.At(x, y) == Water &&
+				s.Declare(Action{Unit: i, Mode: ModeBridge, Target: Tile{x, y}}) {
+				built = true
+			}
+		}
+	}
+
+	return built
+}
+
+// escort returns the tile unit i has to get to, if it's escorted and not
+// there yet.
+func (s *State) escort(i int) (Tile, bool) {
+	for _, o := range s.Mission.Objectives {
+		if !o.Done && o.Kind == Escort && o.Unit == i {
+			return o.Tile, true
+		}
+	}
+
+	return Tile{}, false
+}
+
+// capture returns the tile of the first capture still to do, if any.
+func (s *State) capture() (Tile, bool) {
+	for _, o := range s.Mission.Objectives {
+		if !o.Done && o.Kind == Capture {
+			return o.Tile, true
+		}
+	}
+
+	return Tile{}, false
+}
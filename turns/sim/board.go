@@ -0,0 +1,136 @@
+// Package sim is the rules of the turns exercise, apart from any rendering
+// or input: the state is plain data, and the only way to change it is
+// declaring actions and resolving the turn. Randomness comes from the RNG in
+// the state, so the same state and actions always end up the same, which is
+// what keeping several players in lockstep needs, and it can run headless.
+package sim
+
+type Terrain int
+
+const (
+	Grass Terrain = iota
+	Forest
+	Wall
+	Water
+	Bridge
+	Rubble
+)
+
+// TerrainRules are the rules for a terrain type. A cost of 0 means
+// impassable.
+type TerrainRules struct {
+	Name       string
+	Cost       int
+	BlocksView bool
+}
+
+//nolint:gochecknoglobal
+var Terrains = map[Terrain]TerrainRules{
+	Grass:  {"grass", 1, false},
+	Forest: {"forest", 2, true},
+	Wall:   {"wall", 0, true},
+	Water:  {"water", 0, false},
+	Bridge: {"bridge", 1, false},
+	Rubble: {"rubble", 2, false},
+}
+
+//nolint:gochecknoglobal
+var terrainRunes = map[rune]Terrain{
+	'.': Grass,
+	'T': Forest,
+	'#': Wall,
+	'~': Water,
+	'=': Bridge,
+	',': Rubble,
+}
+
+type Tile struct {
+	X, Y int
+}
+
+// Board is the map, W by H tiles, row by row.
+type Board struct {
+	W, H  int
+	Tiles []Terrain
+}
+
+// ParseBoard parses a map from rows of terrain runes, all of them the same
+// length.
+func ParseBoard(rows []string) Board {
+	b := Board{
+		W: len(rows[0]),
+		H: len(rows),
+	}
+
+	b.Tiles = make([]Terrain, 0, b.W*b.H)
+	for _, row := range rows {
+		for _, r := range row {
+			b.Tiles = append(b.Tiles, terrainRunes[r])
+		}
+	}
+
+	return b
+}
+
+func (b Board) In(x, y int) bool {
+	return x >= 0 && x < b.W && y >= 0 && y < b.H
+}
+
+func (b Board) At(x, y int) Terrain {
+	return b.Tiles[y*b.W+x]
+}
+
+// Cost returns the movement cost of entering the tile, 0 if impassable.
+func (b Board) Cost(x, y int) int {
+	return Terrains[b.At(x, y)].Cost
+}
+
+func (b Board) BlocksView(x, y int) bool {
+	return Terrains[b.At(x, y)].BlocksView
+}
+
+// TileChange is a tile that changed terrain during a resolution, for
+// whatever keeps data derived from the board to update just that.
+type TileChange struct {
+	X, Y     int
+	Old, New Terrain
+}
+
+func (s *State) set(x, y int, t Terrain, r *Result) {
+	old := s.Board.At(x, y)
+	if old == t {
+		return
+	}
+
+	s.Board.Tiles[y*s.Board.W+x] = t
+	r.Changes = append(r.Changes, TileChange{x, y, old, t})
+}
+
+// explode destroys the terrain around (x, y): walls crumble, forests burn
+// and bridges collapse.
+func (s *State) explode(x, y, radius int, r *Result) {
+	for ty := y - radius; ty <= y+radius; ty++ {
+		for tx := x - radius; tx <= x+radius; tx++ {
+			if !s.Board.In(tx, ty) {
+				continue
+			}
+
+			switch s.Board.At(tx, ty) {
+			case Wall:
+				s.set(tx, ty, Rubble, r)
+			case Forest:
+				s.set(tx, ty, Grass, r)
+			case Bridge:
+				s.set(tx, ty, Water, r)
+			case Grass, Water, Rubble:
+			}
+		}
+	}
+}
+
+// buildBridge turns water into a bridge.
+func (s *State) buildBridge(x, y int, r *Result) {
+	if s.Board.At(x, y) == Water {
+		s.set(x, y, Bridge, r)
+	}
+}
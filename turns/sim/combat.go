@@ -0,0 +1,232 @@
+package sim
+
+import "math"
+
+const (
+	AttackDamage = 4
+	UnitHP       = 10
+	// Movement points it costs to turn to face another direction
+	TurnCost = 1
+	// Damage rolls go from 75% to 125% of the base damage
+	damageSpread = 0.25
+	// Counterattacks hit for half the damage
+	counterFactor = 0.5
+)
+
+// Facing is the direction a unit looks at. Attacks from the side or the
+// back hurt more.
+type Facing int
+
+const (
+	North Facing = iota
+	East
+	South
+	West
+)
+
+func (f Facing) String() string {
+	return [...]string{"north", "east", "south", "west"}[f]
+}
+
+func (f Facing) Vec() Tile {
+	return [...]Tile{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}[f]
+}
+
+// FacingTo returns the facing from one tile to an adjacent one.
+func FacingTo(from, to Tile) (Facing, bool) {
+	d := Tile{to.X - from.X, to.Y - from.Y}
+	for f := North; f <= West; f++ {
+		if f.Vec() == d {
+			return f, true
+		}
+	}
+
+	return North, false
+}
+
+func Adjacent(a, b Tile) bool {
+	return abs(a.X-b.X)+abs(a.Y-b.Y) == 1
+}
+
+// Contact returns the tile of a unit of the given size at pos that's
+// adjacent to t, the one it strikes from, if there's one.
+func Contact(pos Tile, size int, t Tile) (Tile, bool) {
+	for _, f := range Footprint(pos, size) {
+		if Adjacent(f, t) {
+			return f, true
+		}
+	}
+
+	return pos, false
+}
+
+// Flank tells where an attack from attacker lands on defender.
+type Flank int
+
+const (
+	Front Flank = iota
+	Side
+	Rear
+)
+
+func (f Flank) String() string {
+	return [...]string{"front", "flank", "rear"}[f]
+}
+
+func (f Flank) multiplier() float64 {
+	return [...]float64{1, 1.5, 2}[f]
+}
+
+// hitChance is harder to dodge the less the defender sees it coming.
+func (f Flank) hitChance() float64 {
+	return [...]float64{0.7, 0.85, 0.95}[f]
+}
+
+func flankOf(attacker Tile, defender Unit) Flank {
+	d := defender.Facing.Vec()
+	v := Tile{attacker.X - defender.Pos.X, attacker.Y - defender.Pos.Y}
+
+	switch dot := d.X*v.X + d.Y*v.Y; {
+	case dot > 0:
+		return Front
+	case dot < 0:
+		return Rear
+	default:
+		return Side
+	}
+}
+
+// Strike is the odds of a single hit.
+type Strike struct {
+	Flank  Flank
+	Chance float64
+	Min    int
+	Max    int
+}
+
+func newStrike(attacker Tile, defender Unit, factor float64) Strike {
+	f := flankOf(attacker, defender)
+	base := AttackDamage * f.multiplier() * factor
+
+	return Strike{
+		Flank:  f,
+		Chance: f.hitChance(),
+		Min:    int(math.Floor(base * (1 - damageSpread))),
+		Max:    int(math.Ceil(base * (1 + damageSpread))),
+	}
+}
+
+// roll returns the damage done, 0 on a miss.
+func (s Strike) roll(rng *RNG) int {
+	if rng.Float64() >= s.Chance {
+		return 0
+	}
+
+	return s.Min + rng.Intn(s.Max-s.Min+1)
+}
+
+// Forecast is what an attack can do, both the preview and the resolution
+// work it out with ForecastAttack so they can't disagree.
+type Forecast struct {
+	Attack Strike
+	// Defenders that see the attack coming strike back, if they survive
+	Counter    bool
+	CounterHit Strike
+}
+
+// ForecastAttack works out an attack from the from tile, adjacent to the
+// defender. The attacker turns to face the defender to attack.
+func ForecastAttack(attacker Unit, from Tile, defender Unit) Forecast {
+	attacker.Pos = from
+	attacker.Facing, _ = FacingTo(from, defender.Pos)
+
+	fc := Forecast{Attack: newStrike(from, defender, 1)}
+	fc.Counter = fc.Attack.Flank != Rear
+	fc.CounterHit = newStrike(defender.Pos, attacker, counterFactor)
+
+	return fc
+}
+
+// Clash is a blow struck during a resolution, for the presentation to show
+// it: who struck whom, and what it did.
+type Clash struct {
+	From Tile
+	To   Tile
+	// The attacker is an enemy
+	Enemy  bool
+	Ranged bool
+	// Zero for a miss
+	Damage int
+	// The defender went down
+	Fatal bool
+	// Index in Result.Events of the line telling about it
+	Event int
+}
+
+// clash records a blow, right after logging it.
+func (r *Result) clash(c Clash) {
+	c.Event = len(r.Events) - 1
+	r.Clashes = append(r.Clashes, c)
+}
+
+// attack resolves an attack by unit u, of the enemy side or not.
+func (s *State) attack(u *Unit, enemy bool, target Tile, r *Result) {
+	e := s.foeAt(enemy, target)
+	from, ok := Contact(u.Pos, u.Side(), target)
+	side, foe := sideName(enemy), sideName(!enemy)
+
+	if e == nil || !ok {
+		r.logf("%s %d attack at %d,%d fizzles", side, u.ID, target.X, target.Y)
+
+		return
+	}
+
+	fc := ForecastAttack(*u, from, *e)
+	// Attacking means facing the target
+	u.Facing, _ = FacingTo(from, target)
+
+	dmg := fc.Attack.roll(&s.RNG)
+	if dmg == 0 {
+		r.logf("%s %d misses %s %d", side, u.ID, foe, e.ID)
+	} else {
+		e.HP -= dmg
+		shake(e, fc.Attack.Flank.moraleLoss())
+		r.logf("%s %d hits %s %d on the %s for %d", side, u.ID, foe, e.ID, fc.Attack.Flank, dmg)
+	}
+
+	r.clash(Clash{From: from, To: target, Enemy: enemy, Damage: dmg, Fatal: e.HP <= 0})
+
+	if e.HP <= 0 {
+		r.logf("%s %d %s", foe, e.ID, downed(!enemy))
+
+		return
+	}
+
+	if !fc.Counter {
+		return
+	}
+
+	if dmg = fc.CounterHit.roll(&s.RNG); dmg == 0 {
+		r.logf("%s %d counterattacks and misses", foe, e.ID)
+	} else {
+		u.HP -= dmg
+		shake(u, fc.CounterHit.Flank.moraleLoss())
+		r.logf("%s %d counterattacks %s %d for %d", foe, e.ID, side, u.ID, dmg)
+	}
+
+	r.clash(Clash{From: e.Pos, To: from, Enemy: !enemy, Damage: dmg, Fatal: u.HP <= 0})
+
+	if u.HP <= 0 {
+		r.logf("%s %d %s", side, u.ID, downed(enemy))
+	}
+}
+
+// downed is how the log says a unit of the side went down: enemies are
+// destroyed, units are down.
+func downed(enemy bool) string {
+	if enemy {
+		return "is destroyed"
+	}
+
+	return "is down"
+}
@@ -0,0 +1,138 @@
+package sim
+
+// PlanEnemies declares the actions of the enemy side for this turn, the
+// other player: each enemy attacks a unit next to it, or closes in on the
+// nearest unit it sees and attacks if it gets next to it, or waits. It only
+// goes by the state, so the same state always gets the same plan.
+func (s *State) PlanEnemies() {
+	for i := range s.Enemies {
+		of := Action{Unit: i, Enemy: true}
+
+		e := &s.Enemies[i]
+		if e.HP <= 0 || e.Routed || s.declared(of) {
+			continue
+		}
+
+		if s.strike(of) {
+			continue
+		}
+
+		if target, ok := s.nearestSeen(e, s.Units); ok && s.closeIn(of, target, false) {
+			s.strike(of)
+
+			continue
+		}
+
+		s.Declare(Action{Unit: i, Enemy: true, Mode: ModeWait, Target: e.Pos})
+	}
+}
+
+// foes returns the units of the other side than the one of the action.
+func (s *State) foes(of Action) []Unit {
+	if of.Enemy {
+		return s.Units
+	}
+
+	return s.Enemies
+}
+
+// friends returns the units of the same side as the one of the action.
+func (s *State) friends(of Action) []Unit {
+	if of.Enemy {
+		return s.Enemies
+	}
+
+	return s.Units
+}
+
+// strike declares an attack by the unit of the action on a foe next to
+// where it'll be, and reports whether there was one.
+func (s *State) strike(of Action) bool {
+	u := s.unit(of)
+	pos := s.plannedPos(of)
+
+	for _, f := range s.foes(of) {
+		if f.HP <= 0 {
+			continue
+		}
+
+		for _, t := range Footprint(f.Pos, f.Side()) {
+			if touches(pos, u.Side(), t) {
+				return s.Declare(Action{Unit: of.Unit, Enemy: of.Enemy, Mode: ModeAttack, Target: t})
+			}
+		}
+	}
+
+	return false
+}
+
+// nearestSeen returns the position of the closest living one of foes that
+// u sees.
+func (s *State) nearestSeen(u *Unit, foes []Unit) (Tile, bool) {
+	best, bestReach := Tile{}, -1
+
+	for _, f := range foes {
+		if f.HP <= 0 || !CanSee(s.Board, u.Pos, f.Pos, SightRange) {
+			continue
+		}
+
+		if r := Reach(u.Pos, f.Pos); bestReach < 0 || r < bestReach {
+			best, bestReach = f.Pos, r
+		}
+	}
+
+	return best, bestReach >= 0
+}
+
+// closeIn declares a move of the unit of the action toward target, and
+// reports whether it could get any closer. With next, being next to target
+// is as good as being on it.
+func (s *State) closeIn(of Action, target Tile, next bool) bool {
+	to, ok := s.approach(of, target, next)
+
+	return ok && s.Declare(Action{Unit: of.Unit, Enemy: of.Enemy, Mode: ModeMove, Target: to})
+}
+
+// approach returns the tile in reach of the unit of the action closest to
+// target, if it's closer than where the unit is, without landing on anyone
+// or on where a friend is going. With next, tiles next to target count as
+// on it, so a diagonal isn't close enough.
+func (s *State) approach(of Action, target Tile, next bool) (Tile, bool) {
+	u := s.unit(of)
+	size := u.Side()
+	f := DistancesFor(s.Board, u.Pos, size)
+	reach := func(t Tile) int {
+		if next && touches(t, size, target) {
+			return 0
+		}
+
+		return Reach(t, target)
+	}
+	best, bestReach, bestDist := u.Pos, reach(u.Pos), 0
+
+	var others []Unit
+
+	for j, o := range s.friends(of) {
+		if j != of.Unit {
+			o.Pos = s.plannedPos(Action{Unit: j, Enemy: of.Enemy})
+			others = append(others, o)
+		}
+	}
+
+	for y := 0; y < s.Board.H; y++ {
+		for x := 0; x < s.Board.W; x++ {
+			t := Tile{x, y}
+
+			d := f.Distance(x, y)
+			if d <= 0 || d > u.MP || s.occupied(t, size, u) || overlaps(t, size, others) {
+				continue
+			}
+
+			if r := reach(t); r < bestReach || (r == bestReach && best != u.Pos && d < bestDist) {
+				best, bestReach, bestDist = t, r, d
+			}
+		}
+	}
+
+	return best, best != u.Pos
+}
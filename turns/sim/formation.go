@@ -0,0 +1,117 @@
+package sim
+
+import "sort"
+
+// DeclareFormation declares moves for a group of units, the first one
+// leading to target and the rest keeping where they stand from it. Where a
+// unit's spot is blocked, out of its reach or taken by another one, it
+// arrives at the closest free tile it can reach instead, so they spread
+// out instead of piling up. It returns how many moves were declared.
+func (s *State) DeclareFormation(units []int, target Tile) int {
+	if len(units) == 0 {
+		return 0
+	}
+
+	lead := s.Units[units[0]].Pos
+	// Where the units end up this turn, moving or not, so the ones that
+	// come later don't land on them
+	var claimed []Unit
+
+	for i := range s.Units {
+		if !inGroup(units, i) {
+			u := s.Units[i]
+			u.Pos = s.PlannedPos(i)
+			claimed = append(claimed, u)
+		}
+	}
+
+	// Closest to the leader first, so the front of the formation holds
+	// best
+	order := append([]int(nil), units...)
+	sort.SliceStable(order[1:], func(i, j int) bool {
+		return Reach(lead, s.Units[order[1+i]].Pos) < Reach(lead, s.Units[order[1+j]].Pos)
+	})
+
+	declared := 0
+
+	for _, i := range order {
+		u := &s.Units[i]
+		want := Tile{target.X + u.Pos.X - lead.X, target.Y + u.Pos.Y - lead.Y}
+
+		to, ok := s.arrival(u, want, claimed)
+		if ok && to != u.Pos && s.Declare(Action{Unit: i, Mode: ModeMove, Target: to}) {
+			declared++
+		} else {
+			to = s.PlannedPos(i)
+		}
+
+		c := *u
+		c.Pos = to
+		claimed = append(claimed, c)
+	}
+
+	return declared
+}
+
+// arrival returns the tile closest to want that the unit can move to this
+// turn without landing on an enemy or a claimed unit. Ties go to the
+// cheapest to reach.
+func (s *State) arrival(u *Unit, want Tile, claimed []Unit) (Tile, bool) {
+	if u.HP <= 0 || u.Routed || u.Moved {
+		return u.Pos, false
+	}
+
+	size := u.Side()
+	f := DistancesFor(s.Board, u.Pos, size)
+	best, bestReach, bestDist := u.Pos, -1, 0
+
+	for y := 0; y < s.Board.H; y++ {
+		for x := 0; x < s.Board.W; x++ {
+			t := Tile{x, y}
+
+			d := f.Distance(x, y)
+			if t != u.Pos && (d <= 0 || d > u.MP) {
+				continue
+			}
+
+			if s.enemyUnder(t, size) || overlaps(t, size, claimed) {
+				continue
+			}
+
+			r := Reach(want, t)
+			if bestReach < 0 || r < bestReach || (r == bestReach && d < bestDist) {
+				best, bestReach, bestDist = t, r, d
+			}
+		}
+	}
+
+	return best, bestReach >= 0
+}
+
+// overlaps reports whether a unit of the given size at pos would share a
+// tile with any of the others.
+func overlaps(pos Tile, size int, others []Unit) bool {
+	for i := range others {
+		if others[i].HP <= 0 {
+			continue
+		}
+
+		for _, t := range Footprint(pos, size) {
+			if others[i].Covers(t) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func inGroup(units []int, i int) bool {
+	for _, u := range units {
+		if u == i {
+			return true
+		}
+	}
+
+	return false
+}
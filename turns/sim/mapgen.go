@@ -0,0 +1,345 @@
+package sim
+
+import (
+	"errors"
+	"math"
+)
+
+// Limits of the size of generated maps, the largest is what fits the
+// screen.
+const (
+	MinMapW = 12
+	MaxMapW = 20
+	MinMapH = 8
+	MaxMapH = 14
+	// Limits of the rivers and the roughness of generated maps
+	MaxRivers    = 4
+	MinRoughness = 1
+	MaxRoughness = 8
+)
+
+const (
+	// Maps tried before giving up on the parameters
+	mapTries = 50
+	// Octaves of noise the elevation and the moisture add up
+	octaves = 3
+	// River tiles between fords, and the width of the fords, wide enough
+	// for vehicles
+	fordEvery = 5
+	fordWidth = 2
+	// Columns from the edge the units start within
+	startColumns = 4
+	// Elevation below the peaks that's scree, rubble at their feet
+	screeBand = 0.06
+	// Turn limit of the mission of generated maps
+	generatedTurns = 15
+)
+
+// ErrNoMap is returned by Generate when no playable map came out of the
+// parameters, or they're out of their limits.
+var ErrNoMap = errors.New("no playable map with these parameters")
+
+// MapParams are what Generate makes a map from.
+type MapParams struct {
+	Seed uint64
+	W, H int
+	// Elevation, from 0 to 1, below which it's water, and above which it's
+	// walls of rock
+	Sea   float64
+	Peaks float64
+	// Part of the land that's forest, roughly, from 0 to 1
+	Forest float64
+	Rivers int
+	// Cells of noise across the map, the more the more broken up the
+	// terrain
+	Roughness int
+}
+
+// DefaultMapParams returns parameters making maps like the level, with the
+// seed.
+func DefaultMapParams(seed uint64) MapParams {
+	return MapParams{Seed: seed, W: MaxMapW, H: MaxMapH, Sea: 0.2, Peaks: 0.85, Forest: 0.25, Rivers: 1, Roughness: 3}
+}
+
+// Generate makes a game on a random map: the elevation and moisture are
+// value noise, the elevation picks water, land or rock and the moisture
+// forest or grass on the land, and rivers run down from the highlands,
+// with fords every few tiles. The map is the same turned half around, and
+// so are where the units and the enemies start, so neither side has the
+// better ground. The mission is holding the center. The same parameters
+// always make the same map.
+func Generate(p MapParams) (State, error) {
+	if !p.valid() {
+		return State{}, ErrNoMap
+	}
+
+	rng := RNG{p.Seed}
+
+	for try := 0; try < mapTries; try++ {
+		b := generateBoard(p, &rng)
+
+		units, ok := startPositions(b, &rng)
+		if !ok {
+			continue
+		}
+
+		enemies := make([]Tile, len(units))
+		for i, u := range units {
+			enemies[i] = mirror(b, u, startSizes[i])
+		}
+
+		s := New(b, units, enemies, p.Seed)
+		for i, size := range startSizes {
+			s.Units[i].Size = size
+			s.Enemies[i].Size = size
+		}
+
+		s.Mission = Mission{TurnLimit: generatedTurns, Objectives: []Objective{
+			{Kind: Capture, Name: "Hold the center", Tile: Tile{p.W / 2, p.H / 2}, Turns: 2},
+		}}
+
+		return s, nil
+	}
+
+	return State{}, ErrNoMap
+}
+
+// valid reports whether the parameters are within their limits.
+func (p MapParams) valid() bool {
+	unit := func(v float64) bool { return v >= 0 && v <= 1 } // false for NaN too
+
+	return p.W >= MinMapW && p.W <= MaxMapW && p.H >= MinMapH && p.H <= MaxMapH &&
+		unit(p.Sea) && unit(p.Peaks) && unit(p.Forest) &&
+		p.Rivers >= 0 && p.Rivers <= MaxRivers && p.Roughness >= MinRoughness && p.Roughness <= MaxRoughness
+}
+
+// Sizes of the units of each side, the last one is a vehicle.
+//
+//nolint:gochecknoglobal
+var startSizes = []int{1, 1, 2}
+
+// mirror returns where a unit of the size at pos is with the map turned
+// half around.
+func mirror(b Board, pos Tile, size int) Tile {
+	return Tile{b.W - pos.X - size, b.H - pos.Y - size}
+}
+
+// generateBoard paints the terrain from the noise and carves the rivers.
+func generateBoard(p MapParams, rng *RNG) Board {
+	b := Board{W: p.W, H: p.H, Tiles: make([]Terrain, p.W*p.H)}
+	elevation := symmetricNoise(p, rng)
+	moisture := symmetricNoise(p, rng)
+
+	for i, e := range elevation {
+		switch {
+		case e < p.Sea:
+			b.Tiles[i] = Water
+		case e > p.Peaks:
+			b.Tiles[i] = Wall
+		case e > p.Peaks-screeBand:
+			b.Tiles[i] = Rubble
+		case moisture[i] > 1-p.Forest:
+			b.Tiles[i] = Forest
+		default:
+			b.Tiles[i] = Grass
+		}
+	}
+
+	for r := 0; r < p.Rivers; r++ {
+		carveRiver(&b, elevation, rng)
+	}
+
+	// The center is open ground, for the objective
+	for y := (p.H - 1) / 2; y <= p.H/2; y++ {
+		for x := (p.W - 1) / 2; x <= p.W/2; x++ {
+			b.Tiles[y*b.W+x] = Grass
+		}
+	}
+
+	return b
+}
+
+// symmetricNoise returns fractal value noise for every tile, from 0 to 1,
+// the same turned half around.
+func symmetricNoise(p MapParams, rng *RNG) []float64 {
+	n := make([]float64, p.W*p.H)
+	cells, amp := p.Roughness, 1.0
+
+	for o := 0; o < octaves; o++ {
+		lattice := make([]float64, (cells+1)*(cells+1))
+		for i := range lattice {
+			lattice[i] = rng.Float64()
+		}
+
+		for y := 0; y < p.H; y++ {
+			for x := 0; x < p.W; x++ {
+				n[y*p.W+x] += amp * valueAt(lattice, cells, float64(x)/float64(p.W-1), float64(y)/float64(p.H-1))
+			}
+		}
+
+		cells *= 2
+		amp /= 2
+	}
+
+	// Averaging with itself turned around makes it symmetric
+	sym := make([]float64, len(n))
+	for i := range n {
+		sym[i] = (n[i] + n[len(n)-1-i]) / 2
+	}
+
+	return normalize(sym)
+}
+
+// valueAt interpolates the lattice of cells by cells at (u, v), from 0 to 1
+// each.
+func valueAt(lattice []float64, cells int, u, v float64) float64 {
+	fx, fy := u*float64(cells), v*float64(cells)
+	x0, y0 := int(math.Min(fx, float64(cells-1))), int(math.Min(fy, float64(cells-1)))
+	tx, ty := smoothstep(fx-float64(x0)), smoothstep(fy-float64(y0))
+	at := func(x, y int) float64 { return lattice[y*(cells+1)+x] }
+
+	top := at(x0, y0) + (at(x0+1, y0)-at(x0, y0))*tx
+	bottom := at(x0, y0+1) + (at(x0+1, y0+1)-at(x0, y0+1))*tx
+
+	return top + (bottom-top)*ty
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// normalize stretches the values to go from 0 to 1.
+func normalize(vs []float64) []float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range vs {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+
+	if hi > lo {
+		for i := range vs {
+			vs[i] = (vs[i] - lo) / (hi - lo)
+		}
+	}
+
+	return vs
+}
+
+// carveRiver runs a river from a random tile of the highlands down the
+// steepest way, until it reaches water or the edge of the map, and the same
+// river turned around, with fords along them.
+func carveRiver(b *Board, elevation []float64, rng *RNG) {
+	// The source is the highest of a few tiles that aren't rock
+	source := -1
+
+	for i := 0; i < 8; i++ {
+		t := rng.Intn(len(b.Tiles))
+		if b.Tiles[t] != Wall && (source < 0 || elevation[t] > elevation[source]) {
+			source = t
+		}
+	}
+
+	if source < 0 {
+		return
+	}
+
+	var river []int
+
+	visited := map[int]bool{}
+
+	for cur := source; cur >= 0 && b.Tiles[cur] != Water; {
+		river = append(river, cur)
+		visited[cur] = true
+
+		x, y := cur%b.W, cur/b.W
+		if x == 0 || y == 0 || x == b.W-1 || y == b.H-1 {
+			break
+		}
+
+		// Downhill if it can, through the lowest way on otherwise
+		next := -1
+
+		for _, d := range [...]Tile{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			n := (y+d.Y)*b.W + x + d.X
+			if !visited[n] && (next < 0 || elevation[n] < elevation[next]) {
+				next = n
+			}
+		}
+
+		cur = next
+	}
+
+	for i, t := range river {
+		terrain := Water
+		if i%fordEvery >= fordEvery-fordWidth {
+			terrain = Bridge
+		}
+
+		b.Tiles[t] = terrain
+		b.Tiles[len(b.Tiles)-1-t] = terrain
+	}
+}
+
+// startPositions picks where the units start, near the west edge, where
+// they fit and from where each of them can get to the center and to where
+// it would start on the other side.
+func startPositions(b Board, rng *RNG) ([]Tile, bool) {
+	center := Tile{b.W / 2, b.H / 2}
+	starts := make([]Tile, 0, len(startSizes))
+
+	for _, size := range startSizes {
+		var candidates []Tile
+
+		for y := 0; y <= b.H-size; y++ {
+			for x := 0; x < startColumns; x++ {
+				t := Tile{x, y}
+				if footprintCost(b, t, size) > 0 && !startsOverlap(starts, t, size) {
+					candidates = append(candidates, t)
+				}
+			}
+		}
+
+		if len(candidates) == 0 {
+			return nil, false
+		}
+
+		t := candidates[rng.Intn(len(candidates))]
+		f := DistancesFor(b, t, size)
+		other := mirror(b, t, size)
+
+		// Vehicles only have to get near the center
+		if f.Distance(other.X, other.Y) == Unreachable || !nearReach(f, b, center, size) {
+			return nil, false
+		}
+
+		starts = append(starts, t)
+	}
+
+	return starts, true
+}
+
+// startsOverlap reports whether a unit of the size at t would be on a tile of
+// the units at starts, of startSizes.
+func startsOverlap(starts []Tile, t Tile, size int) bool {
+	for i, s := range starts {
+		for _, f := range Footprint(t, size) {
+			if covers(s, startSizes[i], f) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// nearReach reports whether f gets a unit of the size to cover the tile.
+func nearReach(f Field, b Board, t Tile, size int) bool {
+	for y := t.Y - size + 1; y <= t.Y; y++ {
+		for x := t.X - size + 1; x <= t.X; x++ {
+			if b.In(x, y) && f.Distance(x, y) != Unreachable {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+package sim
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateDeterministic(t *testing.T) {
+	for _, seed := range []uint64{1, 42, 1 << 40} {
+		p := DefaultMapParams(seed)
+
+		a, err := Generate(p)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		b, err := Generate(p)
+		if err != nil {
+			t.Fatalf("seed %d, again: %v", seed, err)
+		}
+
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("seed %d made two different games", seed)
+		}
+	}
+
+	a, _ := Generate(DefaultMapParams(1))
+	b, _ := Generate(DefaultMapParams(2))
+
+	if reflect.DeepEqual(a.Board, b.Board) {
+		t.Error("seeds 1 and 2 made the same map")
+	}
+}
+
+func TestGenerateSymmetric(t *testing.T) {
+	s, err := Generate(DefaultMapParams(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := s.Board
+	for y := 0; y < b.H; y++ {
+		for x := 0; x < b.W; x++ {
+			if b.At(x, y) != b.At(b.W-1-x, b.H-1-y) {
+				t.Fatalf("(%d, %d) isn't the same turned half around", x, y)
+			}
+		}
+	}
+
+	for i, u := range s.Units {
+		if want := mirror(b, u.Pos, u.Size); s.Enemies[i].Pos != want {
+			t.Errorf("enemy %d starts at %v, want %v", i, s.Enemies[i].Pos, want)
+		}
+	}
+}
+
+func TestGenerateBadParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(p *MapParams)
+	}{
+		{"too narrow", func(p *MapParams) { p.W = MinMapW - 1 }},
+		{"too wide", func(p *MapParams) { p.W = MaxMapW + 1 }},
+		{"too short", func(p *MapParams) { p.H = MinMapH - 1 }},
+		{"too tall", func(p *MapParams) { p.H = MaxMapH + 1 }},
+		{"no roughness", func(p *MapParams) { p.Roughness = 0 }},
+		{"negative roughness", func(p *MapParams) { p.Roughness = -3 }},
+		{"huge roughness", func(p *MapParams) { p.Roughness = 1 << 30 }},
+		{"negative sea", func(p *MapParams) { p.Sea = -0.1 }},
+		{"sea over 1", func(p *MapParams) { p.Sea = 1.5 }},
+		{"NaN peaks", func(p *MapParams) { p.Peaks = math.NaN() }},
+		{"peaks over 1", func(p *MapParams) { p.Peaks = 2 }},
+		{"negative forest", func(p *MapParams) { p.Forest = -1 }},
+		{"negative rivers", func(p *MapParams) { p.Rivers = -1 }},
+		{"too many rivers", func(p *MapParams) { p.Rivers = MaxRivers + 1 }},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p := DefaultMapParams(1)
+			tt.change(&p)
+
+			if _, err := Generate(p); err != ErrNoMap {
+				t.Errorf("got %v, want ErrNoMap", err)
+			}
+		})
+	}
+}
+
+func TestGenerateLimits(t *testing.T) {
+	// The ends of every range make a map or give up, without panicking
+	for _, p := range []MapParams{
+		{Seed: 3, W: MinMapW, H: MinMapH, Sea: 0, Peaks: 1, Forest: 0, Rivers: 0, Roughness: MinRoughness},
+		{Seed: 3, W: MaxMapW, H: MaxMapH, Sea: 0.2, Peaks: 0.85, Forest: 1, Rivers: MaxRivers, Roughness: MaxRoughness},
+		{Seed: 3, W: MaxMapW, H: MaxMapH, Sea: 1, Peaks: 0, Forest: 0.5, Rivers: 1, Roughness: 3},
+	} {
+		if _, err := Generate(p); err != nil && err != ErrNoMap {
+			t.Errorf("%+v: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package sim
+
+// ObjectiveKind is what an objective asks for.
+type ObjectiveKind int
+
+const (
+	// Capture is holding a tile with a unit, and no enemy on it, for some
+	// turns in a row
+	Capture ObjectiveKind = iota
+	// Escort is getting a unit to a tile alive
+	Escort
+)
+
+func (k ObjectiveKind) String() string {
+	return [...]string{"capture", "escort"}[k]
+}
+
+// Objective is a goal of the mission. Once done, it stays done.
+type Objective struct {
+	Kind ObjectiveKind
+	Name string
+	Tile Tile
+	// Turns to hold the tile for, for Capture
+	Turns int
+	// Index in Units of the unit to escort, for Escort
+	Unit int
+	// Turns held in a row so far, for Capture
+	Held int
+	Done bool
+}
+
+// Mission is what the player has to do to win. Without objectives there's
+// nothing to win, the game goes on.
+type Mission struct {
+	Objectives []Objective
+	// The objectives have to be done by the end of this turn, 0 for no
+	// limit
+	TurnLimit int
+}
+
+// Outcome is how the mission went, so far.
+type Outcome int
+
+const (
+	Ongoing Outcome = iota
+	Won
+	Lost
+)
+
+func (o Outcome) String() string {
+	return [...]string{"ongoing", "won", "lost"}[o]
+}
+
+// TurnsLeft returns the turns left to complete the mission in, counting
+// the current one, and whether there's a limit at all.
+func (s *State) TurnsLeft() (int, bool) {
+	if s.Mission.TurnLimit <= 0 {
+		return 0, false
+	}
+
+	return s.Mission.TurnLimit - s.Turn, true
+}
+
+// holds reports whether a living player unit that takes orders is on the
+// tile, with no enemy on it.
+func (s *State) holds(t Tile) bool {
+	u := s.UnitAt(t)
+
+	return u != nil && u.HP > 0 && !u.Routed && s.EnemyAt(t) == nil
+}
+
+// updateMission checks the objectives and the outcome at the end of a
+// resolution, before the turn count goes up.
+func (s *State) updateMission(r *Result) {
+	if s.Outcome != Ongoing || len(s.Mission.Objectives) == 0 {
+		return
+	}
+
+	done := true
+
+	for i := range s.Mission.Objectives {
+		o := &s.Mission.Objectives[i]
+
+		if !o.Done {
+			switch o.Kind {
+			case Capture:
+				if s.holds(o.Tile) {
+					o.Held++
+				} else {
+					o.Held = 0
+				}
+
+				o.Done = o.Held >= o.Turns
+			case Escort:
+				u := s.Units[o.Unit]
+				if u.HP <= 0 {
+					s.Outcome = Lost
+					r.logf("unit %d is down, %s failed", u.ID, o.Name)
+
+					return
+				}
+
+				o.Done = u.Covers(o.Tile)
+			}
+
+			if o.Done {
+				r.logf("objective done: %s", o.Name)
+			}
+		}
+
+		done = done && o.Done
+	}
+
+	switch {
+	case done:
+		s.Outcome = Won
+		r.logf("mission accomplished")
+	case !anyAlive(s.Units):
+		s.Outcome = Lost
+		r.logf("all units are down, mission failed")
+	case s.Mission.TurnLimit > 0 && s.Turn+1 >= s.Mission.TurnLimit:
+		s.Outcome = Lost
+		r.logf("out of turns, mission failed")
+	}
+}
+
+func anyAlive(us []Unit) bool {
+	for _, u := range us {
+		if u.HP > 0 {
+			return true
+		}
+	}
+
+	return false
+}
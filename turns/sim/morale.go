@@ -0,0 +1,255 @@
+package sim
+
+const (
+	MaxMorale = 10
+	// Units at or below this rout, and retreat on their own
+	RoutMorale = 3
+	// Routed units rally, and take orders again, at or above this
+	RallyMorale = 6
+	// Allies this close to a unit going down are shaken by it
+	moraleRadius = 3
+	deathShock   = 5
+	// Morale recovered every turn, faster while retreating away from the
+	// fight
+	moraleRecovery = 1
+	routRecovery   = 3
+)
+
+// moraleLoss is what taking a hit does to the morale of the defender, worse
+// the less it saw it coming.
+func (f Flank) moraleLoss() int {
+	return [...]int{1, 2, 3}[f]
+}
+
+// shake lowers the morale of u.
+func shake(u *Unit, n int) {
+	u.Morale -= n
+	if u.Morale < 0 {
+		u.Morale = 0
+	}
+}
+
+func alive(us []Unit) []bool {
+	a := make([]bool, len(us))
+	for i, u := range us {
+		a[i] = u.HP > 0
+	}
+
+	return a
+}
+
+// mourn shakes the allies near the units of a side that went down since
+// wasAlive was taken.
+func mourn(us []Unit, wasAlive []bool, side string, r *Result) {
+	for i, u := range us {
+		if !wasAlive[i] || u.HP > 0 {
+			continue
+		}
+
+		for j := range us {
+			v := &us[j]
+			if j != i && v.HP > 0 && Reach(v.Pos, u.Pos) <= moraleRadius {
+				shake(v, deathShock)
+				r.logf("%s %d is shaken by the loss of %s %d", side, v.ID, side, u.ID)
+			}
+		}
+	}
+}
+
+// unit returns the unit an action is for, on either side.
+func (s *State) unit(a Action) *Unit {
+	if a.Enemy {
+		return &s.Enemies[a.Unit]
+	}
+
+	return &s.Units[a.Unit]
+}
+
+// rout breaks the units, on both sides, whose morale dropped too low: their
+// actions still in the queue are dropped, and a retreat is queued instead.
+// It returns the new queue.
+func (s *State) rout(queue []Action, r *Result) []Action {
+	for _, side := range []struct {
+		us    []Unit
+		enemy bool
+		name  string
+	}{{s.Units, false, "unit"}, {s.Enemies, true, "enemy"}} {
+		for i := range side.us {
+			u := &side.us[i]
+			if u.HP <= 0 || u.Routed || u.Morale > RoutMorale {
+				continue
+			}
+
+			u.Routed = true
+			r.logf("%s %d breaks and flees", side.name, u.ID)
+
+			kept := queue[:0]
+
+			for _, a := range queue {
+				if a.Unit != i || a.Enemy != side.enemy {
+					kept = append(kept, a)
+				}
+			}
+
+			queue = append(kept, Action{Unit: i, Enemy: side.enemy, Mode: ModeRetreat})
+		}
+	}
+
+	return queue
+}
+
+// retreats queues the retreats of the units still routed from past turns,
+// they don't take orders.
+func (s *State) retreats() []Action {
+	var queue []Action
+
+	for i, u := range s.Units {
+		if u.HP > 0 && u.Routed {
+			queue = append(queue, Action{Unit: i, Mode: ModeRetreat})
+		}
+	}
+
+	for i, e := range s.Enemies {
+		if e.HP > 0 && e.Routed {
+			queue = append(queue, Action{Unit: i, Enemy: true, Mode: ModeRetreat})
+		}
+	}
+
+	return queue
+}
+
+// edge returns the board edge closest to the tile, as the facing looking
+// out of the board there.
+func (b Board) edge(t Tile) Facing {
+	f, d := North, t.Y
+
+	if dd := b.W - 1 - t.X; dd < d {
+		f, d = East, dd
+	}
+
+	if dd := b.H - 1 - t.Y; dd < d {
+		f, d = South, dd
+	}
+
+	if t.X < d {
+		f = West
+	}
+
+	return f
+}
+
+// reaches reports whether a unit of the given size at pos touches the edge.
+func (b Board) reaches(pos Tile, size int, f Facing) bool {
+	for _, t := range Footprint(pos, size) {
+		if b.onEdge(t, f) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b Board) onEdge(t Tile, f Facing) bool {
+	switch f {
+	case North:
+		return t.Y == 0
+	case East:
+		return t.X == b.W-1
+	case South:
+		return t.Y == b.H-1
+	case West:
+		return t.X == 0
+	}
+
+	return false
+}
+
+// retreat moves the unit a turn's worth of movement toward the closest
+// reachable tile of the board edge it came from, stopping short of other
+// units.
+func (s *State) retreat(u *Unit, side string, r *Result) {
+	f := DistancesFor(s.Board, u.Pos, u.Side())
+	edge := s.Board.edge(u.Spawn)
+	best, bestDist := Tile{}, Unreachable
+
+	for y := 0; y < s.Board.H; y++ {
+		for x := 0; x < s.Board.W; x++ {
+			t := Tile{x, y}
+			if !s.Board.reaches(t, u.Side(), edge) {
+				continue
+			}
+
+			if d := f.Distance(x, y); d != Unreachable && (bestDist == Unreachable || d < bestDist) {
+				best, bestDist = t, d
+			}
+		}
+	}
+
+	if bestDist <= 0 {
+		r.logf("%s %d has nowhere left to run", side, u.ID)
+
+		return
+	}
+
+	to := u.Pos
+	from := u.Pos
+
+	for _, t := range f.Path(best.X, best.Y) {
+		if f.Distance(t.X, t.Y) > MoveRange {
+			break
+		}
+
+		if !s.occupied(t, u.Side(), u) {
+			from, to = to, t
+		}
+	}
+
+	if to == u.Pos {
+		r.logf("%s %d cowers at %d,%d", side, u.ID, u.Pos.X, u.Pos.Y)
+
+		return
+	}
+
+	if facing, ok := FacingTo(from, to); ok {
+		u.Facing = facing
+	}
+
+	u.Pos = to
+	r.logf("%s %d retreats to %d,%d", side, u.ID, to.X, to.Y)
+}
+
+// recoverMorale brings the morale back up at the end of the turn, and
+// rallies the units that were routed when it started and have calmed down.
+func recoverMorale(us []Unit, wasRouted []bool, side string, r *Result) {
+	for i := range us {
+		u := &us[i]
+		if u.HP <= 0 {
+			continue
+		}
+
+		if !u.Routed {
+			u.Morale = min(u.Morale+moraleRecovery, MaxMorale)
+
+			continue
+		}
+
+		if !wasRouted[i] {
+			continue
+		}
+
+		u.Morale = min(u.Morale+routRecovery, MaxMorale)
+		if u.Morale >= RallyMorale {
+			u.Routed = false
+			r.logf("%s %d rallies", side, u.ID)
+		}
+	}
+}
+
+func routed(us []Unit) []bool {
+	rs := make([]bool, len(us))
+	for i, u := range us {
+		rs[i] = u.Routed
+	}
+
+	return rs
+}
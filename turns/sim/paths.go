@@ -0,0 +1,137 @@
+package sim
+
+import "container/heap"
+
+// Unreachable is the distance to tiles that can't be reached.
+const Unreachable = -1
+
+// Field is the Dijkstra distance field from an origin tile.
+type Field struct {
+	w      int
+	origin int
+	dist   []int
+	prev   []int
+}
+
+// Distances works out the cost of going from origin to every tile.
+func Distances(b Board, origin Tile) Field {
+	return DistancesFor(b, origin, 1)
+}
+
+// DistancesFor is Distances for a unit of the given size, whose position is
+// the top left of its footprint: it can only stand where the whole of it
+// fits, and it goes as slow as the slowest tile under it.
+func DistancesFor(b Board, origin Tile, size int) Field {
+	n := b.W * b.H
+	f := Field{
+		w:      b.W,
+		origin: origin.Y*b.W + origin.X,
+		dist:   make([]int, n),
+		prev:   make([]int, n),
+	}
+
+	for i := range f.dist {
+		f.dist[i] = Unreachable
+		f.prev[i] = Unreachable
+	}
+
+	f.dist[f.origin] = 0
+
+	pq := &tileQueue{{f.origin, 0}}
+	for pq.Len() > 0 {
+		cur := heap.Pop(pq).(queued)
+		if cur.dist > f.dist[cur.i] {
+			continue
+		}
+
+		cx, cy := cur.i%b.W, cur.i/b.W
+		for _, d := range [...]Tile{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			nx, ny := cx+d.X, cy+d.Y
+			if !b.In(nx, ny) {
+				continue
+			}
+
+			cost := footprintCost(b, Tile{nx, ny}, size)
+			if cost == 0 {
+				continue
+			}
+
+			ni := ny*b.W + nx
+			if nd := cur.dist + cost; f.dist[ni] == Unreachable || nd < f.dist[ni] {
+				f.dist[ni] = nd
+				f.prev[ni] = cur.i
+				heap.Push(pq, queued{ni, nd})
+			}
+		}
+	}
+
+	return f
+}
+
+// footprintCost is the cost of stepping on pos with a unit of the given
+// size, 0 if it doesn't fit there.
+func footprintCost(b Board, pos Tile, size int) int {
+	max := 0
+
+	for _, t := range Footprint(pos, size) {
+		if !b.In(t.X, t.Y) {
+			return 0
+		}
+
+		c := b.Cost(t.X, t.Y)
+		if c == 0 {
+			return 0
+		}
+
+		if c > max {
+			max = c
+		}
+	}
+
+	return max
+}
+
+// Distance returns the cost to go to (x, y), or Unreachable.
+func (f Field) Distance(x, y int) int {
+	return f.dist[y*f.w+x]
+}
+
+// Path returns the tiles from the origin (excluded) to (x, y), or nil if
+// unreachable.
+func (f Field) Path(x, y int) []Tile {
+	i := y*f.w + x
+	if f.dist[i] == Unreachable || i == f.origin {
+		return nil
+	}
+
+	var path []Tile
+	for ; f.prev[i] != Unreachable; i = f.prev[i] {
+		path = append(path, Tile{i % f.w, i / f.w})
+	}
+
+	for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+		path[l], path[r] = path[r], path[l]
+	}
+
+	return path
+}
+
+type queued struct {
+	i    int
+	dist int
+}
+
+type tileQueue []queued
+
+func (q tileQueue) Len() int            { return len(q) }
+func (q tileQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q tileQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *tileQueue) Push(x interface{}) { *q = append(*q, x.(queued)) }
+
+func (q *tileQueue) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return x
+}
@@ -0,0 +1,100 @@
+package sim
+
+import "testing"
+
+//nolint:gochecknoglobal
+var pathBoard = ParseBoard([]string{
+	"..T..",
+	".##..",
+	"..~~.",
+	".....",
+})
+
+func TestDistances(t *testing.T) {
+	f := Distances(pathBoard, Tile{0, 0})
+
+	tests := []struct {
+		name string
+		to   Tile
+		want int
+	}{
+		{"origin", Tile{0, 0}, 0},
+		{"next", Tile{1, 0}, 1},
+		{"into the forest", Tile{2, 0}, 3},
+		{"past the forest", Tile{3, 0}, 4},
+		{"down the side", Tile{0, 3}, 3},
+		{"around the water", Tile{4, 2}, 7},
+		{"wall", Tile{1, 1}, Unreachable},
+		{"water", Tile{2, 2}, Unreachable},
+	}
+
+	for _, tt := range tests {
+		if got := f.Distance(tt.to.X, tt.to.Y); got != tt.want {
+			t.Errorf("%s: distance to %v is %d, want %d", tt.name, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	f := Distances(pathBoard, Tile{0, 0})
+
+	if p := f.Path(0, 0); p != nil {
+		t.Errorf("path to the origin: %v, want none", p)
+	}
+
+	if p := f.Path(1, 1); p != nil {
+		t.Errorf("path into a wall: %v, want none", p)
+	}
+
+	to := Tile{4, 2}
+	p := f.Path(to.X, to.Y)
+
+	if len(p) == 0 || p[len(p)-1] != to {
+		t.Fatalf("path to %v: %v, doesn't end there", to, p)
+	}
+
+	// Each step is to a tile next to the last, and they add up to the
+	// distance
+	cost, from := 0, Tile{0, 0}
+	for _, s := range p {
+		if !Adjacent(from, s) {
+			t.Fatalf("path %v jumps from %v to %v", p, from, s)
+		}
+
+		cost += pathBoard.Cost(s.X, s.Y)
+		from = s
+	}
+
+	if want := f.Distance(to.X, to.Y); cost != want {
+		t.Errorf("path %v costs %d, want %d", p, cost, want)
+	}
+}
+
+func TestDistancesFor(t *testing.T) {
+	// A wall across the board with a gap one tile wide
+	b := ParseBoard([]string{
+		"......",
+		"..#...",
+		"......",
+		"##.###",
+		"......",
+		"......",
+	})
+
+	if d := DistancesFor(b, Tile{0, 0}, 1).Distance(2, 4); d != 6 {
+		t.Errorf("small unit through the gap: %d, want 6", d)
+	}
+
+	if d := DistancesFor(b, Tile{0, 0}, 2).Distance(2, 4); d != Unreachable {
+		t.Errorf("big unit through the gap: %d, want unreachable", d)
+	}
+
+	// Its footprint can't take the wall in the middle
+	if d := DistancesFor(b, Tile{0, 0}, 2).Distance(0, 1); d != 1 {
+		t.Errorf("big unit a step down: %d, want 1", d)
+	}
+
+	if d := DistancesFor(b, Tile{0, 0}, 2).Distance(1, 0); d != Unreachable {
+		t.Errorf("big unit over the wall: %d, want unreachable", d)
+	}
+}
@@ -0,0 +1,27 @@
+package sim
+
+// RNG is a splitmix64 generator. It's all in the one number, so it copies
+// and saves along with the rest of the state, unlike math/rand.
+type RNG struct {
+	State uint64
+}
+
+func (r *RNG) next() uint64 {
+	r.State += 0x9e3779b97f4a7c15
+	z := r.State
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
+
+// Float64 returns a number in [0, 1).
+func (r *RNG) Float64() float64 {
+	return float64(r.next()>>11) / (1 << 53)
+}
+
+// Intn returns a number in [0, n). The modulo bias is negligible for the
+// small n used here.
+func (r *RNG) Intn(n int) int {
+	return int(r.next() % uint64(n))
+}
@@ -0,0 +1,144 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/antoniomo/ebiten-exercises/internal/buildinfo"
+)
+
+// SaveVersion is the version of the save format. Bump it whenever a change
+// to State would read wrong from older saves, and add a migration from the
+// version before.
+const SaveVersion = 1
+
+// migrations upgrade a decoded save from the version at their index to the
+// next, working on the JSON as generic maps and slices, so they don't
+// depend on how State looks now. None yet, as there's only the one version.
+//
+//nolint:gochecknoglobal
+var migrations = map[int]func(state map[string]interface{}) error{}
+
+// ErrNewerSave is returned loading a save from a newer version of the game.
+var ErrNewerSave = errors.New("saved by a newer version")
+
+// save is how a game is saved: the version of the format, the build that
+// saved it, for reference, and the state.
+type save struct {
+	Version int             `json:"version"`
+	Build   buildinfo.Info  `json:"build"`
+	State   json.RawMessage `json:"state"`
+}
+
+// Save returns the state as a save, the whole game: the board, the turn,
+// the units and enemies, the actions declared so far, the dice and the
+// mission.
+func (s State) Save() ([]byte, error) {
+	state, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(save{Version: SaveVersion, Build: buildinfo.Get(), State: state}, "", "  ")
+}
+
+// Load returns the state in a save, upgrading it first if it's from an
+// older version, and checking it can be played.
+func Load(data []byte) (State, error) {
+	var (
+		sv save
+		s  State
+	)
+
+	if err := json.Unmarshal(data, &sv); err != nil {
+		return s, err
+	}
+
+	switch {
+	case sv.Version > SaveVersion:
+		return s, fmt.Errorf("%w, %d (up to %d here)", ErrNewerSave, sv.Version, SaveVersion)
+	case sv.Version < 1:
+		return s, fmt.Errorf("not a save, or version %d", sv.Version)
+	case sv.Version < SaveVersion:
+		state, err := migrate(sv.State, sv.Version)
+		if err != nil {
+			return s, err
+		}
+
+		sv.State = state
+	}
+
+	if err := json.Unmarshal(sv.State, &s); err != nil {
+		return s, err
+	}
+
+	return s, s.repair()
+}
+
+// migrate runs the migrations of the state from the version it was saved
+// with up to SaveVersion.
+func migrate(data json.RawMessage, version int) (json.RawMessage, error) {
+	var state map[string]interface{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	for v := version; v < SaveVersion; v++ {
+		m, ok := migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("no migration from version %d", v)
+		}
+
+		if err := m(state); err != nil {
+			return nil, fmt.Errorf("migrating from version %d: %w", v, err)
+		}
+	}
+
+	return json.Marshal(state)
+}
+
+// repair checks a loaded state can be played, and fills in what changes to
+// the rules that don't need a new version leave out, as cooldowns for
+// abilities added since.
+func (s *State) repair() error {
+	if s.Board.W <= 0 || s.Board.H <= 0 || len(s.Board.Tiles) != s.Board.W*s.Board.H {
+		return fmt.Errorf("board of %dx%d with %d tiles", s.Board.W, s.Board.H, len(s.Board.Tiles))
+	}
+
+	if len(s.Units) == 0 {
+		return errors.New("no units")
+	}
+
+	for _, enemy := range [...]bool{false, true} {
+		for _, u := range s.friends(Action{Enemy: enemy}) {
+			if !s.Board.In(u.Pos.X, u.Pos.Y) {
+				return fmt.Errorf("%s %d off the board", sideName(enemy), u.ID)
+			}
+		}
+	}
+
+	// Enemies don't use abilities, they have no cooldowns
+	for i := range s.Units {
+		u := &s.Units[i]
+		for len(u.Cooldowns) < len(Abilities) {
+			u.Cooldowns = append(u.Cooldowns, 0)
+		}
+
+		u.Cooldowns = u.Cooldowns[:len(Abilities)]
+	}
+
+	for _, a := range s.Pending {
+		if a.Unit < 0 || a.Unit >= len(s.friends(a)) || (a.Mode == ModeAbility && (a.Ability < 0 || a.Ability >= len(Abilities))) {
+			return fmt.Errorf("pending %s of no unit %d", a.Mode, a.Unit)
+		}
+	}
+
+	for _, o := range s.Mission.Objectives {
+		if o.Kind == Escort && (o.Unit < 0 || o.Unit >= len(s.Units)) {
+			return fmt.Errorf("objective %q escorts no unit %d", o.Name, o.Unit)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// savedGame returns a game partway through its turn, and its save.
+func savedGame(t *testing.T) (State, []byte) {
+	s, err := Generate(DefaultMapParams(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Resolve()
+	s.Declare(Action{Mode: ModeWait, Target: s.Units[0].Pos})
+
+	data, err := s.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, data
+}
+
+// resave changes the decoded save with fn and encodes it again.
+func resave(t *testing.T, data []byte, fn func(sv map[string]interface{}, state map[string]interface{})) []byte {
+	var sv map[string]interface{}
+	if err := json.Unmarshal(data, &sv); err != nil {
+		t.Fatal(err)
+	}
+
+	fn(sv, sv["state"].(map[string]interface{}))
+
+	data, err := json.Marshal(sv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestSaveLoad(t *testing.T) {
+	s, data := savedGame(t)
+
+	got, err := Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("loaded\n%+v\nwant\n%+v", got, s)
+	}
+
+	// And it plays on the same
+	r1, r2 := s.Resolve(), got.Resolve()
+	if !reflect.DeepEqual(r1, r2) || !reflect.DeepEqual(got, s) {
+		t.Error("the loaded game resolved differently")
+	}
+}
+
+func TestLoadVersions(t *testing.T) {
+	_, data := savedGame(t)
+
+	newer := resave(t, data, func(sv, _ map[string]interface{}) { sv["version"] = SaveVersion + 1 })
+	if _, err := Load(newer); !errors.Is(err, ErrNewerSave) {
+		t.Errorf("newer save: %v, want ErrNewerSave", err)
+	}
+
+	none := resave(t, data, func(sv, _ map[string]interface{}) { delete(sv, "version") })
+	if _, err := Load(none); err == nil || errors.Is(err, ErrNewerSave) {
+		t.Errorf("save with no version: %v, want an error", err)
+	}
+}
+
+func TestLoadRepair(t *testing.T) {
+	_, data := savedGame(t)
+
+	// Saved before some ability existed
+	old := resave(t, data, func(_, state map[string]interface{}) {
+		u := state["Units"].([]interface{})[0].(map[string]interface{})
+		u["Cooldowns"] = []interface{}{}
+	})
+
+	s, err := Load(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(s.Units[0].Cooldowns); n != len(Abilities) {
+		t.Errorf("%d cooldowns, want one for each of the %d abilities", n, len(Abilities))
+	}
+}
+
+func TestLoadBad(t *testing.T) {
+	_, data := savedGame(t)
+
+	tests := []struct {
+		name   string
+		change func(state map[string]interface{})
+	}{
+		{"no units", func(state map[string]interface{}) { state["Units"] = []interface{}{} }},
+		{"too few tiles", func(state map[string]interface{}) {
+			b := state["Board"].(map[string]interface{})
+			b["Tiles"] = b["Tiles"].([]interface{})[1:]
+		}},
+		{"unit off the board", func(state map[string]interface{}) {
+			u := state["Units"].([]interface{})[0].(map[string]interface{})
+			u["Pos"] = map[string]interface{}{"X": -1, "Y": 0}
+		}},
+		{"pending of no unit", func(state map[string]interface{}) {
+			a := state["Pending"].([]interface{})[0].(map[string]interface{})
+			a["Unit"] = 99
+		}},
+	}
+
+	for _, tt := range tests {
+		bad := resave(t, data, func(_, state map[string]interface{}) { tt.change(state) })
+		if _, err := Load(bad); err == nil {
+			t.Errorf("%s: loaded", tt.name)
+		}
+	}
+
+	if _, err := Load([]byte("{")); err == nil {
+		t.Error("loaded broken JSON")
+	}
+}
@@ -0,0 +1,75 @@
+package sim
+
+// Sight returns which tiles can be seen from viewer, within radius, row by
+// row.
+func Sight(b Board, viewer Tile, radius int) []bool {
+	seen := make([]bool, b.W*b.H)
+
+	for y := 0; y < b.H; y++ {
+		for x := 0; x < b.W; x++ {
+			seen[y*b.W+x] = CanSee(b, viewer, Tile{x, y}, radius)
+		}
+	}
+
+	return seen
+}
+
+// CanSee reports whether to is within radius of from, and in sight.
+func CanSee(b Board, from, to Tile, radius int) bool {
+	return abs(to.X-from.X) <= radius && abs(to.Y-from.Y) <= radius && lineOfSight(b, from, to)
+}
+
+// lineOfSight walks a Bresenham line between both tiles. The target itself
+// doesn't block, so walls and forests are visible, but not what's behind.
+func lineOfSight(b Board, from, to Tile) bool {
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	sx, sy := sign(to.X-from.X), sign(to.Y-from.Y)
+	err := dx + dy
+	x, y := from.X, from.Y
+
+	for x != to.X || y != to.Y {
+		if (x != from.X || y != from.Y) && b.BlocksView(x, y) {
+			return false
+		}
+
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
+	}
+
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,502 @@
+package sim
+
+import "fmt"
+
+const (
+	MoveRange   = 6
+	SightRange  = 6
+	BlastRadius = 1
+)
+
+type Mode int
+
+const (
+	ModeMove Mode = iota
+	ModeExplode
+	ModeBridge
+	ModeAttack
+	ModeFace
+	ModeAbility
+	// Involuntary, routed units retreat on their own, see rout
+	ModeRetreat
+	// Hold and do nothing else this turn
+	ModeWait
+)
+
+func (m Mode) String() string {
+	return [...]string{"move", "explode", "bridge", "attack", "face", "ability", "retreat", "wait"}[m]
+}
+
+// Action is something a unit is told to do this turn.
+type Action struct {
+	Unit   int
+	Mode   Mode
+	Target Tile
+	// Index in Abilities, for ModeAbility
+	Ability int
+	// Unit is an index in Enemies instead. Enemies only move, attack and
+	// wait, see PlanEnemies
+	Enemy bool
+}
+
+// Unit is a piece on the board.
+type Unit struct {
+	ID     int
+	Pos    Tile
+	Facing Facing
+	HP     int
+	// Whether a move was already declared this turn, and the movement
+	// points left to declare
+	Moved bool
+	MP    int
+	// Turns left until each ability can be used again
+	Cooldowns []int
+	// Routed units don't take orders, they retreat toward the board edge
+	// closest to where they started
+	Morale int
+	Routed bool
+	Spawn  Tile
+	// Side of the square of tiles it takes, 0 is 1. Pos is its top left
+	// tile
+	Size int
+}
+
+// Side returns the side of the square of tiles the unit takes.
+func (u Unit) Side() int {
+	if u.Size < 1 {
+		return 1
+	}
+
+	return u.Size
+}
+
+// Covers reports whether the unit is on the tile.
+func (u Unit) Covers(t Tile) bool {
+	return covers(u.Pos, u.Side(), t)
+}
+
+func covers(pos Tile, size int, t Tile) bool {
+	return t.X >= pos.X && t.X < pos.X+size && t.Y >= pos.Y && t.Y < pos.Y+size
+}
+
+// Footprint returns the tiles taken by a unit of the given size at pos.
+func Footprint(pos Tile, size int) []Tile {
+	if size < 1 {
+		size = 1
+	}
+
+	tiles := make([]Tile, 0, size*size)
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			tiles = append(tiles, Tile{pos.X + x, pos.Y + y})
+		}
+	}
+
+	return tiles
+}
+
+// State is everything about a game. Being plain data it's copied with
+// Clone, so the copy doesn't share the slices.
+type State struct {
+	Turn    int
+	Board   Board
+	Units   []Unit
+	Enemies []Unit
+	// Declared this turn, resolved in order
+	Pending []Action
+	RNG     RNG
+	// What the player has to do, and how it's going
+	Mission Mission
+	Outcome Outcome
+}
+
+// Result is what happened on a resolution.
+type Result struct {
+	Events  []string
+	Changes []TileChange
+	Clashes []Clash
+}
+
+func (r *Result) logf(format string, args ...interface{}) {
+	r.Events = append(r.Events, fmt.Sprintf(format, args...))
+}
+
+// New starts a game on the board, with units and enemies at the given
+// tiles.
+func New(board Board, units, enemies []Tile, seed uint64) State {
+	s := State{Board: board, RNG: RNG{seed}}
+
+	for i, pos := range units {
+		s.Units = append(s.Units, Unit{
+			ID:        i + 1,
+			Pos:       pos,
+			Facing:    East,
+			HP:        UnitHP,
+			MP:        MoveRange,
+			Cooldowns: make([]int, len(Abilities)),
+			Morale:    MaxMorale,
+			Spawn:     pos,
+		})
+	}
+
+	for i, pos := range enemies {
+		s.Enemies = append(s.Enemies, Unit{
+			ID:     i + 1,
+			Pos:    pos,
+			Facing: West,
+			HP:     UnitHP,
+			MP:     MoveRange,
+			Morale: MaxMorale,
+			Spawn:  pos,
+		})
+	}
+
+	return s
+}
+
+func (s State) Clone() State {
+	c := s
+	c.Board.Tiles = append([]Terrain(nil), s.Board.Tiles...)
+	c.Units = cloneUnits(s.Units)
+	c.Enemies = cloneUnits(s.Enemies)
+	c.Pending = append([]Action(nil), s.Pending...)
+	c.Mission.Objectives = append([]Objective(nil), s.Mission.Objectives...)
+
+	return c
+}
+
+func cloneUnits(us []Unit) []Unit {
+	c := append([]Unit(nil), us...)
+	for i := range c {
+		c[i].Cooldowns = append([]int(nil), us[i].Cooldowns...)
+	}
+
+	return c
+}
+
+// EnemyAt returns the living enemy on the tile, if any.
+func (s *State) EnemyAt(t Tile) *Unit {
+	for i := range s.Enemies {
+		if e := &s.Enemies[i]; e.HP > 0 && e.Covers(t) {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// UnitAt returns the friendly unit on the tile, if any.
+func (s *State) UnitAt(t Tile) *Unit {
+	for i := range s.Units {
+		if u := &s.Units[i]; u.Covers(t) {
+			return u
+		}
+	}
+
+	return nil
+}
+
+// occupied reports whether a unit other than self, friend or foe, is under
+// a unit of the given size at pos.
+func (s *State) occupied(pos Tile, size int, self *Unit) bool {
+	for _, t := range Footprint(pos, size) {
+		if e := s.EnemyAt(t); e != nil && e != self {
+			return true
+		}
+
+		if u := s.UnitAt(t); u != nil && u != self {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enemyUnder reports whether there's a living enemy under a unit of the
+// given size at pos.
+func (s *State) enemyUnder(pos Tile, size int) bool {
+	return s.foeUnder(false, pos, size)
+}
+
+// foeAt returns the living unit of the other side on the tile, for a unit
+// of the enemy side or not, if any.
+func (s *State) foeAt(enemy bool, t Tile) *Unit {
+	if !enemy {
+		return s.EnemyAt(t)
+	}
+
+	if u := s.UnitAt(t); u != nil && u.HP > 0 {
+		return u
+	}
+
+	return nil
+}
+
+// foeUnder is enemyUnder for either side.
+func (s *State) foeUnder(enemy bool, pos Tile, size int) bool {
+	for _, t := range Footprint(pos, size) {
+		if s.foeAt(enemy, t) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sideName is how the log calls the units of a side.
+func sideName(enemy bool) string {
+	if enemy {
+		return "enemy"
+	}
+
+	return "unit"
+}
+
+func touches(pos Tile, size int, t Tile) bool {
+	_, ok := Contact(pos, size, t)
+
+	return ok
+}
+
+// PlannedPos returns where the unit will be after its declared move and
+// dash.
+func (s *State) PlannedPos(unit int) Tile {
+	return s.plannedPos(Action{Unit: unit})
+}
+
+// plannedPos is PlannedPos for the unit of the action, on either side.
+func (s *State) plannedPos(of Action) Tile {
+	pos := s.unit(of).Pos
+
+	for _, a := range s.Pending {
+		if a.Unit != of.Unit || a.Enemy != of.Enemy {
+			continue
+		}
+
+		if a.Mode == ModeMove || (a.Mode == ModeAbility && Abilities[a.Ability].Targeting == TargetTile) {
+			pos = a.Target
+		}
+	}
+
+	return pos
+}
+
+// declared reports whether the unit of the action has anything declared
+// already, and waits whether that's a wait.
+func (s *State) declared(of Action) bool {
+	for _, a := range s.Pending {
+		if a.Unit == of.Unit && a.Enemy == of.Enemy {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *State) waits(of Action) bool {
+	for _, a := range s.Pending {
+		if a.Unit == of.Unit && a.Enemy == of.Enemy && a.Mode == ModeWait {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Declare validates and registers an action for this turn, for either side,
+// and reports whether it was valid.
+func (s *State) Declare(a Action) bool {
+	u := s.unit(a)
+	pos := s.plannedPos(a)
+
+	// Units that are down can't do anything, routed ones won't, and
+	// waiting ones are done for the turn
+	if u.HP <= 0 || u.Routed || !s.Board.In(a.Target.X, a.Target.Y) || s.waits(a) {
+		return false
+	}
+
+	if a.Enemy && a.Mode != ModeMove && a.Mode != ModeAttack && a.Mode != ModeWait {
+		return false
+	}
+
+	switch a.Mode {
+	case ModeMove:
+		d := DistancesFor(s.Board, u.Pos, u.Side()).Distance(a.Target.X, a.Target.Y)
+		if u.Moved || d <= 0 || d > u.MP || s.foeUnder(a.Enemy, a.Target, u.Side()) {
+			return false
+		}
+
+		u.Moved = true
+		u.MP -= d
+	case ModeExplode:
+		if !CanSee(s.Board, u.Pos, a.Target, SightRange) {
+			return false
+		}
+	case ModeBridge:
+		if s.Board.At(a.Target.X, a.Target.Y) != Water || !touches(pos, u.Side(), a.Target) {
+			return false
+		}
+	case ModeAttack:
+		if s.foeAt(a.Enemy, a.Target) == nil || !touches(pos, u.Side(), a.Target) {
+			return false
+		}
+	case ModeFace:
+		if _, ok := FacingTo(pos, a.Target); !ok || u.MP < TurnCost {
+			return false
+		}
+
+		u.MP -= TurnCost
+	case ModeAbility:
+		if !s.CanUse(a.Unit, a.Ability, a.Target) {
+			return false
+		}
+
+		u.MP -= Abilities[a.Ability].Cost
+	case ModeWait:
+		// Waiting is all it does this turn
+		if s.declared(a) {
+			return false
+		}
+
+		u.Moved = true
+		u.MP = 0
+	case ModeRetreat:
+		return false
+	}
+
+	s.Pending = append(s.Pending, a)
+
+	return true
+}
+
+// Undo removes the last declared action, if any.
+func (s *State) Undo() {
+	if len(s.Pending) == 0 {
+		return
+	}
+
+	last := s.Pending[len(s.Pending)-1]
+	u := s.unit(last)
+
+	switch last.Mode {
+	case ModeMove:
+		u.Moved = false
+		u.MP += DistancesFor(s.Board, u.Pos, u.Side()).Distance(last.Target.X, last.Target.Y)
+	case ModeFace:
+		u.MP += TurnCost
+	case ModeAbility:
+		u.MP += Abilities[last.Ability].Cost
+	case ModeWait:
+		// Nothing else was declared before it
+		u.Moved = false
+		u.MP = MoveRange
+	case ModeExplode, ModeBridge, ModeAttack, ModeRetreat:
+	}
+
+	s.Pending = s.Pending[:len(s.Pending)-1]
+}
+
+// order returns the declared actions in resolution order: each side's in
+// the order they were declared, the sides taking turns action by action.
+// Which side goes first alternates every turn, so neither always strikes
+// first.
+func (s *State) order() []Action {
+	var sides [2][]Action
+
+	for _, a := range s.Pending {
+		i := 0
+		if a.Enemy {
+			i = 1
+		}
+
+		sides[i] = append(sides[i], a)
+	}
+
+	first := s.Turn % 2
+	queue := make([]Action, 0, len(s.Pending))
+
+	for i := 0; i < len(sides[0]) || i < len(sides[1]); i++ {
+		for _, side := range [...]int{first, 1 - first} {
+			if i < len(sides[side]) {
+				queue = append(queue, sides[side][i])
+			}
+		}
+	}
+
+	return queue
+}
+
+// Resolve applies the declared actions, in the order of order, checks the
+// mission and starts the next turn. Routed units retreat first, and units routing during the
+// resolution drop the rest of their actions for a retreat.
+func (s *State) Resolve() Result {
+	var r Result
+
+	unitsRouted, enemiesRouted := routed(s.Units), routed(s.Enemies)
+	queue := append(s.retreats(), s.order()...)
+
+	for i := 0; i < len(queue); i++ {
+		a := queue[i]
+		u := s.unit(a)
+		unitsAlive, enemiesAlive := alive(s.Units), alive(s.Enemies)
+
+		switch a.Mode {
+		case ModeMove:
+			// The map might have changed since it was declared
+			paths := DistancesFor(s.Board, u.Pos, u.Side())
+			if d := paths.Distance(a.Target.X, a.Target.Y); d > 0 && d <= MoveRange {
+				path := paths.Path(a.Target.X, a.Target.Y)
+				// Units end up facing where they were going
+				from := u.Pos
+				if len(path) > 1 {
+					from = path[len(path)-2]
+				}
+
+				u.Facing, _ = FacingTo(from, a.Target)
+				u.Pos = a.Target
+			}
+		case ModeExplode:
+			s.explode(a.Target.X, a.Target.Y, BlastRadius, &r)
+		case ModeBridge:
+			s.buildBridge(a.Target.X, a.Target.Y, &r)
+		case ModeAttack:
+			s.attack(u, a.Enemy, a.Target, &r)
+		case ModeFace:
+			if f, ok := FacingTo(u.Pos, a.Target); ok {
+				u.Facing = f
+			}
+		case ModeAbility:
+			s.use(u, a.Ability, a.Target, &r)
+		case ModeWait:
+			if u.HP > 0 {
+				r.logf("%s %d waits", sideName(a.Enemy), u.ID)
+			}
+		case ModeRetreat:
+			if u.HP > 0 {
+				s.retreat(u, sideName(a.Enemy), &r)
+			}
+		}
+
+		mourn(s.Units, unitsAlive, "unit", &r)
+		mourn(s.Enemies, enemiesAlive, "enemy", &r)
+		queue = append(queue[:i+1], s.rout(queue[i+1:], &r)...)
+	}
+
+	s.tickCooldowns()
+	recoverMorale(s.Units, unitsRouted, "unit", &r)
+	recoverMorale(s.Enemies, enemiesRouted, "enemy", &r)
+
+	for _, us := range [...][]Unit{s.Units, s.Enemies} {
+		for i := range us {
+			us[i].Moved = false
+			us[i].MP = MoveRange
+		}
+	}
+
+	s.updateMission(&r)
+	s.Pending = s.Pending[:0]
+	s.Turn++
+
+	return r
+}
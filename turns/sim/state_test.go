@@ -0,0 +1,225 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testState is a unit and an enemy on an open field, with a wall, a forest
+// and a river to one side.
+func testState() State {
+	b := ParseBoard([]string{
+		"..........",
+		"..#.......",
+		"..T.......",
+		"....~~~...",
+		"..........",
+	})
+
+	return New(b, []Tile{{0, 0}}, []Tile{{9, 4}}, 1)
+}
+
+func TestDeclare(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Action
+		want bool
+	}{
+		{"move in range", Action{Mode: ModeMove, Target: Tile{3, 0}}, true},
+		{"move through the forest", Action{Mode: ModeMove, Target: Tile{2, 3}}, true},
+		{"move too far", Action{Mode: ModeMove, Target: Tile{9, 0}}, false},
+		{"move into a wall", Action{Mode: ModeMove, Target: Tile{2, 1}}, false},
+		{"move onto water", Action{Mode: ModeMove, Target: Tile{4, 3}}, false},
+		{"move nowhere", Action{Mode: ModeMove, Target: Tile{0, 0}}, false},
+		{"move off the board", Action{Mode: ModeMove, Target: Tile{-1, 0}}, false},
+		{"attack nobody", Action{Mode: ModeAttack, Target: Tile{1, 0}}, false},
+		{"attack out of reach", Action{Mode: ModeAttack, Target: Tile{9, 4}}, false},
+		{"face south", Action{Mode: ModeFace, Target: Tile{0, 1}}, true},
+		{"face far away", Action{Mode: ModeFace, Target: Tile{5, 5}}, false},
+		{"bridge on grass", Action{Mode: ModeBridge, Target: Tile{1, 0}}, false},
+		{"wait", Action{Mode: ModeWait, Target: Tile{0, 0}}, true},
+		{"retreat", Action{Mode: ModeRetreat, Target: Tile{0, 0}}, false},
+		{"enemy explodes", Action{Mode: ModeExplode, Target: Tile{9, 3}, Enemy: true}, false},
+		{"enemy moves", Action{Mode: ModeMove, Target: Tile{8, 4}, Enemy: true}, true},
+	}
+
+	for _, tt := range tests {
+		s := testState()
+		if got := s.Declare(tt.a); got != tt.want {
+			t.Errorf("%s: declared %v, want %v", tt.name, got, tt.want)
+		}
+
+		if n := len(s.Pending); (n == 1) != tt.want {
+			t.Errorf("%s: %d actions pending", tt.name, n)
+		}
+	}
+}
+
+func TestDeclareMovePoints(t *testing.T) {
+	s := testState()
+
+	// Two tiles and the forest's cost of 2
+	if !s.Declare(Action{Mode: ModeMove, Target: Tile{1, 2}}) {
+		t.Fatal("couldn't move")
+	}
+
+	if u := s.Units[0]; !u.Moved || u.MP != MoveRange-3 {
+		t.Errorf("after moving: moved %v with %d MP, want %d", u.Moved, u.MP, MoveRange-3)
+	}
+
+	if s.Declare(Action{Mode: ModeMove, Target: Tile{0, 4}}) {
+		t.Error("moved twice in a turn")
+	}
+
+	s.Undo()
+
+	if u := s.Units[0]; u.Moved || u.MP != MoveRange || len(s.Pending) != 0 {
+		t.Errorf("after undoing: moved %v with %d MP and %d pending", u.Moved, u.MP, len(s.Pending))
+	}
+}
+
+func TestResolveMove(t *testing.T) {
+	s := testState()
+	s.Declare(Action{Mode: ModeMove, Target: Tile{0, 3}})
+	s.Resolve()
+
+	u := s.Units[0]
+	if u.Pos != (Tile{0, 3}) || u.Facing != South {
+		t.Errorf("unit at %v facing %v, want at {0 3} facing south", u.Pos, u.Facing)
+	}
+
+	if u.Moved || u.MP != MoveRange || s.Turn != 1 || len(s.Pending) != 0 {
+		t.Errorf("next turn not started: moved %v, %d MP, turn %d, %d pending", u.Moved, u.MP, s.Turn, len(s.Pending))
+	}
+}
+
+func TestResolveMoveBlocked(t *testing.T) {
+	// The map changed since the move was declared, it doesn't happen
+	s := testState()
+	s.Declare(Action{Mode: ModeMove, Target: Tile{3, 0}})
+	s.Board.Tiles[3] = Wall
+	s.Resolve()
+
+	if p := s.Units[0].Pos; p != (Tile{0, 0}) {
+		t.Errorf("unit moved to %v, onto the wall", p)
+	}
+}
+
+func TestResolveTerrain(t *testing.T) {
+	s := testState()
+	s.Units[0].Pos = Tile{4, 2}
+
+	if !s.Declare(Action{Mode: ModeBridge, Target: Tile{4, 3}}) || !s.Declare(Action{Mode: ModeExplode, Target: Tile{2, 1}}) {
+		t.Fatal("couldn't bridge and explode")
+	}
+
+	r := s.Resolve()
+
+	for _, want := range []struct {
+		t    Tile
+		want Terrain
+	}{
+		{Tile{4, 3}, Bridge},
+		{Tile{2, 1}, Rubble},
+		{Tile{2, 2}, Grass},
+		{Tile{2, 0}, Grass},
+	} {
+		if got := s.Board.At(want.t.X, want.t.Y); got != want.want {
+			t.Errorf("%v is %v, want %v", want.t, Terrains[got].Name, Terrains[want.want].Name)
+		}
+	}
+
+	if len(r.Changes) != 3 {
+		t.Errorf("%d tile changes, want the bridge, the wall and the forest", len(r.Changes))
+	}
+}
+
+func TestResolveAttack(t *testing.T) {
+	s := testState()
+	s.Units[0].Pos = Tile{8, 4}
+	s.Enemies[0].Facing = North
+
+	if !s.Declare(Action{Mode: ModeAttack, Target: Tile{9, 4}}) {
+		t.Fatal("couldn't attack")
+	}
+
+	fc := ForecastAttack(s.Units[0], Tile{8, 4}, s.Enemies[0])
+	if fc.Attack.Flank != Side || !fc.Counter {
+		t.Fatalf("forecast %+v, want a flank attack with a counter", fc)
+	}
+
+	r := s.Resolve()
+
+	if len(r.Clashes) == 0 {
+		t.Fatal("no clash")
+	}
+
+	c := r.Clashes[0]
+	if c.From != (Tile{8, 4}) || c.To != (Tile{9, 4}) || c.Enemy {
+		t.Errorf("clash %+v, want from the unit at {8 4} to the enemy", c)
+	}
+
+	if dmg := UnitHP - s.Enemies[0].HP; dmg != c.Damage || (dmg != 0 && (dmg < fc.Attack.Min || dmg > fc.Attack.Max)) {
+		t.Errorf("enemy took %d, the clash says %d, the forecast %d to %d", dmg, c.Damage, fc.Attack.Min, fc.Attack.Max)
+	}
+
+	if s.Units[0].Facing != East {
+		t.Errorf("attacker faces %v, want east", s.Units[0].Facing)
+	}
+}
+
+func TestForecastFlanks(t *testing.T) {
+	defender := Unit{Pos: Tile{5, 5}, Facing: East}
+
+	tests := []struct {
+		from    Tile
+		flank   Flank
+		counter bool
+	}{
+		{Tile{6, 5}, Front, true},
+		{Tile{5, 4}, Side, true},
+		{Tile{5, 6}, Side, true},
+		{Tile{4, 5}, Rear, false},
+	}
+
+	for _, tt := range tests {
+		fc := ForecastAttack(Unit{}, tt.from, defender)
+		if fc.Attack.Flank != tt.flank || fc.Counter != tt.counter {
+			t.Errorf("from %v: %v with counter %v, want %v with counter %v",
+				tt.from, fc.Attack.Flank, fc.Counter, tt.flank, tt.counter)
+		}
+	}
+}
+
+func TestResolveOrder(t *testing.T) {
+	// The sides take turns action by action, the first one alternating
+	// every turn
+	for turn, first := range []bool{false, true} {
+		s := testState()
+		s.Turn = turn
+		s.Declare(Action{Mode: ModeWait, Target: Tile{0, 0}})
+		s.Declare(Action{Mode: ModeWait, Target: Tile{9, 4}, Enemy: true})
+
+		if q := s.order(); len(q) != 2 || q[0].Enemy != first {
+			t.Errorf("turn %d: order %+v, want the enemy first %v", turn, q, first)
+		}
+	}
+}
+
+func TestResolveDeterministic(t *testing.T) {
+	s := testState()
+	s.Units[0].Pos = Tile{8, 4}
+	s.Declare(Action{Mode: ModeAttack, Target: Tile{9, 4}})
+	s.Declare(Action{Mode: ModeAttack, Target: Tile{8, 4}, Enemy: true})
+
+	a, b := s.Clone(), s.Clone()
+	ra, rb := a.Resolve(), b.Resolve()
+
+	if !reflect.DeepEqual(a, b) || !reflect.DeepEqual(ra, rb) {
+		t.Error("the same state and actions resolved differently")
+	}
+
+	if s.Turn != 0 || len(s.Pending) != 2 || s.Units[0].HP != UnitHP || s.Enemies[0].HP != UnitHP {
+		t.Error("resolving a clone changed the original")
+	}
+}
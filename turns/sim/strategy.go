@@ -0,0 +1,334 @@
+package sim
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// Options of each enemy minimax looks further into, the best by greedy
+	minimaxOptions = 4
+	// What a unit down is worth in the score of a state, in hit points
+	downWorth = UnitHP
+	// Mixed into the seed of the random strategy, so it doesn't roll the
+	// same numbers the resolution will
+	randomSalt = 0x5851f42d4c957f2d
+)
+
+// Strategy plans the turn of the computer player, the enemy side: it
+// declares the enemies' actions on the state, after the player declared
+// theirs and without looking at them. The same state always gets the same
+// plan.
+type Strategy interface {
+	Name() string
+	Plan(s *State)
+}
+
+// Strategies are the strategies there are, by name, the rules first as the
+// default.
+//
+//nolint:gochecknoglobal
+var Strategies = []Strategy{Rules{}, Random{}, Greedy{}, Minimax{}}
+
+// StrategyByName returns the strategy with the name.
+func StrategyByName(name string) (Strategy, error) {
+	names := make([]string, len(Strategies))
+
+	for i, st := range Strategies {
+		if st.Name() == name {
+			return st, nil
+		}
+
+		names[i] = st.Name()
+	}
+
+	return nil, fmt.Errorf("unknown strategy %q, there's %s", name, strings.Join(names, ", "))
+}
+
+// Rules is PlanEnemies: attack what's next to it, close in on what it sees.
+type Rules struct{}
+
+func (Rules) Name() string { return "rules" }
+
+func (Rules) Plan(s *State) { s.PlanEnemies() }
+
+// Random has each enemy do any of what it can, at random: wait, attack, or
+// move anywhere in reach and attack from there if it can.
+type Random struct{}
+
+func (Random) Name() string { return "random" }
+
+func (Random) Plan(s *State) {
+	rng := RNG{s.RNG.State ^ randomSalt}
+
+	s.eachEnemy(func(of Action) {
+		opts := s.options(of)
+		s.declareAll(opts[rng.Intn(len(opts))])
+	})
+}
+
+// Greedy has each enemy do what hurts the units most this turn, by the
+// forecast of the attack, and the least it could take back for it. With
+// no attack in reach, it gets as close as it can to the nearest unit it
+// sees, or waits.
+type Greedy struct{}
+
+func (Greedy) Name() string { return "greedy" }
+
+func (Greedy) Plan(s *State) {
+	s.eachEnemy(func(of Action) {
+		s.declareAll(s.ranked(of)[0])
+	})
+}
+
+// Minimax looks a turn ahead: for the best few options of each enemy by
+// greedy, the rest playing greedy too, it plays the turn out on a copy of
+// the state against the ways the player could answer, and keeps the option
+// with the best worst outcome. It's lite for being a single turn deep, one
+// enemy at a time, and knowing the player only by the autoplay AI and by
+// everyone waiting.
+type Minimax struct{}
+
+func (Minimax) Name() string { return "minimax" }
+
+func (Minimax) Plan(s *State) {
+	s.eachEnemy(func(of Action) {
+		ranked := s.ranked(of)
+		if len(ranked) > minimaxOptions {
+			ranked = ranked[:minimaxOptions]
+		}
+
+		best, bestScore := ranked[0], 0
+
+		for i, opt := range ranked {
+			if score := s.worstCase(opt); i == 0 || score > bestScore {
+				best, bestScore = opt, score
+			}
+		}
+
+		s.declareAll(best)
+	})
+}
+
+// worstCase plays the turn out with the enemy option declared and the
+// enemies after it playing greedy, against each answer of the player, and
+// returns the lowest score the enemies get.
+func (s *State) worstCase(opt []Action) int {
+	answers := []func(c *State){
+		func(c *State) { c.PlanUnits() },
+		func(c *State) {},
+	}
+	worst := 0
+
+	for i, answer := range answers {
+		c := s.Clone()
+		// The player's actual orders are theirs to know
+		c.Pending = c.Pending[:0]
+
+		for _, a := range s.Pending {
+			if a.Enemy {
+				c.Pending = append(c.Pending, a)
+			}
+		}
+
+		for i := range c.Units {
+			c.Units[i].Moved = false
+			c.Units[i].MP = MoveRange
+		}
+
+		c.declareAll(opt)
+		Greedy{}.Plan(&c)
+		answer(&c)
+		// Neither are the dice
+		c.RNG.State ^= randomSalt
+		c.Resolve()
+
+		if score := c.enemyScore(); i == 0 || score < worst {
+			worst = score
+		}
+	}
+
+	return worst
+}
+
+// enemyScore is how well the enemies are doing: their hit points against
+// the units', units down counting extra.
+func (s *State) enemyScore() int {
+	side := func(us []Unit) int {
+		n := 0
+
+		for _, u := range us {
+			if u.HP > 0 {
+				n += u.HP + downWorth
+			}
+		}
+
+		return n
+	}
+
+	return side(s.Enemies) - side(s.Units)
+}
+
+// eachEnemy calls plan for every enemy that can still be given orders this
+// turn.
+func (s *State) eachEnemy(plan func(of Action)) {
+	for i := range s.Enemies {
+		of := Action{Unit: i, Enemy: true}
+
+		e := &s.Enemies[i]
+		if e.HP <= 0 || e.Routed || s.declared(of) {
+			continue
+		}
+
+		plan(of)
+	}
+}
+
+// declareAll declares the actions in order, for as long as they're valid.
+func (s *State) declareAll(as []Action) {
+	for _, a := range as {
+		if !s.Declare(a) {
+			return
+		}
+	}
+}
+
+// options returns what the unit of the action can do this turn, each a
+// list of actions to declare: wait, attack a foe next to it, or move to a
+// tile in reach, not landing on anyone or where a friend is going, and
+// maybe attack from there. Waiting is always the first.
+func (s *State) options(of Action) [][]Action {
+	u := s.unit(of)
+	size := u.Side()
+	opts := [][]Action{{{Unit: of.Unit, Enemy: of.Enemy, Mode: ModeWait, Target: u.Pos}}}
+
+	attacks := func(pos Tile, before ...Action) {
+		for _, t := range s.attackTargets(of, pos, size) {
+			opts = append(opts, append(before, Action{Unit: of.Unit, Enemy: of.Enemy, Mode: ModeAttack, Target: t}))
+		}
+	}
+
+	attacks(u.Pos)
+
+	var others []Unit
+
+	for j, o := range s.friends(of) {
+		if j != of.Unit {
+			o.Pos = s.plannedPos(Action{Unit: j, Enemy: of.Enemy})
+			others = append(others, o)
+		}
+	}
+
+	f := DistancesFor(s.Board, u.Pos, size)
+
+	for y := 0; y < s.Board.H; y++ {
+		for x := 0; x < s.Board.W; x++ {
+			t := Tile{x, y}
+
+			d := f.Distance(x, y)
+			if d <= 0 || d > u.MP || s.occupied(t, size, u) || overlaps(t, size, others) {
+				continue
+			}
+
+			move := Action{Unit: of.Unit, Enemy: of.Enemy, Mode: ModeMove, Target: t}
+			opts = append(opts, []Action{move})
+			attacks(t, move)
+		}
+	}
+
+	return opts
+}
+
+// attackTargets returns a tile of each living foe a unit of the size at
+// pos touches.
+func (s *State) attackTargets(of Action, pos Tile, size int) []Tile {
+	var targets []Tile
+
+	for _, f := range s.foes(of) {
+		if f.HP <= 0 {
+			continue
+		}
+
+		for _, t := range Footprint(f.Pos, f.Side()) {
+			if touches(pos, size, t) {
+				targets = append(targets, t)
+
+				break
+			}
+		}
+	}
+
+	return targets
+}
+
+// ranked returns the options of the unit of the action, best first by
+// greedy: the most damage expected, then the least expected back, then the
+// closest to the nearest foe it sees, then the shortest move.
+func (s *State) ranked(of Action) [][]Action {
+	u := s.unit(of)
+	target, seen := s.nearestSeen(u, s.foes(of))
+	opts := s.options(of)
+
+	type rank struct {
+		dealt, taken float64
+		reach, moved int
+	}
+
+	ranks := make([]rank, len(opts))
+	for i, opt := range opts {
+		pos := u.Pos
+		r := &ranks[i]
+
+		for _, a := range opt {
+			switch a.Mode {
+			case ModeMove:
+				pos = a.Target
+				r.moved = Reach(u.Pos, pos)
+			case ModeAttack:
+				fc := ForecastAttack(*u, pos, *s.foeAt(of.Enemy, a.Target))
+				r.dealt = expected(fc.Attack)
+
+				if fc.Counter {
+					r.taken = expected(fc.CounterHit)
+				}
+			}
+		}
+
+		if seen {
+			r.reach = Reach(pos, target)
+		}
+	}
+
+	idx := make([]int, len(opts))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := ranks[idx[i]], ranks[idx[j]]
+
+		switch {
+		case a.dealt != b.dealt:
+			return a.dealt > b.dealt
+		case a.taken != b.taken:
+			return a.taken < b.taken
+		case a.reach != b.reach:
+			return a.reach < b.reach
+		default:
+			return a.moved < b.moved
+		}
+	})
+
+	sorted := make([][]Action, len(opts))
+	for i, j := range idx {
+		sorted[i] = opts[j]
+	}
+
+	return sorted
+}
+
+// expected returns the damage a strike does on average.
+func expected(st Strike) float64 {
+	return st.Chance * float64(st.Min+st.Max) / 2
+}
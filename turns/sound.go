@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/antoniomo/ebiten-exercises/internal/audio"
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+const (
+	// How long the music takes to go over from one phase to the other
+	crossfade = 1500 * time.Millisecond
+	// Volumes go in tenths
+	volumeSteps = 10
+)
+
+// mixerRows are the names of the rows of the volume panel, in order.
+//
+//nolint:gochecknoglobal
+var mixerRows = []string{"Master", "Music", "Effects"}
+
+// mixer plays the music and the sound effects. Planning and resolving have
+// a track each, both loop all along and the mixer crossfades between them
+// as the phases change. The clashes sound from where they are on screen,
+// panned left or right. O, or the pause menu, opens a panel to set the
+// volumes. Without an audio device it stays quiet, the game plays the same.
+type mixer struct {
+	// The volumes of the panel rows, in tenths
+	volumes [3]int
+	// Which track the music is going to, and how far from the planning one
+	// it is, from 0 to 1
+	resolving bool
+	fade      float64
+
+	planning, resolution *audio.Music
+	effects              map[string]*audio.Positional
+
+	open bool
+	row  int
+	// What opens the panel, and the input while it's open
+	opener keymap.Map
+	keys   keymap.Map
+}
+
+func newMixer() *mixer {
+	m := &mixer{volumes: [3]int{8, 6, 8}, effects: map[string]*audio.Positional{}}
+	m.opener = keymap.Map{
+		{Keys: keymap.Keys(ebiten.KeyO), Trigger: keymap.Pressed, Action: keymap.Do(func() { m.open = true })},
+	}
+	m.keys = m.bindings()
+
+	var err error
+
+	if m.planning, err = audio.NewMusic(audio.Drone([]float64{110, 164.81, 220, 261.63}, 8)); err != nil {
+		log.Printf("no sound: %v", err)
+
+		return m
+	}
+
+	if m.resolution, err = audio.NewMusic(audio.Beat(140, 4)); err != nil {
+		log.Printf("no sound: %v", err)
+
+		m.planning = nil
+
+		return m
+	}
+
+	tracks := map[string]*audio.Track{
+		"shot": audio.Blip(1200, 300, 0.12),
+		"hit":  audio.Thud(0.2),
+		"miss": audio.Blip(330, 220, 0.08),
+	}
+
+	for name, t := range tracks {
+		p, err := audio.NewPositional(t)
+		if err != nil {
+			log.Printf("no sound effects: %v", err)
+
+			break
+		}
+
+		m.effects[name] = p
+	}
+
+	_ = m.planning.Play()
+	_ = m.resolution.Play()
+	m.apply()
+
+	return m
+}
+
+// volume returns the volume of a row, from 0 to 1.
+func (m *mixer) volume(row int) float64 {
+	return float64(m.volumes[row]) / volumeSteps
+}
+
+// apply sets the volumes of the players, the music ones by how far the
+// crossfade is. It's an equal power crossfade, the music doesn't dip
+// halfway.
+func (m *mixer) apply() {
+	if m.planning == nil {
+		return
+	}
+
+	music := m.volume(0) * m.volume(1)
+	m.planning.SetVolume(music * math.Cos(m.fade*math.Pi/2))
+	m.resolution.SetVolume(music * math.Sin(m.fade*math.Pi/2))
+
+	for _, p := range m.effects {
+		p.SetVolume(m.volume(0) * m.volume(2))
+	}
+}
+
+// Update moves the crossfade towards the music of the phase.
+func (m *mixer) Update() {
+	to, step := 0.0, 1/(crossfade.Seconds()*float64(ebiten.MaxTPS()))
+	if m.resolving {
+		to = 1
+	}
+
+	if m.fade < to {
+		m.fade = math.Min(to, m.fade+step)
+	} else {
+		m.fade = math.Max(to, m.fade-step)
+	}
+
+	m.apply()
+}
+
+// setPaused pauses the music, or resumes it.
+func (m *mixer) setPaused(paused bool) {
+	if m.planning == nil {
+		return
+	}
+
+	for _, music := range [...]*audio.Music{m.planning, m.resolution} {
+		if music.IsPlaying() == paused {
+			_ = music.Toggle()
+		}
+	}
+}
+
+// strike plays the sounds of a clash, as the camera shows it: ranged
+// attackers fire from their tile, and the blow lands on the defender, a
+// hit or a miss.
+func (m *mixer) strike(c sim.Clash, cam camera, landed bool) {
+	play := func(name string, t sim.Tile) {
+		if p, ok := m.effects[name]; ok {
+			geo := cam.GeoM()
+			x, _ := geo.Apply(tileCenter(t))
+			p.Play(2*x/screenWidth - 1)
+		}
+	}
+
+	switch {
+	case !landed && c.Ranged:
+		play("shot", c.From)
+	case !landed:
+	case c.Damage > 0:
+		play("hit", c.To)
+	default:
+		play("miss", c.To)
+	}
+}
+
+// bindings is the input of the volume panel.
+func (m *mixer) bindings() keymap.Map {
+	row := func(d int) keymap.Action {
+		return keymap.Do(func() { m.row = (m.row + len(mixerRows) + d) % len(mixerRows) })
+	}
+
+	change := func(d int) keymap.Action {
+		return keymap.Do(func() { m.volumes[m.row] = clamp(m.volumes[m.row]+d, 0, volumeSteps) })
+	}
+
+	return keymap.Map{
+		{Name: "volume_close", Keys: keymap.Keys(ebiten.KeyO, ebiten.KeyEscape), Pads: keymap.Pads(keymap.PadB, keymap.PadStart),
+			Trigger: keymap.Pressed, Action: keymap.Do(func() { m.open = false })},
+		{Name: "volume_up", Keys: keymap.Keys(ebiten.KeyUp), Pads: keymap.Pads(keymap.PadUp), Axes: keymap.Sticks(keymap.StickUp),
+			Trigger: keymap.Pressed, Action: row(-1)},
+		{Name: "volume_down", Keys: keymap.Keys(ebiten.KeyDown), Pads: keymap.Pads(keymap.PadDown), Axes: keymap.Sticks(keymap.StickDown),
+			Trigger: keymap.Pressed, Action: row(1)},
+		{Name: "volume_less", Keys: keymap.Keys(ebiten.KeyLeft), Pads: keymap.Pads(keymap.PadLeft), Axes: keymap.Sticks(keymap.StickLeft),
+			Trigger: keymap.Repeat, Action: change(-1)},
+		{Name: "volume_more", Keys: keymap.Keys(ebiten.KeyRight), Pads: keymap.Pads(keymap.PadRight), Axes: keymap.Sticks(keymap.StickRight),
+			Trigger: keymap.Repeat, Action: change(1)},
+	}
+}
+
+// UpdatePanel handles the volume panel input, and reports whether the
+// input was consumed.
+func (m *mixer) UpdatePanel() bool {
+	if !m.open {
+		_ = m.opener.Update()
+
+		return m.open
+	}
+
+	_ = m.keys.Update()
+	m.apply()
+
+	return true
+}
+
+// DrawPanel draws the volume panel, if open, each volume as a bar.
+func (m *mixer) DrawPanel(screen *ebiten.Image) {
+	if !m.open {
+		return
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("Volume\n\n")
+
+	for i, name := range mixerRows {
+		cursor := "  "
+		if i == m.row {
+			cursor = "> "
+		}
+
+		bar := strings.Repeat("#", m.volumes[i]) + strings.Repeat(".", volumeSteps-m.volumes[i])
+		sb.WriteString(fmt.Sprintf("%s%-8s [%s] %d%%\n", cursor, name, bar, m.volumes[i]*100/volumeSteps))
+	}
+
+	if m.planning == nil {
+		sb.WriteString("\nNo audio device, the game is silent")
+	}
+
+	ebitenutil.DrawRect(screen, 20, mapTop+20, screenWidth-40, float64(16*(len(mixerRows)+5)), forecastColor)
+	ebitenutil.DebugPrintAt(screen, sb.String(), 28, mapTop+26)
+}
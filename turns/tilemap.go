@@ -0,0 +1,48 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+)
+
+//nolint:gochecknoglobal
+var terrainColors = map[sim.Terrain]color.RGBA{
+	sim.Grass:  {0x3a, 0x7d, 0x2c, 0xff},
+	sim.Forest: {0x1e, 0x4d, 0x1a, 0xff},
+	sim.Wall:   {0x70, 0x70, 0x70, 0xff},
+	sim.Water:  {0x2a, 0x4f, 0xa8, 0xff},
+	sim.Bridge: {0x8b, 0x5a, 0x2b, 0xff},
+	sim.Rubble: {0x9c, 0x8c, 0x74, 0xff},
+}
+
+// Tilemap is the board of the game state as seen by the subsystems caching
+// data derived from it (paths, visibility, the rendered layer). The sim
+// reports the tiles each resolution changed, and they're broadcast to the
+// listeners to let them invalidate just what they need.
+type Tilemap struct {
+	state     *sim.State
+	listeners []func(x, y int, old, new sim.Terrain)
+}
+
+func newTilemap(state *sim.State) *Tilemap {
+	return &Tilemap{state: state}
+}
+
+func (m *Tilemap) Board() sim.Board {
+	return m.state.Board
+}
+
+// OnChange registers a listener for tile changes.
+func (m *Tilemap) OnChange(l func(x, y int, old, new sim.Terrain)) {
+	m.listeners = append(m.listeners, l)
+}
+
+// Changed notifies the listeners of the changes of a resolution.
+func (m *Tilemap) Changed(changes []sim.TileChange) {
+	for _, c := range changes {
+		for _, l := range m.listeners {
+			l(c.X, c.Y, c.Old, c.New)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"time"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/internal/run"
+	"github.com/antoniomo/ebiten-exercises/internal/scene"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// title is the first scene: it picks between the level and a random map.
+// Its screen is half the size of the game's, so the text comes out twice
+// as big.
+type title struct {
+	g    *Game
+	keys keymap.Map
+}
+
+func newTitle(g *Game) *title {
+	return &title{g: g, keys: keymap.Map{
+		{Name: "play", Keys: keymap.Keys(ebiten.KeyEnter, ebiten.KeySpace), Pads: keymap.Pads(keymap.PadA, keymap.PadStart),
+			Trigger: keymap.Pressed, Action: keymap.Do(func() {
+				g.reset(g.level.Clone())
+				g.scenes.Reset(&planning{g: g}, scene.NewFade(time.Second))
+			})},
+		{Name: "new_map", Keys: keymap.Keys(ebiten.KeyN), Pads: keymap.Pads(keymap.PadX), Trigger: keymap.Pressed, Action: keymap.Do(g.newMap)},
+		{Name: "quit", Keys: keymap.Keys(ebiten.KeyEscape), Pads: keymap.Pads(keymap.PadBack), Action: func() error { return run.ErrCleanExit }},
+	}}
+}
+
+func (t *title) Update() error {
+	return t.keys.Update()
+}
+
+func (t *title) Draw(screen *ebiten.Image) {
+	ebitenutil.DebugPrintAt(screen, "T U R N S", 130, 60)
+	help := "Enter  play the level\nN      random map\nEsc    quit\n\nP pauses while playing"
+	if t.g.device.Pad {
+		help = "A      play the level\nX      random map\nBack   quit\n\nStart pauses while playing"
+	}
+
+	ebitenutil.DebugPrintAt(screen, help, 100, 120)
+}
+
+func (t *title) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenWidth / 2, screenHeight / 2
+}
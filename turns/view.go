@@ -0,0 +1,123 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/antoniomo/ebiten-exercises/internal/keymap"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// Zoom of the planning view zoomed in
+	viewZoom = 2
+	// Pixels per tick the right stick pans it at full tilt
+	panSpeed = 8
+)
+
+// view is the camera on the board while planning: the whole board, or
+// zoomed in around the tile cursor (Z, or clicking the left stick). Zoomed
+// in, the right stick pans it, and it follows the cursor when the cursor
+// leaves it.
+type view struct {
+	cam    camera
+	zoomed bool
+	// Tile the view last followed, it only moves again once the cursor
+	// does, so panning away from the cursor sticks
+	followed sim.Tile
+}
+
+func newView() view {
+	return view{cam: homeCamera}
+}
+
+// viewBindings zoom the view and pan it.
+func (g *Game) viewBindings() keymap.Map {
+	return keymap.Map{
+		{Name: "zoom", Keys: keymap.Keys(ebiten.KeyZ), Pads: keymap.Pads(keymap.PadLS), Trigger: keymap.Pressed,
+			Action: keymap.Do(func() { g.view.toggleZoom(g.cursor) })},
+		{
+			Name: "pan",
+			Axes: keymap.Sticks(keymap.RightStickUp, keymap.RightStickDown, keymap.RightStickLeft, keymap.RightStickRight),
+			Action: keymap.Do(func() {
+				dx := keymap.RightStickRight.Value() - keymap.RightStickLeft.Value()
+				dy := keymap.RightStickDown.Value() - keymap.RightStickUp.Value()
+				g.view.pan(dx*panSpeed, dy*panSpeed)
+			}),
+		},
+	}
+}
+
+// toggleZoom zooms in around the tile, or back out to the whole board.
+func (v *view) toggleZoom(t sim.Tile) {
+	v.zoomed = !v.zoomed
+	if !v.zoomed {
+		v.cam = homeCamera
+
+		return
+	}
+
+	x, y := tileCenter(t)
+	v.cam = camera{x, y, viewZoom}
+	v.followed = t
+	v.clamp()
+}
+
+// pan moves the view by (dx, dy) pixels on the screen, zoomed in.
+func (v *view) pan(dx, dy float64) {
+	if !v.zoomed {
+		return
+	}
+
+	v.cam.x += dx / v.cam.zoom
+	v.cam.y += dy / v.cam.zoom
+	v.clamp()
+}
+
+// follow brings the tile into the view, if it's zoomed in and the tile is a
+// new one.
+func (v *view) follow(t sim.Tile) {
+	if !v.zoomed || t == v.followed {
+		return
+	}
+
+	v.followed = t
+	r := tileRect(t)
+	hw, hh := screenWidth/(2*v.cam.zoom), screenHeight/(2*v.cam.zoom)
+
+	v.cam.x = math.Max(float64(r.Max.X)-hw, math.Min(v.cam.x, float64(r.Min.X)+hw))
+	v.cam.y = math.Max(float64(r.Max.Y)-hh, math.Min(v.cam.y, float64(r.Min.Y)+hh))
+	v.clamp()
+}
+
+// clamp keeps the view within the screen as drawn.
+func (v *view) clamp() {
+	hw, hh := screenWidth/(2*v.cam.zoom), screenHeight/(2*v.cam.zoom)
+	v.cam.x = math.Max(hw, math.Min(v.cam.x, screenWidth-hw))
+	v.cam.y = math.Max(hh, math.Min(v.cam.y, screenHeight-hh))
+}
+
+// toWorld returns the point of the screen as drawn under (x, y) on the
+// view.
+func (v *view) toWorld(x, y int) (int, int) {
+	m := v.cam.GeoM()
+	m.Invert()
+	wx, wy := m.Apply(float64(x), float64(y))
+
+	return int(math.Floor(wx)), int(math.Floor(wy))
+}
+
+// toView returns where r of the screen as drawn is on the view.
+func (v *view) toView(r image.Rectangle) image.Rectangle {
+	m := v.cam.GeoM()
+	x0, y0 := m.Apply(float64(r.Min.X), float64(r.Min.Y))
+	x1, y1 := m.Apply(float64(r.Max.X), float64(r.Max.Y))
+
+	return image.Rect(int(x0), int(y0), int(x1), int(y1))
+}
+
+// tileRect returns the rectangle of the tile on the screen as drawn.
+func tileRect(t sim.Tile) image.Rectangle {
+	return image.Rect(t.X*tileSize, t.Y*tileSize+mapTop, (t.X+1)*tileSize, (t.Y+1)*tileSize+mapTop)
+}
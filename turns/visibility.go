@@ -0,0 +1,78 @@
+package main
+
+import (
+	"github.com/antoniomo/ebiten-exercises/internal/profile"
+	"github.com/antoniomo/ebiten-exercises/turns/sim"
+)
+
+// visibility keeps which tiles can be seen from a viewer tile, for the fog
+// drawn every frame. It's only recomputed when the viewer moves, or when a
+// tile that can be in sight changes whether it blocks the view.
+type visibility struct {
+	m      *Tilemap
+	radius int
+	viewer sim.Tile
+	valid  bool
+	seen   []bool
+
+	// Number of full recomputes, for the HUD
+	recomputes int
+}
+
+func newVisibility(m *Tilemap, radius int) *visibility {
+	v := &visibility{m: m, radius: radius}
+	m.OnChange(v.onChange)
+
+	return v
+}
+
+func (v *visibility) onChange(x, y int, old, new sim.Terrain) {
+	if !v.valid || sim.Terrains[old].BlocksView == sim.Terrains[new].BlocksView {
+		return
+	}
+
+	if abs(x-v.viewer.X) <= v.radius && abs(y-v.viewer.Y) <= v.radius {
+		v.valid = false
+	}
+}
+
+// Visible reports whether (x, y) can be seen from viewer.
+func (v *visibility) Visible(viewer sim.Tile, x, y int) bool {
+	v.ensure(viewer)
+
+	return v.seen[y*v.m.Board().W+x]
+}
+
+func (v *visibility) ensure(viewer sim.Tile) {
+	if v.valid && v.viewer == viewer {
+		return
+	}
+
+	v.viewer = viewer
+	v.valid = true
+	v.recomputes++
+
+	defer profile.Region("visibility")()
+
+	v.seen = sim.Sight(v.m.Board(), viewer, v.radius)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+func clamp(x, lo, hi int) int {
+	if x < lo {
+		return lo
+	}
+
+	if x > hi {
+		return hi
+	}
+
+	return x
+}